@@ -0,0 +1,50 @@
+package sorm
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrRecordNotFound is returned by the FindFirst functions when nothing
+	// matched. It wraps sql.ErrNoRows.
+	ErrRecordNotFound = fmt.Errorf("record not found: %w", sql.ErrNoRows)
+	ErrTooManyRows    = errors.New("too many rows affected")
+)
+
+// RowsAffectedError is returned when a write by ID changed a different number
+// of rows than expected, e.g. because the record was deleted by someone else
+// in the meantime. It unwraps to ErrRecordNotFound or ErrTooManyRows.
+type RowsAffectedError struct {
+	Op       Operation
+	Table    string
+	Expected int64
+	Actual   int64
+}
+
+func (e *RowsAffectedError) Error() string {
+	return fmt.Sprintf("%s of %s affected %d rows; expected %d", e.Op, e.Table, e.Actual, e.Expected)
+}
+
+func (e *RowsAffectedError) Unwrap() error {
+	if e.Actual < e.Expected {
+		return ErrRecordNotFound
+	}
+
+	return ErrTooManyRows
+}
+
+// checkRowsAffected does nothing if the driver can't report affected rows.
+func checkRowsAffected(res sql.Result, op Operation, table string, expected int64) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return nil
+	}
+
+	if n != expected {
+		return &RowsAffectedError{Op: op, Table: table, Expected: expected, Actual: n}
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package sorm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRowsAffectedChecks(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectExec(`^delete from simple_objects where id = \$1$`).WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 0))
+	mockDB.ExpectExec(`^delete from simple_objects where id = \$1$`).WithArgs(4).WillReturnResult(sqlmock.NewResult(0, 2))
+	mockDB.ExpectQuery(`^select \* from simple_objects where id = \$1`).WithArgs(5).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(5, "a"))
+	mockDB.ExpectExec(`^update simple_objects set name = \$2 where id = \$1$`).WithArgs(5, "b").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = DeleteRecord(context.Background(), db, &SimpleObject{ID: 3})
+	a.True(errors.Is(err, ErrRecordNotFound))
+	a.EqualError(err, "DeleteRecord: delete of simple_objects affected 0 rows; expected 1")
+
+	err = DeleteRecord(context.Background(), db, &SimpleObject{ID: 4})
+	a.True(errors.Is(err, ErrTooManyRows))
+
+	var e *RowsAffectedError
+	if a.True(errors.As(err, &e)) {
+		a.Equal(int64(2), e.Actual)
+	}
+
+	err = SaveRecord(context.Background(), db, &SimpleObject{ID: 5, Name: "b"})
+	a.True(errors.Is(err, ErrRecordNotFound))
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
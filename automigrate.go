@@ -0,0 +1,322 @@
+package sorm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+type ModelIndex struct {
+	Name    string
+	Columns []string
+	Unique  bool
+}
+
+// getSQLIndexes lists the indexes a model declares, sorted by name. A bare
+// `sql:"email,unique"` or `sql:"email,index"` makes a single column index
+// named <table>_<column>_key or <table>_<column>_idx; `sql:",unique:name"`
+// (a natural key) and `sql:",index:name"` group every field using the same
+// name into one index. Natural keys are named <table>_<name>, while other
+// index names are used as is.
+func getSQLIndexes(vdesc *structDescription, tbl string) []ModelIndex {
+	m := make(map[string]*ModelIndex)
+
+	_, prefix := splitTableName(tbl)
+
+	add := func(name, col string, unique bool) {
+		idx, ok := m[name]
+		if !ok {
+			idx = &ModelIndex{Name: name, Unique: unique}
+			m[name] = idx
+		}
+
+		idx.Columns = append(idx.Columns, col)
+	}
+
+	for _, f := range getSQLWritableFields(vdesc) {
+		t := f.Tag("sql")
+		if t == nil {
+			continue
+		}
+
+		col := getSQLColumnName(f)
+
+		if p := t.Parameter("unique"); p != nil {
+			if p.Value() == "" {
+				add(prefix+"_"+col+"_key", col, true)
+			} else {
+				add(prefix+"_"+p.Value(), col, true)
+			}
+		}
+
+		if p := t.Parameter("index"); p != nil {
+			if p.Value() == "" {
+				add(prefix+"_"+col+"_idx", col, false)
+			} else {
+				add(p.Value(), col, false)
+			}
+		}
+	}
+
+	var names []string
+	for k := range m {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var l []ModelIndex
+	for _, k := range names {
+		l = append(l, *m[k])
+	}
+
+	return l
+}
+
+// ModelIndexes returns the indexes declared by model's tags, as created by
+// CreateTable and AutoMigrate.
+func ModelIndexes(model interface{}) ([]ModelIndex, error) {
+	vtyp, err := structTypeOf(model)
+	if err != nil {
+		return nil, fmt.Errorf("ModelIndexes: %w", err)
+	}
+
+	vdesc, err := getDescriptionFromType(vtyp)
+	if err != nil {
+		return nil, fmt.Errorf("ModelIndexes: could not get detailed reflection information for type %s: %w", vtyp.String(), err)
+	}
+
+	return getSQLIndexes(vdesc, getSQLTableName(vdesc)), nil
+}
+
+// createTableStatements is the create table for a model followed by the
+// create index for each of its indexes.
+func createTableStatements(vtyp reflect.Type, vdesc *structDescription, tbl string) ([]Statement, error) {
+	stmt, err := buildCreateTable(vtyp, vdesc, tbl)
+	if err != nil {
+		return nil, err
+	}
+
+	l := []Statement{stmt}
+
+	for _, idx := range getSQLIndexes(vdesc, tbl) {
+		stmt, err := createIndexStatement(tbl, idx)
+		if err != nil {
+			return nil, err
+		}
+
+		l = append(l, stmt)
+	}
+
+	return l, nil
+}
+
+func createIndexStatement(tbl string, idx ModelIndex) (Statement, error) {
+	for _, s := range append([]string{tbl, idx.Name}, idx.Columns...) {
+		if err := checkIdentifier(s); err != nil {
+			return Statement{}, err
+		}
+	}
+
+	kind := "index"
+	if idx.Unique {
+		kind = "unique index"
+	}
+
+	return Statement{Query: fmt.Sprintf("create %s %s on %s (%s)", kind, idx.Name, tbl, strings.Join(idx.Columns, ", "))}, nil
+}
+
+// introspectIndexes reads the names of the indexes on tbl. Postgres is tried
+// first since a failed query there aborts the surrounding transaction.
+func introspectIndexes(ctx context.Context, db Querier, tbl string) (map[string]bool, error) {
+	schema, name := splitTableName(tbl)
+
+	attempts := []Statement{
+		{Query: "select indexname from pg_indexes where tablename = " + makeParameter(1), Args: []interface{}{name}},
+		{Query: "select distinct index_name from information_schema.statistics where table_name = " + makeParameter(1), Args: []interface{}{name}},
+	}
+	if schema != "" {
+		attempts[0].Query += " and schemaname = " + makeParameter(2)
+		attempts[0].Args = append(attempts[0].Args, schema)
+		attempts[1].Query += " and table_schema = " + makeParameter(2)
+		attempts[1].Args = append(attempts[1].Args, schema)
+	}
+
+	for _, stmt := range attempts {
+		if m, err := queryIndexNames(ctx, db, stmt, false); err == nil {
+			return m, nil
+		}
+	}
+
+	if err := checkIdentifier(tbl); err != nil {
+		return nil, err
+	}
+
+	query := "pragma index_list(" + quoteIdentifier(name) + ")"
+	if schema != "" {
+		query = "pragma " + quoteIdentifier(schema) + ".index_list(" + quoteIdentifier(name) + ")"
+	}
+
+	return queryIndexNames(ctx, db, Statement{Query: query}, true)
+}
+
+func queryIndexNames(ctx context.Context, db Querier, stmt Statement, pragma bool) (map[string]bool, error) {
+	logQuery(ctx, stmt.Query, stmt.Args)
+
+	start := time.Now()
+
+	rows, err := db.QueryContext(ctx, stmt.Query, stmt.Args...)
+	if err != nil {
+		logQueryAfter(ctx, stmt.Query, stmt.Args, start, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	m := make(map[string]bool)
+	for rows.Next() {
+		var name string
+
+		if pragma {
+			var seq int
+			var rest [3]interface{}
+			if err := rows.Scan(&seq, &name, &rest[0], &rest[1], &rest[2]); err != nil {
+				logQueryAfter(ctx, stmt.Query, stmt.Args, start, err)
+				return nil, err
+			}
+		} else if err := rows.Scan(&name); err != nil {
+			logQueryAfter(ctx, stmt.Query, stmt.Args, start, err)
+			return nil, err
+		}
+
+		m[strings.ToLower(name)] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		logQueryAfter(ctx, stmt.Query, stmt.Args, start, err)
+		return nil, err
+	}
+
+	logQueryAfter(ctx, stmt.Query, stmt.Args, start, nil)
+
+	return m, nil
+}
+
+// AutoMigrateStatements returns the DDL AutoMigrate would run: a create table
+// for each model without a table, and otherwise an alter table for each
+// missing column and a create index for each missing index. Nothing is ever
+// dropped or altered in place.
+func AutoMigrateStatements(ctx context.Context, db Querier, models ...interface{}) ([]Statement, error) {
+	var l []Statement
+
+	for _, model := range models {
+		stmts, err := autoMigrateModel(ctx, db, model)
+		if err != nil {
+			return nil, fmt.Errorf("AutoMigrateStatements: %w", err)
+		}
+
+		l = append(l, stmts...)
+	}
+
+	return l, nil
+}
+
+func autoMigrateModel(ctx context.Context, db Querier, model interface{}) ([]Statement, error) {
+	vtyp, err := structTypeOf(model)
+	if err != nil {
+		return nil, err
+	}
+
+	vdesc, err := getDescriptionContext(ctx, vtyp)
+	if err != nil {
+		return nil, fmt.Errorf("could not get detailed reflection information for type %s: %w", vtyp.String(), err)
+	}
+
+	// views and read-only models are managed outside sorm
+	if getSQLReadOnly(vdesc) != "" {
+		return nil, nil
+	}
+
+	tbl := getSQLTableNameContext(ctx, vdesc)
+
+	live, err := introspectTable(ctx, db, tbl)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read columns of %s: %w", tbl, err)
+	}
+
+	if len(live) == 0 {
+		return createTableStatements(vtyp, vdesc, tbl)
+	}
+
+	indexes := getSQLIndexes(vdesc, tbl)
+
+	var l []Statement
+
+	have := make(map[string]bool)
+	for _, c := range live {
+		have[strings.ToLower(c.Name)] = true
+	}
+
+	for _, f := range getSQLWritableFields(vdesc) {
+		if have[strings.ToLower(getSQLColumnName(f))] {
+			continue
+		}
+
+		def, err := columnDefinition(vtyp, f)
+		if err != nil {
+			return nil, err
+		}
+
+		l = append(l, Statement{Query: "alter table " + tbl + " add column " + def})
+	}
+
+	if len(indexes) == 0 {
+		return l, nil
+	}
+
+	existing, err := introspectIndexes(ctx, db, tbl)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read indexes of %s: %w", tbl, err)
+	}
+
+	for _, idx := range indexes {
+		if existing[strings.ToLower(idx.Name)] {
+			continue
+		}
+
+		stmt, err := createIndexStatement(tbl, idx)
+		if err != nil {
+			return nil, err
+		}
+
+		l = append(l, stmt)
+	}
+
+	return l, nil
+}
+
+// AutoMigrate brings the tables for models up to date with their structs by
+// running the statements from AutoMigrateStatements.
+func AutoMigrate(ctx context.Context, db Querier, models ...interface{}) error {
+	stmts, err := AutoMigrateStatements(ctx, db, models...)
+	if err != nil {
+		return fmt.Errorf("AutoMigrate: %w", err)
+	}
+
+	for _, stmt := range stmts {
+		logQuery(ctx, stmt.Query, stmt.Args)
+
+		start := time.Now()
+
+		if _, err := db.ExecContext(ctx, stmt.Query, stmt.Args...); err != nil {
+			logQueryAfter(ctx, stmt.Query, stmt.Args, start, err)
+
+			return fmt.Errorf("AutoMigrate: %w", err)
+		}
+
+		logQueryAfter(ctx, stmt.Query, stmt.Args, start, nil)
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package sorm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAutoMigrateStatementsNewTable(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`information_schema\.columns where table_name = \$1`).WithArgs("natural_key_objects").WillReturnRows(sqlmock.NewRows([]string{"column_name", "data_type", "is_nullable"}))
+
+	l, err := AutoMigrateStatements(context.Background(), db, &NaturalKeyObject{})
+	a.NoError(err)
+	a.Equal([]Statement{
+		{Query: "create table natural_key_objects (id integer not null, org_id integer not null, slug text not null, name text not null, primary key (id))"},
+		{Query: "create unique index natural_key_objects_org_slug on natural_key_objects (org_id, slug)"},
+	}, l)
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestAutoMigrateExistingTable(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`information_schema\.columns`).WillReturnError(errors.New("no such table"))
+	mockDB.ExpectQuery(`pragma table_info\(natural_key_objects\)`).WillReturnRows(sqlmock.NewRows([]string{"cid", "name", "type", "notnull", "dflt_value", "pk"}).AddRow(0, "id", "INTEGER", 1, nil, 1).AddRow(1, "org_id", "INTEGER", 1, nil, 0).AddRow(2, "slug", "TEXT", 1, nil, 0))
+	mockDB.ExpectQuery(`pg_indexes`).WillReturnError(errors.New("no such table"))
+	mockDB.ExpectQuery(`information_schema\.statistics`).WillReturnError(errors.New("no such table"))
+	mockDB.ExpectQuery(`pragma index_list\(natural_key_objects\)`).WillReturnRows(sqlmock.NewRows([]string{"seq", "name", "unique", "origin", "partial"}))
+	mockDB.ExpectExec(`alter table natural_key_objects add column name text not null`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mockDB.ExpectExec(`create unique index natural_key_objects_org_slug on natural_key_objects \(org_id, slug\)`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	a.NoError(AutoMigrate(context.Background(), db, &NaturalKeyObject{}))
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestAutoMigrateUpToDate(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`information_schema\.columns where table_name = \$1 and table_schema = \$2`).WithArgs("natural_key_objects", "app").WillReturnRows(sqlmock.NewRows([]string{"column_name", "data_type", "is_nullable"}).AddRow("id", "integer", "NO").AddRow("org_id", "integer", "NO").AddRow("slug", "text", "NO").AddRow("name", "text", "NO"))
+	mockDB.ExpectQuery(`select indexname from pg_indexes where tablename = \$1 and schemaname = \$2`).WithArgs("natural_key_objects", "app").WillReturnRows(sqlmock.NewRows([]string{"indexname"}).AddRow("natural_key_objects_pkey").AddRow("natural_key_objects_org_slug"))
+
+	l, err := AutoMigrateStatements(WithOptions(context.Background(), Options{Schema: "app"}), db, &NaturalKeyObject{})
+	a.NoError(err)
+	a.Empty(l)
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+type IndexedObject struct {
+	ID       int
+	TenantID int    `sql:",index:idx_user_tenant"`
+	Email    string `sql:"email,unique"`
+	Name     string `sql:",index"`
+	Created  int    `sql:",index:idx_user_tenant"`
+}
+
+func TestModelIndexes(t *testing.T) {
+	a := assert.New(t)
+
+	l, err := ModelIndexes(&IndexedObject{})
+	a.NoError(err)
+	a.Equal([]ModelIndex{
+		{Name: "idx_user_tenant", Columns: []string{"tenant_id", "created"}},
+		{Name: "indexed_objects_email_key", Columns: []string{"email"}, Unique: true},
+		{Name: "indexed_objects_name_idx", Columns: []string{"name"}},
+	}, l)
+
+	l, err = ModelIndexes(&NaturalKeyObject{})
+	a.NoError(err)
+	a.Equal([]ModelIndex{{Name: "natural_key_objects_org_slug", Columns: []string{"org_id", "slug"}, Unique: true}}, l)
+}
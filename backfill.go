@@ -0,0 +1,131 @@
+package sorm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+type BackfillOptions struct {
+	BatchOptions
+
+	Args       []interface{}
+	StartAfter interface{}
+	Checkpoint func(ctx context.Context, last interface{}) error
+}
+
+var (
+	errorType = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+func trimWhere(where string) string {
+	where = strings.TrimSpace(where)
+	if len(where) >= 6 && strings.EqualFold(where[:6], "where ") {
+		where = strings.TrimSpace(where[6:])
+	}
+
+	return where
+}
+
+func Backfill(ctx context.Context, db Querier, model interface{}, where string, batchSize int, fn interface{}, opts *BackfillOptions) error {
+	if opts == nil {
+		opts = &BackfillOptions{}
+	}
+
+	if batchSize <= 0 {
+		return fmt.Errorf("Backfill: batch size should be positive; was instead %d", batchSize)
+	}
+
+	vtyp, err := structTypeOf(model)
+	if err != nil {
+		return fmt.Errorf("Backfill: %w", err)
+	}
+
+	fv := reflect.ValueOf(fn)
+	if fv.Kind() != reflect.Func || fv.Type().NumIn() != 1 || fv.Type().In(0) != reflect.SliceOf(vtyp) || fv.Type().NumOut() != 1 || fv.Type().Out(0) != errorType {
+		return fmt.Errorf("Backfill: expected fn to be func([]%s) error; was instead %T", vtyp.Name(), fn)
+	}
+
+	vdesc, err := getDescriptionContext(ctx, vtyp)
+	if err != nil {
+		return fmt.Errorf("Backfill: could not get detailed reflection information for type %s: %w", vtyp.String(), err)
+	}
+
+	idFields := getSQLIDFields(vdesc)
+	if len(idFields) != 1 {
+		return fmt.Errorf("Backfill: type %s should have exactly one ID field", vtyp.Name())
+	}
+
+	idIndex := idFields[0].Index()
+	idColumn := getSQLColumnName(idFields[0])
+	if err := checkIdentifier(idColumn); err != nil {
+		return fmt.Errorf("Backfill: %w", err)
+	}
+
+	cond := trimWhere(where)
+
+	last := opts.StartAfter
+
+	tracker := newBatchTracker(&opts.BatchOptions)
+
+	for {
+		args := append([]interface{}(nil), opts.Args...)
+
+		var conds []string
+		if cond != "" {
+			conds = append(conds, "("+cond+")")
+		}
+		if last != nil {
+			args = append(args, last)
+			conds = append(conds, idColumn+" > "+makeParameter(len(args)))
+		}
+
+		var q string
+		if len(conds) > 0 {
+			q = "where " + strings.Join(conds, " and ") + " "
+		}
+		q += fmt.Sprintf("order by %s limit %d", idColumn, batchSize)
+
+		batch := reflect.New(reflect.SliceOf(vtyp))
+		if err := findWhere(ctx, db, batch.Interface(), q, args, findOptions{}); err != nil {
+			return fmt.Errorf("Backfill: %w", err)
+		}
+
+		n := batch.Elem().Len()
+		if n == 0 {
+			return nil
+		}
+
+		if err := callError(fv, batch.Elem()); err != nil {
+			return fmt.Errorf("Backfill: %w", err)
+		}
+
+		last = batch.Elem().Index(n - 1).FieldByIndex(idIndex).Interface()
+
+		if opts.Checkpoint != nil {
+			if err := opts.Checkpoint(ctx, last); err != nil {
+				return fmt.Errorf("Backfill: checkpoint returned an error: %w", err)
+			}
+		}
+
+		tracker.done(int64(n))
+
+		if n < batchSize {
+			return nil
+		}
+
+		if err := tracker.wait(ctx); err != nil {
+			return fmt.Errorf("Backfill: %w", err)
+		}
+	}
+}
+
+func callError(fn reflect.Value, args ...reflect.Value) error {
+	out := fn.Call(args)
+	if err, ok := out[len(out)-1].Interface().(error); ok && err != nil {
+		return err
+	}
+
+	return nil
+}
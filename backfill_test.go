@@ -0,0 +1,62 @@
+package sorm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackfill(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from objects where \(name <> \$1\) order by id limit 2`).WithArgs("x").WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a").AddRow(2, "b"))
+	mockDB.ExpectQuery(`select \* from objects where \(name <> \$1\) and id > \$2 order by id limit 2`).WithArgs("x", 2).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(3, "c"))
+
+	var seen [][]Object
+	var checkpoints []interface{}
+
+	a.NoError(Backfill(context.Background(), db, Object{}, "where name <> $1", 2, func(batch []Object) error {
+		seen = append(seen, batch)
+		return nil
+	}, &BackfillOptions{
+		Args: []interface{}{"x"},
+		Checkpoint: func(ctx context.Context, last interface{}) error {
+			checkpoints = append(checkpoints, last)
+			return nil
+		},
+	}))
+
+	a.Equal([][]Object{{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}}, {{ID: 3, Name: "c"}}}, seen)
+	a.Equal([]interface{}{2, 3}, checkpoints)
+}
+
+func TestBackfillStartAfter(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from objects where id > \$1 order by id limit 10`).WithArgs(5).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+
+	a.NoError(Backfill(context.Background(), db, Object{}, "", 10, func(batch []Object) error {
+		t.Fatal("fn should not be called")
+		return nil
+	}, &BackfillOptions{StartAfter: 5}))
+}
+
+func TestBackfillBadFunc(t *testing.T) {
+	a := assert.New(t)
+
+	a.EqualError(Backfill(context.Background(), nil, Object{}, "", 10, func(batch []SimpleObject) error { return nil }, nil), "Backfill: expected fn to be func([]Object) error; was instead func([]sorm.SimpleObject) error")
+}
@@ -0,0 +1,68 @@
+package sorm
+
+import (
+	"context"
+	"time"
+)
+
+type BatchProgress struct {
+	Batches int
+	Rows    int64
+	Elapsed time.Duration
+}
+
+type BatchOptions struct {
+	Progress         func(p BatchProgress)
+	Sleep            time.Duration
+	MaxRowsPerSecond float64
+}
+
+type batchTracker struct {
+	opts  BatchOptions
+	start time.Time
+	p     BatchProgress
+}
+
+func newBatchTracker(opts *BatchOptions) *batchTracker {
+	t := batchTracker{start: time.Now()}
+	if opts != nil {
+		t.opts = *opts
+	}
+
+	return &t
+}
+
+func (t *batchTracker) done(rows int64) {
+	t.p.Batches++
+	t.p.Rows += rows
+	t.p.Elapsed = time.Since(t.start)
+
+	if t.opts.Progress != nil {
+		t.opts.Progress(t.p)
+	}
+}
+
+func (t *batchTracker) wait(ctx context.Context) error {
+	d := t.opts.Sleep
+
+	if t.opts.MaxRowsPerSecond > 0 {
+		min := time.Duration(float64(t.p.Rows) / t.opts.MaxRowsPerSecond * float64(time.Second))
+		if r := min - time.Since(t.start); r > d {
+			d = r
+		}
+	}
+
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,63 @@
+package sorm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchOptionsProgress(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from objects order by id limit 2`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a").AddRow(2, "b"))
+	mockDB.ExpectQuery(`select \* from objects where id > \$1 order by id limit 2`).WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(3, "c"))
+
+	var progress []BatchProgress
+
+	start := time.Now()
+
+	a.NoError(Backfill(context.Background(), db, Object{}, "", 2, func(batch []Object) error { return nil }, &BackfillOptions{
+		BatchOptions: BatchOptions{
+			Sleep: time.Millisecond * 20,
+			Progress: func(p BatchProgress) {
+				p.Elapsed = 0
+				progress = append(progress, p)
+			},
+		},
+	}))
+
+	a.True(time.Since(start) >= time.Millisecond*20)
+	a.Equal([]BatchProgress{{Batches: 1, Rows: 2}, {Batches: 2, Rows: 3}}, progress)
+}
+
+func TestBatchOptionsCancel(t *testing.T) {
+	a := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tracker := newBatchTracker(&BatchOptions{Sleep: time.Hour})
+	tracker.done(1)
+
+	a.Equal(context.Canceled, tracker.wait(ctx))
+}
+
+func TestBatchOptionsRateLimit(t *testing.T) {
+	a := assert.New(t)
+
+	tracker := newBatchTracker(&BatchOptions{MaxRowsPerSecond: 100})
+	tracker.done(3)
+
+	start := time.Now()
+	a.NoError(tracker.wait(context.Background()))
+	a.True(time.Since(start) >= time.Millisecond*20)
+}
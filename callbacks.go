@@ -0,0 +1,118 @@
+package sorm
+
+import (
+	"context"
+	"fmt"
+)
+
+type Operation int
+
+const (
+	OperationCreate Operation = iota
+	OperationSave
+	OperationReplace
+	OperationDelete
+	OperationFind
+	OperationCount
+	// OperationQuery is a hand-written query run with QueryInto.
+	OperationQuery
+	// OperationExec is a hand-written statement run with Exec.
+	OperationExec
+)
+
+func (o Operation) String() string {
+	switch o {
+	case OperationCreate:
+		return "create"
+	case OperationSave:
+		return "save"
+	case OperationReplace:
+		return "replace"
+	case OperationDelete:
+		return "delete"
+	case OperationFind:
+		return "find"
+	case OperationCount:
+		return "count"
+	case OperationQuery:
+		return "query"
+	case OperationExec:
+		return "exec"
+	default:
+		return fmt.Sprintf("Operation(%d)", int(o))
+	}
+}
+
+type Phase int
+
+const (
+	PhaseBefore Phase = iota
+	PhaseAfter
+)
+
+func (p Phase) String() string {
+	switch p {
+	case PhaseBefore:
+		return "before"
+	case PhaseAfter:
+		return "after"
+	default:
+		return fmt.Sprintf("Phase(%d)", int(p))
+	}
+}
+
+// CallbackEvent describes the operation a callback is intercepting. Value is
+// the record pointer for writes and the output pointer for finds; Previous is
+// the stored record a save is replacing. Before callbacks run once the
+// statement is built, just before it's executed, so changes they make to
+// Value aren't reflected in Query.
+type CallbackEvent struct {
+	Operation Operation
+	Phase     Phase
+	Value     interface{}
+	Previous  interface{}
+	Table     string
+	Query     string
+	Args      []interface{}
+}
+
+type CallbackFunc func(ctx context.Context, db Querier, e *CallbackEvent) error
+
+type callback struct {
+	op    Operation
+	phase Phase
+	fn    CallbackFunc
+}
+
+var (
+	callbacks []callback
+)
+
+func RegisterCallback(op Operation, phase Phase, fn CallbackFunc) {
+	callbacks = append(callbacks, callback{op: op, phase: phase, fn: fn})
+}
+
+func runCallbacks(ctx context.Context, db Querier, op Operation, phase Phase, value interface{}, table string, stmt Statement) error {
+	return runCallbackEvent(ctx, db, &CallbackEvent{
+		Operation: op,
+		Phase:     phase,
+		Value:     value,
+		Table:     table,
+		Query:     stmt.Query,
+		Args:      stmt.Args,
+	})
+}
+
+func runCallbackEvent(ctx context.Context, db Querier, e *CallbackEvent) error {
+	for _, c := range callbacks {
+		if c.op != e.Operation || c.phase != e.Phase {
+			continue
+		}
+
+		if err := callHook(ctx, "sorm callback "+e.Phase.String()+" "+e.Operation.String(), func(ctx context.Context) error { return c.fn(ctx, db, e) }); err != nil {
+			return fmt.Errorf("%s %s callback returned an error: %w", e.Phase, e.Operation, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package sorm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterCallback(t *testing.T) {
+	a := assert.New(t)
+
+	defer func(l []callback) { callbacks = l }(callbacks)
+
+	var events []CallbackEvent
+	record := func(ctx context.Context, db Querier, e *CallbackEvent) error {
+		events = append(events, *e)
+		return nil
+	}
+
+	RegisterCallback(OperationCreate, PhaseBefore, record)
+	RegisterCallback(OperationCreate, PhaseAfter, record)
+	RegisterCallback(OperationFind, PhaseAfter, record)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectExec(`insert into simple_objects \(id, name\) values \(\$1, \$2\)`).WithArgs(1, "test1").WillReturnResult(sqlmock.NewResult(1, 1))
+	mockDB.ExpectQuery(`select \* from simple_objects`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "test1"))
+
+	r := SimpleObject{ID: 1, Name: "test1"}
+	a.NoError(CreateRecord(context.Background(), db, &r))
+
+	var l []SimpleObject
+	a.NoError(FindAll(context.Background(), db, &l))
+
+	a.Equal([]CallbackEvent{
+		{Operation: OperationCreate, Phase: PhaseBefore, Value: &r, Table: "simple_objects", Query: "insert into simple_objects (id, name) values ($1, $2)", Args: []interface{}{1, "test1"}},
+		{Operation: OperationCreate, Phase: PhaseAfter, Value: &r, Table: "simple_objects", Query: "insert into simple_objects (id, name) values ($1, $2)", Args: []interface{}{1, "test1"}},
+		{Operation: OperationFind, Phase: PhaseAfter, Value: &l, Table: "simple_objects", Query: "select * from simple_objects"},
+	}, events)
+}
+
+func TestRegisterCallbackError(t *testing.T) {
+	a := assert.New(t)
+
+	defer func(l []callback) { callbacks = l }(callbacks)
+
+	RegisterCallback(OperationDelete, PhaseBefore, func(ctx context.Context, db Querier, e *CallbackEvent) error {
+		return errors.New("deletes are disabled")
+	})
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	a.EqualError(DeleteRecord(context.Background(), db, &SimpleObject{ID: 1}), "DeleteRecord: before delete callback returned an error: deletes are disabled")
+	a.NoError(mockDB.ExpectationsWereMet())
+}
@@ -0,0 +1,159 @@
+package sorm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Clauser is anything that can produce the part of a query following "from
+// <table>", along with its arguments. Clause wraps a plain string; qsorm
+// builds them from sqlbuilder expressions.
+type Clauser interface {
+	Clause() (string, []interface{}, error)
+}
+
+type rawClause struct {
+	sql  string
+	args []interface{}
+}
+
+func (c rawClause) Clause() (string, []interface{}, error) {
+	return c.sql, c.args, nil
+}
+
+// Clause returns a Clauser for a where clause written by hand, e.g.
+// Clause("where id = $1", 5).
+func Clause(sql string, args ...interface{}) Clauser {
+	return rawClause{sql: sql, args: args}
+}
+
+func FindClause(ctx context.Context, db Querier, out interface{}, c Clauser) error {
+	where, args, err := c.Clause()
+	if err != nil {
+		return fmt.Errorf("FindClause: %w", err)
+	}
+
+	return findWhere(ctx, db, out, where, args, findOptions{guardLimit: true})
+}
+
+func FindFirstClause(ctx context.Context, db Querier, out interface{}, c Clauser) error {
+	where, args, err := c.Clause()
+	if err != nil {
+		return fmt.Errorf("FindFirstClause: %w", err)
+	}
+
+	return findFirstWhere(ctx, db, out, where, args, findOptions{})
+}
+
+func CountClause(ctx context.Context, db Querier, val interface{}, c Clauser) (int, error) {
+	where, args, err := c.Clause()
+	if err != nil {
+		return 0, fmt.Errorf("CountClause: %w", err)
+	}
+
+	return CountWhere(ctx, db, val, where, args...)
+}
+
+// DeleteClause deletes the rows of model's table matching c and returns how
+// many there were. No hooks or callbacks are run, and an empty clause is an
+// error rather than a way to empty the table.
+func DeleteClause(ctx context.Context, db Querier, model interface{}, c Clauser) (int64, error) {
+	n, err := execClause(ctx, db, OperationDelete, model, "delete from ", c)
+	if err != nil {
+		return 0, fmt.Errorf("DeleteClause: %w", err)
+	}
+
+	return n, nil
+}
+
+// UpdateClause runs "update <table> " followed by c, which should start with
+// "set", and returns how many rows changed. Like DeleteClause it skips hooks
+// and callbacks and refuses an empty clause.
+func UpdateClause(ctx context.Context, db Querier, model interface{}, c Clauser) (int64, error) {
+	n, err := execClause(ctx, db, OperationSave, model, "update ", c)
+	if err != nil {
+		return 0, fmt.Errorf("UpdateClause: %w", err)
+	}
+
+	return n, nil
+}
+
+func execClause(ctx context.Context, db Querier, op Operation, model interface{}, verb string, c Clauser) (int64, error) {
+	ctx, db = route(ctx, db, model, op)
+
+	vtyp, err := structTypeOf(model)
+	if err != nil {
+		return 0, err
+	}
+
+	vdesc, err := getDescriptionContext(ctx, vtyp)
+	if err != nil {
+		return 0, fmt.Errorf("could not get detailed reflection information for type %s: %w", vtyp.String(), err)
+	}
+
+	if err := checkWritable(vdesc); err != nil {
+		return 0, err
+	}
+
+	clause, args, err := c.Clause()
+	if err != nil {
+		return 0, err
+	}
+	if clause == "" {
+		return 0, fmt.Errorf("refusing to %s every row of %s; use a clause", strings.Fields(verb)[0], vtyp.Name())
+	}
+
+	clause, args, err = expandArgs(clause, args)
+	if err != nil {
+		return 0, err
+	}
+
+	scope, scopeArgs, err := defaultScope(ctx, vtyp, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	if scope != "" {
+		clause, args, err = renumberArgs(clause, args, 0)
+		if err != nil {
+			return 0, err
+		}
+
+		scope, scopeArgs, err = renumberArgs(scope, scopeArgs, len(args))
+		if err != nil {
+			return 0, err
+		}
+
+		clause, args = injectCondition(clause, scope), append(args, scopeArgs...)
+	}
+
+	tbl := getSQLTableNameContext(ctx, vdesc)
+	if err := checkIdentifier(tbl); err != nil {
+		return 0, err
+	}
+
+	query := verb + tbl + " " + clause
+
+	stmt := Statement{Query: query, Args: args}
+
+	logQuery(ctx, query, args)
+
+	start := time.Now()
+
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		logQueryAfter(ctx, query, args, start, err)
+		observeOperation(ctx, op, vtyp, tbl, stmt, start, 0, err)
+
+		return 0, TranslateError(err)
+	}
+
+	logQueryAfter(ctx, query, args, start, nil)
+	observeOperation(ctx, op, vtyp, tbl, stmt, start, rowsAffected(res), nil)
+
+	invalidateResultCache(ctx, tbl)
+
+	return res.RowsAffected()
+}
@@ -0,0 +1,54 @@
+package sorm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClause(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`^select \* from simple_objects where name = \$1$`).WithArgs("a").WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a"))
+	mockDB.ExpectQuery(`^select count\(\*\) from simple_objects where name = \$1$`).WithArgs("a").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mockDB.ExpectQuery(`^select \* from simple_objects where id = \$1 limit 1$`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a"))
+	mockDB.ExpectExec(`^delete from simple_objects where name = \$1$`).WithArgs("a").WillReturnResult(sqlmock.NewResult(0, 3))
+	mockDB.ExpectExec(`^update simple_objects set name = \$1 where id in \(\$2, \$3\)$`).WithArgs("b", 1, 2).WillReturnResult(sqlmock.NewResult(0, 2))
+
+	var l []SimpleObject
+	a.NoError(FindClause(context.Background(), db, &l, Clause("where name = $1", "a")))
+	a.Equal([]SimpleObject{{ID: 1, Name: "a"}}, l)
+
+	n, err := CountClause(context.Background(), db, &SimpleObject{}, Clause("where name = $1", "a"))
+	a.NoError(err)
+	a.Equal(1, n)
+
+	var r SimpleObject
+	a.NoError(FindFirstClause(context.Background(), db, &r, Clause("where id = $1", 1)))
+	a.Equal(SimpleObject{ID: 1, Name: "a"}, r)
+
+	deleted, err := DeleteClause(context.Background(), db, SimpleObject{}, Clause("where name = $1", "a"))
+	a.NoError(err)
+	a.Equal(int64(3), deleted)
+
+	updated, err := UpdateClause(context.Background(), db, SimpleObject{}, Clause("set name = $1 where id in ($2)", "b", []int{1, 2}))
+	a.NoError(err)
+	a.Equal(int64(2), updated)
+
+	_, err = DeleteClause(context.Background(), db, SimpleObject{}, Clause(""))
+	a.EqualError(err, "DeleteClause: refusing to delete every row of SimpleObject; use a clause")
+
+	_, err = UpdateClause(context.Background(), db, UserListItem{}, Clause("set name = $1", "b"))
+	a.True(errors.Is(err, ErrProjection))
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
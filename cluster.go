@@ -0,0 +1,152 @@
+package sorm
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type Balance int
+
+const (
+	BalanceRoundRobin Balance = iota
+	// BalanceLeastConnections picks the replica with the fewest connections
+	// in use.
+	BalanceLeastConnections
+)
+
+// Cluster is a Querier that sends writes to Primary and spreads reads across
+// Replicas.
+type Cluster struct {
+	Primary  *sql.DB
+	Replicas []*sql.DB
+	Balance  Balance
+	// FreshFor is how long reads made with a RequireFresh context keep going
+	// to the primary after that context was used for a write. It should be
+	// at least the expected replication lag.
+	FreshFor time.Duration
+
+	next uint32
+}
+
+var _ Querier = (*Cluster)(nil)
+
+func NewCluster(primary *sql.DB, replicas ...*sql.DB) *Cluster {
+	return &Cluster{Primary: primary, Replicas: replicas, FreshFor: time.Second}
+}
+
+type freshnessKey struct{}
+
+type freshness struct {
+	m         sync.Mutex
+	lastWrite time.Time
+}
+
+// RequireFresh returns a context whose reads through a Cluster will see the
+// writes made with it, by routing them to the primary for a while after each
+// write.
+func RequireFresh(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(freshnessKey{}).(*freshness); ok {
+		return ctx
+	}
+
+	return context.WithValue(ctx, freshnessKey{}, &freshness{})
+}
+
+type primaryKey struct{}
+
+// UsePrimary makes reads through a Cluster with ctx go to the primary, e.g.
+// to read back a record just written. The write functions use it for the
+// reads they make themselves, like loading the previous version of a record.
+func UsePrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// primaryOnly are the parts of a select that make it lock rows or change
+// state, so it can't go to a replica.
+var primaryOnly = []string{" for update", " for share", " for no key update", " for key share", " lock in share mode", "updlock", "holdlock", "nextval(", "setval("}
+
+func isReadQuery(query string) bool {
+	q := strings.TrimSpace(query)
+	if len(q) < 6 || !strings.EqualFold(q[:6], "select") {
+		return false
+	}
+
+	q = strings.ToLower(q)
+	for _, s := range primaryOnly {
+		if strings.Contains(q, s) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c *Cluster) reader(ctx context.Context) *sql.DB {
+	if len(c.Replicas) == 0 {
+		return c.Primary
+	}
+
+	if b, _ := ctx.Value(primaryKey{}).(bool); b {
+		return c.Primary
+	}
+
+	if f, ok := ctx.Value(freshnessKey{}).(*freshness); ok {
+		f.m.Lock()
+		lastWrite := f.lastWrite
+		f.m.Unlock()
+
+		if !lastWrite.IsZero() && timeNow().Sub(lastWrite) < c.FreshFor {
+			return c.Primary
+		}
+	}
+
+	if c.Balance == BalanceLeastConnections {
+		best := c.Replicas[0]
+		inUse := best.Stats().InUse
+		for _, db := range c.Replicas[1:] {
+			if n := db.Stats().InUse; n < inUse {
+				best, inUse = db, n
+			}
+		}
+
+		return best
+	}
+
+	n := atomic.AddUint32(&c.next, 1)
+
+	return c.Replicas[int(n-1)%len(c.Replicas)]
+}
+
+func (c *Cluster) writer(ctx context.Context) *sql.DB {
+	if f, ok := ctx.Value(freshnessKey{}).(*freshness); ok {
+		f.m.Lock()
+		f.lastWrite = timeNow()
+		f.m.Unlock()
+	}
+
+	return c.Primary
+}
+
+func (c *Cluster) route(ctx context.Context, query string) *sql.DB {
+	if isReadQuery(query) {
+		return c.reader(ctx)
+	}
+
+	return c.writer(ctx)
+}
+
+func (c *Cluster) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return c.writer(ctx).ExecContext(ctx, query, args...)
+}
+
+func (c *Cluster) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return c.route(ctx, query).QueryContext(ctx, query, args...)
+}
+
+func (c *Cluster) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return c.route(ctx, query).QueryRowContext(ctx, query, args...)
+}
@@ -0,0 +1,184 @@
+package sorm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClusterRouting(t *testing.T) {
+	a := assert.New(t)
+
+	primary, primaryMock, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer primary.Close()
+
+	replica, replicaMock, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer replica.Close()
+
+	c := NewCluster(primary, replica)
+
+	replicaMock.ExpectQuery(`select \* from simple_objects`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "test1"))
+	primaryMock.ExpectQuery(`insert into simple_objects \(name\) values \(\$1\) returning id`).WithArgs("test2").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	replicaMock.ExpectQuery(`select \* from simple_objects`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "test1"))
+
+	var l []SimpleObject
+	a.NoError(FindAll(context.Background(), c, &l))
+	a.NoError(CreateRecord(context.Background(), c, &SimpleObject{Name: "test2"}))
+	a.NoError(FindAll(context.Background(), c, &l))
+
+	a.NoError(primaryMock.ExpectationsWereMet())
+	a.NoError(replicaMock.ExpectationsWereMet())
+}
+
+func TestClusterRequireFresh(t *testing.T) {
+	a := assert.New(t)
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	primary, primaryMock, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer primary.Close()
+
+	replica, replicaMock, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer replica.Close()
+
+	c := NewCluster(primary, replica)
+
+	ctx := RequireFresh(context.Background())
+
+	replicaMock.ExpectQuery(`select \* from simple_objects`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	primaryMock.ExpectExec(`insert into simple_objects \(id, name\) values \(\$1, \$2\)`).WithArgs(1, "test1").WillReturnResult(sqlmock.NewResult(1, 1))
+	primaryMock.ExpectQuery(`select \* from simple_objects`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "test1"))
+	replicaMock.ExpectQuery(`select \* from simple_objects`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "test1"))
+
+	var l []SimpleObject
+	a.NoError(FindAll(ctx, c, &l))
+	a.NoError(CreateRecord(ctx, c, &SimpleObject{ID: 1, Name: "test1"}))
+	a.NoError(FindAll(ctx, c, &l))
+
+	now = now.Add(2 * time.Second)
+	a.NoError(FindAll(ctx, c, &l))
+
+	a.NoError(primaryMock.ExpectationsWereMet())
+	a.NoError(replicaMock.ExpectationsWereMet())
+}
+
+func TestClusterUsePrimary(t *testing.T) {
+	a := assert.New(t)
+
+	primary, primaryMock, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer primary.Close()
+
+	replica, replicaMock, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer replica.Close()
+
+	c := NewCluster(primary, replica)
+
+	primaryMock.ExpectQuery(`select \* from simple_objects`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "test1"))
+	primaryMock.ExpectQuery(`select \* from simple_objects where id = \$1`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "test1"))
+	primaryMock.ExpectExec(`update simple_objects set name = \$2 where id = \$1`).WithArgs(1, "test2").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	var l []SimpleObject
+	a.NoError(FindAll(UsePrimary(context.Background()), c, &l))
+	a.NoError(SaveRecord(context.Background(), c, &SimpleObject{ID: 1, Name: "test2"}))
+
+	a.NoError(primaryMock.ExpectationsWereMet())
+	a.NoError(replicaMock.ExpectationsWereMet())
+}
+
+func TestClusterLeastConnections(t *testing.T) {
+	a := assert.New(t)
+
+	primary, _, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer primary.Close()
+
+	busy, busyMock, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer busy.Close()
+
+	idle, idleMock, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer idle.Close()
+
+	c := NewCluster(primary, busy, idle)
+	c.Balance = BalanceLeastConnections
+
+	conn, err := busy.Conn(context.Background())
+	if !a.NoError(err) {
+		return
+	}
+	defer conn.Close()
+
+	idleMock.ExpectQuery(`select \* from simple_objects`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	idleMock.ExpectQuery(`select \* from simple_objects`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+
+	var l []SimpleObject
+	a.NoError(FindAll(context.Background(), c, &l))
+	a.NoError(FindAll(context.Background(), c, &l))
+
+	a.NoError(busyMock.ExpectationsWereMet())
+	a.NoError(idleMock.ExpectationsWereMet())
+}
+
+func TestClusterLockingReads(t *testing.T) {
+	a := assert.New(t)
+
+	primary, primaryMock, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer primary.Close()
+
+	replica, replicaMock, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer replica.Close()
+
+	c := NewCluster(primary, replica)
+
+	primaryMock.ExpectQuery(`^select \* from simple_objects order by id limit 2 for update skip locked$`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "test1"))
+	primaryMock.ExpectQuery(`^select nextval\('objects_id_seq'\)$`).WillReturnRows(sqlmock.NewRows([]string{"nextval"}).AddRow(5))
+
+	var l []SimpleObject
+	a.NoError(ClaimRecords(context.Background(), c, &l, 2, "", "id"))
+
+	var n int
+	a.NoError(ScanScalar(context.Background(), c, &n, "select nextval('objects_id_seq')"))
+	a.Equal(5, n)
+
+	a.True(isReadQuery("SELECT * FROM objects"))
+	a.False(isReadQuery("select * from objects for share"))
+
+	a.NoError(primaryMock.ExpectationsWereMet())
+	a.NoError(replicaMock.ExpectationsWereMet())
+}
@@ -0,0 +1,342 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+type generator struct {
+	param string
+}
+
+func (g *generator) parameter(n int) string {
+	return g.param + strconv.Itoa(n)
+}
+
+func (g *generator) columns(m *model) string {
+	var l []string
+	for _, f := range m.Fields {
+		l = append(l, f.Column)
+	}
+
+	return strings.Join(l, ", ")
+}
+
+func (g *generator) where(fields []field, offset int) string {
+	var l []string
+	for i, f := range fields {
+		l = append(l, f.Column+" = "+g.parameter(offset+i+1))
+	}
+
+	return "where " + strings.Join(l, " and ")
+}
+
+func writable(m *model) []field {
+	var l []field
+	for _, f := range m.Fields {
+		if !f.ReadOnly {
+			l = append(l, f)
+		}
+	}
+
+	return l
+}
+
+// fetchesID mirrors CreateRecord: a zero ID field named ID is left for the
+// database to fill in.
+func fetchesID(m *model) *field {
+	ids := m.IDFields()
+	if len(ids) == 1 && ids[0].Name == "ID" && !ids[0].ReadOnly {
+		return &ids[0]
+	}
+
+	return nil
+}
+
+func (g *generator) insert(m *model, withID bool) string {
+	var cols, params []string
+	for _, f := range writable(m) {
+		if !withID && f.ID {
+			continue
+		}
+
+		cols = append(cols, f.Column)
+		params = append(params, g.parameter(len(cols)))
+	}
+
+	q := fmt.Sprintf("insert into %s (%s) values (%s)", m.Table, strings.Join(cols, ", "), strings.Join(params, ", "))
+	if !withID {
+		q += " returning id"
+	}
+
+	return q
+}
+
+func insertArgs(m *model, withID bool) string {
+	var l []string
+	for _, f := range writable(m) {
+		if withID || !f.ID {
+			l = append(l, "v."+f.Name)
+		}
+	}
+
+	return strings.Join(l, ", ")
+}
+
+// update returns the update statement and its arguments, or nothing when
+// only ID columns are writable.
+func (g *generator) update(m *model) []string {
+	var sets, args []string
+	for _, f := range writable(m) {
+		if f.ID {
+			continue
+		}
+
+		args = append(args, "v."+f.Name)
+		sets = append(sets, f.Column+" = "+g.parameter(len(args)))
+	}
+
+	ids := m.IDFields()
+	for _, f := range ids {
+		args = append(args, "v."+f.Name)
+	}
+
+	if len(sets) == 0 {
+		return nil
+	}
+
+	return []string{fmt.Sprintf("update %s set %s %s", m.Table, strings.Join(sets, ", "), g.where(ids, len(sets))), strings.Join(args, ", ")}
+}
+
+func (g *generator) delete(m *model) string {
+	return fmt.Sprintf("delete from %s %s", m.Table, g.where(m.IDFields(), 0))
+}
+
+// argName turns a field name into a parameter name that can't clash with
+// anything else in the generated methods.
+func argName(s string) string {
+	r := []rune(s)
+	for i := 0; i < len(r) && unicode.IsUpper(r[i]); i++ {
+		if i > 0 && i+1 < len(r) && unicode.IsLower(r[i+1]) {
+			break
+		}
+		r[i] = unicode.ToLower(r[i])
+	}
+	s = string(r)
+
+	switch {
+	case token.IsKeyword(s), s == "ctx", s == "r", s == "v", s == "err", s == "rows", s == "res":
+		return s + "_"
+	}
+
+	return s
+}
+
+func params(fields []field) string {
+	var l []string
+	for _, f := range fields {
+		l = append(l, argName(f.Name)+" "+f.Type)
+	}
+
+	return strings.Join(l, ", ")
+}
+
+func args(fields []field) string {
+	var l []string
+	for _, f := range fields {
+		l = append(l, argName(f.Name))
+	}
+
+	return strings.Join(l, ", ")
+}
+
+func uniques(m *model) []field {
+	var l []field
+	for _, f := range m.Fields {
+		if f.Unique && !f.ID {
+			l = append(l, f)
+		}
+	}
+
+	return l
+}
+
+func (g *generator) generate(pkgName string, imports []string, models []*model) ([]byte, error) {
+	funcs := template.FuncMap{
+		"columns":    g.columns,
+		"where":      g.where,
+		"insert":     g.insert,
+		"insertArgs": insertArgs,
+		"update":     g.update,
+		"delete":     g.delete,
+		"fetchesID":  fetchesID,
+		"params":     params,
+		"args":       args,
+		"uniques":    uniques,
+		"one":        func(f field) []field { return []field{f} },
+		"quote":      strconv.Quote,
+	}
+
+	t, err := template.New("repo").Funcs(funcs).Parse(repoTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	if err := t.Execute(&b, map[string]interface{}{"Package": pkgName, "Imports": imports, "Models": models}); err != nil {
+		return nil, err
+	}
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("couldn't format generated code: %w", err)
+	}
+
+	return src, nil
+}
+
+const repoTemplate = `// Code generated by sormgen; DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"fknsrs.biz/p/sorm"
+{{- range .Imports}}
+	{{quote .}}
+{{- end}}
+)
+
+{{range $m := .Models}}
+{{- $ids := $m.IDFields}}
+// {{$m.Name}}Repo reads{{if not $m.ReadOnly}} and writes{{end}} {{$m.Name}} records in {{$m.Table}} without reflection.
+type {{$m.Name}}Repo struct {
+	DB sorm.Querier
+}
+
+func New{{$m.Name}}Repo(db sorm.Querier) *{{$m.Name}}Repo {
+	return &{{$m.Name}}Repo{DB: db}
+}
+
+func scanDest{{$m.Name}}(v *{{$m.Name}}) []interface{} {
+	return []interface{}{ {{- range $i, $f := $m.Fields}}{{if $i}}, {{end}}&v.{{$f.Name}}{{end -}} }
+}
+
+func (r *{{$m.Name}}Repo) findOne(ctx context.Context, op, query string, args ...interface{}) (*{{$m.Name}}, error) {
+	var v {{$m.Name}}
+	if err := r.DB.QueryRowContext(ctx, query, args...).Scan(scanDest{{$m.Name}}(&v)...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("{{$m.Name}}Repo.%s: %w", op, sorm.ErrRecordNotFound)
+		}
+
+		return nil, fmt.Errorf("{{$m.Name}}Repo.%s: %w", op, sorm.TranslateError(err))
+	}
+
+	return &v, nil
+}
+
+func (r *{{$m.Name}}Repo) FindByID(ctx context.Context, {{params $ids}}) (*{{$m.Name}}, error) {
+	return r.findOne(ctx, "FindByID", {{quote (printf "select %s from %s %s" (columns $m) $m.Table (where $ids 0))}}, {{args $ids}})
+}
+{{range $f := uniques $m}}
+func (r *{{$m.Name}}Repo) FindBy{{$f.Name}}(ctx context.Context, {{params (one $f)}}) (*{{$m.Name}}, error) {
+	return r.findOne(ctx, "FindBy{{$f.Name}}", {{quote (printf "select %s from %s %s" (columns $m) $m.Table (where (one $f) 0))}}, {{args (one $f)}})
+}
+{{end}}
+// FindWhere finds the records matching where, which is the text after the
+// table name, e.g. "where name = $1 order by id".
+func (r *{{$m.Name}}Repo) FindWhere(ctx context.Context, where string, args ...interface{}) ([]{{$m.Name}}, error) {
+	query := {{quote (printf "select %s from %s" (columns $m) $m.Table)}}
+	if where != "" {
+		query += " " + where
+	}
+
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("{{$m.Name}}Repo.FindWhere: %w", sorm.TranslateError(err))
+	}
+	defer rows.Close()
+
+	var l []{{$m.Name}}
+	for rows.Next() {
+		var v {{$m.Name}}
+		if err := rows.Scan(scanDest{{$m.Name}}(&v)...); err != nil {
+			return nil, fmt.Errorf("{{$m.Name}}Repo.FindWhere: %w", err)
+		}
+
+		l = append(l, v)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("{{$m.Name}}Repo.FindWhere: %w", sorm.TranslateError(err))
+	}
+
+	return l, nil
+}
+
+func (r *{{$m.Name}}Repo) FindAll(ctx context.Context) ([]{{$m.Name}}, error) {
+	return r.FindWhere(ctx, "")
+}
+{{- if not $m.ReadOnly}}
+
+func (r *{{$m.Name}}Repo) Create(ctx context.Context, v *{{$m.Name}}) error {
+{{- with $id := fetchesID $m}}
+	var zero {{$id.Type}}
+	if v.ID == zero {
+		if err := r.DB.QueryRowContext(ctx, {{quote (insert $m false)}}, {{insertArgs $m false}}).Scan(&v.ID); err != nil {
+			return fmt.Errorf("{{$m.Name}}Repo.Create: %w", sorm.TranslateError(err))
+		}
+
+		return nil
+	}
+
+{{end}}
+	if _, err := r.DB.ExecContext(ctx, {{quote (insert $m true)}}, {{insertArgs $m true}}); err != nil {
+		return fmt.Errorf("{{$m.Name}}Repo.Create: %w", sorm.TranslateError(err))
+	}
+
+	return nil
+}
+{{- $update := update $m}}
+{{- if $update}}
+
+// Save writes every column but the IDs, rather than only the changed ones as
+// sorm.SaveRecord does.
+func (r *{{$m.Name}}Repo) Save(ctx context.Context, v *{{$m.Name}}) error {
+	res, err := r.DB.ExecContext(ctx, {{quote (index $update 0)}}, {{index $update 1}})
+	if err != nil {
+		return fmt.Errorf("{{$m.Name}}Repo.Save: %w", sorm.TranslateError(err))
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n != 1 {
+		return fmt.Errorf("{{$m.Name}}Repo.Save: %w", &sorm.RowsAffectedError{Op: sorm.OperationSave, Table: {{quote $m.Table}}, Expected: 1, Actual: n})
+	}
+
+	return nil
+}
+{{- end}}
+
+func (r *{{$m.Name}}Repo) Delete(ctx context.Context, v *{{$m.Name}}) error {
+	res, err := r.DB.ExecContext(ctx, {{quote (delete $m)}}, {{range $i, $f := $ids}}{{if $i}}, {{end}}v.{{$f.Name}}{{end}})
+	if err != nil {
+		return fmt.Errorf("{{$m.Name}}Repo.Delete: %w", sorm.TranslateError(err))
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n != 1 {
+		return fmt.Errorf("{{$m.Name}}Repo.Delete: %w", &sorm.RowsAffectedError{Op: sorm.OperationDelete, Table: {{quote $m.Table}}, Expected: 1, Actual: n})
+	}
+
+	return nil
+}
+{{- end}}
+{{end}}`
@@ -0,0 +1,26 @@
+// Package example has models for testing sormgen's output.
+package example
+
+import "time"
+
+//go:generate go run fknsrs.biz/p/sorm/cmd/sormgen -type User,Membership,Report -output models_sorm.go
+
+type User struct {
+	ID        int
+	Email     string `sql:",unique"`
+	Name      string
+	Type      string
+	CreatedAt time.Time `sql:"created_at,readonly"`
+}
+
+type Membership struct {
+	UserID  int `sql:",id"`
+	GroupID int `sql:",id"`
+	Role    string
+}
+
+type Report struct {
+	_     struct{} `sorm:"view" table:"user_reports"`
+	ID    int
+	Total int
+}
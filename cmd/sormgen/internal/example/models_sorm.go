@@ -0,0 +1,292 @@
+// Code generated by sormgen; DO NOT EDIT.
+
+package example
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"fknsrs.biz/p/sorm"
+)
+
+// UserRepo reads and writes User records in users without reflection.
+type UserRepo struct {
+	DB sorm.Querier
+}
+
+func NewUserRepo(db sorm.Querier) *UserRepo {
+	return &UserRepo{DB: db}
+}
+
+func scanDestUser(v *User) []interface{} {
+	return []interface{}{&v.ID, &v.Email, &v.Name, &v.Type, &v.CreatedAt}
+}
+
+func (r *UserRepo) findOne(ctx context.Context, op, query string, args ...interface{}) (*User, error) {
+	var v User
+	if err := r.DB.QueryRowContext(ctx, query, args...).Scan(scanDestUser(&v)...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("UserRepo.%s: %w", op, sorm.ErrRecordNotFound)
+		}
+
+		return nil, fmt.Errorf("UserRepo.%s: %w", op, sorm.TranslateError(err))
+	}
+
+	return &v, nil
+}
+
+func (r *UserRepo) FindByID(ctx context.Context, id int) (*User, error) {
+	return r.findOne(ctx, "FindByID", "select id, email, name, type, created_at from users where id = $1", id)
+}
+
+func (r *UserRepo) FindByEmail(ctx context.Context, email string) (*User, error) {
+	return r.findOne(ctx, "FindByEmail", "select id, email, name, type, created_at from users where email = $1", email)
+}
+
+// FindWhere finds the records matching where, which is the text after the
+// table name, e.g. "where name = $1 order by id".
+func (r *UserRepo) FindWhere(ctx context.Context, where string, args ...interface{}) ([]User, error) {
+	query := "select id, email, name, type, created_at from users"
+	if where != "" {
+		query += " " + where
+	}
+
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("UserRepo.FindWhere: %w", sorm.TranslateError(err))
+	}
+	defer rows.Close()
+
+	var l []User
+	for rows.Next() {
+		var v User
+		if err := rows.Scan(scanDestUser(&v)...); err != nil {
+			return nil, fmt.Errorf("UserRepo.FindWhere: %w", err)
+		}
+
+		l = append(l, v)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("UserRepo.FindWhere: %w", sorm.TranslateError(err))
+	}
+
+	return l, nil
+}
+
+func (r *UserRepo) FindAll(ctx context.Context) ([]User, error) {
+	return r.FindWhere(ctx, "")
+}
+
+func (r *UserRepo) Create(ctx context.Context, v *User) error {
+	var zero int
+	if v.ID == zero {
+		if err := r.DB.QueryRowContext(ctx, "insert into users (email, name, type) values ($1, $2, $3) returning id", v.Email, v.Name, v.Type).Scan(&v.ID); err != nil {
+			return fmt.Errorf("UserRepo.Create: %w", sorm.TranslateError(err))
+		}
+
+		return nil
+	}
+
+	if _, err := r.DB.ExecContext(ctx, "insert into users (id, email, name, type) values ($1, $2, $3, $4)", v.ID, v.Email, v.Name, v.Type); err != nil {
+		return fmt.Errorf("UserRepo.Create: %w", sorm.TranslateError(err))
+	}
+
+	return nil
+}
+
+// Save writes every column but the IDs, rather than only the changed ones as
+// sorm.SaveRecord does.
+func (r *UserRepo) Save(ctx context.Context, v *User) error {
+	res, err := r.DB.ExecContext(ctx, "update users set email = $1, name = $2, type = $3 where id = $4", v.Email, v.Name, v.Type, v.ID)
+	if err != nil {
+		return fmt.Errorf("UserRepo.Save: %w", sorm.TranslateError(err))
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n != 1 {
+		return fmt.Errorf("UserRepo.Save: %w", &sorm.RowsAffectedError{Op: sorm.OperationSave, Table: "users", Expected: 1, Actual: n})
+	}
+
+	return nil
+}
+
+func (r *UserRepo) Delete(ctx context.Context, v *User) error {
+	res, err := r.DB.ExecContext(ctx, "delete from users where id = $1", v.ID)
+	if err != nil {
+		return fmt.Errorf("UserRepo.Delete: %w", sorm.TranslateError(err))
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n != 1 {
+		return fmt.Errorf("UserRepo.Delete: %w", &sorm.RowsAffectedError{Op: sorm.OperationDelete, Table: "users", Expected: 1, Actual: n})
+	}
+
+	return nil
+}
+
+// MembershipRepo reads and writes Membership records in memberships without reflection.
+type MembershipRepo struct {
+	DB sorm.Querier
+}
+
+func NewMembershipRepo(db sorm.Querier) *MembershipRepo {
+	return &MembershipRepo{DB: db}
+}
+
+func scanDestMembership(v *Membership) []interface{} {
+	return []interface{}{&v.UserID, &v.GroupID, &v.Role}
+}
+
+func (r *MembershipRepo) findOne(ctx context.Context, op, query string, args ...interface{}) (*Membership, error) {
+	var v Membership
+	if err := r.DB.QueryRowContext(ctx, query, args...).Scan(scanDestMembership(&v)...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("MembershipRepo.%s: %w", op, sorm.ErrRecordNotFound)
+		}
+
+		return nil, fmt.Errorf("MembershipRepo.%s: %w", op, sorm.TranslateError(err))
+	}
+
+	return &v, nil
+}
+
+func (r *MembershipRepo) FindByID(ctx context.Context, userID int, groupID int) (*Membership, error) {
+	return r.findOne(ctx, "FindByID", "select user_id, group_id, role from memberships where user_id = $1 and group_id = $2", userID, groupID)
+}
+
+// FindWhere finds the records matching where, which is the text after the
+// table name, e.g. "where name = $1 order by id".
+func (r *MembershipRepo) FindWhere(ctx context.Context, where string, args ...interface{}) ([]Membership, error) {
+	query := "select user_id, group_id, role from memberships"
+	if where != "" {
+		query += " " + where
+	}
+
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("MembershipRepo.FindWhere: %w", sorm.TranslateError(err))
+	}
+	defer rows.Close()
+
+	var l []Membership
+	for rows.Next() {
+		var v Membership
+		if err := rows.Scan(scanDestMembership(&v)...); err != nil {
+			return nil, fmt.Errorf("MembershipRepo.FindWhere: %w", err)
+		}
+
+		l = append(l, v)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("MembershipRepo.FindWhere: %w", sorm.TranslateError(err))
+	}
+
+	return l, nil
+}
+
+func (r *MembershipRepo) FindAll(ctx context.Context) ([]Membership, error) {
+	return r.FindWhere(ctx, "")
+}
+
+func (r *MembershipRepo) Create(ctx context.Context, v *Membership) error {
+	if _, err := r.DB.ExecContext(ctx, "insert into memberships (user_id, group_id, role) values ($1, $2, $3)", v.UserID, v.GroupID, v.Role); err != nil {
+		return fmt.Errorf("MembershipRepo.Create: %w", sorm.TranslateError(err))
+	}
+
+	return nil
+}
+
+// Save writes every column but the IDs, rather than only the changed ones as
+// sorm.SaveRecord does.
+func (r *MembershipRepo) Save(ctx context.Context, v *Membership) error {
+	res, err := r.DB.ExecContext(ctx, "update memberships set role = $1 where user_id = $2 and group_id = $3", v.Role, v.UserID, v.GroupID)
+	if err != nil {
+		return fmt.Errorf("MembershipRepo.Save: %w", sorm.TranslateError(err))
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n != 1 {
+		return fmt.Errorf("MembershipRepo.Save: %w", &sorm.RowsAffectedError{Op: sorm.OperationSave, Table: "memberships", Expected: 1, Actual: n})
+	}
+
+	return nil
+}
+
+func (r *MembershipRepo) Delete(ctx context.Context, v *Membership) error {
+	res, err := r.DB.ExecContext(ctx, "delete from memberships where user_id = $1 and group_id = $2", v.UserID, v.GroupID)
+	if err != nil {
+		return fmt.Errorf("MembershipRepo.Delete: %w", sorm.TranslateError(err))
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n != 1 {
+		return fmt.Errorf("MembershipRepo.Delete: %w", &sorm.RowsAffectedError{Op: sorm.OperationDelete, Table: "memberships", Expected: 1, Actual: n})
+	}
+
+	return nil
+}
+
+// ReportRepo reads Report records in user_reports without reflection.
+type ReportRepo struct {
+	DB sorm.Querier
+}
+
+func NewReportRepo(db sorm.Querier) *ReportRepo {
+	return &ReportRepo{DB: db}
+}
+
+func scanDestReport(v *Report) []interface{} {
+	return []interface{}{&v.ID, &v.Total}
+}
+
+func (r *ReportRepo) findOne(ctx context.Context, op, query string, args ...interface{}) (*Report, error) {
+	var v Report
+	if err := r.DB.QueryRowContext(ctx, query, args...).Scan(scanDestReport(&v)...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("ReportRepo.%s: %w", op, sorm.ErrRecordNotFound)
+		}
+
+		return nil, fmt.Errorf("ReportRepo.%s: %w", op, sorm.TranslateError(err))
+	}
+
+	return &v, nil
+}
+
+func (r *ReportRepo) FindByID(ctx context.Context, id int) (*Report, error) {
+	return r.findOne(ctx, "FindByID", "select id, total from user_reports where id = $1", id)
+}
+
+// FindWhere finds the records matching where, which is the text after the
+// table name, e.g. "where name = $1 order by id".
+func (r *ReportRepo) FindWhere(ctx context.Context, where string, args ...interface{}) ([]Report, error) {
+	query := "select id, total from user_reports"
+	if where != "" {
+		query += " " + where
+	}
+
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ReportRepo.FindWhere: %w", sorm.TranslateError(err))
+	}
+	defer rows.Close()
+
+	var l []Report
+	for rows.Next() {
+		var v Report
+		if err := rows.Scan(scanDestReport(&v)...); err != nil {
+			return nil, fmt.Errorf("ReportRepo.FindWhere: %w", err)
+		}
+
+		l = append(l, v)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ReportRepo.FindWhere: %w", sorm.TranslateError(err))
+	}
+
+	return l, nil
+}
+
+func (r *ReportRepo) FindAll(ctx context.Context) ([]Report, error) {
+	return r.FindWhere(ctx, "")
+}
@@ -0,0 +1,63 @@
+package example
+
+import (
+	"context"
+	"testing"
+
+	"fknsrs.biz/p/sorm"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserRepo(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`insert into users \(email, name, type\) values \(\$1, \$2, \$3\) returning id`).WithArgs("a@example.com", "a", "admin").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mockDB.ExpectQuery(`select id, email, name, type, created_at from users where email = \$1`).WithArgs("b@example.com").WillReturnRows(sqlmock.NewRows([]string{"id", "email", "name", "type", "created_at"}))
+	mockDB.ExpectExec(`update users set email = \$1, name = \$2, type = \$3 where id = \$4`).WithArgs("a@example.com", "b", "admin", 7).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	r := NewUserRepo(db)
+
+	u := User{Email: "a@example.com", Name: "a", Type: "admin"}
+	a.NoError(r.Create(context.Background(), &u))
+	a.Equal(7, u.ID)
+
+	_, err = r.FindByEmail(context.Background(), "b@example.com")
+	a.ErrorIs(err, sorm.ErrRecordNotFound)
+
+	u.Name = "b"
+	err = r.Save(context.Background(), &u)
+	a.ErrorIs(err, sorm.ErrRecordNotFound)
+	a.EqualError(err, "UserRepo.Save: save of users affected 0 rows; expected 1")
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestMembershipRepo(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select user_id, group_id, role from memberships where user_id = \$1 and group_id = \$2`).WithArgs(1, 2).WillReturnRows(sqlmock.NewRows([]string{"user_id", "group_id", "role"}).AddRow(1, 2, "owner"))
+	mockDB.ExpectExec(`delete from memberships where user_id = \$1 and group_id = \$2`).WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	r := NewMembershipRepo(db)
+
+	m, err := r.FindByID(context.Background(), 1, 2)
+	if a.NoError(err) {
+		a.Equal(&Membership{UserID: 1, GroupID: 2, Role: "owner"}, m)
+		a.NoError(r.Delete(context.Background(), m))
+	}
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
@@ -0,0 +1,111 @@
+// Command sormgen writes typed repositories for sorm models, for code that
+// can't afford reflection on every query. For each type it writes a
+// <Type>Repo with FindByID, FindBy<Field> for unique fields, FindWhere,
+// FindAll and, unless the model is read-only, Create, Save and Delete. Table
+// and column names follow sorm's tags and naming rules, and errors match
+// sorm's, but the generated code doesn't run hooks, callbacks, default scopes,
+// logging or the result cache. Models using tag features that need sorm's
+// write path, like json or hash, are rejected.
+//
+// It's meant for go:generate:
+//
+//	//go:generate sormgen -type User,Post
+//
+// "sormgen vet ./..." checks models for mistakes that sorm only reports at run
+// time: missing ID fields, columns used by more than one field, malformed or
+// unknown sql tags and unexported fields that aren't tagged sql:"-". Models
+// are the structs with sorm tags and those passed to sorm's functions.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fknsrs.biz/p/sorm"
+)
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "vet" {
+		os.Exit(vetMain(os.Args[2:], os.Stdout))
+	}
+
+	types := flag.String("type", "", "comma-separated list of type names")
+	output := flag.String("output", "", "output file; default <first type>_sorm.go")
+	param := flag.String("param", "$", "parameter prefix, as set with sorm.SetParameterPrefix")
+	naming := flag.String("naming", "default", "table naming: default, plural or singular")
+	flag.Parse()
+
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+
+	if err := run(dir, *types, *output, *param, *naming); err != nil {
+		fmt.Fprintf(os.Stderr, "sormgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func namingStrategy(s string) (sorm.NamingStrategy, error) {
+	switch s {
+	case "default":
+		return sorm.SnakeCaseNaming{}, nil
+	case "plural":
+		return sorm.SnakeCaseNaming{Pluralize: true}, nil
+	case "singular":
+		return sorm.SnakeCaseNaming{SingularTables: true}, nil
+	default:
+		return nil, fmt.Errorf("unknown naming %q", s)
+	}
+}
+
+func run(dir, types, output, param, naming string) error {
+	if types == "" {
+		return fmt.Errorf("-type is required")
+	}
+
+	names := strings.Split(types, ",")
+
+	if output == "" {
+		output = strings.ToLower(names[0]) + "_sorm.go"
+	}
+	if !filepath.IsAbs(output) {
+		output = filepath.Join(dir, output)
+	}
+
+	ns, err := namingStrategy(naming)
+	if err != nil {
+		return err
+	}
+
+	src, err := generateFile(dir, output, names, param, ns)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(output, src, 0644)
+}
+
+func generateFile(dir, output string, names []string, param string, naming sorm.NamingStrategy) ([]byte, error) {
+	p, err := parsePackage(dir, output)
+	if err != nil {
+		return nil, err
+	}
+
+	var models []*model
+	for _, name := range names {
+		m, err := p.buildModel(strings.TrimSpace(name), naming)
+		if err != nil {
+			return nil, err
+		}
+
+		models = append(models, m)
+	}
+
+	g := generator{param: param}
+
+	return g.generate(p.name, p.usedImports(), models)
+}
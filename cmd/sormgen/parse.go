@@ -0,0 +1,297 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"fknsrs.biz/p/sorm"
+)
+
+type model struct {
+	Name     string
+	Table    string
+	ReadOnly bool
+	Fields   []field
+}
+
+type field struct {
+	Name     string
+	Column   string
+	Type     string
+	ID       bool
+	ReadOnly bool
+	Unique   bool
+}
+
+func (m *model) IDFields() []field {
+	var l []field
+	for _, f := range m.Fields {
+		if f.ID {
+			l = append(l, f)
+		}
+	}
+
+	return l
+}
+
+// unsupported are the sql tag parameters whose behaviour lives in sorm's
+// reflection-based write path, so generated code can't match it.
+var unsupported = []string{"json", "compress", "hash", "tenant", "ttl", "default", "enum", "from", "sequence", "prefix", "generate"}
+
+type pkg struct {
+	name    string
+	structs map[string]*ast.StructType
+	imports map[string]string
+	fset    *token.FileSet
+	used    map[string]bool
+}
+
+func parsePackage(dir string, skip string) (*pkg, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	p := pkg{
+		structs: make(map[string]*ast.StructType),
+		imports: make(map[string]string),
+		fset:    token.NewFileSet(),
+		used:    make(map[string]bool),
+	}
+
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") || filepath.Base(file) == filepath.Base(skip) {
+			continue
+		}
+
+		f, err := parser.ParseFile(p.fset, file, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+
+		p.name = f.Name.Name
+
+		for _, spec := range f.Imports {
+			path, _ := strconv.Unquote(spec.Path.Value)
+
+			name := path[strings.LastIndexByte(path, '/')+1:]
+			if spec.Name != nil {
+				name = spec.Name.Name
+			}
+
+			p.imports[name] = path
+		}
+
+		ast.Inspect(f, func(n ast.Node) bool {
+			if ts, ok := n.(*ast.TypeSpec); ok {
+				if st, ok := ts.Type.(*ast.StructType); ok {
+					p.structs[ts.Name.Name] = st
+				}
+			}
+
+			return true
+		})
+	}
+
+	if p.name == "" {
+		return nil, fmt.Errorf("no go files in %s", dir)
+	}
+
+	return &p, nil
+}
+
+// need remembers the imports used by a type that's written out in the
+// generated code.
+func (p *pkg) need(expr ast.Expr) {
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok {
+				if path, ok := p.imports[id.Name]; ok {
+					p.used[path] = true
+				}
+			}
+		}
+
+		return true
+	})
+}
+
+func (p *pkg) typeString(expr ast.Expr) string {
+	var b strings.Builder
+	printer.Fprint(&b, p.fset, expr)
+
+	return b.String()
+}
+
+func (p *pkg) usedImports() []string {
+	var l []string
+	for path := range p.used {
+		l = append(l, path)
+	}
+	sort.Strings(l)
+
+	return l
+}
+
+func parseTag(s string) (string, map[string]string) {
+	params := make(map[string]string)
+
+	a := strings.SplitN(s, ",", 2)
+	if len(a) == 2 {
+		for _, e := range strings.Split(a[1], ",") {
+			if e == "" {
+				continue
+			}
+
+			kv := strings.SplitN(e, ":", 2)
+			if len(kv) == 2 {
+				params[kv[0]] = kv[1]
+			} else {
+				params[kv[0]] = ""
+			}
+		}
+	}
+
+	return a[0], params
+}
+
+func isPlainIdentifier(s string) bool {
+	if s == "" || (s[0] >= '0' && s[0] <= '9') {
+		return false
+	}
+
+	return strings.Trim(s, "abcdefghijklmnopqrstuvwxyz0123456789_") == ""
+}
+
+func quoteIdentifier(s string) string {
+	if isPlainIdentifier(s) || strings.HasPrefix(s, `"`) {
+		return s
+	}
+
+	return `"` + s + `"`
+}
+
+// buildModel applies sorm's naming rules to the struct name.
+func (p *pkg) buildModel(name string, naming sorm.NamingStrategy) (*model, error) {
+	st, ok := p.structs[name]
+	if !ok {
+		return nil, fmt.Errorf("no struct type %s in package %s", name, p.name)
+	}
+
+	m := model{Name: name}
+
+	var schema string
+	var idTagged bool
+	types := make(map[string]ast.Expr)
+
+	for _, af := range st.Fields.List {
+		tag := fieldTag(af)
+
+		if len(af.Names) == 0 {
+			return nil, fmt.Errorf("%s has an embedded field, which sormgen doesn't support", name)
+		}
+
+		sqlValue, params := parseTag(tag.Get("sql"))
+
+		if v := tag.Get("table"); v != "" {
+			m.Table = v
+		}
+		if v := params["table"]; v != "" {
+			m.Table = v
+		}
+		if v := tag.Get("schema"); v != "" {
+			schema = v
+		}
+
+		sormValue, sormParams := parseTag(tag.Get("sorm"))
+		if _, ok := sormParams["projection"]; ok || strings.HasPrefix(sormValue, "projection:") {
+			return nil, fmt.Errorf("%s is a projection, which sormgen doesn't support", name)
+		}
+		for _, s := range []string{"view", "readonly"} {
+			if _, ok := sormParams[s]; ok || sormValue == s {
+				m.ReadOnly = true
+			}
+		}
+
+		if relationTag(sqlValue, params) != "" {
+			continue
+		}
+
+		for _, id := range af.Names {
+			if id.Name == "_" || sqlValue == "-" {
+				continue
+			}
+
+			if !id.IsExported() {
+				return nil, fmt.Errorf("field %s on %s is unexported; tag it sql:\"-\"", id.Name, name)
+			}
+
+			for _, s := range unsupported {
+				if _, ok := params[s]; ok {
+					return nil, fmt.Errorf("field %s on %s uses %s, which sormgen doesn't support", id.Name, name, s)
+				}
+			}
+
+			f := field{
+				Name:   id.Name,
+				Column: sqlValue,
+				Type:   p.typeString(af.Type),
+			}
+			types[id.Name] = af.Type
+			if f.Column == "" {
+				f.Column = naming.ColumnName(id.Name)
+			}
+
+			if _, ok := params["id"]; ok {
+				f.ID = true
+				idTagged = true
+			}
+			if _, ok := params["readonly"]; ok || tag.Get("readonly") != "" {
+				f.ReadOnly = true
+			}
+			if _, ok := params["unique"]; ok {
+				f.Unique = true
+			}
+			if v, _, _ := strings.Cut(tag.Get("validate"), ","); v == "unique" {
+				f.Unique = true
+			}
+
+			m.Fields = append(m.Fields, f)
+		}
+	}
+
+	if !idTagged {
+		for i := range m.Fields {
+			if m.Fields[i].Name == "ID" {
+				m.Fields[i].ID = true
+			}
+		}
+	}
+
+	if len(m.IDFields()) == 0 {
+		return nil, fmt.Errorf("%s: %w", name, sorm.ErrNoIDFields)
+	}
+
+	if m.Table == "" {
+		m.Table = naming.TableName(name)
+	}
+	if schema != "" && !strings.Contains(m.Table, ".") {
+		m.Table = quoteIdentifier(schema) + "." + quoteIdentifier(m.Table)
+	}
+
+	// these types are written out in method signatures
+	for _, f := range m.Fields {
+		if f.ID || f.Unique {
+			p.need(types[f.Name])
+		}
+	}
+
+	return &m, nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"fknsrs.biz/p/sorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExampleUpToDate(t *testing.T) {
+	a := assert.New(t)
+
+	want, err := os.ReadFile("internal/example/models_sorm.go")
+	if !a.NoError(err) {
+		return
+	}
+
+	got, err := generateFile("internal/example", "internal/example/models_sorm.go", []string{"User", "Membership", "Report"}, "$", sorm.SnakeCaseNaming{})
+	if a.NoError(err) {
+		a.Equal(string(want), string(got), "run go generate in internal/example")
+	}
+}
+
+func generateSource(t *testing.T, src, name string, naming sorm.NamingStrategy) (string, error) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "models.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := generateFile(dir, filepath.Join(dir, "out.go"), []string{name}, "@p", naming)
+
+	return string(b), err
+}
+
+func TestGenerateNaming(t *testing.T) {
+	a := assert.New(t)
+
+	out, err := generateSource(t, `package models
+
+import "github.com/google/uuid"
+
+type Person struct {
+	_    struct{} `+"`schema:\"Directory\"`"+`
+	Key  uuid.UUID `+"`sql:\",id\"`"+`
+	Type string
+}
+`, "Person", sorm.SnakeCaseNaming{Pluralize: true})
+	if !a.NoError(err) {
+		return
+	}
+
+	a.Contains(out, `"github.com/google/uuid"`)
+	a.Contains(out, `func (r *PersonRepo) FindByID(ctx context.Context, key uuid.UUID) (*Person, error) {`)
+	a.Contains(out, `"update \"Directory\".people set type = @p1 where key = @p2", v.Type, v.Key`)
+	a.NotContains(out, "returning id")
+}
+
+func TestGenerateUnsupported(t *testing.T) {
+	a := assert.New(t)
+
+	_, err := generateSource(t, "package models\n\ntype Doc struct {\n\tID   int\n\tBody map[string]string `sql:\",json\"`\n}\n", "Doc", sorm.SnakeCaseNaming{})
+	a.EqualError(err, "field Body on Doc uses json, which sormgen doesn't support")
+
+	_, err = generateSource(t, "package models\n\ntype Addr struct {\n\tStreet string\n}\n\ntype Doc struct {\n\tID   int\n\tHome Addr `sql:\",prefix:home_\"`\n}\n", "Doc", sorm.SnakeCaseNaming{})
+	a.EqualError(err, "field Home on Doc uses prefix, which sormgen doesn't support")
+
+	_, err = generateSource(t, "package models\n\ntype Doc struct {\n\tID string `sql:\"id,id,generate:uuid\"`\n}\n", "Doc", sorm.SnakeCaseNaming{})
+	a.EqualError(err, "field ID on Doc uses generate, which sormgen doesn't support")
+
+	_, err = generateSource(t, "package models\n\ntype Doc struct {\n\tName string\n}\n", "Doc", sorm.SnakeCaseNaming{})
+	a.ErrorIs(err, sorm.ErrNoIDFields)
+
+	_, err = generateSource(t, "package models\n\ntype Doc struct {\n\tID int\n}\n", "Missing", sorm.SnakeCaseNaming{})
+	a.EqualError(err, "no struct type Missing in package models")
+}
@@ -0,0 +1,495 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"fknsrs.biz/p/sorm"
+)
+
+// sqlParameters are the sql tag parameters sorm knows, and whether they need
+// a value.
+var sqlParameters = map[string]bool{
+	"alias":      true,
+	"belongs_to": false,
+	"charset":    true,
+	"collate":    true,
+	"compress":   true,
+	"default":    true,
+	"enum":       true,
+	"fk":         true,
+	"from":       true,
+	"generate":   true,
+	"has_many":   false,
+	"has_one":    false,
+	"hash":       false,
+	"id":         false,
+	"index":      false,
+	"json":       false,
+	"key":        true,
+	"prefix":     true,
+	"readonly":   false,
+	"sequence":   true,
+	"table":      true,
+	"tenant":     false,
+	"ttl":        false,
+	"type":       true,
+	"unique":     false,
+}
+
+type diagnostic struct {
+	pos token.Position
+	msg string
+}
+
+type vetter struct {
+	fset  *token.FileSet
+	diags []diagnostic
+}
+
+func (v *vetter) report(pos token.Pos, format string, args ...interface{}) {
+	v.diags = append(v.diags, diagnostic{pos: v.fset.Position(pos), msg: fmt.Sprintf(format, args...)})
+}
+
+// vetMain runs "sormgen vet", which checks the models in the given
+// directories for mistakes that sorm would otherwise only report at run
+// time. A directory ending in /... includes the ones below it.
+func vetMain(args []string, stdout io.Writer) int {
+	fl := flag.NewFlagSet("sormgen vet", flag.ExitOnError)
+	fl.Parse(args)
+
+	dirs := fl.Args()
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+
+	v := vetter{fset: token.NewFileSet()}
+
+	for _, dir := range dirs {
+		if err := v.vetPattern(dir); err != nil {
+			fmt.Fprintf(os.Stderr, "sormgen vet: %v\n", err)
+			return 2
+		}
+	}
+
+	sort.SliceStable(v.diags, func(i, j int) bool {
+		a, b := v.diags[i].pos, v.diags[j].pos
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		return a.Offset < b.Offset
+	})
+
+	for _, d := range v.diags {
+		fmt.Fprintf(stdout, "%s: %s\n", d.pos, d.msg)
+	}
+
+	if len(v.diags) > 0 {
+		return 1
+	}
+
+	return 0
+}
+
+func (v *vetter) vetPattern(pattern string) error {
+	root, recursive := strings.CutSuffix(pattern, "/...")
+	if !recursive {
+		return v.vetDir(pattern)
+	}
+
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+
+		if path != root {
+			if name := d.Name(); name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+				return filepath.SkipDir
+			}
+		}
+
+		return v.vetDir(path)
+	})
+}
+
+func (v *vetter) vetDir(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return err
+	}
+
+	structs := make(map[string]*ast.TypeSpec)
+	used := make(map[string]bool)
+
+	for _, file := range files {
+		f, err := parser.ParseFile(v.fset, file, nil, 0)
+		if err != nil {
+			return err
+		}
+
+		for name, spec := range modelStructs(f) {
+			structs[name] = spec
+		}
+
+		for name := range sormArguments(f) {
+			used[name] = true
+		}
+	}
+
+	var names []string
+	for name, spec := range structs {
+		if used[name] || hasSORMTags(spec.Type.(*ast.StructType)) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		v.vetModel(structs[name])
+	}
+
+	return nil
+}
+
+func modelStructs(f *ast.File) map[string]*ast.TypeSpec {
+	m := make(map[string]*ast.TypeSpec)
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		if ts, ok := n.(*ast.TypeSpec); ok {
+			if _, ok := ts.Type.(*ast.StructType); ok {
+				m[ts.Name.Name] = ts
+			}
+		}
+
+		return true
+	})
+
+	return m
+}
+
+func hasSORMTags(st *ast.StructType) bool {
+	for _, af := range st.Fields.List {
+		tag := fieldTag(af)
+		for _, k := range []string{"sql", "table", "schema", "sorm", "validate", "unique"} {
+			// malformed tags count too, so that they get reported
+			if strings.Contains(string(tag), k+":") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// sormArguments finds the local types passed to functions of sorm and its
+// subpackages as T{}, &T{}, &[]T{}, new(T), or a pointer to a variable
+// declared with one of those types. Models are the first argument, or the
+// first after the context and database, so only those positions count;
+// clauses and the like come later.
+func sormArguments(f *ast.File) map[string]bool {
+	pkgs := make(map[string]bool)
+	for _, spec := range f.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		if path != "fknsrs.biz/p/sorm" && !strings.HasPrefix(path, "fknsrs.biz/p/sorm/") {
+			continue
+		}
+
+		name := path[strings.LastIndexByte(path, '/')+1:]
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		pkgs[name] = true
+	}
+
+	m := make(map[string]bool)
+	if len(pkgs) == 0 {
+		return m
+	}
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if id, ok := sel.X.(*ast.Ident); !ok || !pkgs[id.Name] {
+			return true
+		}
+
+		for i, arg := range call.Args {
+			if i != 0 && i != 2 {
+				continue
+			}
+
+			if name := argumentType(arg); name != "" {
+				m[name] = true
+			}
+		}
+
+		return true
+	})
+
+	return m
+}
+
+func argumentType(e ast.Expr) string {
+	switch e := e.(type) {
+	case *ast.UnaryExpr:
+		if e.Op == token.AND {
+			return argumentType(e.X)
+		}
+	case *ast.CompositeLit:
+		return typeName(e.Type)
+	case *ast.CallExpr:
+		if id, ok := e.Fun.(*ast.Ident); ok && id.Name == "new" && len(e.Args) == 1 {
+			return typeName(e.Args[0])
+		}
+	case *ast.Ident:
+		if e.Obj == nil {
+			return ""
+		}
+
+		switch d := e.Obj.Decl.(type) {
+		case *ast.ValueSpec:
+			if d.Type != nil {
+				return typeName(d.Type)
+			}
+			for i, name := range d.Names {
+				if name.Name == e.Name && i < len(d.Values) {
+					return argumentType(d.Values[i])
+				}
+			}
+		case *ast.AssignStmt:
+			for i, lhs := range d.Lhs {
+				if id, ok := lhs.(*ast.Ident); ok && id.Name == e.Name && i < len(d.Rhs) && len(d.Lhs) == len(d.Rhs) {
+					return argumentType(d.Rhs[i])
+				}
+			}
+		}
+	}
+
+	return ""
+}
+
+func typeName(e ast.Expr) string {
+	switch e := e.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.StarExpr:
+		return typeName(e.X)
+	case *ast.ArrayType:
+		return typeName(e.Elt)
+	}
+
+	return ""
+}
+
+func fieldTag(af *ast.Field) reflect.StructTag {
+	if af.Tag == nil {
+		return ""
+	}
+
+	s, _ := strconv.Unquote(af.Tag.Value)
+
+	return reflect.StructTag(s)
+}
+
+func (v *vetter) vetModel(ts *ast.TypeSpec) {
+	name := ts.Name.Name
+	st := ts.Type.(*ast.StructType)
+
+	readOnly := false
+	hasID := false
+	columns := make(map[string]string)
+
+	for _, af := range st.Fields.List {
+		tag := fieldTag(af)
+		if af.Tag != nil {
+			if err := validateStructTag(string(tag)); err != nil {
+				v.report(af.Tag.Pos(), "%s has a malformed struct tag: %v", fieldName(name, af), err)
+				continue
+			}
+		}
+
+		sormValue, sormParams := parseTag(tag.Get("sorm"))
+		for _, s := range []string{"view", "readonly", "projection"} {
+			if _, ok := sormParams[s]; ok || sormValue == s || strings.HasPrefix(sormValue, s+":") {
+				readOnly = true
+			}
+		}
+
+		if scope, ok := tag.Lookup("unique"); ok {
+			want := `validate:"unique"`
+			if scope != "" {
+				want = `validate:"unique,within:` + scope + `"`
+			}
+			v.report(af.Tag.Pos(), "%s has a unique tag, which sorm doesn't read; check uniqueness with %s", fieldName(name, af), want)
+		}
+
+		sqlValue, params := parseTag(tag.Get("sql"))
+		v.vetParameters(af, name, tag.Get("sql"), params)
+		if _, ok := params["from"]; ok {
+			readOnly = true
+		}
+
+		if relation := relationTag(sqlValue, params); relation != "" {
+			if _, ok := params[relation]; ok && sqlValue != "" && sqlValue != "-" {
+				v.report(af.Pos(), "%s is a %s relation, so it can't also name column %s; tag it sql:\"%s\"", fieldName(name, af), relation, sqlValue, relation)
+			}
+			continue
+		}
+
+		if sqlValue == "-" {
+			continue
+		}
+
+		if _, ok := params["prefix"]; ok {
+			continue
+		}
+
+		if len(af.Names) == 0 {
+			continue
+		}
+
+		for _, id := range af.Names {
+			if id.Name == "_" {
+				continue
+			}
+
+			if !id.IsExported() {
+				v.report(id.Pos(), "%s.%s is unexported, so sorm can't read or write it; export it or tag it sql:\"-\"", name, id.Name)
+				continue
+			}
+
+			if _, ok := params["id"]; ok || id.Name == "ID" {
+				hasID = true
+			}
+
+			col := sqlValue
+			if col == "" {
+				col = sorm.SnakeCaseNaming{}.ColumnName(id.Name)
+			}
+
+			if other, ok := columns[col]; ok {
+				v.report(id.Pos(), "%s.%s and %s.%s both use column %s", name, other, name, id.Name, col)
+				continue
+			}
+			columns[col] = id.Name
+		}
+	}
+
+	if !hasID && !readOnly {
+		v.report(ts.Name.Pos(), "%s has no ID field; add one named ID or tag one sql:\",id\"", name)
+	}
+}
+
+func (v *vetter) vetParameters(af *ast.Field, model, raw string, params map[string]string) {
+	var keys []string
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		needsValue, ok := sqlParameters[k]
+		switch {
+		case !ok:
+			v.report(af.Tag.Pos(), "%s has unknown sql tag parameter %q in %q", fieldName(model, af), k, raw)
+		case needsValue && params[k] == "":
+			v.report(af.Tag.Pos(), "%s has sql tag parameter %s without a value", fieldName(model, af), k)
+		}
+	}
+}
+
+// relationTag is the kind of relation a sql tag declares, as
+// sql:"has_many:comments" or sql:"-,has_many", or "" if it isn't one.
+func relationTag(value string, params map[string]string) string {
+	kinds := []string{"has_many", "has_one", "belongs_to"}
+
+	name, _, _ := strings.Cut(value, ":")
+	for _, k := range kinds {
+		if name == k {
+			return k
+		}
+	}
+
+	for _, k := range kinds {
+		if _, ok := params[k]; ok {
+			return k
+		}
+	}
+
+	return ""
+}
+
+func fieldName(model string, af *ast.Field) string {
+	if len(af.Names) == 0 {
+		return model + "." + typeName(af.Type)
+	}
+
+	return model + "." + af.Names[0].Name
+}
+
+// validateStructTag checks that a tag is in the key:"value" form that
+// reflect.StructTag.Get understands.
+func validateStructTag(tag string) error {
+	for tag != "" {
+		i := 0
+		for i < len(tag) && tag[i] == ' ' {
+			i++
+		}
+		tag = tag[i:]
+		if tag == "" {
+			break
+		}
+
+		i = 0
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+		if i == 0 {
+			return fmt.Errorf("expected a key at %q", tag)
+		}
+		if i+1 >= len(tag) || tag[i] != ':' || tag[i+1] != '"' {
+			return fmt.Errorf("expected %s to be followed by :\"", tag[:i])
+		}
+		key := tag[:i]
+		tag = tag[i+1:]
+
+		i = 1
+		for i < len(tag) && tag[i] != '"' {
+			if tag[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(tag) {
+			return fmt.Errorf("value of %s isn't terminated", key)
+		}
+		if _, err := strconv.Unquote(tag[:i+1]); err != nil {
+			return fmt.Errorf("value of %s isn't a valid string", key)
+		}
+		tag = tag[i+1:]
+
+		if tag != "" && tag[0] != ' ' {
+			return fmt.Errorf("expected a space after the value of %s", key)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVet(t *testing.T) {
+	a := assert.New(t)
+
+	dir := t.TempDir()
+	src := "package models\n\n" +
+		"import \"fknsrs.biz/p/sorm\"\n\n" +
+		"type Good struct {\n\tID   int\n\tName string `sql:\"name,unique\"`\n}\n\n" +
+		"type NoID struct {\n\tName string\n}\n\n" +
+		"type Dupes struct {\n\tID       int\n\tName     string\n\tFullName string `sql:\"name\"`\n}\n\n" +
+		"type BadTags struct {\n\tID    int    `sql:\",id,colour\"`\n\tEmail string `sql:\",fk\"`\n\tNote  string `sql:note`\n}\n\n" +
+		"type Hidden struct {\n\tID     int\n\tsecret string\n\tcache  string `sql:\"-\"`\n}\n\n" +
+		"type Report struct {\n\t_     struct{} `sorm:\"view\"`\n\tTotal int\n}\n\n" +
+		"type Post struct {\n\tID       int\n\tComments []Comment `sql:\"comments,has_many\"`\n\tReplies  []Comment `sql:\"has_many:comments,fk:post_id\"`\n}\n\n" +
+		"type Comment struct {\n\tID int `sql:\",id\"`\n}\n\n" +
+		"type Scoped struct {\n\tID   int\n\tSlug string `unique:\"org_id\"`\n}\n\n" +
+		"type Unused struct {\n\tName string\n}\n\n" +
+		"func f() {\n\tvar l []NoID\n\tsorm.FindAll(nil, nil, &l)\n\tsorm.CreateRecord(nil, nil, &Hidden{})\n}\n"
+	if !a.NoError(os.WriteFile(filepath.Join(dir, "models.go"), []byte(src), 0644)) {
+		return
+	}
+
+	var b strings.Builder
+	a.Equal(1, vetMain([]string{dir + "/..."}, &b))
+
+	var got []string
+	for _, line := range strings.Split(strings.TrimSpace(b.String()), "\n") {
+		got = append(got, strings.TrimPrefix(line, filepath.Join(dir, "models.go")+":"))
+	}
+
+	a.Equal([]string{
+		`10:6: NoID has no ID field; add one named ID or tag one sql:",id"`,
+		`17:2: Dupes.Name and Dupes.FullName both use column name`,
+		`21:15: BadTags.ID has unknown sql tag parameter "colour" in ",id,colour"`,
+		`22:15: BadTags.Email has sql tag parameter fk without a value`,
+		`23:15: BadTags.Note has a malformed struct tag: expected sql to be followed by :"`,
+		`28:2: Hidden.secret is unexported, so sorm can't read or write it; export it or tag it sql:"-"`,
+		`39:2: Post.Comments is a has_many relation, so it can't also name column comments; tag it sql:"has_many"`,
+		`49:14: Scoped.Slug has a unique tag, which sorm doesn't read; check uniqueness with validate:"unique,within:org_id"`,
+	}, got)
+}
+
+func TestVetClean(t *testing.T) {
+	a := assert.New(t)
+
+	var b strings.Builder
+	a.Equal(0, vetMain([]string{"internal/example"}, &b))
+	a.Empty(b.String())
+}
@@ -0,0 +1,60 @@
+package sorm
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrUnknownColumn = errors.New("unknown column")
+
+type UnknownColumnError struct {
+	Model  string
+	Column string
+}
+
+func (e *UnknownColumnError) Error() string {
+	return fmt.Sprintf("unknown column: %s has no column %s", e.Model, e.Column)
+}
+
+func (e *UnknownColumnError) Unwrap() error {
+	return ErrUnknownColumn
+}
+
+// ValidateWhereColumns returns an *UnknownColumnError for the first of
+// columns that isn't a column of model. Only real column names (and aliases)
+// are accepted, so it's safe to check sort and filter fields taken straight
+// from a request.
+func ValidateWhereColumns(model interface{}, columns ...string) error {
+	vtyp, err := structTypeOf(model)
+	if err != nil {
+		return fmt.Errorf("ValidateWhereColumns: %w", err)
+	}
+
+	plan, err := getPlanFromType(vtyp)
+	if err != nil {
+		return fmt.Errorf("ValidateWhereColumns: could not get detailed reflection information for type %s: %w", vtyp.String(), err)
+	}
+
+	vdesc, err := getDescriptionFromType(vtyp)
+	if err != nil {
+		return fmt.Errorf("ValidateWhereColumns: could not get detailed reflection information for type %s: %w", vtyp.String(), err)
+	}
+
+	known := make(map[string]bool)
+	for _, f := range getSQLWritableFields(vdesc) {
+		known[getSQLColumnName(f)] = true
+	}
+	for _, f := range plan.Fields {
+		if f.Alias != "" {
+			known[f.Alias] = true
+		}
+	}
+
+	for _, col := range columns {
+		if !known[col] {
+			return &UnknownColumnError{Model: vtyp.Name(), Column: col}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,33 @@
+package sorm
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateWhereColumns(t *testing.T) {
+	a := assert.New(t)
+
+	type ColumnsObject struct {
+		ID      int
+		Name    string `sql:"display_name,alias:name"`
+		Ignored string `sql:"-"`
+	}
+
+	a.NoError(ValidateWhereColumns(ColumnsObject{}, "id", "display_name", "name"))
+	a.NoError(ValidateWhereColumns(&[]ColumnsObject{}))
+
+	err := ValidateWhereColumns(&ColumnsObject{}, "id", "ignored")
+	a.EqualError(err, "unknown column: ColumnsObject has no column ignored")
+	a.True(errors.Is(err, ErrUnknownColumn))
+
+	var cerr *UnknownColumnError
+	if a.True(errors.As(err, &cerr)) {
+		a.Equal("ignored", cerr.Column)
+	}
+
+	a.Error(ValidateWhereColumns(ColumnsObject{}, "ID"))
+	a.Error(ValidateWhereColumns(ColumnsObject{}, "id; drop table users"))
+}
@@ -0,0 +1,185 @@
+package sorm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Compressor is a codec for `sql:",compress:name"` fields. Every compressed
+// value has to start with Magic so that values written before a field was
+// compressed can still be read.
+type Compressor interface {
+	Magic() []byte
+	Compress(b []byte) ([]byte, error)
+	Decompress(b []byte) ([]byte, error)
+}
+
+var (
+	compressors     = map[string]Compressor{"gzip": gzipCompressor{}}
+	compressorsLock sync.RWMutex
+)
+
+// RegisterCompressor makes a codec available to compress tags. gzip is built
+// in; others such as zstd can be registered using a third party package, e.g.
+// with magic 28 b5 2f fd for zstd.
+func RegisterCompressor(name string, c Compressor) {
+	compressorsLock.Lock()
+	defer compressorsLock.Unlock()
+
+	compressors[name] = c
+}
+
+func getCompressor(name string) (Compressor, error) {
+	compressorsLock.RLock()
+	defer compressorsLock.RUnlock()
+
+	c, ok := compressors[name]
+	if !ok {
+		return nil, fmt.Errorf("no compressor registered for %q", name)
+	}
+
+	return c, nil
+}
+
+func getSQLCompression(f structField) string {
+	if t := f.Tag("sql"); t != nil {
+		if p := t.Parameter("compress"); p != nil {
+			return p.Value()
+		}
+	}
+
+	return ""
+}
+
+type gzipCompressor struct{}
+
+func (gzipCompressor) Magic() []byte { return []byte{0x1f, 0x8b} }
+
+func (gzipCompressor) Compress(b []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(b); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (gzipCompressor) Decompress(b []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return io.ReadAll(r)
+}
+
+// compressValue compresses the string or []byte form of v.
+type compressValue struct {
+	name string
+	v    interface{}
+}
+
+func (c compressValue) Value() (driver.Value, error) {
+	v := c.v
+	if vv, ok := v.(driver.Valuer); ok {
+		var err error
+		if v, err = vv.Value(); err != nil {
+			return nil, err
+		}
+	}
+
+	var b []byte
+	switch s := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		b = []byte(s)
+	case []byte:
+		b = s
+	default:
+		return nil, fmt.Errorf("can't compress %T", v)
+	}
+
+	cc, err := getCompressor(c.name)
+	if err != nil {
+		return nil, err
+	}
+
+	return cc.Compress(b)
+}
+
+// decompressScanner decompresses values starting with the magic bytes of any
+// registered compressor before passing them on to dst; other values are
+// passed on untouched.
+type decompressScanner struct{ dst interface{} }
+
+func (d decompressScanner) Scan(src interface{}) error {
+	var b []byte
+	switch s := src.(type) {
+	case []byte:
+		b = s
+	case string:
+		b = []byte(s)
+	}
+
+	if b != nil {
+		compressorsLock.RLock()
+		var found Compressor
+		for _, c := range compressors {
+			if bytes.HasPrefix(b, c.Magic()) {
+				found = c
+				break
+			}
+		}
+		compressorsLock.RUnlock()
+
+		if found != nil {
+			v, err := found.Decompress(b)
+			if err != nil {
+				return fmt.Errorf("couldn't decompress value: %w", err)
+			}
+
+			src = v
+		} else {
+			src = append([]byte(nil), b...)
+		}
+	}
+
+	switch dst := d.dst.(type) {
+	case sql.Scanner:
+		return dst.Scan(src)
+	case *string:
+		switch s := src.(type) {
+		case nil:
+			*dst = ""
+		case []byte:
+			*dst = string(s)
+		default:
+			return fmt.Errorf("couldn't scan %T into a compressed string", src)
+		}
+	case *[]byte:
+		switch s := src.(type) {
+		case nil:
+			*dst = nil
+		case []byte:
+			*dst = s
+		default:
+			return fmt.Errorf("couldn't scan %T into compressed bytes", src)
+		}
+	default:
+		return fmt.Errorf("compressed fields must be strings or []byte; was instead %T", d.dst)
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package sorm
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type CompressedObject struct {
+	ID      int
+	Body    string            `sql:",compress:gzip"`
+	Raw     []byte            `sql:",compress:gzip"`
+	Payload map[string]string `sql:",json,compress:gzip"`
+}
+
+type compressedArg struct{ want string }
+
+func (c compressedArg) Match(v driver.Value) bool {
+	b, ok := v.([]byte)
+	if !ok {
+		return false
+	}
+
+	d, err := gzipCompressor{}.Decompress(b)
+
+	return err == nil && string(d) == c.want
+}
+
+func TestCompressedFieldsCreate(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`insert into compressed_objects \(body, raw, payload\) values \(\$1, \$2, \$3\) returning id`).WithArgs(compressedArg{"hello hello hello"}, compressedArg{"raw"}, compressedArg{`{"k":"v"}`}).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	a.NoError(CreateRecord(context.Background(), db, &CompressedObject{Body: "hello hello hello", Raw: []byte("raw"), Payload: map[string]string{"k": "v"}}))
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestCompressedFieldsScan(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	body, _ := gzipCompressor{}.Compress([]byte("hello"))
+	payload, _ := gzipCompressor{}.Compress([]byte(`{"k":"v"}`))
+
+	mockDB.ExpectQuery(`select \* from compressed_objects`).WillReturnRows(sqlmock.NewRows([]string{"id", "body", "raw", "payload"}).
+		AddRow(1, body, []byte("not compressed yet"), payload).
+		AddRow(2, "plain text", nil, `{"a":"b"}`))
+
+	var l []CompressedObject
+	if !a.NoError(FindAll(context.Background(), db, &l)) {
+		return
+	}
+
+	if a.Len(l, 2) {
+		a.Equal("hello", l[0].Body)
+		a.Equal([]byte("not compressed yet"), l[0].Raw)
+		a.Equal(map[string]string{"k": "v"}, l[0].Payload)
+		a.Equal("plain text", l[1].Body)
+		a.Nil(l[1].Raw)
+		a.Equal(map[string]string{"a": "b"}, l[1].Payload)
+	}
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestCompressedFieldsUnknownCodec(t *testing.T) {
+	a := assert.New(t)
+
+	type ZstdObject struct {
+		ID   int
+		Body string `sql:",compress:zstd"`
+	}
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	a.ErrorContains(CreateRecord(context.Background(), db, &ZstdObject{Body: "a"}), `no compressor registered for "zstd"`)
+	a.NoError(mockDB.ExpectationsWereMet())
+
+	s, err := CreateTableStatement(ZstdObject{})
+	if a.NoError(err) {
+		a.Equal("create table zstd_objects (id integer not null, body blob not null, primary key (id))", s.Query)
+	}
+}
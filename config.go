@@ -0,0 +1,202 @@
+package sorm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Config collects the settings that would otherwise be made with the Set*
+// functions, so a service can load them from the environment or a JSON file
+// and apply them in one place with NewSession.
+type Config struct {
+	// Dialect is one of postgres, mysql, sqlite or sqlserver. It picks the
+	// parameter prefix and replace mode unless they're set explicitly.
+	Dialect            string
+	ParameterPrefix    string
+	QueryLogger        QueryLogger
+	SlowQueryThreshold time.Duration
+	MaxRows            int
+	DefaultLimit       int
+	StrictLimit        bool
+	Strict             bool
+}
+
+type configJSON struct {
+	Dialect            string `json:"dialect"`
+	ParameterPrefix    string `json:"parameter_prefix"`
+	SlowQueryThreshold string `json:"slow_query_threshold"`
+	MaxRows            int    `json:"max_rows"`
+	DefaultLimit       int    `json:"default_limit"`
+	StrictLimit        bool   `json:"strict_limit"`
+	Strict             bool   `json:"strict"`
+}
+
+// UnmarshalJSON reads a config with the slow query threshold written as a
+// duration string, e.g. {"dialect": "postgres", "slow_query_threshold":
+// "200ms"}. The query logger can't be set from JSON.
+func (c *Config) UnmarshalJSON(d []byte) error {
+	var j configJSON
+	if err := json.Unmarshal(d, &j); err != nil {
+		return err
+	}
+
+	var threshold time.Duration
+	if j.SlowQueryThreshold != "" {
+		v, err := time.ParseDuration(j.SlowQueryThreshold)
+		if err != nil {
+			return fmt.Errorf("Config: invalid slow_query_threshold: %w", err)
+		}
+
+		threshold = v
+	}
+
+	*c = Config{
+		Dialect:            j.Dialect,
+		ParameterPrefix:    j.ParameterPrefix,
+		QueryLogger:        c.QueryLogger,
+		SlowQueryThreshold: threshold,
+		MaxRows:            j.MaxRows,
+		DefaultLimit:       j.DefaultLimit,
+		StrictLimit:        j.StrictLimit,
+		Strict:             j.Strict,
+	}
+
+	return nil
+}
+
+// ConfigFromEnv reads SORM_DIALECT, SORM_PARAMETER_PREFIX,
+// SORM_SLOW_QUERY_THRESHOLD, SORM_MAX_ROWS, SORM_DEFAULT_LIMIT,
+// SORM_STRICT_LIMIT and SORM_STRICT.
+func ConfigFromEnv() (Config, error) {
+	c := Config{
+		Dialect:         os.Getenv("SORM_DIALECT"),
+		ParameterPrefix: os.Getenv("SORM_PARAMETER_PREFIX"),
+	}
+
+	if s := os.Getenv("SORM_SLOW_QUERY_THRESHOLD"); s != "" {
+		v, err := time.ParseDuration(s)
+		if err != nil {
+			return Config{}, fmt.Errorf("ConfigFromEnv: SORM_SLOW_QUERY_THRESHOLD: %w", err)
+		}
+
+		c.SlowQueryThreshold = v
+	}
+
+	if s := os.Getenv("SORM_MAX_ROWS"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil {
+			return Config{}, fmt.Errorf("ConfigFromEnv: SORM_MAX_ROWS: %w", err)
+		}
+
+		c.MaxRows = v
+	}
+
+	if s := os.Getenv("SORM_DEFAULT_LIMIT"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil {
+			return Config{}, fmt.Errorf("ConfigFromEnv: SORM_DEFAULT_LIMIT: %w", err)
+		}
+
+		c.DefaultLimit = v
+	}
+
+	if s := os.Getenv("SORM_STRICT_LIMIT"); s != "" {
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return Config{}, fmt.Errorf("ConfigFromEnv: SORM_STRICT_LIMIT: %w", err)
+		}
+
+		c.StrictLimit = v
+	}
+
+	if s := os.Getenv("SORM_STRICT"); s != "" {
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return Config{}, fmt.Errorf("ConfigFromEnv: SORM_STRICT: %w", err)
+		}
+
+		c.Strict = v
+	}
+
+	return c, nil
+}
+
+func (c Config) Options() Options {
+	return Options{
+		QueryLogger:        c.QueryLogger,
+		SlowQueryThreshold: c.SlowQueryThreshold,
+		MaxRows:            c.MaxRows,
+		DefaultLimit:       c.DefaultLimit,
+		StrictLimit:        c.StrictLimit,
+		Strict:             c.Strict,
+	}
+}
+
+// Apply makes the process-wide part of the config (parameter prefix, replace
+// mode, json column type, locking and purge syntax and identifier quote)
+// take effect.
+func (c Config) Apply() error {
+	prefix := c.ParameterPrefix
+	mode := ReplaceInsertOrReplace
+	jsonType := "text"
+	locking := LockingSuffix
+	purge := PurgeSubquery
+	quote := `"`
+
+	switch c.Dialect {
+	case "", "postgres":
+		jsonType = "jsonb"
+	case "sqlite":
+		if prefix == "" {
+			prefix = "?"
+		}
+		locking = LockingNone
+	case "mysql":
+		mode = ReplaceOnDuplicateKey
+		jsonType = "json"
+		purge = PurgeOrderLimit
+		quote = "`"
+	case "sqlserver":
+		if prefix == "" {
+			prefix = "@p"
+		}
+		mode = ReplaceMerge
+		jsonType = "nvarchar(max)"
+		locking = LockingTableHints
+		purge = PurgeTop
+	default:
+		return fmt.Errorf("unknown dialect %q", c.Dialect)
+	}
+
+	SetParameterPrefix(prefix)
+	SetReplaceMode(mode)
+	SetJSONColumnType(jsonType)
+	SetLockingSyntax(locking)
+	SetPurgeSyntax(purge)
+	SetIdentifierQuote(quote)
+
+	return nil
+}
+
+// Session pairs a database with the per-call part of a Config. Use Context
+// to get a context carrying its options.
+type Session struct {
+	Querier
+	Options Options
+}
+
+func NewSession(db Querier, c Config) (*Session, error) {
+	if err := c.Apply(); err != nil {
+		return nil, fmt.Errorf("NewSession: %w", err)
+	}
+
+	return &Session{Querier: db, Options: c.Options()}, nil
+}
+
+func (s *Session) Context(ctx context.Context) context.Context {
+	return WithOptions(ctx, s.Options)
+}
@@ -0,0 +1,141 @@
+package sorm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigJSON(t *testing.T) {
+	a := assert.New(t)
+
+	var c Config
+	if !a.NoError(json.Unmarshal([]byte(`{"dialect": "sqlserver", "slow_query_threshold": "200ms", "max_rows": 10, "strict": true}`), &c)) {
+		return
+	}
+
+	a.Equal(Config{Dialect: "sqlserver", SlowQueryThreshold: 200 * time.Millisecond, MaxRows: 10, Strict: true}, c)
+
+	a.Error(json.Unmarshal([]byte(`{"slow_query_threshold": "soon"}`), &c))
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	a := assert.New(t)
+
+	t.Setenv("SORM_DIALECT", "mysql")
+	t.Setenv("SORM_PARAMETER_PREFIX", "?")
+	t.Setenv("SORM_SLOW_QUERY_THRESHOLD", "1s")
+	t.Setenv("SORM_MAX_ROWS", "100")
+	t.Setenv("SORM_DEFAULT_LIMIT", "50")
+	t.Setenv("SORM_STRICT", "true")
+
+	c, err := ConfigFromEnv()
+	if !a.NoError(err) {
+		return
+	}
+
+	a.Equal(Config{Dialect: "mysql", ParameterPrefix: "?", SlowQueryThreshold: time.Second, MaxRows: 100, DefaultLimit: 50, Strict: true}, c)
+
+	t.Setenv("SORM_MAX_ROWS", "lots")
+
+	_, err = ConfigFromEnv()
+	a.Error(err)
+}
+
+func TestNewSession(t *testing.T) {
+	a := assert.New(t)
+
+	defer SetParameterPrefix("")
+	defer SetReplaceMode(ReplaceInsertOrReplace)
+	defer SetJSONColumnType("text")
+	defer SetLockingSyntax(LockingSuffix)
+	defer SetPurgeSyntax(PurgeSubquery)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	_, err = NewSession(db, Config{Dialect: "oracle"})
+	a.EqualError(err, `NewSession: unknown dialect "oracle"`)
+
+	s, err := NewSession(db, Config{Dialect: "sqlserver", MaxRows: 1})
+	if !a.NoError(err) {
+		return
+	}
+
+	mockDB.ExpectQuery(`select \* from simple_objects where id > @p1`).WithArgs(0).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a").AddRow(2, "b"))
+	mockDB.ExpectExec(`merge into simple_objects`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	var l []SimpleObject
+	a.EqualError(FindWhere(s.Context(context.Background()), s, &l, "where id > "+makeParameter(1), 0), "ScanRows: query returned more than 1 rows")
+	a.NoError(ReplaceRecord(s.Context(context.Background()), s, &SimpleObject{ID: 1, Name: "a"}))
+	a.Equal("nvarchar(max)", jsonColumnType)
+	a.Equal(LockingTableHints, lockingSyntax)
+	a.Equal(PurgeTop, purgeSyntax)
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+type afterLogger struct{ queries []string }
+
+func (l *afterLogger) LogQuery(query string, vars []interface{}) {
+	l.queries = append(l.queries, "before: "+query)
+}
+
+func (l *afterLogger) LogQueryAfter(query string, vars []interface{}, duration time.Duration, err error) {
+	l.queries = append(l.queries, "after: "+query)
+}
+
+func TestSlowQueryThreshold(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from simple_objects`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	mockDB.ExpectQuery(`select \* from simple_objects`).WillReturnError(errors.New("broken"))
+
+	var l afterLogger
+	ctx := WithOptions(context.Background(), Options{QueryLogger: &l, SlowQueryThreshold: time.Hour})
+
+	var out []SimpleObject
+	a.NoError(FindAll(ctx, db, &out))
+	a.Error(FindAll(ctx, db, &out))
+
+	a.Equal([]string{"after: select * from simple_objects"}, l.queries)
+}
+
+func TestSlowQueryThresholdPlainLogger(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from simple_objects`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	mockDB.ExpectQuery(`select \* from simple_objects`).WillReturnError(errors.New("broken"))
+
+	var queries []string
+	l := QueryLoggerFunc(func(query string, vars []interface{}) { queries = append(queries, query) })
+	ctx := WithOptions(context.Background(), Options{QueryLogger: l, SlowQueryThreshold: time.Hour})
+
+	var out []SimpleObject
+	a.NoError(FindAll(ctx, db, &out))
+	a.Error(FindAll(ctx, db, &out))
+
+	a.Equal([]string{"select * from simple_objects"}, queries)
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
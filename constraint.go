@@ -0,0 +1,164 @@
+package sorm
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrDuplicateKey         = errors.New("duplicate key")
+	ErrForeignKeyViolation  = errors.New("foreign key violation")
+	ErrCheckViolation       = errors.New("check constraint violation")
+	ErrNotNullViolation     = errors.New("not null violation")
+	ErrConstraintViolation  = errors.New("constraint violation")
+	constraintStates        = map[string]error{"23505": ErrDuplicateKey, "23503": ErrForeignKeyViolation, "23514": ErrCheckViolation, "23502": ErrNotNullViolation}
+	constraintNumbers       = map[uint64]error{}
+	constraintKeyPattern    = regexp.MustCompile(`for key '([^']+)'`)
+	constraintDetailPattern = regexp.MustCompile(`Key \(([^)]+)\)`)
+	constraintSQLitePattern = regexp.MustCompile(`constraint failed: (.+)$`)
+)
+
+func init() {
+	for kind, numbers := range map[error][]uint64{
+		// mysql, sql server, then sqlite extended result codes
+		ErrDuplicateKey:        {1062, 1586, 2601, 2627, 1555, 2067},
+		ErrForeignKeyViolation: {1216, 1217, 1451, 1452, 787},
+		ErrCheckViolation:      {3819, 275},
+		ErrNotNullViolation:    {1048, 515, 1299},
+		// sql server uses 547 for both foreign key and check violations
+		ErrConstraintViolation: {547},
+	} {
+		for _, n := range numbers {
+			constraintNumbers[n] = kind
+		}
+	}
+}
+
+// ConstraintError is a driver error classified by TranslateError. It matches
+// its Kind (e.g. ErrDuplicateKey) with errors.Is, and unwraps to the original
+// driver error.
+type ConstraintError struct {
+	Kind       error
+	Constraint string
+	Columns    []string
+	Err        error
+}
+
+func (e *ConstraintError) Error() string {
+	s := e.Kind.Error()
+	if e.Constraint != "" {
+		s += " on " + e.Constraint
+	}
+	if len(e.Columns) > 0 {
+		s += " (" + strings.Join(e.Columns, ", ") + ")"
+	}
+
+	return s + ": " + e.Err.Error()
+}
+
+func (e *ConstraintError) Is(target error) bool {
+	return target == e.Kind || target == ErrConstraintViolation
+}
+
+func (e *ConstraintError) Unwrap() error {
+	return e.Err
+}
+
+// TranslateError turns a Postgres, MySQL, SQLite or SQL Server constraint
+// violation into a *ConstraintError. Any other error is returned unchanged.
+// The write functions call it on the errors they get from the database.
+func TranslateError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var ce *ConstraintError
+	if errors.As(err, &ce) {
+		return err
+	}
+
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		state, number := errorCodes(e)
+
+		kind := constraintStates[state]
+		if kind == nil {
+			kind = constraintNumbers[number]
+		}
+		if kind == nil {
+			continue
+		}
+
+		constraint, columns := constraintDetails(e)
+
+		return &ConstraintError{Kind: kind, Constraint: constraint, Columns: columns, Err: err}
+	}
+
+	return err
+}
+
+// constraintDetails finds the constraint name and columns either in the
+// fields of the driver error (lib/pq, pgx) or in its message (mysql, sqlite).
+func constraintDetails(err error) (string, []string) {
+	constraint := stringField(err, "Constraint", "ConstraintName")
+
+	var columns []string
+	if s := stringField(err, "Column", "ColumnName"); s != "" {
+		columns = []string{s}
+	}
+
+	if m := constraintDetailPattern.FindStringSubmatch(stringField(err, "Detail")); m != nil {
+		columns = splitColumns(m[1])
+	}
+
+	msg := err.Error()
+
+	if m := constraintKeyPattern.FindStringSubmatch(msg); constraint == "" && m != nil {
+		constraint = m[1]
+		if i := strings.LastIndex(constraint, "."); i != -1 {
+			constraint = constraint[i+1:]
+		}
+	}
+
+	if m := constraintSQLitePattern.FindStringSubmatch(msg); columns == nil && m != nil {
+		for _, c := range splitColumns(m[1]) {
+			if i := strings.LastIndex(c, "."); i != -1 {
+				c = c[i+1:]
+			}
+
+			columns = append(columns, c)
+		}
+	}
+
+	return constraint, columns
+}
+
+func splitColumns(s string) []string {
+	var l []string
+	for _, c := range strings.Split(s, ",") {
+		l = append(l, strings.Trim(strings.TrimSpace(c), `"`))
+	}
+
+	return l
+}
+
+func stringField(err error, names ...string) string {
+	v := reflect.ValueOf(err)
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		return ""
+	}
+
+	v = reflect.Indirect(v)
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+
+	for _, name := range names {
+		if f := v.FieldByName(name); f.IsValid() && f.Kind() == reflect.String && f.String() != "" {
+			return f.String()
+		}
+	}
+
+	return ""
+}
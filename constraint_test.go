@@ -0,0 +1,84 @@
+package sorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type pqError struct {
+	Code       string
+	Detail     string
+	Constraint string
+}
+
+func (e *pqError) Error() string { return "pq: " + e.Code }
+
+type sqliteError struct {
+	Code         int
+	ExtendedCode int
+	msg          string
+}
+
+func (e sqliteError) Error() string { return e.msg }
+
+func TestTranslateError(t *testing.T) {
+	a := assert.New(t)
+
+	err := TranslateError(&pqError{Code: "23505", Detail: "Key (email)=(a@b.c) already exists.", Constraint: "users_email_key"})
+	a.True(errors.Is(err, ErrDuplicateKey))
+	a.True(errors.Is(err, ErrConstraintViolation))
+	a.False(errors.Is(err, ErrForeignKeyViolation))
+
+	var ce *ConstraintError
+	if a.True(errors.As(err, &ce)) {
+		a.Equal("users_email_key", ce.Constraint)
+		a.Equal([]string{"email"}, ce.Columns)
+	}
+
+	var pe *pqError
+	a.True(errors.As(err, &pe))
+
+	err = TranslateError(fmt.Errorf("wrapped: %w", &txMySQLError{Number: 1062, Message: "Duplicate entry 'a@b.c' for key 'users.users_email_key'"}))
+	if a.True(errors.As(err, &ce)) {
+		a.Equal(ErrDuplicateKey, ce.Kind)
+		a.Equal("users_email_key", ce.Constraint)
+	}
+
+	err = TranslateError(sqliteError{Code: 19, ExtendedCode: 2067, msg: "UNIQUE constraint failed: users.org_id, users.email"})
+	if a.True(errors.As(err, &ce)) {
+		a.Equal(ErrDuplicateKey, ce.Kind)
+		a.Equal([]string{"org_id", "email"}, ce.Columns)
+	}
+
+	a.True(errors.Is(TranslateError(txStateError("23503")), ErrForeignKeyViolation))
+	a.True(errors.Is(TranslateError(&txMySQLError{Number: 3819}), ErrCheckViolation))
+	a.True(errors.Is(TranslateError(sqliteError{Code: 19, ExtendedCode: 1299}), ErrNotNullViolation))
+
+	plain := errors.New("nope")
+	a.Equal(plain, TranslateError(plain))
+	a.Equal(txStateError("40001"), TranslateError(txStateError("40001")))
+	a.NoError(TranslateError(nil))
+}
+
+func TestCreateRecordDuplicateKey(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectExec(`^insert into simple_objects \(id, name\) values \(\$1, \$2\)$`).WithArgs(1, "a").WillReturnError(&pqError{Code: "23505", Constraint: "simple_objects_pkey"})
+
+	err = CreateRecord(context.Background(), db, &SimpleObject{ID: 1, Name: "a"})
+	a.True(errors.Is(err, ErrDuplicateKey))
+	a.EqualError(err, "CreateRecord: duplicate key on simple_objects_pkey: pq: 23505")
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
@@ -0,0 +1,92 @@
+package sorm
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// CountGrouped counts the model's records matching where, grouped by the
+// values of groupByColumn. Records where the column is null are counted under
+// the empty string.
+func CountGrouped(ctx context.Context, db Querier, model interface{}, groupByColumn, where string, args ...interface{}) (map[string]int64, error) {
+	ctx, db = route(ctx, db, model, OperationCount)
+
+	vtyp, err := structTypeOf(model)
+	if err != nil {
+		return nil, fmt.Errorf("CountGrouped: %w", err)
+	}
+
+	vdesc, err := getDescriptionContext(ctx, vtyp)
+	if err != nil {
+		return nil, fmt.Errorf("CountGrouped: could not get detailed reflection information for type %s: %w", vtyp.String(), err)
+	}
+
+	if err := ValidateWhereColumns(model, groupByColumn); err != nil {
+		return nil, fmt.Errorf("CountGrouped: %w", err)
+	}
+
+	if err := checkIdentifier(groupByColumn); err != nil {
+		return nil, fmt.Errorf("CountGrouped: %w", err)
+	}
+
+	if where != "" {
+		where += " "
+	}
+
+	o, err := findOptions{}.withDefaultScope(ctx, vtyp)
+	if err != nil {
+		return nil, fmt.Errorf("CountGrouped: %w", err)
+	}
+
+	stmt, err := buildSelect(vdesc, getSQLTableNameContext(ctx, vdesc), groupByColumn+", count(*)", where+"group by "+groupByColumn, args, o)
+	if err != nil {
+		return nil, fmt.Errorf("CountGrouped: %w", err)
+	}
+
+	explainQuery(ctx, db, stmt.Query, stmt.Args)
+
+	logQuery(ctx, stmt.Query, stmt.Args)
+
+	start := time.Now()
+
+	m, err := countGroupedRows(ctx, db, stmt)
+	if err != nil {
+		logQueryAfter(ctx, stmt.Query, stmt.Args, start, err)
+		observeOperation(ctx, OperationCount, vtyp, getSQLTableNameContext(ctx, vdesc), stmt, start, 0, err)
+
+		return nil, fmt.Errorf("CountGrouped: %w", err)
+	}
+
+	logQueryAfter(ctx, stmt.Query, stmt.Args, start, nil)
+	observeOperation(ctx, OperationCount, vtyp, getSQLTableNameContext(ctx, vdesc), stmt, start, int64(len(m)), nil)
+
+	return m, nil
+}
+
+func countGroupedRows(ctx context.Context, db Querier, stmt Statement) (map[string]int64, error) {
+	rows, err := db.QueryContext(ctx, stmt.Query, stmt.Args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	m := make(map[string]int64)
+
+	for rows.Next() {
+		var k sql.NullString
+		var n int64
+		if err := rows.Scan(&k, &n); err != nil {
+			return nil, err
+		}
+
+		m[k.String] += n
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
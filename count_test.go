@@ -0,0 +1,35 @@
+package sorm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountGrouped(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select name, count\(\*\) from simple_objects where id > \$1 group by name$`).WithArgs(5).WillReturnRows(sqlmock.NewRows([]string{"name", "count"}).AddRow("a", 3).AddRow(nil, 1).AddRow("b", 2))
+	mockDB.ExpectQuery(`select id, count\(\*\) from simple_objects group by id$`).WillReturnRows(sqlmock.NewRows([]string{"id", "count"}).AddRow(1, 1))
+
+	m, err := CountGrouped(context.Background(), db, &SimpleObject{}, "name", "where id > $1", 5)
+	a.NoError(err)
+	a.Equal(map[string]int64{"a": 3, "b": 2, "": 1}, m)
+
+	m, err = CountGrouped(context.Background(), db, SimpleObject{}, "id", "")
+	a.NoError(err)
+	a.Equal(map[string]int64{"1": 1}, m)
+
+	_, err = CountGrouped(context.Background(), db, SimpleObject{}, "id; drop table x", "")
+	a.Error(err)
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
@@ -0,0 +1,133 @@
+package sorm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// CountRelated counts the records of a has_many or has_one relation of
+// parent without loading them. It's the count Preload would load into the
+// relation's field.
+func CountRelated(ctx context.Context, db Querier, parent interface{}, relation string) (int, error) {
+	ptr := reflect.ValueOf(parent)
+	if ptr.Kind() != reflect.Ptr || ptr.Elem().Kind() != reflect.Struct {
+		return 0, fmt.Errorf("CountRelated: %w", typeErrorf(ErrNotAStruct, "expected parent to be pointer to struct; was instead %T", parent))
+	}
+
+	r, err := countableRelation(ctx, ptr.Elem().Type(), relation)
+	if err != nil {
+		return 0, fmt.Errorf("CountRelated: %w", err)
+	}
+
+	k, ok := relationKey(ptr.Elem().FieldByIndex(r.localIndex))
+	if !ok {
+		return 0, nil
+	}
+
+	n, err := CountWhere(ctx, db, reflect.New(r.targetType).Interface(), "where "+r.targetColumn+" = "+makeParameter(1), k)
+	if err != nil {
+		return 0, fmt.Errorf("CountRelated: %w", err)
+	}
+
+	return n, nil
+}
+
+// CountRelatedBatch counts a relation for every parent in the slice out
+// points to with one grouped query. Each count is stored in the parent's
+// field tagged count:"<relation>", which should be an integer with sql:"-",
+// e.g.
+//
+//	CommentCount int `sql:"-" count:"Comments"`
+func CountRelatedBatch(ctx context.Context, db Querier, out interface{}, relation string) error {
+	vtyp, parents, err := preloadTargets(out)
+	if err != nil {
+		return fmt.Errorf("CountRelatedBatch: %w", err)
+	}
+
+	r, err := countableRelation(ctx, vtyp, relation)
+	if err != nil {
+		return fmt.Errorf("CountRelatedBatch: %w", err)
+	}
+
+	vdesc, err := getDescriptionContext(ctx, vtyp)
+	if err != nil {
+		return fmt.Errorf("CountRelatedBatch: could not get detailed reflection information for type %s: %w", vtyp.String(), err)
+	}
+
+	var countIndex []int
+	for _, f := range vdesc.Fields() {
+		if t := f.Tag("count"); t != nil && t.Value() == relation {
+			countIndex = f.Index()
+			break
+		}
+	}
+	if countIndex == nil {
+		return fmt.Errorf("CountRelatedBatch: %s has no field tagged count:%q", vtyp.Name(), relation)
+	}
+
+	switch k := vtyp.FieldByIndex(countIndex).Type.Kind(); {
+	case k >= reflect.Int && k <= reflect.Int64, k >= reflect.Uint && k <= reflect.Uint64:
+	default:
+		return fmt.Errorf("CountRelatedBatch: count field for %s on %s should be an integer; was instead %s", relation, vtyp.Name(), k)
+	}
+
+	var keys []interface{}
+	var params []string
+	seen := make(map[interface{}]bool)
+	for _, p := range parents {
+		k, ok := relationKey(p.FieldByIndex(r.localIndex))
+		if !ok || seen[k] {
+			continue
+		}
+		seen[k] = true
+
+		keys = append(keys, k)
+		params = append(params, makeParameter(len(keys)))
+	}
+
+	var counts map[string]int64
+	if len(keys) > 0 {
+		m, err := CountGrouped(ctx, db, reflect.New(r.targetType).Interface(), r.targetColumn, "where "+r.targetColumn+" in ("+strings.Join(params, ", ")+")", keys...)
+		if err != nil {
+			return fmt.Errorf("CountRelatedBatch: %w", err)
+		}
+
+		counts = m
+	}
+
+	for _, p := range parents {
+		var n int64
+		if k, ok := relationKey(p.FieldByIndex(r.localIndex)); ok {
+			n = counts[fmt.Sprint(k)]
+		}
+
+		f := p.FieldByIndex(countIndex)
+		if f.Kind() >= reflect.Uint && f.Kind() <= reflect.Uint64 {
+			f.SetUint(uint64(n))
+		} else {
+			f.SetInt(n)
+		}
+	}
+
+	return nil
+}
+
+func countableRelation(ctx context.Context, vtyp reflect.Type, name string) (*relation, error) {
+	vdesc, err := getDescriptionContext(ctx, vtyp)
+	if err != nil {
+		return nil, fmt.Errorf("could not get detailed reflection information for type %s: %w", vtyp.String(), err)
+	}
+
+	r, err := getRelation(ctx, vtyp, vdesc, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.kind == relationBelongsTo {
+		return nil, fmt.Errorf("can't count %s relation %s on %s", r.kind, name, vtyp.Name())
+	}
+
+	return r, nil
+}
@@ -0,0 +1,59 @@
+package sorm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type CountedPost struct {
+	ID           int
+	Title        string
+	Comments     []PreloadComment `sql:"-,has_many,fk:post_id"`
+	CommentCount int              `sql:"-" count:"Comments"`
+}
+
+func TestCountRelated(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select count\(\*\) from preload_comments where post_id = \$1`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	n, err := CountRelated(context.Background(), db, &PreloadPost{ID: 1}, "Comments")
+	a.NoError(err)
+	a.Equal(3, n)
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestCountRelatedBatch(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select post_id, count\(\*\) from preload_comments where post_id in \(\$1, \$2, \$3\) group by post_id$`).WithArgs(1, 2, 3).WillReturnRows(sqlmock.NewRows([]string{"post_id", "count"}).AddRow(1, 2).AddRow(3, 5))
+
+	posts := []CountedPost{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 1}}
+	a.NoError(CountRelatedBatch(context.Background(), db, &posts, "Comments"))
+	a.Equal([]int{2, 0, 5, 2}, []int{posts[0].CommentCount, posts[1].CommentCount, posts[2].CommentCount, posts[3].CommentCount})
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestCountRelatedBatchNoCountField(t *testing.T) {
+	a := assert.New(t)
+
+	posts := []PreloadPost{{ID: 1}}
+	a.EqualError(CountRelatedBatch(context.Background(), nil, &posts, "Comments"), `CountRelatedBatch: PreloadPost has no field tagged count:"Comments"`)
+}
@@ -0,0 +1,232 @@
+package sorm
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	collateComparisons bool
+)
+
+func SetCollateComparisons(b bool) {
+	collateComparisons = b
+}
+
+func getSQLCollation(f structField) string {
+	if t := f.Tag("sql"); t != nil {
+		if p := t.Parameter("collate"); p != nil {
+			return p.Value()
+		}
+	}
+
+	return ""
+}
+
+func getSQLCharset(f structField) string {
+	if t := f.Tag("sql"); t != nil {
+		if p := t.Parameter("charset"); p != nil {
+			return p.Value()
+		}
+	}
+
+	return ""
+}
+
+func columnComparison(f structField, col string) string {
+	if c := getSQLCollation(f); c != "" && collateComparisons {
+		return col + " collate " + c
+	}
+
+	return col
+}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	bytesType   = reflect.TypeOf([]byte(nil))
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+)
+
+func getSQLColumnType(f structField, typ reflect.Type) (string, bool, error) {
+	nullable := false
+	switch typ.Kind() {
+	case reflect.Ptr:
+		nullable = true
+		typ = typ.Elem()
+	case reflect.Map, reflect.Slice, reflect.Interface:
+		// nil values of these are only written as NULL for json fields
+		nullable = isJSONField(f)
+	}
+
+	if t := f.Tag("sql"); t != nil {
+		if p := t.Parameter("type"); p != nil && p.Value() != "" {
+			return p.Value(), nullable, nil
+		}
+	}
+
+	if getSQLCompression(f) != "" {
+		return "blob", nullable, nil
+	}
+
+	if isJSONField(f) {
+		return jsonColumnType, nullable, nil
+	}
+
+	switch typ {
+	case timeType:
+		return "timestamp", nullable, nil
+	case bytesType, rawBytesType:
+		return "blob", nullable, nil
+	}
+
+	if strings.HasPrefix(typ.Name(), "Null") && reflect.PtrTo(typ).Implements(scannerType) {
+		nullable = true
+		if vf, ok := typ.FieldByName(strings.TrimPrefix(typ.Name(), "Null")); ok {
+			typ = vf.Type
+		} else if vf, ok := typ.FieldByName("V"); ok {
+			typ = vf.Type
+		}
+	}
+
+	if isByteArray(typ) {
+		return "blob", nullable, nil
+	}
+
+	switch typ.Kind() {
+	case reflect.Bool:
+		return "boolean", nullable, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer", nullable, nil
+	case reflect.Float32, reflect.Float64:
+		return "real", nullable, nil
+	case reflect.String:
+		return "text", nullable, nil
+	}
+
+	if typ == timeType {
+		return "timestamp", nullable, nil
+	}
+
+	return "", false, fmt.Errorf("couldn't determine sql type for field %s of type %s; use the type tag parameter", f.Name(), typ.String())
+}
+
+func CreateTableStatement(model interface{}) (Statement, error) {
+	vtyp, err := structTypeOf(model)
+	if err != nil {
+		return Statement{}, fmt.Errorf("CreateTableStatement: %w", err)
+	}
+
+	vdesc, err := getDescriptionFromType(vtyp)
+	if err != nil {
+		return Statement{}, fmt.Errorf("CreateTableStatement: could not get detailed reflection information for type %s: %w", vtyp.String(), err)
+	}
+
+	stmt, err := buildCreateTable(vtyp, vdesc, getSQLTableName(vdesc))
+	if err != nil {
+		return Statement{}, fmt.Errorf("CreateTableStatement: %w", err)
+	}
+
+	return stmt, nil
+}
+
+func buildCreateTable(vtyp reflect.Type, vdesc *structDescription, tbl string) (Statement, error) {
+	if err := checkIdentifier(tbl); err != nil {
+		return Statement{}, err
+	}
+
+	var defs []string
+	for _, f := range getSQLWritableFields(vdesc) {
+		def, err := columnDefinition(vtyp, f)
+		if err != nil {
+			return Statement{}, err
+		}
+
+		defs = append(defs, def)
+	}
+
+	var ids []string
+	for _, f := range getSQLIDFields(vdesc) {
+		ids = append(ids, getSQLColumnName(f))
+	}
+	if len(ids) > 0 {
+		defs = append(defs, "primary key ("+strings.Join(ids, ", ")+")")
+	}
+
+	return Statement{Query: fmt.Sprintf("create table %s (%s)", tbl, strings.Join(defs, ", "))}, nil
+}
+
+func columnDefinition(vtyp reflect.Type, f structField) (string, error) {
+	col := getSQLColumnName(f)
+	if err := checkIdentifier(col); err != nil {
+		return "", err
+	}
+
+	typ, nullable, err := getSQLColumnType(f, vtyp.FieldByIndex(f.Index()).Type)
+	if err != nil {
+		return "", err
+	}
+
+	def := col + " " + typ
+	if c := getSQLCharset(f); c != "" {
+		if err := checkIdentifier(c); err != nil {
+			return "", err
+		}
+		def += " character set " + c
+	}
+	if c := getSQLCollation(f); c != "" {
+		if err := checkIdentifier(c); err != nil {
+			return "", err
+		}
+		def += " collate " + c
+	}
+	if d := getSQLDefaultExpression(f); d != "" {
+		def += " default " + d
+	}
+	if !nullable {
+		def += " not null"
+	}
+	if l := getSQLEnum(f); l != nil && enumChecks {
+		def += " " + enumCheckConstraint(col, l)
+	}
+
+	return def, nil
+}
+
+// CreateTable creates the table for model along with any indexes declared by
+// its tags.
+func CreateTable(ctx context.Context, db Querier, model interface{}) error {
+	vtyp, err := structTypeOf(model)
+	if err != nil {
+		return fmt.Errorf("CreateTable: %w", err)
+	}
+
+	vdesc, err := getDescriptionContext(ctx, vtyp)
+	if err != nil {
+		return fmt.Errorf("CreateTable: could not get detailed reflection information for type %s: %w", vtyp.String(), err)
+	}
+
+	stmts, err := createTableStatements(vtyp, vdesc, getSQLTableName(vdesc))
+	if err != nil {
+		return fmt.Errorf("CreateTable: %w", err)
+	}
+
+	for _, stmt := range stmts {
+		logQuery(ctx, stmt.Query, stmt.Args)
+
+		start := time.Now()
+
+		if _, err := db.ExecContext(ctx, stmt.Query, stmt.Args...); err != nil {
+			logQueryAfter(ctx, stmt.Query, stmt.Args, start, err)
+
+			return fmt.Errorf("CreateTable: %w", err)
+		}
+
+		logQueryAfter(ctx, stmt.Query, stmt.Args, start, nil)
+	}
+
+	return nil
+}
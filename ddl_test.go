@@ -0,0 +1,105 @@
+package sorm
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type DDLObject struct {
+	ID       int    `table:"ddl_objects"`
+	Email    string `sql:",collate:nocase"`
+	Name     string `sql:",charset:utf8mb4,collate:utf8mb4_unicode_ci"`
+	Code     string `sql:",type:varchar(16)"`
+	Score    *float64
+	Active   bool
+	Nickname sql.NullString
+	Created  time.Time
+	Ignored  string `sql:"-"`
+}
+
+func TestCreateTableStatement(t *testing.T) {
+	a := assert.New(t)
+
+	s, err := CreateTableStatement(DDLObject{})
+	if !a.NoError(err) {
+		return
+	}
+
+	a.Equal("create table ddl_objects (id integer not null, email text collate nocase not null, name text character set utf8mb4 collate utf8mb4_unicode_ci not null, code varchar(16) not null, score real, active boolean not null, nickname text, created timestamp not null, primary key (id))", s.Query)
+	a.Empty(s.Args)
+}
+
+func TestCreateTableStatementUnknownType(t *testing.T) {
+	a := assert.New(t)
+
+	type DDLBadObject struct {
+		ID   int
+		Tags map[string]string
+	}
+
+	_, err := CreateTableStatement(DDLBadObject{})
+	a.EqualError(err, "CreateTableStatement: couldn't determine sql type for field Tags of type map[string]string; use the type tag parameter")
+}
+
+func TestCreateTable(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectExec(`create table ddl_objects \(id integer not null, email text collate nocase not null, .+, primary key \(id\)\)`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	a.NoError(CreateTable(context.Background(), db, &DDLObject{}))
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestCollateComparisons(t *testing.T) {
+	a := assert.New(t)
+
+	type DDLUser struct {
+		Email string `sql:",id,collate:nocase" table:"ddl_users"`
+		Name  string
+	}
+
+	s, err := DeleteStatement(DDLUser{Email: "A@example.com"})
+	if !a.NoError(err) {
+		return
+	}
+	a.Equal("delete from ddl_users where email = $1", s.Query)
+
+	SetCollateComparisons(true)
+	defer SetCollateComparisons(false)
+
+	s, err = DeleteStatement(DDLUser{Email: "A@example.com"})
+	if !a.NoError(err) {
+		return
+	}
+	a.Equal("delete from ddl_users where email collate nocase = $1", s.Query)
+	a.Equal([]interface{}{"A@example.com"}, s.Args)
+}
+
+func TestCreateTableIndexes(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectExec(`create table indexed_objects \(.+\)`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mockDB.ExpectExec(`create index idx_user_tenant on indexed_objects \(tenant_id, created\)`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mockDB.ExpectExec(`create unique index indexed_objects_email_key on indexed_objects \(email\)`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mockDB.ExpectExec(`create index indexed_objects_name_idx on indexed_objects \(name\)`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	a.NoError(CreateTable(context.Background(), db, &IndexedObject{}))
+	a.NoError(mockDB.ExpectationsWereMet())
+}
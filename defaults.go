@@ -0,0 +1,230 @@
+package sorm
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// DefaultGenerator makes a value for a field of type typ that's zero when
+// it's created. It may return a value of any type that converts to typ.
+type DefaultGenerator func(typ reflect.Type) (interface{}, error)
+
+var (
+	defaultGenerators = map[string]DefaultGenerator{
+		"uuid":   uuidGenerator(newUUIDv4),
+		"uuidv4": uuidGenerator(newUUIDv4),
+		"uuidv7": uuidGenerator(newUUIDv7),
+		"ulid":   generateULID,
+		"now":    generateNow,
+	}
+	defaultGeneratorsLock sync.RWMutex
+)
+
+// RegisterDefault adds a generator for `sql:",default:name"` tags. uuid (v4),
+// uuidv4, uuidv7, ulid and now are built in. A default that isn't a
+// registered name is a SQL expression instead: CreateTable adds it to the
+// column definition and CreateRecord leaves zero values out of the insert so
+// the database fills them in.
+func RegisterDefault(name string, fn DefaultGenerator) {
+	defaultGeneratorsLock.Lock()
+	defer defaultGeneratorsLock.Unlock()
+
+	defaultGenerators[name] = fn
+}
+
+func getDefaultGenerator(name string) DefaultGenerator {
+	defaultGeneratorsLock.RLock()
+	defer defaultGeneratorsLock.RUnlock()
+
+	return defaultGenerators[name]
+}
+
+func getSQLDefault(f structField) string {
+	if t := f.Tag("sql"); t != nil {
+		if p := t.Parameter("default"); p != nil {
+			return p.Value()
+		}
+	}
+
+	return ""
+}
+
+// getSQLDefaultExpression returns the default of f if it's a SQL expression
+// rather than a generator.
+func getSQLDefaultExpression(f structField) string {
+	if s := getSQLDefault(f); s != "" && getDefaultGenerator(s) == nil {
+		return s
+	}
+
+	return ""
+}
+
+// setGeneratedValue fills the field fv using generator name.
+func setGeneratedValue(fv reflect.Value, name string) error {
+	fn := getDefaultGenerator(name)
+	if fn == nil {
+		return fmt.Errorf("no default generator registered for %q", name)
+	}
+
+	typ := fv.Type()
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	v, err := fn(typ)
+	if err != nil {
+		return err
+	}
+
+	rv := reflect.ValueOf(v)
+	if !rv.Type().ConvertibleTo(typ) {
+		return fmt.Errorf("default %s made a %s, which can't be used as a %s", name, rv.Type(), typ)
+	}
+	rv = rv.Convert(typ)
+
+	if fv.Kind() == reflect.Ptr {
+		p := reflect.New(typ)
+		p.Elem().Set(rv)
+		rv = p
+	}
+
+	fv.Set(rv)
+
+	return nil
+}
+
+// getSQLGenerate returns the generator named by an ID field's
+// `sql:",id,generate:uuidv7"` tag.
+func getSQLGenerate(f structField) string {
+	if t := f.Tag("sql"); t != nil {
+		if p := t.Parameter("generate"); p != nil {
+			return p.Value()
+		}
+	}
+
+	return ""
+}
+
+// applyDefaults fills zero fields of v that have a generated default, and
+// zero ID fields that have a generate strategy.
+func applyDefaults(vdesc *structDescription, v reflect.Value) error {
+	for _, f := range getSQLWritableFields(vdesc) {
+		name := getSQLGenerate(f)
+		if name == "" {
+			if name = getSQLDefault(f); name == "" || getDefaultGenerator(name) == nil {
+				continue
+			}
+		}
+
+		fv := v.FieldByIndex(f.Index())
+		if !isZero(fv.Interface()) {
+			continue
+		}
+
+		if err := setGeneratedValue(fv, name); err != nil {
+			return fmt.Errorf("couldn't set default for %s: %w", f.Name(), err)
+		}
+	}
+
+	return nil
+}
+
+func uuidGenerator(fn func() ([16]byte, error)) DefaultGenerator {
+	return func(typ reflect.Type) (interface{}, error) {
+		b, err := fn()
+		if err != nil {
+			return nil, err
+		}
+
+		if typ.Kind() == reflect.String {
+			return formatUUID(b), nil
+		}
+
+		return b, nil
+	}
+}
+
+func newUUIDv4() ([16]byte, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return b, err
+	}
+
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return b, nil
+}
+
+func newUUIDv7() ([16]byte, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[6:]); err != nil {
+		return b, err
+	}
+
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], uint64(time.Now().UnixMilli()))
+	copy(b[:6], ts[2:])
+
+	b[6] = (b[6] & 0x0f) | 0x70
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return b, nil
+}
+
+func formatUUID(b [16]byte) string {
+	s := hex.EncodeToString(b[:])
+	return s[0:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:]
+}
+
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+func newULID() ([16]byte, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[6:]); err != nil {
+		return b, err
+	}
+
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], uint64(time.Now().UnixMilli()))
+	copy(b[:6], ts[2:])
+
+	return b, nil
+}
+
+// formatULID writes the 128 bits of b as 26 base32 characters, the first
+// of which only carries 3 bits.
+func formatULID(b [16]byte) string {
+	hi, lo := binary.BigEndian.Uint64(b[:8]), binary.BigEndian.Uint64(b[8:])
+
+	out := make([]byte, 26)
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+
+	return string(out)
+}
+
+func generateULID(typ reflect.Type) (interface{}, error) {
+	b, err := newULID()
+	if err != nil {
+		return nil, err
+	}
+
+	if typ.Kind() == reflect.String {
+		return formatULID(b), nil
+	}
+
+	return b, nil
+}
+
+func generateNow(typ reflect.Type) (interface{}, error) {
+	return time.Now().UTC(), nil
+}
@@ -0,0 +1,127 @@
+package sorm
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type DefaultsObject struct {
+	ID      int
+	Token   string    `sql:",default:uuidv4"`
+	Ref     string    `sql:",default:ulid"`
+	Key     [16]byte  `sql:",default:uuidv7,type:uuid"`
+	Created time.Time `sql:",default:now"`
+	Updated time.Time `sql:",default:current_timestamp"`
+	Name    string    `sql:",default:'anonymous'"`
+}
+
+func TestDefaultsCreate(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`insert into defaults_objects \(token, ref, key, created\) values \(\$1, \$2, \$3, \$4\) returning id`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mockDB.ExpectQuery(`insert into defaults_objects \(token, ref, key, created, name\) values \(\$1, \$2, \$3, \$4, \$5\) returning id`).WithArgs("given", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "bob").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+
+	var r DefaultsObject
+	if !a.NoError(CreateRecord(context.Background(), db, &r)) {
+		return
+	}
+
+	a.Regexp(regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`), r.Token)
+	a.Regexp(regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`), r.Ref)
+	a.Equal(byte(0x70), r.Key[6]&0xf0)
+	a.WithinDuration(time.Now(), r.Created, time.Minute)
+	a.True(r.Updated.IsZero())
+
+	a.NoError(CreateRecord(context.Background(), db, &DefaultsObject{Token: "given", Name: "bob"}))
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestDefaultsCreateTable(t *testing.T) {
+	a := assert.New(t)
+
+	s, err := CreateTableStatement(DefaultsObject{})
+	if !a.NoError(err) {
+		return
+	}
+
+	a.Equal("create table defaults_objects (id integer not null, token text not null, ref text not null, key uuid not null, created timestamp not null, updated timestamp default current_timestamp not null, name text default 'anonymous' not null, primary key (id))", s.Query)
+}
+
+func TestFormatULID(t *testing.T) {
+	a := assert.New(t)
+
+	a.Equal("00000000000000000000000000", formatULID([16]byte{}))
+	a.Equal("7ZZZZZZZZZZZZZZZZZZZZZZZZZ", formatULID([16]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}))
+	a.Equal("01", formatULID([16]byte{15: 1})[24:])
+
+	RegisterDefault("answer", func(typ reflect.Type) (interface{}, error) { return 42, nil })
+	defer delete(defaultGenerators, "answer")
+
+	var v struct{ N int64 }
+	a.NoError(setGeneratedValue(reflect.ValueOf(&v).Elem().Field(0), "answer"))
+	a.Equal(int64(42), v.N)
+}
+
+type GeneratedIDObject struct {
+	ID   string `sql:",id,generate:uuidv7"`
+	Name string
+}
+
+type GeneratedBinaryIDObject struct {
+	ID   [16]byte `sql:",id,generate:ulid"`
+	Name string
+}
+
+func TestGeneratedIDs(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectExec(`insert into generated_id_objects \(id, name\) values \(\$1, \$2\)$`).WithArgs(sqlmock.AnyArg(), "a").WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec(`insert into generated_id_objects \(id, name\) values \(\$1, \$2\)$`).WithArgs("mine", "b").WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec(`insert into generated_binary_id_objects \(id, name\) values \(\$1, \$2\)$`).WithArgs(sqlmock.AnyArg(), "c").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	r := GeneratedIDObject{Name: "a"}
+	a.NoError(CreateRecord(context.Background(), db, &r))
+	a.Regexp(regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`), r.ID)
+
+	a.NoError(CreateRecord(context.Background(), db, &GeneratedIDObject{ID: "mine", Name: "b"}))
+
+	b := GeneratedBinaryIDObject{Name: "c"}
+	a.NoError(CreateRecord(context.Background(), db, &b))
+	a.NotEqual([16]byte{}, b.ID)
+
+	mockDB.ExpectQuery(`select \* from generated_binary_id_objects where id = \$1`).WithArgs(b.ID[:]).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(b.ID[:], "c"))
+
+	var found GeneratedBinaryIDObject
+	a.NoError(FindFirstWhere(context.Background(), db, &found, "where id = $1", b.ID[:]))
+	a.Equal(b, found)
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestByteArrayScanUUIDText(t *testing.T) {
+	a := assert.New(t)
+
+	var v [16]byte
+	a.NoError(byteArrayScanner{reflect.ValueOf(&v).Elem()}.Scan("01890a5d-ac96-774b-bcce-b302099a8057"))
+	a.Equal("01890a5d-ac96-774b-bcce-b302099a8057", formatUUID(v))
+	a.Error(byteArrayScanner{reflect.ValueOf(&v).Elem()}.Scan([]byte{1, 2}))
+}
@@ -0,0 +1,200 @@
+package sorm
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// DefaultScoper is implemented by models whose finds, counts and deletes
+// should always be limited, e.g. to rows that aren't archived. Placeholders
+// are numbered from 1 or written as "?".
+type DefaultScoper interface {
+	DefaultScope() (string, []interface{})
+}
+
+// DefaultScopeFunc is a default scope depending on the context. Returning an
+// error stops the query.
+type DefaultScopeFunc func(ctx context.Context) (string, []interface{}, error)
+
+var (
+	defaultScopesMu sync.RWMutex
+	defaultScopes   = map[reflect.Type][]DefaultScopeFunc{}
+)
+
+// RegisterDefaultScope adds a default scope for model's type, on top of any
+// DefaultScope method it has.
+func RegisterDefaultScope(model interface{}, fn DefaultScopeFunc) {
+	vtyp, err := structTypeOf(model)
+	if err != nil {
+		panic(err)
+	}
+
+	defaultScopesMu.Lock()
+	defaultScopes[vtyp] = append(defaultScopes[vtyp], fn)
+	defaultScopesMu.Unlock()
+}
+
+type unscopedKey struct{}
+
+// Unscoped makes queries using ctx ignore default scopes, for admin tools and
+// migrations.
+func Unscoped(ctx context.Context) context.Context {
+	return context.WithValue(ctx, unscopedKey{}, true)
+}
+
+func isUnscoped(ctx context.Context) bool {
+	b, _ := ctx.Value(unscopedKey{}).(bool)
+	return b
+}
+
+// defaultScope combines the default scopes of vtyp into one condition
+// numbering its placeholders from base+1. It's empty when there are none.
+func defaultScope(ctx context.Context, vtyp reflect.Type, base int) (string, []interface{}, error) {
+	if isUnscoped(ctx) {
+		return "", nil, nil
+	}
+
+	var conds []string
+	var args []interface{}
+
+	add := func(cond string, a []interface{}) error {
+		s, a, err := renumberArgs(cond, a, base+len(args))
+		if err != nil {
+			return err
+		}
+
+		conds = append(conds, "("+s+")")
+		args = append(args, a...)
+
+		return nil
+	}
+
+	if cond, a, err := tenantScope(ctx, vtyp); err != nil {
+		return "", nil, err
+	} else if cond != "" {
+		if err := add(cond, a); err != nil {
+			return "", nil, err
+		}
+	}
+
+	if v, ok := reflect.New(vtyp).Interface().(DefaultScoper); ok {
+		if cond, a := v.DefaultScope(); cond != "" {
+			if err := add(cond, a); err != nil {
+				return "", nil, err
+			}
+		}
+	}
+
+	defaultScopesMu.RLock()
+	fns := defaultScopes[vtyp]
+	defaultScopesMu.RUnlock()
+
+	for _, fn := range fns {
+		cond, a, err := fn(ctx)
+		if err != nil {
+			return "", nil, err
+		}
+
+		if cond != "" {
+			if err := add(cond, a); err != nil {
+				return "", nil, err
+			}
+		}
+	}
+
+	return strings.Join(conds, " and "), args, nil
+}
+
+// withDefaultScope records the default scope of vtyp for buildSelect.
+func (o findOptions) withDefaultScope(ctx context.Context, vtyp reflect.Type) (findOptions, error) {
+	scope, args, err := defaultScope(ctx, vtyp, 0)
+	if err != nil {
+		return o, err
+	}
+
+	o.scope, o.scopeArgs = scope, args
+
+	return o, nil
+}
+
+// injectCondition adds cond to the top-level where of a clause written after
+// "update t" or "delete from t", wrapping the existing condition in
+// parentheses so an "or" in it can't escape the scope.
+func injectCondition(clause, cond string) string {
+	where, whereEnd, tail := -1, -1, len(clause)
+
+	topLevelWords(clause, func(word string, i, j int) {
+		switch word {
+		case "where":
+			if where == -1 {
+				where, whereEnd = i, j
+			}
+		case "order", "limit", "offset", "returning", "for":
+			if tail == len(clause) {
+				tail = i
+			}
+		}
+	})
+
+	if where == -1 || where > tail {
+		before, after := strings.TrimRight(clause[:tail], " "), clause[tail:]
+		s := before + " where " + cond
+		if after != "" {
+			s += " " + after
+		}
+
+		return s
+	}
+
+	s := clause[:where] + "where " + cond + " and (" + strings.TrimSpace(clause[whereEnd:tail]) + ")"
+	if tail < len(clause) {
+		s += " " + clause[tail:]
+	}
+
+	return s
+}
+
+// topLevelWords calls fn with each word of clause that isn't inside
+// parentheses or quotes, lowercased, along with where it starts and ends.
+func topLevelWords(clause string, fn func(word string, i, j int)) {
+	depth := 0
+	for i := 0; i < len(clause); i++ {
+		c := clause[i]
+
+		switch {
+		case c == '\'' || c == '"':
+			j := i + 1
+			for j < len(clause) && clause[j] != c {
+				j++
+			}
+			i = j
+			continue
+		case c == '(':
+			depth++
+			continue
+		case c == ')':
+			depth--
+			continue
+		}
+
+		if depth != 0 || (i > 0 && isWordByte(clause[i-1])) {
+			continue
+		}
+
+		j := i
+		for j < len(clause) && isWordByte(clause[j]) {
+			j++
+		}
+
+		if j > i {
+			fn(strings.ToLower(clause[i:j]), i, j)
+			i = j - 1
+		}
+	}
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
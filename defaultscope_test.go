@@ -0,0 +1,86 @@
+package sorm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type ScopedObject struct {
+	ID    int
+	OrgID int
+	Name  string
+}
+
+func (ScopedObject) DefaultScope() (string, []interface{}) {
+	return "archived = ?", []interface{}{false}
+}
+
+type scopeOrgKey struct{}
+
+func TestDefaultScope(t *testing.T) {
+	a := assert.New(t)
+
+	RegisterDefaultScope(ScopedObject{}, func(ctx context.Context) (string, []interface{}, error) {
+		org, ok := ctx.Value(scopeOrgKey{}).(int)
+		if !ok {
+			return "", nil, errors.New("no org")
+		}
+
+		return "org_id = $1", []interface{}{org}, nil
+	})
+	defer func() {
+		defaultScopesMu.Lock()
+		delete(defaultScopes, reflect.TypeOf(ScopedObject{}))
+		defaultScopesMu.Unlock()
+	}()
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	ctx := context.WithValue(context.Background(), scopeOrgKey{}, 7)
+
+	mockDB.ExpectQuery(`^select \* from \(select \* from scoped_objects where \(archived = \$2\) and \(org_id = \$3\)\) scoped_objects where name = \$1$`).WithArgs("a", false, 7).WillReturnRows(sqlmock.NewRows([]string{"id", "org_id", "name"}).AddRow(1, 7, "a"))
+	mockDB.ExpectQuery(`^select count\(\*\) from \(select \* from scoped_objects where \(archived = \$1\) and \(org_id = \$2\)\) scoped_objects$`).WithArgs(false, 7).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mockDB.ExpectExec(`^delete from scoped_objects where id = \$1 and \(archived = \$2\) and \(org_id = \$3\)$`).WithArgs(1, false, 7).WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec(`^delete from scoped_objects where \(archived = \$2\) and \(org_id = \$3\) and \(name = \$1 or name = ''\)$`).WithArgs("b", false, 7).WillReturnResult(sqlmock.NewResult(0, 2))
+	mockDB.ExpectExec(`^update scoped_objects set name = \$1 where \(archived = \$2\) and \(org_id = \$3\)$`).WithArgs("c", false, 7).WillReturnResult(sqlmock.NewResult(0, 2))
+	mockDB.ExpectQuery(`^select \* from scoped_objects$`).WillReturnRows(sqlmock.NewRows([]string{"id", "org_id", "name"}))
+
+	var l []ScopedObject
+	a.NoError(FindWhere(ctx, db, &l, "where name = $1", "a"))
+	a.Len(l, 1)
+
+	n, err := CountAll(ctx, db, &ScopedObject{})
+	a.NoError(err)
+	a.Equal(3, n)
+
+	a.NoError(DeleteRecord(ctx, db, &ScopedObject{ID: 1}))
+
+	_, err = DeleteClause(ctx, db, ScopedObject{}, Clause("where name = $1 or name = ''", "b"))
+	a.NoError(err)
+
+	_, err = UpdateClause(ctx, db, ScopedObject{}, Clause("set name = $1", "c"))
+	a.NoError(err)
+
+	a.NoError(FindAll(Unscoped(context.Background()), db, &l))
+
+	a.EqualError(FindAll(context.Background(), db, &l), "FindWhere: no org")
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestInjectCondition(t *testing.T) {
+	a := assert.New(t)
+
+	a.Equal("where (s) and (a = 1 or b = 2) order by a", injectCondition("where a = 1 or b = 2 order by a", "(s)"))
+	a.Equal("set a = (select x from y where z) where (s)", injectCondition("set a = (select x from y where z)", "(s)"))
+	a.Equal("set a = 'where' where (s) returning id", injectCondition("set a = 'where' returning id", "(s)"))
+}
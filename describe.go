@@ -0,0 +1,163 @@
+package sorm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// ModelInfo is what sorm knows about a model, as returned by DescribeModel.
+type ModelInfo struct {
+	Name  string
+	Type  reflect.Type
+	Table string
+	// Projection is the source table of a projection model.
+	Projection string
+	View       bool
+	ReadOnly   bool
+	Columns    []ColumnInfo
+	IDColumns  []string
+	Relations  []RelationInfo
+}
+
+// ColumnInfo describes one column of a model. Unique is set both for unique
+// indexes and for validate:"unique" checks. Tag is the field's whole struct tag, for
+// reading tags sorm doesn't know about.
+type ColumnInfo struct {
+	Field    string
+	Index    []int
+	Column   string
+	Type     reflect.Type
+	SQLType  string
+	Nullable bool
+	ID       bool
+	ReadOnly bool
+	JSON     bool
+	Unique   bool
+	Tenant   bool
+	TTL      bool
+	Compress string
+	Hash     string
+	Default  string
+	Enum     []string
+	// Sensitive is the sensitive tag's mode, "mask" or "omit", if it has one.
+	Sensitive string
+	Tag       reflect.StructTag
+}
+
+// RelationInfo describes a has_many, has_one or belongs_to field. Column is
+// the column of Target that the relation matches on.
+type RelationInfo struct {
+	Field  string
+	Kind   string
+	Target reflect.Type
+	Column string
+}
+
+// DescribeModel returns the table, columns and relations sorm uses for
+// model, which can be a struct, a pointer to one or a slice of either. SQLType
+// is the type CreateTable would use, and is empty for fields it can't map.
+func DescribeModel(model interface{}) (ModelInfo, error) {
+	vtyp, err := structTypeOf(model)
+	if err != nil {
+		return ModelInfo{}, fmt.Errorf("DescribeModel: %w", err)
+	}
+
+	vdesc, err := getDescriptionFromType(vtyp)
+	if err != nil {
+		return ModelInfo{}, fmt.Errorf("DescribeModel: could not get detailed reflection information for type %s: %w", vtyp.String(), err)
+	}
+
+	info := ModelInfo{
+		Name:       vdesc.Name(),
+		Type:       vtyp,
+		Table:      getSQLTableName(vdesc),
+		Projection: getSQLProjection(vdesc),
+	}
+
+	switch getSQLReadOnly(vdesc) {
+	case "view":
+		info.View = true
+		info.ReadOnly = true
+	case "readonly":
+		info.ReadOnly = true
+	}
+
+	ids := make(map[string]bool)
+	for _, f := range getSQLIDFields(vdesc) {
+		ids[f.Name()] = true
+		info.IDColumns = append(info.IDColumns, getSQLColumnName(f))
+	}
+
+	var tenant, ttl string
+	if f := getSQLTenantField(vdesc); f != nil {
+		tenant = f.Name()
+	}
+	if f := getSQLTTLField(vdesc); f != nil {
+		ttl = f.Name()
+	}
+
+	for _, f := range getSQLWritableFields(vdesc) {
+		sf := vtyp.FieldByIndex(f.Index())
+
+		c := ColumnInfo{
+			Field:    f.Name(),
+			Index:    f.Index(),
+			Column:   getSQLColumnName(f),
+			Type:     sf.Type,
+			ID:       ids[f.Name()],
+			JSON:     isJSONField(f),
+			Tenant:   f.Name() == tenant,
+			TTL:      f.Name() == ttl,
+			Compress: getSQLCompression(f),
+			Hash:     getSQLHash(f),
+			Default:  getSQLDefault(f),
+			Enum:     getSQLEnum(f),
+			Tag:      sf.Tag,
+		}
+
+		if typ, nullable, err := getSQLColumnType(f, sf.Type); err == nil {
+			c.SQLType, c.Nullable = typ, nullable
+		}
+
+		if t := f.Tag("sql"); t != nil {
+			c.ReadOnly = t.Parameter("readonly") != nil
+			c.Unique = t.Parameter("unique") != nil
+		}
+		if t := f.Tag("validate"); t != nil && t.Value() == "unique" {
+			c.Unique = true
+		}
+		if t := f.Tag("readonly"); t != nil && t.Value() != "" {
+			c.ReadOnly = true
+		}
+
+		if t := f.Tag("sensitive"); t != nil {
+			c.Sensitive = t.Value()
+			if c.Sensitive == "" {
+				c.Sensitive = "mask"
+			}
+		}
+
+		info.Columns = append(info.Columns, c)
+	}
+
+	for _, f := range vdesc.Fields() {
+		if !isSQLRelation(f) {
+			continue
+		}
+
+		r, err := getRelation(context.Background(), vtyp, vdesc, f.Name())
+		if err != nil {
+			return ModelInfo{}, fmt.Errorf("DescribeModel: %w", err)
+		}
+
+		info.Relations = append(info.Relations, RelationInfo{
+			Field:  f.Name(),
+			Kind:   r.kind.String(),
+			Target: r.targetType,
+			Column: r.targetColumn,
+		})
+	}
+
+	return info, nil
+}
@@ -0,0 +1,83 @@
+package sorm
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type DescribedAccount struct {
+	_        struct{} `schema:"billing"`
+	ID       int
+	Email    string            `sql:",unique"`
+	Password string            `sql:",hash:sha256" sensitive:""`
+	Status   string            `sql:",enum:active|closed,default:active"`
+	Settings map[string]string `sql:",json"`
+	Note     *string           `custom:"x" validate:"unique"`
+	Created  string            `sql:"created_at,readonly"`
+}
+
+func TestDescribeModel(t *testing.T) {
+	a := assert.New(t)
+
+	info, err := DescribeModel(&[]DescribedAccount{})
+	if !a.NoError(err) {
+		return
+	}
+
+	a.Equal("DescribedAccount", info.Name)
+	a.Equal(reflect.TypeOf(DescribedAccount{}), info.Type)
+	a.Equal("billing.described_accounts", info.Table)
+	a.Equal([]string{"id"}, info.IDColumns)
+	a.False(info.ReadOnly)
+
+	if !a.Len(info.Columns, 7) {
+		return
+	}
+
+	a.Equal(ColumnInfo{Field: "ID", Index: []int{1}, Column: "id", Type: reflect.TypeOf(0), SQLType: "integer", ID: true}, info.Columns[0])
+	a.True(info.Columns[1].Unique)
+	a.Equal("sha256", info.Columns[2].Hash)
+	a.Equal("mask", info.Columns[2].Sensitive)
+	a.Equal([]string{"active", "closed"}, info.Columns[3].Enum)
+	a.Equal("active", info.Columns[3].Default)
+	a.True(info.Columns[4].JSON)
+	a.True(info.Columns[5].Nullable)
+	a.Equal("x", info.Columns[5].Tag.Get("custom"))
+	a.True(info.Columns[5].Unique)
+	a.Equal("created_at", info.Columns[6].Column)
+	a.True(info.Columns[6].ReadOnly)
+}
+
+func TestDescribeModelRelations(t *testing.T) {
+	a := assert.New(t)
+
+	info, err := DescribeModel(PreloadComment{})
+	if !a.NoError(err) {
+		return
+	}
+
+	a.Equal([]RelationInfo{{Field: "Post", Kind: "belongs_to", Target: reflect.TypeOf(PreloadPost{}), Column: "id"}}, info.Relations)
+
+	info, err = DescribeModel(TTLSession{})
+	if a.NoError(err) && a.Len(info.Columns, 3) {
+		a.True(info.Columns[2].TTL)
+	}
+}
+
+func TestDescribeModelView(t *testing.T) {
+	a := assert.New(t)
+
+	_, err := DescribeModel(1)
+	a.ErrorIs(err, ErrNotAStruct)
+
+	info, err := DescribeModel(struct {
+		_  struct{} `sorm:"view"`
+		ID int
+	}{})
+	if a.NoError(err) {
+		a.True(info.View)
+		a.True(info.ReadOnly)
+	}
+}
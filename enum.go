@@ -0,0 +1,72 @@
+package sorm
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var (
+	enumChecks bool
+)
+
+// SetEnumChecks makes CreateTable add a CHECK constraint for each
+// `sql:",enum:a|b|c"` field.
+func SetEnumChecks(b bool) {
+	enumChecks = b
+}
+
+func getSQLEnum(f structField) []string {
+	if t := f.Tag("sql"); t != nil {
+		if p := t.Parameter("enum"); p != nil && p.Value() != "" {
+			return strings.Split(p.Value(), "|")
+		}
+	}
+
+	return nil
+}
+
+// checkEnums returns a *ValidationError for the first enum field whose value
+// isn't one of the allowed ones. Nil pointers are allowed.
+func checkEnums(vdesc *structDescription, v reflect.Value) error {
+	for _, f := range getSQLWritableFields(vdesc) {
+		allowed := getSQLEnum(f)
+		if allowed == nil {
+			continue
+		}
+
+		fv := v.FieldByIndex(f.Index())
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				continue
+			}
+
+			fv = fv.Elem()
+		}
+
+		s := fmt.Sprint(fv.Interface())
+
+		ok := false
+		for _, e := range allowed {
+			if e == s {
+				ok = true
+				break
+			}
+		}
+
+		if !ok {
+			return &ValidationError{Field: f.Name(), Column: getSQLColumnName(f), Message: fmt.Sprintf("%q is not one of %s", s, strings.Join(allowed, ", "))}
+		}
+	}
+
+	return nil
+}
+
+func enumCheckConstraint(col string, allowed []string) string {
+	var l []string
+	for _, e := range allowed {
+		l = append(l, "'"+strings.ReplaceAll(e, "'", "''")+"'")
+	}
+
+	return "check (" + col + " in (" + strings.Join(l, ", ") + "))"
+}
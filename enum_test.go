@@ -0,0 +1,59 @@
+package sorm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type EnumObject struct {
+	ID     int
+	Status string  `sql:"status,enum:pending|active|closed"`
+	Kind   *string `sql:",enum:a|b'c"`
+}
+
+func TestEnumValidation(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`insert into enum_objects \(status, kind\) values \(\$1, \$2\) returning id`).WithArgs("active", nil).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	a.NoError(CreateRecord(context.Background(), db, &EnumObject{Status: "active"}))
+
+	err = CreateRecord(context.Background(), db, &EnumObject{Status: "open"})
+	a.EqualError(err, `CreateRecord: validation failed for Status: "open" is not one of pending, active, closed`)
+
+	var verr *ValidationError
+	if a.True(errors.As(err, &verr)) {
+		a.Equal("status", verr.Column)
+	}
+
+	mockDB.ExpectQuery(`select \* from enum_objects where id = \$1`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "status", "kind"}).AddRow(1, "active", nil))
+
+	kind := "c"
+	a.EqualError(SaveRecord(context.Background(), db, &EnumObject{ID: 1, Status: "active", Kind: &kind}), `SaveRecord: validation failed for Kind: "c" is not one of a, b'c`)
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestEnumCheckConstraint(t *testing.T) {
+	a := assert.New(t)
+
+	defer SetEnumChecks(false)
+	SetEnumChecks(true)
+
+	s, err := CreateTableStatement(EnumObject{})
+	if !a.NoError(err) {
+		return
+	}
+
+	a.Equal("create table enum_objects (id integer not null, status text not null check (status in ('pending', 'active', 'closed')), kind text check (kind in ('a', 'b''c')), primary key (id))", s.Query)
+}
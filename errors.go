@@ -0,0 +1,47 @@
+package sorm
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrNotAPointer    = errors.New("not a pointer")
+	ErrNotAStruct     = errors.New("not a struct")
+	ErrNoIDFields     = errors.New("couldn't determine ID field(s)")
+	ErrMissingColumns = errors.New("missing columns")
+)
+
+// typeError keeps the detailed message about what was expected while letting
+// callers match the kind of mistake with errors.Is.
+type typeError struct {
+	err error
+	msg string
+}
+
+func typeErrorf(err error, format string, args ...interface{}) error {
+	return &typeError{err: err, msg: fmt.Sprintf(format, args...)}
+}
+
+func (e *typeError) Error() string {
+	return e.msg
+}
+
+func (e *typeError) Unwrap() error {
+	return e.err
+}
+
+// MissingColumnsError is returned by ScanRows when result columns have no
+// matching field on the model. It unwraps to ErrMissingColumns.
+type MissingColumnsError struct {
+	Model   string
+	Columns []string
+}
+
+func (e *MissingColumnsError) Error() string {
+	return fmt.Sprintf("couldn't find fields on %s for these sql fields: %v", e.Model, e.Columns)
+}
+
+func (e *MissingColumnsError) Unwrap() error {
+	return ErrMissingColumns
+}
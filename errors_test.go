@@ -0,0 +1,52 @@
+package sorm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type NoIDObject struct {
+	Name string
+}
+
+func TestTypedErrors(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`^select \* from simple_objects where id = \$1 limit 1$`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	mockDB.ExpectQuery(`^select \* from simple_objects$`).WillReturnRows(sqlmock.NewRows([]string{"id", "name", "extra"}).AddRow(1, "a", "b"))
+
+	err = CreateRecord(context.Background(), db, SimpleObject{})
+	a.True(errors.Is(err, ErrNotAPointer))
+	a.EqualError(err, "CreateRecord: expected input to be a pointer; was instead struct")
+
+	var n int
+	a.True(errors.Is(SaveRecord(context.Background(), db, &n), ErrNotAStruct))
+	a.True(errors.Is(DeleteRecord(context.Background(), db, &NoIDObject{}), ErrNoIDFields))
+
+	var r SimpleObject
+	err = FindFirstWhere(context.Background(), db, &r, "where id = $1", 1)
+	a.True(errors.Is(err, ErrRecordNotFound))
+	a.True(errors.Is(err, sql.ErrNoRows))
+
+	var l []SimpleObject
+	err = FindAll(context.Background(), db, &l)
+	a.True(errors.Is(err, ErrMissingColumns))
+
+	var e *MissingColumnsError
+	if a.True(errors.As(err, &e)) {
+		a.Equal([]string{"extra"}, e.Columns)
+	}
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
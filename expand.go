@@ -0,0 +1,212 @@
+package sorm
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ErrEmptySlice is returned for an empty slice argument. There's nothing to
+// write in its place that's right for both "in" and "not in", so the caller
+// has to handle the empty case itself.
+var ErrEmptySlice = errors.New("empty slice argument")
+
+// Named holds the values for ":name" parameters. Pass it as the only
+// argument, e.g. FindWhere(ctx, db, &out, "where tenant_id = :tenant",
+// Named{"tenant": t}).
+type Named map[string]interface{}
+
+// isExpandable reports whether an argument is a slice to be spread over
+// several placeholders, as opposed to a value the driver takes as is.
+func isExpandable(arg interface{}) bool {
+	if arg == nil {
+		return false
+	}
+
+	t := reflect.TypeOf(arg)
+	if t.Kind() != reflect.Slice || t.Elem().Kind() == reflect.Uint8 || t.Implements(valuerType) {
+		return false
+	}
+
+	return true
+}
+
+// expandArgs spreads slice arguments over as many placeholders as they have
+// elements, so "where id in (?)" with []int{1, 2} becomes "where id in ($1,
+// $2)". Placeholders can be bare question marks, taken in order, or numbered
+// ones in the configured style. Everything is renumbered, and an empty slice
+// is an ErrEmptySlice. Queries without slice arguments are returned
+// untouched.
+func expandArgs(query string, args []interface{}) (string, []interface{}, error) {
+	if len(args) == 1 {
+		if named, ok := args[0].(Named); ok {
+			return expandNamed(query, named)
+		}
+	}
+
+	var found bool
+	for _, arg := range args {
+		if isExpandable(arg) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return query, args, nil
+	}
+
+	return renumberArgs(query, args, 0)
+}
+
+// renumberArgs is expandArgs without the shortcut, numbering the new
+// placeholders from base+1 so fragments can be joined together.
+func renumberArgs(query string, args []interface{}, base int) (string, []interface{}, error) {
+	prefix := parameterPrefix
+	if prefix == "" {
+		prefix = "$"
+	}
+
+	var b strings.Builder
+	var out []interface{}
+	var next int
+
+	add := func(n int) error {
+		if n < 1 || n > len(args) {
+			return fmt.Errorf("placeholder %d is out of range; there are %d arguments", n, len(args))
+		}
+
+		arg := args[n-1]
+		if !isExpandable(arg) {
+			out = append(out, arg)
+			b.WriteString(makeParameter(base + len(out)))
+			return nil
+		}
+
+		v := reflect.ValueOf(arg)
+		if v.Len() == 0 {
+			return fmt.Errorf("placeholder %d: %w", n, ErrEmptySlice)
+		}
+
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+
+			out = append(out, v.Index(i).Interface())
+			b.WriteString(makeParameter(base + len(out)))
+		}
+
+		return nil
+	}
+
+	for i := 0; i < len(query); {
+		c := query[i]
+
+		if c == '\'' || c == '"' {
+			j := i + 1
+			for j < len(query) && query[j] != c {
+				j++
+			}
+			if j < len(query) {
+				j++
+			}
+
+			b.WriteString(query[i:j])
+			i = j
+			continue
+		}
+
+		if strings.HasPrefix(query[i:], prefix) {
+			j := i + len(prefix)
+			for j < len(query) && query[j] >= '0' && query[j] <= '9' {
+				j++
+			}
+
+			if j > i+len(prefix) {
+				n, _ := strconv.Atoi(query[i+len(prefix) : j])
+				if err := add(n); err != nil {
+					return "", nil, err
+				}
+
+				i = j
+				continue
+			}
+		}
+
+		if c == '?' {
+			next++
+			if err := add(next); err != nil {
+				return "", nil, err
+			}
+
+			i++
+			continue
+		}
+
+		b.WriteByte(c)
+		i++
+	}
+
+	return b.String(), out, nil
+}
+
+// expandNamed rewrites ":name" parameters as numbered placeholders, leaving
+// quoted strings and Postgres "::type" casts alone. Slices in named are
+// expanded just like positional ones.
+func expandNamed(query string, named Named) (string, []interface{}, error) {
+	var b strings.Builder
+	var out []interface{}
+
+	for i := 0; i < len(query); {
+		c := query[i]
+
+		if c == '\'' || c == '"' {
+			j := i + 1
+			for j < len(query) && query[j] != c {
+				j++
+			}
+			if j < len(query) {
+				j++
+			}
+
+			b.WriteString(query[i:j])
+			i = j
+			continue
+		}
+
+		if c == ':' && i+1 < len(query) && query[i+1] == ':' {
+			b.WriteString("::")
+			i += 2
+			continue
+		}
+
+		if c == ':' && i+1 < len(query) && isNameStart(query[i+1]) {
+			j := i + 2
+			for j < len(query) && (isNameStart(query[j]) || (query[j] >= '0' && query[j] <= '9')) {
+				j++
+			}
+
+			name := query[i+1 : j]
+			v, ok := named[name]
+			if !ok {
+				return "", nil, fmt.Errorf("no value for named parameter %s", name)
+			}
+
+			out = append(out, v)
+			b.WriteString(makeParameter(len(out)))
+			i = j
+			continue
+		}
+
+		b.WriteByte(c)
+		i++
+	}
+
+	return expandArgs(b.String(), out)
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,69 @@
+package sorm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandArgs(t *testing.T) {
+	a := assert.New(t)
+
+	q, args, err := expandArgs("where id in (?)", []interface{}{[]int{1, 2, 3}})
+	a.NoError(err)
+	a.Equal("where id in ($1, $2, $3)", q)
+	a.Equal([]interface{}{1, 2, 3}, args)
+
+	q, args, err = expandArgs("where org_id = $1 and id in ($2) and name = $3", []interface{}{5, []string{"a", "b"}, "c"})
+	a.NoError(err)
+	a.Equal("where org_id = $1 and id in ($2, $3) and name = $4", q)
+	a.Equal([]interface{}{5, "a", "b", "c"}, args)
+
+	_, _, err = expandArgs("where id not in (?) and name <> '?'", []interface{}{[]int{}})
+	a.EqualError(err, "placeholder 1: empty slice argument")
+	a.True(errors.Is(err, ErrEmptySlice))
+
+	q, args, err = expandArgs("where data = $1", []interface{}{[]byte("x")})
+	a.NoError(err)
+	a.Equal("where data = $1", q)
+	a.Equal([]interface{}{[]byte("x")}, args)
+
+	_, _, err = expandArgs("where id in ($2)", []interface{}{[]int{1}})
+	a.EqualError(err, "placeholder 2 is out of range; there are 1 arguments")
+}
+
+func TestFindWhereIn(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`^select \* from simple_objects where id in \(\$1, \$2\)$`).WithArgs(1, 2).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a").AddRow(2, "b"))
+	mockDB.ExpectQuery(`^select \* from simple_objects where id in \(\$1, \$2\)$`).WithArgs(3, 4).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+
+	var l []SimpleObject
+	a.NoError(FindWhere(context.Background(), db, &l, "where id in (?)", []int{1, 2}))
+	a.Len(l, 2)
+
+	a.NoError(FindBy(context.Background(), db, &l, map[string]interface{}{"id": []int{3, 4}}))
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestExpandNamed(t *testing.T) {
+	a := assert.New(t)
+
+	q, args, err := expandArgs("where tenant_id = :tenant and status = :status and created::date = :day and note <> ':x' and id in (:ids) or parent_tenant_id = :tenant", []interface{}{Named{"tenant": 3, "status": "open", "day": "2020-01-01", "ids": []int{7, 8}}})
+	a.NoError(err)
+	a.Equal("where tenant_id = $1 and status = $2 and created::date = $3 and note <> ':x' and id in ($4, $5) or parent_tenant_id = $6", q)
+	a.Equal([]interface{}{3, "open", "2020-01-01", 7, 8, 3}, args)
+
+	_, _, err = expandArgs("where id = :id", []interface{}{Named{}})
+	a.EqualError(err, "no value for named parameter id")
+}
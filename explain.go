@@ -0,0 +1,100 @@
+package sorm
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+type ExplainMode int
+
+const (
+	// ExplainPlain prefixes queries with "explain" (Postgres, MySQL).
+	ExplainPlain ExplainMode = iota
+	// ExplainQueryPlan prefixes queries with "explain query plan" (SQLite).
+	ExplainQueryPlan
+	// ExplainAnalyze prefixes queries with "explain analyze", which runs the
+	// query a second time to collect real timings.
+	ExplainAnalyze
+)
+
+func (m ExplainMode) prefix() string {
+	switch m {
+	case ExplainQueryPlan:
+		return "explain query plan "
+	case ExplainAnalyze:
+		return "explain analyze "
+	default:
+		return "explain "
+	}
+}
+
+// ExplainRow is one row of EXPLAIN output keyed by lowercased column name,
+// e.g. "query plan" for Postgres or "detail" for SQLite.
+type ExplainRow map[string]string
+
+// ExplainFunc receives the plan for a query that's about to run. err is set
+// if the EXPLAIN itself failed; the query still runs either way.
+type ExplainFunc func(ctx context.Context, query string, args []interface{}, plan []ExplainRow, err error)
+
+var (
+	explainMode ExplainMode
+	explainFunc ExplainFunc
+)
+
+// SetExplainLogger makes every Find* and Count* call run EXPLAIN on its query
+// first and pass the plan to fn. It's meant for development; pass a nil fn to
+// turn it off again.
+func SetExplainLogger(mode ExplainMode, fn ExplainFunc) {
+	explainMode = mode
+	explainFunc = fn
+}
+
+func explainQuery(ctx context.Context, db Querier, query string, args []interface{}) {
+	if explainFunc == nil {
+		return
+	}
+
+	plan, err := runExplain(ctx, db, explainMode.prefix()+query, args)
+
+	explainFunc(ctx, query, args, plan, err)
+}
+
+func runExplain(ctx context.Context, db Querier, query string, args []interface{}) ([]ExplainRow, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	var plan []ExplainRow
+	for rows.Next() {
+		vals := make([]sql.NullString, len(cols))
+		ptrs := make([]interface{}, len(cols))
+		for i := range vals {
+			ptrs[i] = &vals[i]
+		}
+
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+
+		r := make(ExplainRow)
+		for i, c := range cols {
+			r[strings.ToLower(c)] = vals[i].String
+		}
+
+		plan = append(plan, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
@@ -0,0 +1,51 @@
+package sorm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetExplainLogger(t *testing.T) {
+	a := assert.New(t)
+
+	type explained struct {
+		query string
+		plan  []ExplainRow
+		err   error
+	}
+
+	var l []explained
+	SetExplainLogger(ExplainQueryPlan, func(ctx context.Context, query string, args []interface{}, plan []ExplainRow, err error) {
+		l = append(l, explained{query, plan, err})
+	})
+	defer SetExplainLogger(ExplainPlain, nil)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`explain query plan select \* from simple_objects where name = \$1`).WithArgs("a").WillReturnRows(sqlmock.NewRows([]string{"id", "parent", "notused", "detail"}).AddRow(2, 0, 0, "SCAN simple_objects"))
+	mockDB.ExpectQuery(`select \* from simple_objects where name = \$1`).WithArgs("a").WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a"))
+	mockDB.ExpectQuery(`explain query plan select count\(\*\) from simple_objects`).WillReturnError(errors.New("no explain for you"))
+	mockDB.ExpectQuery(`select count\(\*\) from simple_objects`).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	var out []SimpleObject
+	a.NoError(FindWhere(context.Background(), db, &out, "where name = $1", "a"))
+
+	n, err := CountAll(context.Background(), db, &SimpleObject{})
+	a.NoError(err)
+	a.Equal(1, n)
+
+	a.Equal([]explained{
+		{"select * from simple_objects where name = $1", []ExplainRow{{"id": "2", "parent": "0", "notused": "0", "detail": "SCAN simple_objects"}}, nil},
+		{"select count(*) from simple_objects", nil, errors.New("no explain for you")},
+	}, l)
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
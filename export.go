@@ -0,0 +1,174 @@
+package sorm
+
+import (
+	"database/sql/driver"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+)
+
+const redactedValue = "[redacted]"
+
+type ExportOptions struct {
+	Unredacted bool
+}
+
+type exportColumn struct {
+	name  string
+	field structField
+	mask  bool
+	// json columns are exported as JSON rather than as a string of it
+	json bool
+}
+
+func exportColumns(vdesc *structDescription, opts *ExportOptions) ([]exportColumn, error) {
+	var l []exportColumn
+
+	for _, f := range getSQLWritableFields(vdesc) {
+		c := exportColumn{name: getSQLColumnName(f), field: f, json: isJSONField(f) && getSQLCompression(f) == ""}
+
+		if t := f.Tag("sensitive"); t != nil && (opts == nil || !opts.Unredacted) {
+			switch t.Value() {
+			case "omit":
+				continue
+			case "", "mask":
+				c.mask = true
+			default:
+				return nil, fmt.Errorf("field %s on %s has unknown sensitive mode %q", f.Name(), vdesc.Name(), t.Value())
+			}
+		}
+
+		l = append(l, c)
+	}
+
+	return l, nil
+}
+
+func exportTargets(records interface{}) (*structDescription, reflect.Value, error) {
+	arr := reflect.Indirect(reflect.ValueOf(records))
+	if arr.Kind() != reflect.Slice {
+		return nil, reflect.Value{}, fmt.Errorf("expected records to be a slice or pointer to slice; was instead %s", arr.Kind())
+	}
+
+	vtyp := arr.Type().Elem()
+	if vtyp.Kind() != reflect.Struct {
+		return nil, reflect.Value{}, fmt.Errorf("expected records to be a slice of struct; was instead slice of %s", vtyp.Kind())
+	}
+
+	vdesc, err := getDescriptionFromType(vtyp)
+	if err != nil {
+		return nil, reflect.Value{}, fmt.Errorf("could not get detailed reflection information for type %s: %w", vtyp.String(), err)
+	}
+
+	return vdesc, arr, nil
+}
+
+// exportValue is the value written to the database for column c of v, so
+// json and compressed fields are encoded the same way.
+func exportValue(c exportColumn, v reflect.Value) (interface{}, error) {
+	return driver.DefaultParameterConverter.ConvertValue(fieldValue(c.field, v))
+}
+
+func ExportCSV(w io.Writer, records interface{}, opts *ExportOptions) error {
+	vdesc, arr, err := exportTargets(records)
+	if err != nil {
+		return fmt.Errorf("ExportCSV: %w", err)
+	}
+
+	cols, err := exportColumns(vdesc, opts)
+	if err != nil {
+		return fmt.Errorf("ExportCSV: %w", err)
+	}
+
+	cw := csv.NewWriter(w)
+
+	row := make([]string, len(cols))
+	for i, c := range cols {
+		row[i] = c.name
+	}
+	if err := cw.Write(row); err != nil {
+		return fmt.Errorf("ExportCSV: %w", err)
+	}
+
+	for i := 0; i < arr.Len(); i++ {
+		for j, c := range cols {
+			if c.mask {
+				row[j] = redactedValue
+				continue
+			}
+
+			v, err := exportValue(c, arr.Index(i))
+			if err != nil {
+				return fmt.Errorf("ExportCSV: column %s: %w", c.name, err)
+			}
+
+			switch v := v.(type) {
+			case nil:
+				row[j] = ""
+			case []byte:
+				row[j] = string(v)
+			case time.Time:
+				row[j] = v.Format(time.RFC3339Nano)
+			default:
+				row[j] = fmt.Sprint(v)
+			}
+		}
+
+		if err := cw.Write(row); err != nil {
+			return fmt.Errorf("ExportCSV: %w", err)
+		}
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("ExportCSV: %w", err)
+	}
+
+	return nil
+}
+
+func ExportJSON(w io.Writer, records interface{}, opts *ExportOptions) error {
+	vdesc, arr, err := exportTargets(records)
+	if err != nil {
+		return fmt.Errorf("ExportJSON: %w", err)
+	}
+
+	cols, err := exportColumns(vdesc, opts)
+	if err != nil {
+		return fmt.Errorf("ExportJSON: %w", err)
+	}
+
+	l := make([]map[string]interface{}, arr.Len())
+	for i := range l {
+		m := make(map[string]interface{}, len(cols))
+
+		for _, c := range cols {
+			if c.mask {
+				m[c.name] = redactedValue
+				continue
+			}
+
+			v, err := exportValue(c, arr.Index(i))
+			if err != nil {
+				return fmt.Errorf("ExportJSON: column %s: %w", c.name, err)
+			}
+
+			if s, ok := v.(string); ok && c.json {
+				v = json.RawMessage(s)
+			}
+
+			m[c.name] = v
+		}
+
+		l[i] = m
+	}
+
+	if err := json.NewEncoder(w).Encode(l); err != nil {
+		return fmt.Errorf("ExportJSON: %w", err)
+	}
+
+	return nil
+}
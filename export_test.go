@@ -0,0 +1,92 @@
+package sorm
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type ExportPerson struct {
+	ID       int
+	Name     string
+	Email    string `sensitive:"mask"`
+	Password string `sensitive:"omit"`
+	Nickname *string
+}
+
+func TestExportCSV(t *testing.T) {
+	a := assert.New(t)
+
+	nick := "al"
+	l := []ExportPerson{
+		{ID: 1, Name: "alice", Email: "alice@example.com", Password: "hunter2", Nickname: &nick},
+		{ID: 2, Name: "bob, jr", Email: "bob@example.com", Password: "secret"},
+	}
+
+	var b bytes.Buffer
+	if !a.NoError(ExportCSV(&b, l, nil)) {
+		return
+	}
+	a.Equal("id,name,email,nickname\n1,alice,[redacted],al\n2,\"bob, jr\",[redacted],\n", b.String())
+
+	b.Reset()
+	if !a.NoError(ExportCSV(&b, &l, &ExportOptions{Unredacted: true})) {
+		return
+	}
+	a.Equal("id,name,email,password,nickname\n1,alice,alice@example.com,hunter2,al\n2,\"bob, jr\",bob@example.com,secret,\n", b.String())
+}
+
+func TestExportJSON(t *testing.T) {
+	a := assert.New(t)
+
+	l := []ExportPerson{{ID: 1, Name: "alice", Email: "alice@example.com", Password: "hunter2"}}
+
+	var b bytes.Buffer
+	if !a.NoError(ExportJSON(&b, l, nil)) {
+		return
+	}
+	a.Equal(`[{"email":"[redacted]","id":1,"name":"alice","nickname":null}]`+"\n", b.String())
+
+	b.Reset()
+	if !a.NoError(ExportJSON(&b, l, &ExportOptions{Unredacted: true})) {
+		return
+	}
+	a.Equal(`[{"email":"alice@example.com","id":1,"name":"alice","nickname":null,"password":"hunter2"}]`+"\n", b.String())
+}
+
+type ExportSettings struct {
+	ID   int
+	Tags map[string]string `sql:",json"`
+	Blob []string          `sql:",json,compress:gzip"`
+}
+
+func TestExportJSONFields(t *testing.T) {
+	a := assert.New(t)
+
+	l := []ExportSettings{{ID: 1, Tags: map[string]string{"a": "b"}}, {ID: 2}}
+
+	var b bytes.Buffer
+	if !a.NoError(ExportCSV(&b, l, nil)) {
+		return
+	}
+	a.Equal("id,tags,blob\n1,\"{\"\"a\"\":\"\"b\"\"}\",\n2,,\n", b.String())
+
+	b.Reset()
+	if !a.NoError(ExportJSON(&b, l, nil)) {
+		return
+	}
+	a.Equal(`[{"blob":null,"id":1,"tags":{"a":"b"}},{"blob":null,"id":2,"tags":null}]`+"\n", b.String())
+}
+
+func TestExportUnknownSensitiveMode(t *testing.T) {
+	a := assert.New(t)
+
+	type ExportBad struct {
+		ID    int
+		Token string `sensitive:"hash"`
+	}
+
+	var b bytes.Buffer
+	a.EqualError(ExportCSV(&b, []ExportBad{{}}, nil), `ExportCSV: field Token on ExportBad has unknown sensitive mode "hash"`)
+}
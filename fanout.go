@@ -0,0 +1,196 @@
+package sorm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type FanoutOptions struct {
+	// OrderBy re-sorts the merged records, e.g. []string{"created_at desc",
+	// "id"}. Each database's own order is kept otherwise, with databases
+	// appended in the order they were given.
+	OrderBy []string
+	// Limit caps the number of merged records. Each database should still
+	// be given a limit in where, since that's the most any one of them can
+	// contribute.
+	Limit int
+}
+
+// FindWhereFanout runs the same FindWhere against every database in dbs
+// concurrently and merges the results into out.
+func FindWhereFanout(ctx context.Context, dbs []Querier, out interface{}, where string, args ...interface{}) error {
+	return FindWhereFanoutWithOptions(ctx, dbs, out, nil, where, args...)
+}
+
+func FindWhereFanoutWithOptions(ctx context.Context, dbs []Querier, out interface{}, opts *FanoutOptions, where string, args ...interface{}) error {
+	if opts == nil {
+		opts = &FanoutOptions{}
+	}
+
+	ptr := reflect.ValueOf(out)
+	if ptr.Kind() != reflect.Ptr || ptr.Elem().Kind() != reflect.Slice || ptr.Elem().Type().Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("FindWhereFanout: %w", typeErrorf(ErrNotAStruct, "expected output to be pointer to slice of struct; was instead %T", out))
+	}
+
+	styp := ptr.Elem().Type()
+
+	less, err := fanoutLess(styp.Elem(), opts.OrderBy)
+	if err != nil {
+		return fmt.Errorf("FindWhereFanout: %w", err)
+	}
+
+	results := make([]reflect.Value, len(dbs))
+	errs := make([]error, len(dbs))
+
+	var wg sync.WaitGroup
+	for i, db := range dbs {
+		wg.Add(1)
+		go func(i int, db Querier) {
+			defer wg.Done()
+
+			p := reflect.New(styp)
+			errs[i] = FindWhere(ctx, db, p.Interface(), where, args...)
+			results[i] = p.Elem()
+		}(i, db)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("FindWhereFanout: database %d: %w", i, err)
+		}
+	}
+
+	arr := reflect.MakeSlice(styp, 0, 0)
+	for _, r := range results {
+		arr = reflect.AppendSlice(arr, r)
+	}
+
+	if less != nil {
+		sort.SliceStable(arr.Interface(), func(i, j int) bool { return less(arr.Index(i), arr.Index(j)) })
+	}
+
+	if opts.Limit > 0 && arr.Len() > opts.Limit {
+		arr = arr.Slice(0, opts.Limit)
+	}
+
+	ptr.Elem().Set(arr)
+
+	return nil
+}
+
+func fanoutLess(vtyp reflect.Type, orderBy []string) (func(a, b reflect.Value) bool, error) {
+	if len(orderBy) == 0 {
+		return nil, nil
+	}
+
+	plan, err := getPlanFromType(vtyp)
+	if err != nil {
+		return nil, err
+	}
+
+	type key struct {
+		index []int
+		desc  bool
+	}
+
+	var keys []key
+	for _, s := range orderBy {
+		parts := strings.Fields(s)
+		if len(parts) == 0 || len(parts) > 2 || (len(parts) == 2 && parts[1] != "asc" && parts[1] != "desc") {
+			return nil, fmt.Errorf("couldn't parse order %q", s)
+		}
+
+		f := plan.fieldForColumn(parts[0])
+		if f == nil {
+			return nil, fmt.Errorf("couldn't find field on %s for sql field %s", vtyp.Name(), parts[0])
+		}
+
+		if _, ok := compareFieldValues(reflect.Zero(vtyp.FieldByIndex(f.Index).Type), reflect.Zero(vtyp.FieldByIndex(f.Index).Type)); !ok {
+			return nil, fmt.Errorf("can't order by %s; field %s of type %s isn't comparable", parts[0], f.Name, vtyp.FieldByIndex(f.Index).Type)
+		}
+
+		keys = append(keys, key{index: f.Index, desc: len(parts) == 2 && parts[1] == "desc"})
+	}
+
+	return func(a, b reflect.Value) bool {
+		for _, k := range keys {
+			c, _ := compareFieldValues(a.FieldByIndex(k.index), b.FieldByIndex(k.index))
+			if c == 0 {
+				continue
+			}
+
+			if k.desc {
+				return c > 0
+			}
+
+			return c < 0
+		}
+
+		return false
+	}, nil
+}
+
+// compareFieldValues orders two values of the same type, with nil pointers
+// first. It reports false for types it doesn't know how to order.
+func compareFieldValues(a, b reflect.Value) (int, bool) {
+	if a.Kind() == reflect.Ptr {
+		if _, ok := compareFieldValues(reflect.Zero(a.Type().Elem()), reflect.Zero(a.Type().Elem())); !ok {
+			return 0, false
+		}
+
+		switch {
+		case a.IsNil() && b.IsNil():
+			return 0, true
+		case a.IsNil():
+			return -1, true
+		case b.IsNil():
+			return 1, true
+		}
+
+		return compareFieldValues(a.Elem(), b.Elem())
+	}
+
+	if a.Type() == timeType {
+		ta, tb := a.Interface().(time.Time), b.Interface().(time.Time)
+		switch {
+		case ta.Before(tb):
+			return -1, true
+		case ta.After(tb):
+			return 1, true
+		}
+
+		return 0, true
+	}
+
+	cmp := func(less, greater bool) (int, bool) {
+		switch {
+		case less:
+			return -1, true
+		case greater:
+			return 1, true
+		}
+
+		return 0, true
+	}
+
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmp(a.Int() < b.Int(), a.Int() > b.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return cmp(a.Uint() < b.Uint(), a.Uint() > b.Uint())
+	case reflect.Float32, reflect.Float64:
+		return cmp(a.Float() < b.Float(), a.Float() > b.Float())
+	case reflect.String:
+		return cmp(a.String() < b.String(), a.String() > b.String())
+	case reflect.Bool:
+		return cmp(!a.Bool() && b.Bool(), a.Bool() && !b.Bool())
+	}
+
+	return 0, false
+}
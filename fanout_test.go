@@ -0,0 +1,74 @@
+package sorm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type FanoutObject struct {
+	ID      int
+	Name    string
+	Created time.Time
+}
+
+func TestFindWhereFanout(t *testing.T) {
+	a := assert.New(t)
+
+	db1, mockDB1, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db1.Close()
+
+	db2, mockDB2, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db2.Close()
+
+	t0 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mockDB1.ExpectQuery(`select \* from fanout_objects where name <> \$1 order by created desc limit 2`).WithArgs("x").WillReturnRows(sqlmock.NewRows([]string{"id", "name", "created"}).AddRow(1, "a", t0.Add(3*time.Hour)).AddRow(2, "b", t0.Add(time.Hour)))
+	mockDB2.ExpectQuery(`select \* from fanout_objects where name <> \$1 order by created desc limit 2`).WithArgs("x").WillReturnRows(sqlmock.NewRows([]string{"id", "name", "created"}).AddRow(3, "c", t0.Add(2*time.Hour)).AddRow(4, "d", t0))
+
+	var l []FanoutObject
+	a.NoError(FindWhereFanoutWithOptions(context.Background(), []Querier{db1, db2}, &l, &FanoutOptions{OrderBy: []string{"created desc"}, Limit: 3}, "where name <> $1 order by created desc limit 2", "x"))
+
+	var ids []int
+	for _, e := range l {
+		ids = append(ids, e.ID)
+	}
+	a.Equal([]int{1, 3, 2}, ids)
+
+	a.NoError(mockDB1.ExpectationsWereMet())
+	a.NoError(mockDB2.ExpectationsWereMet())
+}
+
+func TestFindWhereFanoutError(t *testing.T) {
+	a := assert.New(t)
+
+	db1, mockDB1, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db1.Close()
+
+	db2, mockDB2, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db2.Close()
+
+	mockDB1.ExpectQuery(`select \* from fanout_objects`).WillReturnRows(sqlmock.NewRows([]string{"id", "name", "created"}))
+	mockDB2.ExpectQuery(`select \* from fanout_objects`).WillReturnError(errors.New("shard down"))
+
+	var l []FanoutObject
+	a.EqualError(FindWhereFanout(context.Background(), []Querier{db1, db2}, &l, ""), "FindWhereFanout: database 1: FindWhere: shard down")
+
+	a.EqualError(FindWhereFanoutWithOptions(context.Background(), nil, &l, &FanoutOptions{OrderBy: []string{"nope"}}, ""), "FindWhereFanout: couldn't find field on FanoutObject for sql field nope")
+}
@@ -0,0 +1,117 @@
+package sorm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// buildConditions turns a struct or a map[string]interface{} into a where
+// clause of "column = $n" terms joined with "and", or "column in (...)" for
+// slices. Struct fields are named like model fields and only non-zero ones
+// are used, so a pointer field can match a zero value; map entries are always
+// used, with nil meaning "is null".
+func buildConditions(model interface{}, conditions interface{}) (string, []interface{}, error) {
+	var cols, cmps []string
+	var args []interface{}
+	var isNull []bool
+
+	switch c := conditions.(type) {
+	case map[string]interface{}:
+		for k := range c {
+			cols = append(cols, k)
+		}
+		sort.Strings(cols)
+
+		for _, col := range cols {
+			cmps = append(cmps, col)
+			args = append(args, c[col])
+			isNull = append(isNull, c[col] == nil)
+		}
+	default:
+		v := reflect.Indirect(reflect.ValueOf(conditions))
+		if v.Kind() != reflect.Struct {
+			return "", nil, typeErrorf(ErrNotAStruct, "expected conditions to be a struct or map[string]interface{}; was instead %T", conditions)
+		}
+
+		cdesc, err := getDescriptionFromType(v.Type())
+		if err != nil {
+			return "", nil, fmt.Errorf("could not get detailed reflection information for type %s: %w", v.Type().String(), err)
+		}
+
+		for _, f := range getSQLWritableFields(cdesc) {
+			if isZero(v.FieldByIndex(f.Index()).Interface()) {
+				continue
+			}
+
+			col := getSQLColumnName(f)
+			cols = append(cols, col)
+			cmps = append(cmps, columnComparison(f, col))
+			args = append(args, fieldValue(f, v))
+			isNull = append(isNull, false)
+		}
+	}
+
+	if err := ValidateWhereColumns(model, cols...); err != nil {
+		return "", nil, err
+	}
+
+	var terms []string
+	var values []interface{}
+	for i, col := range cols {
+		if err := checkIdentifier(col); err != nil {
+			return "", nil, err
+		}
+
+		if isNull[i] {
+			terms = append(terms, cmps[i]+" is null")
+			continue
+		}
+
+		values = append(values, args[i])
+		if isExpandable(args[i]) {
+			terms = append(terms, cmps[i]+" in ("+makeParameter(len(values))+")")
+		} else {
+			terms = append(terms, cmps[i]+" = "+makeParameter(len(values)))
+		}
+	}
+
+	if len(terms) == 0 {
+		return "", nil, nil
+	}
+
+	return "where " + strings.Join(terms, " and "), values, nil
+}
+
+// FindBy finds the records matching conditions, e.g.
+// FindBy(ctx, db, &users, map[string]interface{}{"org_id": 5, "deleted": nil})
+// or FindBy(ctx, db, &users, User{OrgID: 5}). Conditions with no non-zero
+// fields match everything.
+func FindBy(ctx context.Context, db Querier, out interface{}, conditions interface{}) error {
+	where, args, err := buildConditions(out, conditions)
+	if err != nil {
+		return fmt.Errorf("FindBy: %w", err)
+	}
+
+	return findWhere(ctx, db, out, where, args, findOptions{guardLimit: true})
+}
+
+func FindFirstBy(ctx context.Context, db Querier, out interface{}, conditions interface{}) error {
+	where, args, err := buildConditions(out, conditions)
+	if err != nil {
+		return fmt.Errorf("FindFirstBy: %w", err)
+	}
+
+	return findFirstWhere(ctx, db, out, where, args, findOptions{})
+}
+
+func CountBy(ctx context.Context, db Querier, val interface{}, conditions interface{}) (int, error) {
+	where, args, err := buildConditions(val, conditions)
+	if err != nil {
+		return 0, fmt.Errorf("CountBy: %w", err)
+	}
+
+	return CountWhere(ctx, db, val, where, args...)
+}
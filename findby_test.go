@@ -0,0 +1,51 @@
+package sorm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type FindByFilter struct {
+	ID   *int
+	Name string
+}
+
+func TestFindBy(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`^select \* from simple_objects where id = \$1 and name = \$2$`).WithArgs(0, "a").WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(0, "a"))
+	mockDB.ExpectQuery(`^select \* from simple_objects where name = \$1$`).WithArgs("b").WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	mockDB.ExpectQuery(`^select \* from simple_objects where id = \$1 and name is null limit 1$`).WithArgs(4).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(4, ""))
+	mockDB.ExpectQuery(`^select count\(\*\) from simple_objects where name = \$1$`).WithArgs("c").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	var zero int
+	var l []SimpleObject
+	a.NoError(FindBy(context.Background(), db, &l, FindByFilter{ID: &zero, Name: "a"}))
+	a.Equal([]SimpleObject{{ID: 0, Name: "a"}}, l)
+
+	a.NoError(FindBy(context.Background(), db, &l, SimpleObject{Name: "b"}))
+	a.Len(l, 0)
+
+	var r SimpleObject
+	a.NoError(FindFirstBy(context.Background(), db, &r, map[string]interface{}{"name": nil, "id": 4}))
+	a.Equal(SimpleObject{ID: 4}, r)
+
+	n, err := CountBy(context.Background(), db, &SimpleObject{}, map[string]interface{}{"name": "c"})
+	a.NoError(err)
+	a.Equal(2, n)
+
+	err = FindBy(context.Background(), db, &l, map[string]interface{}{"name; drop table x": 1})
+	a.True(errors.Is(err, ErrUnknownColumn))
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
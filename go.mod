@@ -1,6 +1,6 @@
 module fknsrs.biz/p/sorm
 
-go 1.13
+go 1.21
 
 require (
 	fknsrs.biz/p/reflectutil v0.0.3
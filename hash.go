@@ -0,0 +1,283 @@
+package sorm
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Hasher is a one-way codec for `sql:",hash:name"` fields. sorm only hashes
+// values that differ from the stored record, so values loaded from the
+// database aren't hashed a second time.
+type Hasher interface {
+	Hash(plain string) (string, error)
+	Compare(hash, plain string) (bool, error)
+}
+
+// defaultHasher is used by a bare `sql:",hash"` tag.
+const defaultHasher = "pbkdf2"
+
+var (
+	hashers     = map[string]Hasher{"pbkdf2": PBKDF2Hasher{}, "sha256": sha256Hasher{}}
+	hashersLock sync.RWMutex
+)
+
+// RegisterHasher makes a hasher available to hash tags. pbkdf2, the default,
+// and sha256 are built in. sha256 is unsalted and fast, so it's only
+// suitable for high entropy values like API tokens. Other names, e.g. bcrypt
+// from golang.org/x/crypto, fail to write until they're registered.
+func RegisterHasher(name string, h Hasher) {
+	hashersLock.Lock()
+	defer hashersLock.Unlock()
+
+	hashers[name] = h
+}
+
+func getHasher(name string) (Hasher, error) {
+	hashersLock.RLock()
+	defer hashersLock.RUnlock()
+
+	h, ok := hashers[name]
+	if !ok {
+		return nil, fmt.Errorf("no hasher registered for %q", name)
+	}
+
+	return h, nil
+}
+
+func getSQLHash(f structField) string {
+	if t := f.Tag("sql"); t != nil {
+		if p := t.Parameter("hash"); p != nil {
+			if p.Value() == "" {
+				return defaultHasher
+			}
+
+			return p.Value()
+		}
+	}
+
+	return ""
+}
+
+type sha256Hasher struct{}
+
+func (sha256Hasher) Hash(plain string) (string, error) {
+	sum := sha256.Sum256([]byte(plain))
+	return "sha256$" + hex.EncodeToString(sum[:]), nil
+}
+
+func (h sha256Hasher) Compare(hash, plain string) (bool, error) {
+	s, _ := h.Hash(plain)
+	return subtle.ConstantTimeCompare([]byte(s), []byte(hash)) == 1, nil
+}
+
+// DefaultPBKDF2Iterations is the work factor PBKDF2Hasher uses when its
+// Iterations is zero.
+const DefaultPBKDF2Iterations = 600000
+
+// PBKDF2Hasher hashes with PBKDF2-HMAC-SHA256 and a random 16 byte salt, as
+// pbkdf2-sha256$<iterations>$<salt>$<key>. Compare reads the iterations from
+// the hash, so raising them only affects new hashes.
+type PBKDF2Hasher struct {
+	Iterations int
+}
+
+func (h PBKDF2Hasher) Hash(plain string) (string, error) {
+	iter := h.Iterations
+	if iter <= 0 {
+		iter = DefaultPBKDF2Iterations
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	return formatPBKDF2(iter, salt, pbkdf2SHA256([]byte(plain), salt, iter, sha256.Size)), nil
+}
+
+func (PBKDF2Hasher) Compare(hash, plain string) (bool, error) {
+	l := strings.Split(hash, "$")
+	if len(l) != 4 || l[0] != "pbkdf2-sha256" {
+		return false, fmt.Errorf("PBKDF2Hasher: malformed hash")
+	}
+
+	iter, err := strconv.Atoi(l[1])
+	if err != nil || iter <= 0 {
+		return false, fmt.Errorf("PBKDF2Hasher: malformed iteration count %q", l[1])
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(l[2])
+	if err != nil {
+		return false, fmt.Errorf("PBKDF2Hasher: malformed salt: %w", err)
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(l[3])
+	if err != nil {
+		return false, fmt.Errorf("PBKDF2Hasher: malformed key: %w", err)
+	}
+
+	return subtle.ConstantTimeCompare(pbkdf2SHA256([]byte(plain), salt, iter, len(key)), key) == 1, nil
+}
+
+func formatPBKDF2(iter int, salt, key []byte) string {
+	return "pbkdf2-sha256$" + strconv.Itoa(iter) + "$" + base64.RawStdEncoding.EncodeToString(salt) + "$" + base64.RawStdEncoding.EncodeToString(key)
+}
+
+// pbkdf2SHA256 is PBKDF2 from RFC 8018 with HMAC-SHA256 as the PRF.
+func pbkdf2SHA256(password, salt []byte, iter, keyLen int) []byte {
+	prf := hmac.New(sha256.New, password)
+
+	var key []byte
+	var counter [4]byte
+	for block := uint32(1); len(key) < keyLen; block++ {
+		binary.BigEndian.PutUint32(counter[:], block)
+
+		prf.Reset()
+		prf.Write(salt)
+		prf.Write(counter[:])
+		u := prf.Sum(nil)
+
+		t := make([]byte, len(u))
+		copy(t, u)
+
+		for i := 1; i < iter; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+
+		key = append(key, t...)
+	}
+
+	return key[:keyLen]
+}
+
+// applyHashes replaces the plaintext in each hash field of v with its hash,
+// leaving empty values alone. previous is the stored record, if there is one;
+// fields that still hold its value are already hashes.
+func applyHashes(vdesc *structDescription, v, previous reflect.Value) error {
+	for _, f := range getSQLWritableFields(vdesc) {
+		name := getSQLHash(f)
+		if name == "" {
+			continue
+		}
+
+		fv := v.FieldByIndex(f.Index())
+		if previous.IsValid() && reflect.DeepEqual(fv.Interface(), previous.FieldByIndex(f.Index()).Interface()) {
+			continue
+		}
+
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				continue
+			}
+
+			fv = fv.Elem()
+		}
+
+		if fv.Kind() != reflect.String {
+			return fmt.Errorf("hashed field %s on %s must be a string", f.Name(), vdesc.Name())
+		}
+
+		h, err := getHasher(name)
+		if err != nil {
+			return err
+		}
+
+		if fv.String() == "" {
+			continue
+		}
+
+		s, err := h.Hash(fv.String())
+		if err != nil {
+			return fmt.Errorf("couldn't hash %s: %w", f.Name(), err)
+		}
+
+		fv.SetString(s)
+	}
+
+	return nil
+}
+
+// storedHashes loads the stored version of v for ReplaceRecord, which has no
+// previous record of its own, so that its hashes aren't hashed again. It
+// skips the query for models without hash fields.
+func storedHashes(ctx context.Context, tx Querier, vdesc *structDescription, idFields []structField, v reflect.Value) (reflect.Value, error) {
+	hashed := false
+	for _, f := range getSQLWritableFields(vdesc) {
+		if getSQLHash(f) != "" {
+			hashed = true
+		}
+	}
+	if !hashed {
+		return reflect.Value{}, nil
+	}
+
+	where, values, err := buildIDWhere(idFields, v)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	previous := reflect.New(v.Type())
+	if err := findFirstWhere(UsePrimary(ctx), tx, previous.Interface(), where, values, findOptions{includeExpired: true}); err != nil {
+		if errors.Is(err, ErrRecordNotFound) {
+			return reflect.Value{}, nil
+		}
+
+		return reflect.Value{}, fmt.Errorf("couldn't find stored hashes: %w", err)
+	}
+
+	return previous.Elem(), nil
+}
+
+// CompareHashedField reports whether candidate matches the hash stored in the
+// named field of record, e.g. CompareHashedField(&user, "Password", input).
+func CompareHashedField(record interface{}, field, candidate string) (bool, error) {
+	v := reflect.Indirect(reflect.ValueOf(record))
+	if v.Kind() != reflect.Struct {
+		return false, fmt.Errorf("CompareHashedField: expected record to be a struct or pointer to struct; was instead %T", record)
+	}
+
+	vdesc, err := getDescriptionFromType(v.Type())
+	if err != nil {
+		return false, fmt.Errorf("CompareHashedField: could not get detailed reflection information for type %s: %w", v.Type().String(), err)
+	}
+
+	f := vdesc.Field(field)
+	if f == nil || getSQLHash(*f) == "" {
+		return false, fmt.Errorf("CompareHashedField: %s has no hashed field %s", vdesc.Name(), field)
+	}
+
+	h, err := getHasher(getSQLHash(*f))
+	if err != nil {
+		return false, fmt.Errorf("CompareHashedField: %w", err)
+	}
+
+	fv := reflect.Indirect(v.FieldByIndex(f.Index()))
+	if !fv.IsValid() || fv.String() == "" {
+		return false, nil
+	}
+
+	ok, err := h.Compare(fv.String(), candidate)
+	if err != nil {
+		return false, fmt.Errorf("CompareHashedField: %w", err)
+	}
+
+	return ok, nil
+}
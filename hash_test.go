@@ -0,0 +1,158 @@
+package sorm
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type HashedObject struct {
+	ID    int
+	Name  string
+	Token string `sql:",hash:sha256"`
+}
+
+const tokenHash = "sha256$2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"
+
+func TestHashedFieldCreate(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`insert into hashed_objects \(name, token\) values \(\$1, \$2\) returning id`).WithArgs("a", tokenHash).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	r := HashedObject{Name: "a", Token: "foo"}
+	a.NoError(CreateRecord(context.Background(), db, &r))
+	a.Equal(tokenHash, r.Token)
+
+	ok, err := CompareHashedField(&r, "Token", "foo")
+	a.NoError(err)
+	a.True(ok)
+
+	ok, err = CompareHashedField(r, "Token", "bar")
+	a.NoError(err)
+	a.False(ok)
+
+	_, err = CompareHashedField(r, "Name", "a")
+	a.EqualError(err, "CompareHashedField: HashedObject has no hashed field Name")
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestHashedFieldSave(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from hashed_objects where id = \$1`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "name", "token"}).AddRow(1, "a", tokenHash))
+	mockDB.ExpectExec(`update hashed_objects set name = \$2 where id = \$1`).WithArgs(1, "b").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	a.NoError(SaveRecord(context.Background(), db, &HashedObject{ID: 1, Name: "b", Token: tokenHash}))
+
+	mockDB.ExpectQuery(`select \* from hashed_objects where id = \$1`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "name", "token"}).AddRow(1, "b", tokenHash))
+	mockDB.ExpectExec(`update hashed_objects set token = \$2 where id = \$1`).WithArgs(1, "sha256$fcde2b2edba56bf408601fb721fe9b5c338d10ee429ea04fae5511b68fbf8fb9").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	a.NoError(SaveRecord(context.Background(), db, &HashedObject{ID: 1, Name: "b", Token: "bar"}))
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestHashedFieldReplace(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	// the stored hash is kept as is, while a value that only looks like a
+	// hash is still plaintext to a new record
+	mockDB.ExpectQuery(`select \* from hashed_objects where id = \$1`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "name", "token"}).AddRow(1, "a", tokenHash))
+	mockDB.ExpectExec(`replace into hashed_objects`).WithArgs(1, "b", tokenHash).WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectQuery(`select \* from hashed_objects where id = \$1`).WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"id", "name", "token"}))
+	mockDB.ExpectExec(`replace into hashed_objects`).WithArgs(2, "c", "sha256$9f05645a65203599b00cb1d09ba8c955716598f2097873c73db078325d76f7db").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	a.NoError(ReplaceRecord(context.Background(), db, &HashedObject{ID: 1, Name: "b", Token: tokenHash}))
+	a.NoError(ReplaceRecord(context.Background(), db, &HashedObject{ID: 2, Name: "c", Token: tokenHash}))
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+type DefaultHashedObject struct {
+	ID       int
+	Password string `sql:",hash"`
+}
+
+type pbkdf2Arg struct{}
+
+func (pbkdf2Arg) Match(v driver.Value) bool {
+	s, ok := v.(string)
+	return ok && strings.HasPrefix(s, "pbkdf2-sha256$600000$")
+}
+
+func TestHashedFieldDefault(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`insert into default_hashed_objects \(password\) values \(\$1\) returning id`).WithArgs(pbkdf2Arg{}).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	r := DefaultHashedObject{Password: "hunter2"}
+	a.NoError(CreateRecord(context.Background(), db, &r))
+
+	ok, err := CompareHashedField(&r, "Password", "hunter2")
+	a.NoError(err)
+	a.True(ok)
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestPBKDF2Hasher(t *testing.T) {
+	a := assert.New(t)
+
+	// RFC 7914 section 11
+	a.Equal("55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783", hex.EncodeToString(pbkdf2SHA256([]byte("passwd"), []byte("salt"), 1, 64)))
+
+	h := PBKDF2Hasher{Iterations: 1000}
+
+	stored := "pbkdf2-sha256$1000$MDEyMzQ1Njc4OWFiY2RlZg$6HSgCOY3G+fzhyxCc+qnA6CoEHC/rw++ljZ3bM6E7ZM"
+
+	ok, err := h.Compare(stored, "foo")
+	a.NoError(err)
+	a.True(ok)
+
+	ok, err = h.Compare(stored, "bar")
+	a.NoError(err)
+	a.False(ok)
+
+	s1, err := h.Hash("foo")
+	a.NoError(err)
+	s2, err := h.Hash("foo")
+	a.NoError(err)
+	a.NotEqual(s1, s2)
+
+	ok, err = h.Compare(s1, "foo")
+	a.NoError(err)
+	a.True(ok)
+
+	_, err = h.Compare(tokenHash, "foo")
+	a.EqualError(err, "PBKDF2Hasher: malformed hash")
+}
@@ -0,0 +1,296 @@
+package sorm
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+var ErrIdempotencyKeyReused = errors.New("idempotency key was already used for a different write")
+
+// ErrIdempotencyNeedsTransaction is returned for writes with an idempotency
+// key made on a Querier that's neither a *sql.Tx nor able to begin one.
+var ErrIdempotencyNeedsTransaction = errors.New("idempotency keys need a *sql.Tx or a database that can begin one")
+
+var (
+	idempotencyTable = "sorm_idempotency_keys"
+)
+
+// SetIdempotencyTable changes the table idempotency keys are recorded in. It
+// needs idempotency_key, operation, table_name and record_id text columns;
+// CreateIdempotencyTable makes one.
+func SetIdempotencyTable(name string) {
+	idempotencyTable = name
+}
+
+type idempotencyKey struct{}
+
+// WithIdempotencyKey makes writes using ctx claim key in the idempotency
+// table, in the same transaction as the write, which is opened for them if
+// the Querier isn't a *sql.Tx. If key has been claimed already the write is
+// skipped and the input is reloaded from the record written the first time.
+// Writes made by hooks and callbacks don't see the key.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKey{}, key)
+}
+
+func idempotencyKeyFrom(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKey{}).(string)
+	return key
+}
+
+func CreateIdempotencyTable(ctx context.Context, db Querier) error {
+	if err := checkIdentifier(idempotencyTable); err != nil {
+		return fmt.Errorf("CreateIdempotencyTable: %w", err)
+	}
+
+	query := "create table " + idempotencyTable + " (idempotency_key text not null, operation text not null, table_name text not null, record_id text not null, primary key (idempotency_key))"
+
+	logQuery(ctx, query, nil)
+
+	start := time.Now()
+
+	if _, err := db.ExecContext(ctx, query); err != nil {
+		logQueryAfter(ctx, query, nil, start, err)
+
+		return fmt.Errorf("CreateIdempotencyTable: %w", err)
+	}
+
+	logQueryAfter(ctx, query, nil, start, nil)
+
+	return nil
+}
+
+// replayIdempotent reports whether the write described by op and input was
+// already done under key, loading the earlier record into input if so.
+func replayIdempotent(ctx context.Context, tx Querier, key string, op Operation, input interface{}) (bool, error) {
+	v := reflect.ValueOf(input)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return false, nil
+	}
+
+	vdesc, err := getDescriptionContext(ctx, v.Elem().Type())
+	if err != nil {
+		return false, err
+	}
+
+	if err := checkIdentifier(idempotencyTable); err != nil {
+		return false, err
+	}
+
+	query := "select operation, table_name, record_id from " + idempotencyTable + " where idempotency_key = " + makeParameter(1)
+	args := []interface{}{key}
+
+	logQuery(ctx, query, args)
+
+	start := time.Now()
+
+	var prevOp, prevTable, recordID string
+	if err := tx.QueryRowContext(ctx, query, args...).Scan(&prevOp, &prevTable, &recordID); err != nil {
+		if err == sql.ErrNoRows {
+			logQueryAfter(ctx, query, args, start, nil)
+			return false, nil
+		}
+
+		logQueryAfter(ctx, query, args, start, err)
+
+		return false, fmt.Errorf("couldn't look up idempotency key: %w", err)
+	}
+
+	logQueryAfter(ctx, query, args, start, nil)
+
+	tbl := getSQLTableNameContext(ctx, vdesc)
+	if prevOp != op.String() || prevTable != tbl {
+		return false, fmt.Errorf("%w: key %q was used to %s %s", ErrIdempotencyKeyReused, key, prevOp, prevTable)
+	}
+
+	if op == OperationDelete {
+		return true, nil
+	}
+
+	idFields := getSQLIDFields(vdesc)
+
+	var raw []json.RawMessage
+	if err := json.Unmarshal([]byte(recordID), &raw); err != nil {
+		return false, fmt.Errorf("couldn't decode recorded id for idempotency key %q: %w", key, err)
+	}
+	if len(raw) != len(idFields) {
+		return false, fmt.Errorf("recorded id for idempotency key %q has %d value(s); expected %d", key, len(raw), len(idFields))
+	}
+
+	for i, f := range idFields {
+		fv := v.Elem().FieldByIndex(f.Index())
+
+		p := reflect.New(fv.Type())
+		if err := json.Unmarshal(raw[i], p.Interface()); err != nil {
+			return false, fmt.Errorf("couldn't decode recorded id for idempotency key %q: %w", key, err)
+		}
+
+		fv.Set(p.Elem())
+	}
+
+	where, values, err := buildIDWhere(idFields, v.Elem())
+	if err != nil {
+		return false, err
+	}
+
+	if err := findFirstWhere(ctx, tx, input, where, values, findOptions{includeExpired: true}); err != nil {
+		return false, fmt.Errorf("couldn't reload record for idempotency key %q: %w", key, err)
+	}
+
+	return true, nil
+}
+
+type txBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// runIdempotent runs fn, the write described by op and input, under key. It
+// claims the key before the write and fills in the record's ID after it, all
+// in one transaction, so concurrent writes with the same key can't both
+// happen. name prefixes errors that don't come from fn.
+func runIdempotent(ctx context.Context, db Querier, name, key string, op Operation, input interface{}, fn func(ctx context.Context, tx Querier) error) error {
+	ctx = WithIdempotencyKey(ctx, "")
+
+	switch q := db.(type) {
+	case *sql.Tx:
+		return claimIdempotent(ctx, q, name, key, op, input, fn)
+	case txBeginner:
+		tx, err := q.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("%s: couldn't open a transaction: %w", name, err)
+		}
+		defer tx.Rollback()
+
+		if err := claimIdempotent(ctx, tx, name, key, op, input, fn); err != nil {
+			return err
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("%s: couldn't commit transaction: %w", name, err)
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("%s: %w", name, ErrIdempotencyNeedsTransaction)
+	}
+}
+
+func claimIdempotent(ctx context.Context, tx Querier, name, key string, op Operation, input interface{}, fn func(ctx context.Context, tx Querier) error) error {
+	v := reflect.ValueOf(input)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fn(ctx, tx)
+	}
+
+	vdesc, err := getDescriptionContext(ctx, v.Elem().Type())
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	tbl := getSQLTableNameContext(ctx, vdesc)
+
+	claimed, err := claimIdempotencyKey(ctx, tx, key, op, tbl)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	if !claimed {
+		ok, err := replayIdempotent(ctx, tx, key, op, input)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if !ok {
+			return fmt.Errorf("%s: idempotency key %q is claimed, but its record couldn't be found", name, key)
+		}
+
+		return nil
+	}
+
+	if err := fn(ctx, tx); err != nil {
+		return err
+	}
+
+	if err := recordIdempotent(ctx, tx, key, getSQLIDFields(vdesc), v.Elem()); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	return nil
+}
+
+// claimIdempotencyKey inserts key unless it's there already, reporting
+// whether it did. A concurrent claim of the same key waits on this one's
+// transaction.
+func claimIdempotencyKey(ctx context.Context, tx Querier, key string, op Operation, tbl string) (bool, error) {
+	if err := checkIdentifier(idempotencyTable); err != nil {
+		return false, err
+	}
+
+	cols := "idempotency_key, operation, table_name, record_id"
+	params := makeParameter(1) + ", " + makeParameter(2) + ", " + makeParameter(3) + ", " + makeParameter(4)
+
+	var query string
+	switch replaceMode {
+	case ReplaceOnDuplicateKey:
+		query = "insert ignore into " + idempotencyTable + " (" + cols + ") values (" + params + ")"
+	case ReplaceMerge:
+		query = "merge into " + idempotencyTable + " with (holdlock) as t using (values (" + params + ")) as s (" + cols + ") on t.idempotency_key = s.idempotency_key when not matched then insert (" + cols + ") values (s.idempotency_key, s.operation, s.table_name, s.record_id);"
+	default:
+		query = "insert into " + idempotencyTable + " (" + cols + ") values (" + params + ") on conflict (idempotency_key) do nothing"
+	}
+
+	args := []interface{}{key, op.String(), tbl, ""}
+
+	logQuery(ctx, query, args)
+
+	start := time.Now()
+
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		logQueryAfter(ctx, query, args, start, err)
+
+		return false, fmt.Errorf("couldn't claim idempotency key: %w", err)
+	}
+
+	logQueryAfter(ctx, query, args, start, nil)
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("couldn't claim idempotency key: %w", err)
+	}
+
+	return n > 0, nil
+}
+
+// recordIdempotent stores the ID of the record just written under key.
+func recordIdempotent(ctx context.Context, tx Querier, key string, idFields []structField, v reflect.Value) error {
+	var ids []interface{}
+	for _, f := range idFields {
+		ids = append(ids, v.FieldByIndex(f.Index()).Interface())
+	}
+
+	recordID, err := json.Marshal(ids)
+	if err != nil {
+		return fmt.Errorf("couldn't encode id for idempotency key %q: %w", key, err)
+	}
+
+	query := "update " + idempotencyTable + " set record_id = " + makeParameter(1) + " where idempotency_key = " + makeParameter(2)
+	args := []interface{}{string(recordID), key}
+
+	logQuery(ctx, query, args)
+
+	start := time.Now()
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		logQueryAfter(ctx, query, args, start, err)
+
+		return fmt.Errorf("couldn't record idempotency key: %w", err)
+	}
+
+	logQueryAfter(ctx, query, args, start, nil)
+
+	return nil
+}
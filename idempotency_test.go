@@ -0,0 +1,129 @@
+package sorm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotencyKeyFirstWrite(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectBegin()
+	mockDB.ExpectExec(`insert into sorm_idempotency_keys \(idempotency_key, operation, table_name, record_id\) values \(\$1, \$2, \$3, \$4\) on conflict \(idempotency_key\) do nothing`).WithArgs("req-1", "create", "simple_objects", "").WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectQuery(`insert into simple_objects \(name\) values \(\$1\) returning id`).WithArgs("a").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mockDB.ExpectExec(`update sorm_idempotency_keys set record_id = \$1 where idempotency_key = \$2`).WithArgs("[7]", "req-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectCommit()
+
+	r := SimpleObject{Name: "a"}
+	a.NoError(CreateRecord(WithIdempotencyKey(context.Background(), "req-1"), db, &r))
+	a.Equal(7, r.ID)
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestIdempotencyKeyReplay(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	claim := `insert into sorm_idempotency_keys \(idempotency_key, operation, table_name, record_id\) values \(\$1, \$2, \$3, \$4\) on conflict \(idempotency_key\) do nothing`
+
+	mockDB.ExpectBegin()
+	mockDB.ExpectExec(claim).WithArgs("req-1", "create", "simple_objects", "").WillReturnResult(sqlmock.NewResult(0, 0))
+	mockDB.ExpectQuery(`select operation, table_name, record_id from sorm_idempotency_keys where idempotency_key = \$1`).WithArgs("req-1").WillReturnRows(sqlmock.NewRows([]string{"operation", "table_name", "record_id"}).AddRow("create", "simple_objects", "[7]"))
+	mockDB.ExpectQuery(`select \* from simple_objects where id = \$1 limit 1`).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(7, "a"))
+	mockDB.ExpectCommit()
+	mockDB.ExpectBegin()
+	mockDB.ExpectExec(claim).WithArgs("req-1", "delete", "simple_objects", "").WillReturnResult(sqlmock.NewResult(0, 0))
+	mockDB.ExpectQuery(`select operation, table_name, record_id from sorm_idempotency_keys where idempotency_key = \$1`).WithArgs("req-1").WillReturnRows(sqlmock.NewRows([]string{"operation", "table_name", "record_id"}).AddRow("create", "simple_objects", "[7]"))
+	mockDB.ExpectRollback()
+
+	r := SimpleObject{Name: "a"}
+	a.NoError(CreateRecord(WithIdempotencyKey(context.Background(), "req-1"), db, &r))
+	a.Equal(SimpleObject{ID: 7, Name: "a"}, r)
+
+	err = DeleteRecord(WithIdempotencyKey(context.Background(), "req-1"), db, &SimpleObject{ID: 7})
+	a.True(errors.Is(err, ErrIdempotencyKeyReused))
+	a.EqualError(err, `DeleteRecord: idempotency key was already used for a different write: key "req-1" was used to create simple_objects`)
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestIdempotencyKeyInTransaction(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	SetReplaceMode(ReplaceOnDuplicateKey)
+	defer SetReplaceMode(ReplaceInsertOrReplace)
+
+	mockDB.ExpectBegin()
+	mockDB.ExpectExec(`^insert ignore into sorm_idempotency_keys \(idempotency_key, operation, table_name, record_id\) values \(\$1, \$2, \$3, \$4\)$`).WithArgs("req-1", "create", "simple_objects", "").WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectQuery(`insert into simple_objects \(name\) values \(\$1\) returning id`).WithArgs("a").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mockDB.ExpectExec(`update sorm_idempotency_keys set record_id = \$1 where idempotency_key = \$2`).WithArgs("[7]", "req-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectRollback()
+
+	tx, err := db.BeginTx(context.Background(), nil)
+	if !a.NoError(err) {
+		return
+	}
+
+	r := SimpleObject{Name: "a"}
+	a.NoError(CreateRecord(WithIdempotencyKey(context.Background(), "req-1"), tx, &r))
+	a.Equal(7, r.ID)
+
+	a.NoError(tx.Rollback())
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestIdempotencyKeyNeedsTransaction(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	err = CreateRecord(WithIdempotencyKey(context.Background(), "req-1"), NewStmtCache(db, 1), &SimpleObject{Name: "a"})
+	a.ErrorIs(err, ErrIdempotencyNeedsTransaction)
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestCreateIdempotencyTable(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	SetIdempotencyTable("idem")
+	defer SetIdempotencyTable("sorm_idempotency_keys")
+
+	mockDB.ExpectExec(`create table idem \(idempotency_key text not null, operation text not null, table_name text not null, record_id text not null, primary key \(idempotency_key\)\)`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	a.NoError(CreateIdempotencyTable(context.Background(), db))
+	a.NoError(mockDB.ExpectationsWereMet())
+}
@@ -0,0 +1,90 @@
+package sorm
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+var (
+	jsonColumnType = "text"
+)
+
+// SetJSONColumnType sets the column type CreateTable uses for `sql:",json"`
+// fields. Config.Apply picks one for the dialect; the default is text.
+func SetJSONColumnType(s string) {
+	jsonColumnType = s
+}
+
+func isJSONField(f structField) bool {
+	t := f.Tag("sql")
+	return t != nil && t.Parameter("json") != nil
+}
+
+// fieldValue is the argument written for field f of v.
+func fieldValue(f structField, v reflect.Value) interface{} {
+	fv := v.FieldByIndex(f.Index())
+
+	var r interface{}
+	if isJSONField(f) {
+		r = jsonValue{fv}
+	} else {
+		r = sqlValue(fv)
+	}
+
+	if name := getSQLCompression(f); name != "" {
+		r = compressValue{name: name, v: r}
+	}
+
+	return r
+}
+
+// jsonValue writes a field as JSON text. Nil maps, slices and pointers are
+// written as NULL rather than as the JSON null.
+type jsonValue struct{ v reflect.Value }
+
+func (j jsonValue) Value() (driver.Value, error) {
+	switch j.v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		if j.v.IsNil() {
+			return nil, nil
+		}
+	}
+
+	b, err := json.Marshal(j.v.Interface())
+	if err != nil {
+		return nil, fmt.Errorf("couldn't marshal %s as json: %w", j.v.Type(), err)
+	}
+
+	return string(b), nil
+}
+
+// jsonScanner reads JSON text into a field, leaving it as its zero value for
+// NULL.
+type jsonScanner struct{ v reflect.Value }
+
+func (j jsonScanner) Scan(src interface{}) error {
+	p := reflect.New(j.v.Type())
+
+	var b []byte
+	switch s := src.(type) {
+	case nil:
+		j.v.Set(p.Elem())
+		return nil
+	case []byte:
+		b = s
+	case string:
+		b = []byte(s)
+	default:
+		return fmt.Errorf("couldn't scan %T as json", src)
+	}
+
+	if err := json.Unmarshal(b, p.Interface()); err != nil {
+		return fmt.Errorf("couldn't unmarshal json into %s: %w", j.v.Type(), err)
+	}
+
+	j.v.Set(p.Elem())
+
+	return nil
+}
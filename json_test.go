@@ -0,0 +1,91 @@
+package sorm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type JSONSettings struct {
+	Theme string `json:"theme"`
+	Beta  bool   `json:"beta"`
+}
+
+type JSONObject struct {
+	ID       int
+	Settings JSONSettings      `sql:",json"`
+	Tags     []string          `sql:",json"`
+	Meta     map[string]string `sql:",json,type:jsonb"`
+}
+
+func TestJSONFieldsCreate(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`insert into json_objects \(settings, tags, meta\) values \(\$1, \$2, \$3\) returning id`).WithArgs(`{"theme":"dark","beta":true}`, `["a","b"]`, nil).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	a.NoError(CreateRecord(context.Background(), db, &JSONObject{Settings: JSONSettings{Theme: "dark", Beta: true}, Tags: []string{"a", "b"}}))
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestJSONFieldsScanAndSave(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from json_objects`).WillReturnRows(sqlmock.NewRows([]string{"id", "settings", "tags", "meta"}).AddRow(1, []byte(`{"theme":"light"}`), nil, `{"k":"v"}`))
+
+	var r JSONObject
+	if !a.NoError(FindFirst(context.Background(), db, &r)) {
+		return
+	}
+	a.Equal(JSONSettings{Theme: "light"}, r.Settings)
+	a.Nil(r.Tags)
+	a.Equal(map[string]string{"k": "v"}, r.Meta)
+
+	mockDB.ExpectQuery(`select \* from json_objects where id = \$1`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "settings", "tags", "meta"}).AddRow(1, []byte(`{"theme":"light"}`), nil, `{"k":"v"}`))
+	mockDB.ExpectExec(`update json_objects set tags = \$2 where id = \$1`).WithArgs(1, `["x"]`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	r.Tags = []string{"x"}
+	a.NoError(SaveRecord(context.Background(), db, &r))
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestJSONFieldsScanInvalid(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from json_objects`).WillReturnRows(sqlmock.NewRows([]string{"id", "settings", "tags", "meta"}).AddRow(1, "nope", nil, nil))
+
+	var l []JSONObject
+	a.ErrorContains(FindAll(context.Background(), db, &l), "couldn't unmarshal json into sorm.JSONSettings")
+}
+
+func TestJSONFieldsCreateTable(t *testing.T) {
+	a := assert.New(t)
+
+	s, err := CreateTableStatement(JSONObject{})
+	if !a.NoError(err) {
+		return
+	}
+
+	a.Equal("create table json_objects (id integer not null, settings text not null, tags text, meta jsonb, primary key (id))", s.Query)
+}
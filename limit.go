@@ -0,0 +1,81 @@
+package sorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+var ErrUnboundedQuery = errors.New("query has no limit")
+
+type UnboundedQueryError struct {
+	Model string
+	Limit int
+}
+
+func (e *UnboundedQueryError) Error() string {
+	return fmt.Sprintf("query has no limit: finds of %s need a limit; the default is %d", e.Model, e.Limit)
+}
+
+func (e *UnboundedQueryError) Unwrap() error {
+	return ErrUnboundedQuery
+}
+
+// DefaultLimiter overrides Options.DefaultLimit for a model. A negative limit
+// turns the guard off for it.
+type DefaultLimiter interface {
+	DefaultLimit() int
+}
+
+var lockClausePattern = regexp.MustCompile(`(?i)^for\s+(update|share|no\s+key\s+update|key\s+share)\b`)
+
+// guardLimit adds the default limit to a where clause that doesn't have one,
+// or with Options.StrictLimit refuses to run it. Only the clause's own limit
+// counts, not one in a subquery or a string.
+func guardLimit(ctx context.Context, vtyp reflect.Type, where string) (string, error) {
+	o := optionsFrom(ctx)
+
+	limit := o.DefaultLimit
+	if v, ok := reflect.New(vtyp).Interface().(DefaultLimiter); ok && v.DefaultLimit() != 0 {
+		limit = v.DefaultLimit()
+	}
+
+	// the limit has to come before an offset or a locking clause
+	bounded := false
+	tail := len(where)
+	topLevelWords(where, func(word string, i, j int) {
+		switch word {
+		case "limit", "fetch":
+			bounded = true
+		case "offset":
+			if tail == len(where) {
+				tail = i
+			}
+		case "for":
+			if tail == len(where) && lockClausePattern.MatchString(where[i:]) {
+				tail = i
+			}
+		}
+	})
+
+	if limit <= 0 || bounded {
+		return where, nil
+	}
+
+	if o.StrictLimit {
+		return "", &UnboundedQueryError{Model: vtyp.Name(), Limit: limit}
+	}
+
+	s := fmt.Sprintf("limit %d", limit)
+	if before := strings.TrimRight(where[:tail], " "); before != "" {
+		s = before + " " + s
+	}
+	if after := where[tail:]; after != "" {
+		s += " " + after
+	}
+
+	return s, nil
+}
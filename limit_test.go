@@ -0,0 +1,94 @@
+package sorm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type UnlimitedObject struct {
+	ID   int
+	Name string
+}
+
+func (UnlimitedObject) DefaultLimit() int { return -1 }
+
+func TestDefaultLimit(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	ctx := WithOptions(context.Background(), Options{DefaultLimit: 100})
+
+	mockDB.ExpectQuery(`^select \* from simple_objects limit 100$`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	mockDB.ExpectQuery(`^select \* from simple_objects where id > \$1 order by id limit 100 for update$`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	mockDB.ExpectQuery(`^select \* from simple_objects where id > \$1 limit 5$`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	mockDB.ExpectQuery(`^select \* from unlimited_objects$`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+
+	var l []SimpleObject
+	a.NoError(FindAll(ctx, db, &l))
+	a.NoError(FindWhere(ctx, db, &l, "where id > $1 order by id for update", 1))
+	a.NoError(FindWhere(ctx, db, &l, "where id > $1 limit 5", 1))
+
+	var u []UnlimitedObject
+	a.NoError(FindAll(ctx, db, &u))
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestDefaultLimitStrict(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	ctx := WithOptions(context.Background(), Options{DefaultLimit: 100, StrictLimit: true})
+
+	mockDB.ExpectQuery(`^select \* from simple_objects limit 1$`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a"))
+
+	var l []SimpleObject
+	err = FindAll(ctx, db, &l)
+	a.EqualError(err, "FindWhere: query has no limit: finds of SimpleObject need a limit; the default is 100")
+	a.True(errors.Is(err, ErrUnboundedQuery))
+
+	var r SimpleObject
+	a.NoError(FindFirst(ctx, db, &r))
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestDefaultLimitTopLevel(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	ctx := WithOptions(context.Background(), Options{DefaultLimit: 100, Strict: true})
+
+	mockDB.ExpectQuery(`^select \* from simple_objects where id in \(select id from simple_objects order by id limit 5\) limit 100$`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	mockDB.ExpectQuery(`^select \* from simple_objects where name = 'no limit 5' limit 100$`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	mockDB.ExpectQuery(`^select \* from simple_objects order by id limit 100 offset 20$`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	mockDB.ExpectQuery(`^select \* from simple_objects order by id limit 10 offset 20$`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+
+	// Strict is about scanning, so it doesn't refuse unbounded finds
+	var l []SimpleObject
+	a.NoError(FindWhere(ctx, db, &l, "where id in (select id from simple_objects order by id limit 5)"))
+	a.NoError(FindWhere(ctx, db, &l, "where name = 'no limit 5'"))
+	a.NoError(FindWhere(ctx, db, &l, "order by id offset 20"))
+	a.NoError(FindWhere(ctx, db, &l, "order by id limit 10 offset 20"))
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
@@ -0,0 +1,117 @@
+package sorm
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// maxBatchIDs caps the IDs in one batched query, keeping well clear of
+// driver parameter limits.
+const maxBatchIDs = 1000
+
+type loaderKey struct{}
+
+type loader struct {
+	mu      sync.Mutex
+	wait    time.Duration
+	batches map[batchKey]*batch
+}
+
+type batchKey struct {
+	db  Querier
+	typ reflect.Type
+}
+
+type batch struct {
+	ids  []interface{}
+	seen map[string]bool
+	done chan struct{}
+	rows map[string]reflect.Value
+	err  error
+}
+
+// WithBatching makes FindByID calls made with the returned context within
+// wait of each other, for the same type and database, share one "where id in
+// (...)" query. It's meant for a single request, e.g. so GraphQL resolvers
+// can load records one at a time. Models with composite IDs aren't batched.
+// The query runs with the context of the first call in the batch, so every
+// call in it should share tenant and options.
+func WithBatching(ctx context.Context, wait time.Duration) context.Context {
+	return context.WithValue(ctx, loaderKey{}, &loader{wait: wait, batches: make(map[batchKey]*batch)})
+}
+
+func loaderFrom(ctx context.Context) *loader {
+	l, _ := ctx.Value(loaderKey{}).(*loader)
+	return l
+}
+
+func (l *loader) load(ctx context.Context, db Querier, out reflect.Value, idField structField, col string, id interface{}) error {
+	k := batchKey{db: db, typ: out.Elem().Type()}
+	idKey := tableCacheKey([]interface{}{id})
+
+	l.mu.Lock()
+
+	b := l.batches[k]
+	if b == nil {
+		b = &batch{seen: make(map[string]bool), done: make(chan struct{})}
+		l.batches[k] = b
+
+		runCtx := context.WithoutCancel(ctx)
+		time.AfterFunc(l.wait, func() { l.run(runCtx, db, k, b, idField, col) })
+	}
+
+	if !b.seen[idKey] {
+		b.seen[idKey] = true
+		b.ids = append(b.ids, id)
+	}
+
+	if len(b.ids) >= maxBatchIDs {
+		delete(l.batches, k)
+	}
+
+	l.mu.Unlock()
+
+	select {
+	case <-b.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	if b.err != nil {
+		return b.err
+	}
+
+	v, ok := b.rows[idKey]
+	if !ok {
+		return ErrRecordNotFound
+	}
+
+	out.Elem().Set(v)
+
+	return nil
+}
+
+func (l *loader) run(ctx context.Context, db Querier, k batchKey, b *batch, idField structField, col string) {
+	defer close(b.done)
+
+	l.mu.Lock()
+	if l.batches[k] == b {
+		delete(l.batches, k)
+	}
+	ids := b.ids
+	l.mu.Unlock()
+
+	arr := reflect.New(reflect.SliceOf(k.typ))
+	if err := findWhere(ctx, db, arr.Interface(), "where "+col+" in ("+makeParameter(1)+")", []interface{}{ids}, findOptions{}); err != nil {
+		b.err = err
+		return
+	}
+
+	b.rows = make(map[string]reflect.Value)
+	for i := 0; i < arr.Elem().Len(); i++ {
+		v := arr.Elem().Index(i)
+		b.rows[tableCacheKey([]interface{}{v.FieldByIndex(idField.Index()).Interface()})] = v
+	}
+}
@@ -0,0 +1,65 @@
+package sorm
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindByIDBatching(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from simple_objects where id in \(\$1, \$2, \$3\)`).WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a").AddRow(2, "b"))
+
+	ctx := WithBatching(context.Background(), time.Millisecond*20)
+
+	ids := []int{1, 2, 1, 3}
+	out := make([]SimpleObject, len(ids))
+	errs := make([]error, len(ids))
+
+	var wg sync.WaitGroup
+	for i := range ids {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = FindByID(ctx, db, &out[i], ids[i])
+		}(i)
+	}
+	wg.Wait()
+
+	a.NoError(errs[0])
+	a.NoError(errs[1])
+	a.NoError(errs[2])
+	a.ErrorIs(errs[3], ErrRecordNotFound)
+	a.Equal([]SimpleObject{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}, {ID: 1, Name: "a"}, {}}, out)
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestFindByIDBatchingCompositeID(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from composite_id_objects where id_1 = \$1 and id_2 = \$2 limit 1`).WithArgs(101, 201).WillReturnRows(sqlmock.NewRows([]string{"id_1", "id_2", "name"}).AddRow(101, 201, "a"))
+
+	var r CompositeIDObject
+	a.NoError(FindByID(WithBatching(context.Background(), time.Millisecond), db, &r, 101, 201))
+	a.Equal(CompositeIDObject{ID1: 101, ID2: 201, Name: "a"}, r)
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
@@ -0,0 +1,144 @@
+package sorm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Lock picks the row lock taken by FindWhereLocked and FindFirstWhereLocked.
+// Combine a strength with at most one of LockNoWait and LockSkipLocked, e.g.
+// LockForUpdate|LockSkipLocked for a job queue.
+type Lock int
+
+const (
+	LockForUpdate Lock = 1 << iota
+	LockForShare
+	// LockNoWait fails instead of waiting for rows locked by someone else.
+	LockNoWait
+	// LockSkipLocked leaves out rows locked by someone else.
+	LockSkipLocked
+)
+
+type LockingSyntax int
+
+const (
+	// LockingSuffix adds "for update", "for share", "nowait" and "skip
+	// locked" to the end of the select, as Postgres and MySQL 8 expect.
+	LockingSuffix LockingSyntax = iota
+	// LockingTableHints adds SQL Server's "with (updlock, rowlock)" style
+	// table hints.
+	LockingTableHints
+	// LockingNone leaves the lock out, for SQLite, which locks the whole
+	// database when a transaction writes.
+	LockingNone
+)
+
+var (
+	lockingSyntax LockingSyntax
+)
+
+// SetLockingSyntax picks how locking reads are written. Config.Apply picks
+// one for the dialect.
+func SetLockingSyntax(s LockingSyntax) {
+	lockingSyntax = s
+}
+
+func (l Lock) validate() error {
+	if (l&LockForUpdate == 0) == (l&LockForShare == 0) {
+		return fmt.Errorf("lock needs exactly one of LockForUpdate and LockForShare")
+	}
+
+	if l&LockNoWait != 0 && l&LockSkipLocked != 0 {
+		return fmt.Errorf("lock can't have both LockNoWait and LockSkipLocked")
+	}
+
+	return nil
+}
+
+func (l Lock) suffix() string {
+	if lockingSyntax != LockingSuffix {
+		return ""
+	}
+
+	s := " for update"
+	if l&LockForShare != 0 {
+		s = " for share"
+	}
+
+	switch {
+	case l&LockNoWait != 0:
+		s += " nowait"
+	case l&LockSkipLocked != 0:
+		s += " skip locked"
+	}
+
+	return s
+}
+
+func (l Lock) tableHints() string {
+	if lockingSyntax != LockingTableHints {
+		return ""
+	}
+
+	hints := []string{"updlock", "rowlock"}
+	if l&LockForShare != 0 {
+		hints = []string{"holdlock", "rowlock"}
+	}
+
+	switch {
+	case l&LockNoWait != 0:
+		hints = append(hints, "nowait")
+	case l&LockSkipLocked != 0:
+		hints = append(hints, "readpast")
+	}
+
+	return " with (" + strings.Join(hints, ", ") + ")"
+}
+
+// FindWhereLocked is FindWhere with the matching rows locked until the end of
+// tx's transaction. Results never come from the result cache.
+func FindWhereLocked(ctx context.Context, tx Querier, out interface{}, lock Lock, where string, args ...interface{}) error {
+	if err := lock.validate(); err != nil {
+		return fmt.Errorf("FindWhereLocked: %w", err)
+	}
+
+	return findWhere(ctx, tx, out, where, args, findOptions{guardLimit: true, lock: lock})
+}
+
+// FindFirstWhereLocked is FindFirstWhere with the row locked until the end of
+// tx's transaction.
+func FindFirstWhereLocked(ctx context.Context, tx Querier, out interface{}, lock Lock, where string, args ...interface{}) error {
+	if err := lock.validate(); err != nil {
+		return fmt.Errorf("FindFirstWhereLocked: %w", err)
+	}
+
+	return findFirstWhere(ctx, tx, out, where, args, findOptions{lock: lock})
+}
+
+// ClaimRecords locks up to n of the rows matching where, in order, skipping
+// any that another transaction has already locked, and stores them in out.
+// It's the usual work queue pattern: claim jobs, process or mark them in tx,
+// then commit to release them. order is the text of an order by clause, and
+// args are for where.
+//
+// With LockingNone, as on SQLite, there's no row locking to skip past, so
+// claims are only exclusive because SQLite allows one writer at a time; tx
+// should be started with BEGIN IMMEDIATE so that the lock is taken before the
+// rows are read.
+func ClaimRecords(ctx context.Context, tx Querier, out interface{}, n int, where, order string, args ...interface{}) error {
+	if n < 1 {
+		return fmt.Errorf("ClaimRecords: expected n to be at least 1; was instead %d", n)
+	}
+
+	if order != "" {
+		where += " order by " + order
+	}
+	where = strings.TrimSpace(where + fmt.Sprintf(" limit %d", n))
+
+	if err := findWhere(ctx, tx, out, where, args, findOptions{lock: LockForUpdate | LockSkipLocked}); err != nil {
+		return fmt.Errorf("ClaimRecords: %w", err)
+	}
+
+	return nil
+}
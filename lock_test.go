@@ -0,0 +1,96 @@
+package sorm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindFirstWhereLocked(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from simple_objects where id = \$1 limit 1 for update$`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a"))
+	mockDB.ExpectQuery(`select \* from simple_objects where id = \$1 limit 1 for share nowait$`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a"))
+
+	var r SimpleObject
+	a.NoError(FindFirstWhereLocked(context.Background(), db, &r, LockForUpdate, "where id = $1", 1))
+	a.NoError(FindFirstWhereLocked(context.Background(), db, &r, LockForShare|LockNoWait, "where id = $1", 1))
+	a.Equal(SimpleObject{ID: 1, Name: "a"}, r)
+
+	a.EqualError(FindFirstWhereLocked(context.Background(), db, &r, LockNoWait, "where id = $1", 1), "FindFirstWhereLocked: lock needs exactly one of LockForUpdate and LockForShare")
+	a.EqualError(FindFirstWhereLocked(context.Background(), db, &r, LockForUpdate|LockNoWait|LockSkipLocked, "where id = $1", 1), "FindFirstWhereLocked: lock can't have both LockNoWait and LockSkipLocked")
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestFindWhereLockedSkipLocked(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from \(select \* from ttl_sessions where expires_at is null or expires_at > \$2\) ttl_sessions where token = \$1 limit 10 for update skip locked$`).WillReturnRows(sqlmock.NewRows([]string{"id", "token", "expires_at"}))
+
+	var l []TTLSession
+	a.NoError(FindWhereLocked(context.Background(), db, &l, LockForUpdate|LockSkipLocked, "where token = $1 limit 10", "a"))
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestLockingTableHints(t *testing.T) {
+	a := assert.New(t)
+
+	SetLockingSyntax(LockingTableHints)
+	defer SetLockingSyntax(LockingSuffix)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from simple_objects with \(updlock, rowlock, readpast\) where id = \$1 limit 1$`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a"))
+
+	var r SimpleObject
+	a.NoError(FindFirstWhereLocked(context.Background(), db, &r, LockForUpdate|LockSkipLocked, "where id = $1", 1))
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestClaimRecords(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from simple_objects where name = \$1 order by id limit 2 for update skip locked$`).WithArgs("pending").WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "pending").AddRow(2, "pending"))
+	mockDB.ExpectQuery(`select \* from simple_objects limit 5$`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+
+	var l []SimpleObject
+	a.NoError(ClaimRecords(context.Background(), db, &l, 2, "where name = $1", "id", "pending"))
+	a.Equal([]SimpleObject{{ID: 1, Name: "pending"}, {ID: 2, Name: "pending"}}, l)
+
+	SetLockingSyntax(LockingNone)
+	defer SetLockingSyntax(LockingSuffix)
+
+	a.NoError(ClaimRecords(context.Background(), db, &l, 5, "", ""))
+	a.Len(l, 0)
+
+	a.EqualError(ClaimRecords(context.Background(), db, &l, 0, "", ""), "ClaimRecords: expected n to be at least 1; was instead 0")
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
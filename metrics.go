@@ -0,0 +1,52 @@
+package sorm
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"time"
+)
+
+type MetricsCollector interface {
+	ObserveOperation(op Operation, table string, duration time.Duration, rowsAffected int64, err error)
+}
+
+var (
+	metricsCollector MetricsCollector
+)
+
+func SetMetricsCollector(m MetricsCollector) {
+	metricsCollector = m
+}
+
+func observeOperation(ctx context.Context, op Operation, model reflect.Type, table string, stmt Statement, start time.Time, rowsAffected int64, err error) {
+	o := optionsFrom(ctx)
+	d := time.Now().Sub(start)
+
+	if o.MetricsCollector != nil {
+		o.MetricsCollector.ObserveOperation(op, table, d, rowsAffected, err)
+	}
+
+	if o.QueryLoggerV2 != nil {
+		o.QueryLoggerV2.LogQueryV2(QueryLogEntry{
+			Context:   ctx,
+			Operation: op,
+			Table:     table,
+			Model:     model,
+			Query:     stmt.Query,
+			Args:      stmt.Args,
+			Rows:      rowsAffected,
+			Duration:  d,
+			Err:       err,
+		})
+	}
+}
+
+func rowsAffected(res sql.Result) int64 {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return -1
+	}
+
+	return n
+}
@@ -0,0 +1,102 @@
+package sorm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type observation struct {
+	op    Operation
+	table string
+	rows  int64
+	err   error
+}
+
+type testMetricsCollector struct {
+	l []observation
+}
+
+func (c *testMetricsCollector) ObserveOperation(op Operation, table string, duration time.Duration, rowsAffected int64, err error) {
+	c.l = append(c.l, observation{op, table, rowsAffected, err})
+}
+
+func TestMetricsCollector(t *testing.T) {
+	a := assert.New(t)
+
+	var c testMetricsCollector
+	SetMetricsCollector(&c)
+	defer SetMetricsCollector(nil)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	testErr := errors.New("test error")
+
+	mockDB.ExpectExec(`insert into simple_objects`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mockDB.ExpectQuery(`select \* from simple_objects`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a").AddRow(2, "b"))
+	mockDB.ExpectExec(`delete from simple_objects`).WillReturnError(testErr)
+
+	a.NoError(CreateRecord(context.Background(), db, &SimpleObject{ID: 1, Name: "a"}))
+
+	var l []SimpleObject
+	a.NoError(FindAll(context.Background(), db, &l))
+
+	a.Error(DeleteRecord(context.Background(), db, &SimpleObject{ID: 1}))
+
+	a.Equal([]observation{
+		{OperationCreate, "simple_objects", 1, nil},
+		{OperationFind, "simple_objects", 2, nil},
+		{OperationDelete, "simple_objects", 0, testErr},
+	}, c.l)
+}
+
+type testQueryLoggerV2 struct {
+	l []QueryLogEntry
+}
+
+func (q *testQueryLoggerV2) LogQueryV2(e QueryLogEntry) {
+	e.Duration = 0
+	q.l = append(q.l, e)
+}
+
+func TestQueryLoggerV2(t *testing.T) {
+	a := assert.New(t)
+
+	var q testQueryLoggerV2
+	SetQueryLoggerV2(&q)
+	defer SetQueryLoggerV2(nil)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select count\(\*\) from simple_objects where name = \$1`).WithArgs("a").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	ctx := context.WithValue(context.Background(), testContextKey{}, "request-1")
+
+	_, err = CountWhere(ctx, db, &SimpleObject{}, "where name = $1", "a")
+	a.NoError(err)
+
+	a.Equal([]QueryLogEntry{{
+		Context:   ctx,
+		Operation: OperationCount,
+		Table:     "simple_objects",
+		Model:     reflect.TypeOf(SimpleObject{}),
+		Query:     "select count(*) from simple_objects where name = $1",
+		Args:      []interface{}{"a"},
+		Rows:      1,
+	}}, q.l)
+}
+
+type testContextKey struct{}
@@ -0,0 +1,158 @@
+package sorm
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+)
+
+// NamedStatementConn is a single connection that can create and drop named
+// server-side prepared statements, and run them by passing the name in place
+// of the query. For pgx that's a thin wrapper around pgx.Conn.Prepare and
+// pgx.Conn.Deallocate; pgx accepts a statement name anywhere it takes SQL.
+type NamedStatementConn interface {
+	Querier
+	PrepareNamed(ctx context.Context, name, query string) error
+	DeallocateNamed(ctx context.Context, name string) error
+}
+
+// NamedStatements is a Querier that gives every distinct query a named
+// prepared statement on conn, so Postgres can cache its plan. At most Size
+// statements exist at a time; the least recently used one is deallocated to
+// make room.
+type NamedStatements struct {
+	Conn NamedStatementConn
+	Size int
+
+	m       sync.Mutex
+	next    int
+	lru     *list.List
+	byQuery map[string]*list.Element
+	stats   StmtCacheStats
+}
+
+var _ Querier = (*NamedStatements)(nil)
+
+type namedStatement struct {
+	query string
+	name  string
+}
+
+func NewNamedStatements(conn NamedStatementConn, size int) *NamedStatements {
+	return &NamedStatements{Conn: conn, Size: size, lru: list.New(), byQuery: make(map[string]*list.Element)}
+}
+
+func (s *NamedStatements) get(ctx context.Context, query string) (string, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if e, ok := s.byQuery[query]; ok {
+		s.lru.MoveToFront(e)
+		s.stats.Hits++
+		return e.Value.(*namedStatement).name, nil
+	}
+
+	s.stats.Misses++
+
+	for s.Size > 0 && s.lru.Len() >= s.Size {
+		e := s.lru.Back()
+		if err := s.Conn.DeallocateNamed(ctx, e.Value.(*namedStatement).name); err != nil {
+			return "", fmt.Errorf("couldn't deallocate statement %s: %w", e.Value.(*namedStatement).name, err)
+		}
+		s.lru.Remove(e)
+		delete(s.byQuery, e.Value.(*namedStatement).query)
+		s.stats.Evictions++
+	}
+
+	s.next++
+	name := fmt.Sprintf("sorm_%d", s.next)
+
+	if err := s.Conn.PrepareNamed(ctx, name, query); err != nil {
+		return "", fmt.Errorf("couldn't prepare statement %s: %w", name, err)
+	}
+
+	s.byQuery[query] = s.lru.PushFront(&namedStatement{query: query, name: name})
+
+	return name, nil
+}
+
+func (s *NamedStatements) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	name, err := s.get(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Conn.ExecContext(ctx, name, args...)
+}
+
+func (s *NamedStatements) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	name, err := s.get(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Conn.QueryContext(ctx, name, args...)
+}
+
+func (s *NamedStatements) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	name, err := s.get(ctx, query)
+	if err != nil {
+		// let the connection report the problem through the row
+		return s.Conn.QueryRowContext(ctx, query, args...)
+	}
+
+	return s.Conn.QueryRowContext(ctx, name, args...)
+}
+
+func (s *NamedStatements) Stats() StmtCacheStats {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	st := s.stats
+	st.Size = s.lru.Len()
+
+	return st
+}
+
+// Deallocate drops the statement prepared for query, if there is one.
+func (s *NamedStatements) Deallocate(ctx context.Context, query string) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	e, ok := s.byQuery[query]
+	if !ok {
+		return nil
+	}
+
+	if err := s.Conn.DeallocateNamed(ctx, e.Value.(*namedStatement).name); err != nil {
+		return fmt.Errorf("Deallocate: %w", err)
+	}
+
+	s.lru.Remove(e)
+	delete(s.byQuery, query)
+
+	return nil
+}
+
+// DeallocateAll drops every statement prepared so far.
+func (s *NamedStatements) DeallocateAll(ctx context.Context) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	for e := s.lru.Front(); e != nil; {
+		next := e.Next()
+
+		if err := s.Conn.DeallocateNamed(ctx, e.Value.(*namedStatement).name); err != nil {
+			return fmt.Errorf("DeallocateAll: %w", err)
+		}
+
+		s.lru.Remove(e)
+		delete(s.byQuery, e.Value.(*namedStatement).query)
+
+		e = next
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package sorm
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type testNamedConn struct {
+	*sql.DB
+	calls []string
+}
+
+func (c *testNamedConn) PrepareNamed(ctx context.Context, name, query string) error {
+	c.calls = append(c.calls, fmt.Sprintf("prepare %s as %s", name, query))
+	return nil
+}
+
+func (c *testNamedConn) DeallocateNamed(ctx context.Context, name string) error {
+	c.calls = append(c.calls, "deallocate "+name)
+	return nil
+}
+
+func TestNamedStatements(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`^sorm_1$`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a"))
+	mockDB.ExpectQuery(`^sorm_1$`).WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(2, "b"))
+	mockDB.ExpectExec(`^sorm_2$`).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectQuery(`^sorm_3$`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a"))
+
+	conn := &testNamedConn{DB: db}
+	s := NewNamedStatements(conn, 1)
+
+	var l []SimpleObject
+	a.NoError(FindWhere(context.Background(), s, &l, "where id = $1", 1))
+	a.NoError(FindWhere(context.Background(), s, &l, "where id = $1", 2))
+	a.NoError(DeleteRecord(context.Background(), s, &SimpleObject{ID: 1}))
+	a.NoError(FindWhere(context.Background(), s, &l, "where id = $1", 1))
+
+	a.Equal(StmtCacheStats{Hits: 1, Misses: 3, Evictions: 2, Size: 1}, s.Stats())
+
+	a.NoError(s.DeallocateAll(context.Background()))
+	a.Equal(0, s.Stats().Size)
+
+	a.Equal([]string{
+		"prepare sorm_1 as select * from simple_objects where id = $1",
+		"deallocate sorm_1",
+		"prepare sorm_2 as delete from simple_objects where id = $1",
+		"deallocate sorm_2",
+		"prepare sorm_3 as select * from simple_objects where id = $1",
+		"deallocate sorm_3",
+	}, conn.calls)
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
@@ -0,0 +1,234 @@
+package sorm
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/serenize/snaker"
+)
+
+// NamingStrategy derives table and column names from Go type and field names
+// that don't have them set with tags.
+type NamingStrategy interface {
+	TableName(typeName string) string
+	ColumnName(fieldName string) string
+}
+
+// SnakeCaseNaming names tables and columns in snake case. By default table
+// names just get an "s", as they always have.
+type SnakeCaseNaming struct {
+	// Pluralize makes table names English plurals, so Person becomes people
+	// and Category categories.
+	Pluralize bool
+	// SingularTables leaves table names singular.
+	SingularTables bool
+}
+
+func (n SnakeCaseNaming) TableName(typeName string) string {
+	s := snaker.CamelToSnake(typeName)
+
+	switch {
+	case n.SingularTables:
+		return s
+	case n.Pluralize:
+		return Pluralize(s)
+	default:
+		return s + "s"
+	}
+}
+
+func (n SnakeCaseNaming) ColumnName(fieldName string) string {
+	return snaker.CamelToSnake(fieldName)
+}
+
+var (
+	namingStrategy NamingStrategy = SnakeCaseNaming{}
+)
+
+// SetNamingStrategy sets how table and column names are derived. Column
+// names are cached with each type's plan, so it should be called before any
+// models are used; calling it clears plans already built, other than
+// imported ones. Options.NamingStrategy changes table and column names per
+// call; strategies that can't be compared with == work there, but aren't
+// cached.
+func SetNamingStrategy(n NamingStrategy) {
+	if n == nil {
+		n = SnakeCaseNaming{}
+	}
+
+	namingStrategy = n
+
+	planCacheLock.Lock()
+	for typ := range planCache {
+		if !importedPlans[typ] {
+			delete(planCache, typ)
+		}
+	}
+	planCacheLock.Unlock()
+}
+
+type namedKey struct {
+	typ    reflect.Type
+	naming NamingStrategy
+}
+
+var (
+	namedDescriptions = map[namedKey]*structDescription{}
+	namedPlans        = map[namedKey]*ModelDescription{}
+	namedCacheLock    sync.RWMutex
+)
+
+// contextNaming returns the context's naming strategy, or nil if it's the
+// global one that the plain description and plan caches use.
+func contextNaming(ctx context.Context) NamingStrategy {
+	n := optionsFrom(ctx).NamingStrategy
+	if comparableNaming(n) && n == namingStrategy {
+		return nil
+	}
+
+	return n
+}
+
+func comparableNaming(n NamingStrategy) bool {
+	return reflect.TypeOf(n).Comparable()
+}
+
+// getDescriptionContext is getDescriptionFromType, with the fields naming
+// their columns with the context's naming strategy.
+func getDescriptionContext(ctx context.Context, typ reflect.Type) (*structDescription, error) {
+	d, err := getDescriptionFromType(typ)
+	if err != nil {
+		return nil, err
+	}
+
+	n := contextNaming(ctx)
+	if n == nil {
+		return d, nil
+	}
+
+	k := namedKey{typ: typ, naming: n}
+
+	if comparableNaming(n) {
+		namedCacheLock.RLock()
+		nd, ok := namedDescriptions[k]
+		namedCacheLock.RUnlock()
+		if ok {
+			return nd, nil
+		}
+	}
+
+	nd := d.withNaming(n)
+
+	if comparableNaming(n) {
+		namedCacheLock.Lock()
+		namedDescriptions[k] = nd
+		namedCacheLock.Unlock()
+	}
+
+	return nd, nil
+}
+
+// getPlanContext is getPlanFromType, with column names from the context's
+// naming strategy.
+func getPlanContext(ctx context.Context, typ reflect.Type) (*ModelDescription, error) {
+	n := contextNaming(ctx)
+	if n == nil {
+		return getPlanFromType(typ)
+	}
+
+	k := namedKey{typ: typ, naming: n}
+
+	if comparableNaming(n) {
+		namedCacheLock.RLock()
+		d, ok := namedPlans[k]
+		namedCacheLock.RUnlock()
+		if ok {
+			return d, nil
+		}
+	}
+
+	d, err := buildPlan(typ, n)
+	if err != nil {
+		return nil, err
+	}
+
+	if comparableNaming(n) {
+		namedCacheLock.Lock()
+		namedPlans[k] = d
+		namedCacheLock.Unlock()
+	}
+
+	return d, nil
+}
+
+var (
+	irregularPlurals = map[string]string{
+		"person": "people",
+		"man":    "men",
+		"woman":  "women",
+		"child":  "children",
+		"mouse":  "mice",
+		"goose":  "geese",
+		"foot":   "feet",
+		"tooth":  "teeth",
+		"ox":     "oxen",
+		"leaf":   "leaves",
+		"life":   "lives",
+		"knife":  "knives",
+		"wife":   "wives",
+		"half":   "halves",
+		"shelf":  "shelves",
+		"wolf":   "wolves",
+		"hero":   "heroes",
+		"potato": "potatoes",
+		"tomato": "tomatoes",
+		"quiz":   "quizzes",
+	}
+
+	uncountables = map[string]bool{
+		"data":        true,
+		"deer":        true,
+		"equipment":   true,
+		"fish":        true,
+		"information": true,
+		"metadata":    true,
+		"money":       true,
+		"news":        true,
+		"series":      true,
+		"sheep":       true,
+		"species":     true,
+	}
+)
+
+// Pluralize returns the English plural of a lower case word. For snake case
+// names only the last word is changed, so blog_category becomes
+// blog_categories.
+func Pluralize(s string) string {
+	prefix, word := "", s
+	if i := strings.LastIndexByte(s, '_'); i != -1 {
+		prefix, word = s[:i+1], s[i+1:]
+	}
+
+	if word == "" || uncountables[word] {
+		return s
+	}
+
+	if p, ok := irregularPlurals[word]; ok {
+		return prefix + p
+	}
+
+	switch {
+	case strings.HasSuffix(word, "is"):
+		word = strings.TrimSuffix(word, "is") + "es"
+	case strings.HasSuffix(word, "s"), strings.HasSuffix(word, "x"), strings.HasSuffix(word, "z"), strings.HasSuffix(word, "ch"), strings.HasSuffix(word, "sh"):
+		word += "es"
+	case strings.HasSuffix(word, "y") && len(word) > 1 && !strings.ContainsRune("aeiou", rune(word[len(word)-2])):
+		word = word[:len(word)-1] + "ies"
+	default:
+		word += "s"
+	}
+
+	return prefix + word
+}
@@ -0,0 +1,116 @@
+package sorm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type Person struct {
+	ID        int
+	FirstName string
+}
+
+type BlogCategory struct {
+	ID   int
+	Name string
+}
+
+func TestPluralize(t *testing.T) {
+	a := assert.New(t)
+
+	for in, out := range map[string]string{
+		"user":          "users",
+		"person":        "people",
+		"category":      "categories",
+		"day":           "days",
+		"box":           "boxes",
+		"address":       "addresses",
+		"batch":         "batches",
+		"analysis":      "analyses",
+		"sheep":         "sheep",
+		"blog_category": "blog_categories",
+		"sales_person":  "sales_people",
+		"news":          "news",
+	} {
+		a.Equal(out, Pluralize(in), in)
+	}
+}
+
+func TestSnakeCaseNaming(t *testing.T) {
+	a := assert.New(t)
+
+	a.Equal("persons", SnakeCaseNaming{}.TableName("Person"))
+	a.Equal("people", SnakeCaseNaming{Pluralize: true}.TableName("Person"))
+	a.Equal("blog_categories", SnakeCaseNaming{Pluralize: true}.TableName("BlogCategory"))
+	a.Equal("blog_category", SnakeCaseNaming{SingularTables: true}.TableName("BlogCategory"))
+	a.Equal("first_name", SnakeCaseNaming{}.ColumnName("FirstName"))
+}
+
+func TestSetNamingStrategy(t *testing.T) {
+	a := assert.New(t)
+
+	SetNamingStrategy(SnakeCaseNaming{Pluralize: true})
+	defer SetNamingStrategy(nil)
+
+	a.Equal("people", TableName(Person{}))
+	a.Equal("blog_categories", TableName(BlogCategory{}))
+
+	SetNamingStrategy(nil)
+
+	a.Equal("persons", TableName(Person{}))
+}
+
+func TestNamingStrategyOption(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from person where id = \$1`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "first_name"}).AddRow(1, "a"))
+	mockDB.ExpectQuery(`select \* from persons where id = \$1`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "first_name"}).AddRow(1, "a"))
+
+	var l []Person
+	a.NoError(FindWhere(WithOptions(context.Background(), Options{NamingStrategy: SnakeCaseNaming{SingularTables: true}}), db, &l, "where id = $1", 1))
+	a.NoError(FindWhere(context.Background(), db, &l, "where id = $1", 1))
+
+	if a.Len(l, 1) {
+		a.Equal("a", l[0].FirstName)
+	}
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestNamingStrategyOptionColumns(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`^insert into person \(firstname\) values \(\$1\) returning id$`).WithArgs("a").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mockDB.ExpectQuery(`^select \* from person where id = \$1 limit 1$`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "firstname"}).AddRow(1, "a"))
+	mockDB.ExpectQuery(`^insert into persons \(first_name\) values \(\$1\) returning id$`).WithArgs("b").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+
+	ctx := WithOptions(context.Background(), Options{NamingStrategy: lowerNaming{}})
+
+	p := Person{FirstName: "a"}
+	a.NoError(CreateRecord(ctx, db, &p))
+	a.Equal(1, p.ID)
+
+	var r Person
+	if a.NoError(FindByID(ctx, db, &r, 1)) {
+		a.Equal(Person{ID: 1, FirstName: "a"}, r)
+	}
+
+	a.NoError(CreateRecord(context.Background(), db, &Person{FirstName: "b"}))
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
@@ -0,0 +1,91 @@
+package sorm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// getSQLNaturalKeys groups fields tagged like `sql:",unique:org_slug"` by key
+// name, keeping struct field order within each key.
+func getSQLNaturalKeys(vdesc *structDescription) map[string][]structField {
+	m := make(map[string][]structField)
+
+	for _, f := range getSQLWritableFields(vdesc) {
+		if t := f.Tag("sql"); t != nil {
+			if p := t.Parameter("unique"); p != nil && p.Value() != "" {
+				m[p.Value()] = append(m[p.Value()], f)
+			}
+		}
+	}
+
+	return m
+}
+
+// FindByNaturalKey finds the record matching values on the model's only
+// natural key, as declared with `sql:",unique:name"` tags. Values are given in
+// field order.
+func FindByNaturalKey(ctx context.Context, db Querier, out interface{}, values ...interface{}) error {
+	return findByNaturalKey(ctx, db, out, "", values)
+}
+
+// FindByNaturalKeyName is FindByNaturalKey for models with more than one
+// natural key.
+func FindByNaturalKeyName(ctx context.Context, db Querier, out interface{}, name string, values ...interface{}) error {
+	return findByNaturalKey(ctx, db, out, name, values)
+}
+
+func findByNaturalKey(ctx context.Context, db Querier, out interface{}, name string, values []interface{}) error {
+	ptr := reflect.ValueOf(out)
+	if ptr.Kind() != reflect.Ptr || ptr.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("FindByNaturalKey: %w", typeErrorf(ErrNotAStruct, "expected output to be pointer to struct; was instead %T", out))
+	}
+
+	vtyp := ptr.Elem().Type()
+
+	vdesc, err := getDescriptionContext(ctx, vtyp)
+	if err != nil {
+		return fmt.Errorf("FindByNaturalKey: could not get detailed reflection information for type %s: %w", vtyp.String(), err)
+	}
+
+	keys := getSQLNaturalKeys(vdesc)
+
+	if name == "" {
+		if len(keys) != 1 {
+			var names []string
+			for k := range keys {
+				names = append(names, k)
+			}
+			sort.Strings(names)
+
+			return fmt.Errorf("FindByNaturalKey: expected %s to have exactly one natural key; found %v", vtyp.Name(), names)
+		}
+
+		for k := range keys {
+			name = k
+		}
+	}
+
+	fields, ok := keys[name]
+	if !ok {
+		return fmt.Errorf("FindByNaturalKey: %s has no natural key named %s", vtyp.Name(), name)
+	}
+
+	if len(values) != len(fields) {
+		return fmt.Errorf("FindByNaturalKey: natural key %s of %s has %d field(s); got %d value(s)", name, vtyp.Name(), len(fields), len(values))
+	}
+
+	var conds []string
+	for i, f := range fields {
+		col := getSQLColumnName(f)
+		if err := checkIdentifier(col); err != nil {
+			return fmt.Errorf("FindByNaturalKey: %w", err)
+		}
+
+		conds = append(conds, columnComparison(f, col)+" = "+makeParameter(i+1))
+	}
+
+	return FindFirstWhere(ctx, db, out, "where "+strings.Join(conds, " and "), values...)
+}
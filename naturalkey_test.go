@@ -0,0 +1,43 @@
+package sorm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type NaturalKeyObject struct {
+	ID    int
+	OrgID int    `sql:",unique:org_slug"`
+	Slug  string `sql:",unique:org_slug"`
+	Name  string
+}
+
+func TestFindByNaturalKey(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from natural_key_objects where org_id = \$1 and slug = \$2 limit 1`).WithArgs(5, "hello").WillReturnRows(sqlmock.NewRows([]string{"id", "org_id", "slug", "name"}).AddRow(1, 5, "hello", "Hello"))
+	mockDB.ExpectQuery(`select \* from natural_key_objects where org_id = \$1 and slug = \$2 limit 1`).WithArgs(5, "nope").WillReturnRows(sqlmock.NewRows([]string{"id", "org_id", "slug", "name"}))
+
+	var r NaturalKeyObject
+	a.NoError(FindByNaturalKey(context.Background(), db, &r, 5, "hello"))
+	a.Equal(NaturalKeyObject{ID: 1, OrgID: 5, Slug: "hello", Name: "Hello"}, r)
+
+	a.True(errors.Is(FindByNaturalKeyName(context.Background(), db, &r, "org_slug", 5, "nope"), sql.ErrNoRows))
+
+	a.EqualError(FindByNaturalKey(context.Background(), db, &r, 5), "FindByNaturalKey: natural key org_slug of NaturalKeyObject has 2 field(s); got 1 value(s)")
+	a.EqualError(FindByNaturalKeyName(context.Background(), db, &r, "email", 5), "FindByNaturalKey: NaturalKeyObject has no natural key named email")
+	a.EqualError(FindByNaturalKey(context.Background(), db, &SimpleObject{}, 5), "FindByNaturalKey: expected SimpleObject to have exactly one natural key; found []")
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
@@ -0,0 +1,99 @@
+package sorm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+type Notification struct {
+	Table     string                 `json:"table"`
+	Operation string                 `json:"op"`
+	PK        map[string]interface{} `json:"pk"`
+}
+
+// EnableNotifications makes every create, save, replace and delete send a
+// Postgres NOTIFY on channel describing the changed record. Notifications
+// sent inside a transaction are delivered when it commits.
+func EnableNotifications(channel string) {
+	for _, op := range []Operation{OperationCreate, OperationSave, OperationReplace, OperationDelete} {
+		RegisterCallback(op, PhaseAfter, func(ctx context.Context, db Querier, e *CallbackEvent) error {
+			return notify(ctx, db, channel, e)
+		})
+	}
+}
+
+func notify(ctx context.Context, db Querier, channel string, e *CallbackEvent) error {
+	v := reflect.Indirect(reflect.ValueOf(e.Value))
+
+	vdesc, err := getDescriptionContext(ctx, v.Type())
+	if err != nil {
+		return fmt.Errorf("could not get detailed reflection information for type %s: %w", v.Type().String(), err)
+	}
+
+	n := Notification{Table: e.Table, Operation: e.Operation.String(), PK: make(map[string]interface{})}
+	for _, f := range getSQLIDFields(vdesc) {
+		n.PK[getSQLColumnName(f)] = v.FieldByIndex(f.Index()).Interface()
+	}
+
+	payload, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+
+	query := "select pg_notify(" + makeParameter(1) + ", " + makeParameter(2) + ")"
+	args := []interface{}{channel, string(payload)}
+
+	logQuery(ctx, query, args)
+
+	start := time.Now()
+
+	if _, err := db.ExecContext(ctx, query, args...); err != nil {
+		logQueryAfter(ctx, query, args, start, err)
+
+		return err
+	}
+
+	logQueryAfter(ctx, query, args, start, nil)
+
+	return nil
+}
+
+// NotificationConn is a dedicated connection that can receive asynchronous
+// notifications. database/sql can't deliver these, so drivers need a small
+// adapter; for lib/pq that's a wrapper around pq.Listener, and for pgx one
+// around pgx.Conn.WaitForNotification.
+type NotificationConn interface {
+	Listen(ctx context.Context, channel string) error
+	WaitForNotification(ctx context.Context) (string, error)
+}
+
+// Listen subscribes conn to channel and calls handler for each notification
+// sent by EnableNotifications until ctx is cancelled or handler fails.
+func Listen(ctx context.Context, conn NotificationConn, channel string, handler func(ctx context.Context, n Notification) error) error {
+	if err := conn.Listen(ctx, channel); err != nil {
+		return fmt.Errorf("Listen: couldn't listen on %s: %w", channel, err)
+	}
+
+	for {
+		payload, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			return fmt.Errorf("Listen: %w", err)
+		}
+
+		var n Notification
+		if err := json.Unmarshal([]byte(payload), &n); err != nil {
+			return fmt.Errorf("Listen: couldn't decode notification %q: %w", payload, err)
+		}
+
+		if err := handler(ctx, n); err != nil {
+			return fmt.Errorf("Listen: %w", err)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package sorm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnableNotifications(t *testing.T) {
+	a := assert.New(t)
+
+	defer func(l []callback) { callbacks = l }(callbacks)
+	EnableNotifications("changes")
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectExec(`insert into simple_objects \(id, name\) values \(\$1, \$2\)`).WithArgs(1, "test1").WillReturnResult(sqlmock.NewResult(1, 1))
+	mockDB.ExpectExec(`select pg_notify\(\$1, \$2\)`).WithArgs("changes", `{"table":"simple_objects","op":"create","pk":{"id":1}}`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	a.NoError(CreateRecord(context.Background(), db, &SimpleObject{ID: 1, Name: "test1"}))
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+type testNotificationConn struct {
+	channel  string
+	payloads []string
+}
+
+func (c *testNotificationConn) Listen(ctx context.Context, channel string) error {
+	c.channel = channel
+	return nil
+}
+
+func (c *testNotificationConn) WaitForNotification(ctx context.Context) (string, error) {
+	if len(c.payloads) == 0 {
+		return "", errors.New("connection closed")
+	}
+
+	p := c.payloads[0]
+	c.payloads = c.payloads[1:]
+
+	return p, nil
+}
+
+func TestListen(t *testing.T) {
+	a := assert.New(t)
+
+	conn := testNotificationConn{payloads: []string{
+		`{"table":"simple_objects","op":"create","pk":{"id":1}}`,
+		`{"table":"simple_objects","op":"delete","pk":{"id":2}}`,
+	}}
+
+	var got []Notification
+	err := Listen(context.Background(), &conn, "changes", func(ctx context.Context, n Notification) error {
+		got = append(got, n)
+		return nil
+	})
+
+	a.EqualError(err, "Listen: connection closed")
+	a.Equal("changes", conn.channel)
+	a.Equal([]Notification{
+		{Table: "simple_objects", Operation: "create", PK: map[string]interface{}{"id": float64(1)}},
+		{Table: "simple_objects", Operation: "delete", PK: map[string]interface{}{"id": float64(2)}},
+	}, got)
+}
@@ -0,0 +1,237 @@
+package sorm
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Null is a nullable column value that scans, writes and marshals to JSON
+// consistently: a null column is a JSON null and vice versa.
+type Null[T any] struct {
+	V     T
+	Valid bool
+}
+
+func NewNull[T any](v T) Null[T] {
+	return Null[T]{V: v, Valid: true}
+}
+
+// Ptr returns a pointer to a copy of the value, or nil if it's null.
+func (n Null[T]) Ptr() *T {
+	if !n.Valid {
+		return nil
+	}
+
+	v := n.V
+
+	return &v
+}
+
+func (n *Null[T]) Scan(src interface{}) error {
+	var zero T
+
+	if src == nil {
+		n.V, n.Valid = zero, false
+		return nil
+	}
+
+	if s, ok := interface{}(&n.V).(sql.Scanner); ok {
+		if err := s.Scan(src); err != nil {
+			return err
+		}
+
+		n.Valid = true
+
+		return nil
+	}
+
+	if v, ok := src.(T); ok {
+		if b, ok := src.([]byte); ok {
+			// drivers may reuse the buffer after the next call to Next
+			v = interface{}(append([]byte(nil), b...)).(T)
+		}
+
+		n.V, n.Valid = v, true
+		return nil
+	}
+
+	out := reflect.ValueOf(&n.V).Elem()
+	if err := convertNullValue(out, src); err != nil {
+		return fmt.Errorf("Null: can't scan %T into %s: %w", src, out.Type(), err)
+	}
+
+	n.Valid = true
+
+	return nil
+}
+
+func convertNullValue(out reflect.Value, src interface{}) error {
+	in := reflect.ValueOf(src)
+
+	var text string
+	switch v := src.(type) {
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	default:
+		if out.Kind() == reflect.Bool && in.CanInt() {
+			out.SetBool(in.Int() != 0)
+			return nil
+		}
+
+		if !in.Type().ConvertibleTo(out.Type()) || out.Kind() == reflect.String {
+			return fmt.Errorf("unsupported conversion")
+		}
+
+		out.Set(in.Convert(out.Type()))
+
+		return nil
+	}
+
+	switch out.Kind() {
+	case reflect.String:
+		out.SetString(text)
+	case reflect.Bool:
+		v, err := strconv.ParseBool(text)
+		if err != nil {
+			return err
+		}
+		out.SetBool(v)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v, err := strconv.ParseInt(text, 10, out.Type().Bits())
+		if err != nil {
+			return err
+		}
+		out.SetInt(v)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v, err := strconv.ParseUint(text, 10, out.Type().Bits())
+		if err != nil {
+			return err
+		}
+		out.SetUint(v)
+	case reflect.Float32, reflect.Float64:
+		v, err := strconv.ParseFloat(text, out.Type().Bits())
+		if err != nil {
+			return err
+		}
+		out.SetFloat(v)
+	case reflect.Slice:
+		if out.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("unsupported conversion")
+		}
+		out.SetBytes([]byte(text))
+	default:
+		return fmt.Errorf("unsupported conversion")
+	}
+
+	return nil
+}
+
+func (n Null[T]) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+
+	if v, ok := interface{}(n.V).(driver.Valuer); ok {
+		return v.Value()
+	}
+
+	return driver.DefaultParameterConverter.ConvertValue(n.V)
+}
+
+func (n Null[T]) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(n.V)
+}
+
+func (n *Null[T]) UnmarshalJSON(d []byte) error {
+	if string(d) == "null" {
+		var zero T
+		n.V, n.Valid = zero, false
+		return nil
+	}
+
+	if err := json.Unmarshal(d, &n.V); err != nil {
+		return err
+	}
+
+	n.Valid = true
+
+	return nil
+}
+
+var valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+
+// sqlValue is the argument written for a field. Pointer fields are
+// dereferenced, with nil becoming NULL, unless the pointer is itself a
+// driver.Valuer.
+func sqlValue(v reflect.Value) interface{} {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+
+		if v.Type().Implements(valuerType) {
+			break
+		}
+
+		v = v.Elem()
+	}
+
+	// drivers don't take arrays, but [16]byte is a common way to hold a uuid
+	if v.Kind() == reflect.Array && v.Type().Elem().Kind() == reflect.Uint8 && !v.Type().Implements(valuerType) {
+		b := make([]byte, v.Len())
+		reflect.Copy(reflect.ValueOf(b), v)
+		return b
+	}
+
+	return v.Interface()
+}
+
+func isByteArray(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Array && typ.Elem().Kind() == reflect.Uint8 && !reflect.PtrTo(typ).Implements(scannerType)
+}
+
+// byteArrayScanner reads bytes of exactly the right length, or for [16]byte
+// a uuid in its text form, into a byte array field.
+type byteArrayScanner struct{ v reflect.Value }
+
+func (s byteArrayScanner) Scan(src interface{}) error {
+	var b []byte
+	switch t := src.(type) {
+	case nil:
+		s.v.Set(reflect.Zero(s.v.Type()))
+		return nil
+	case []byte:
+		b = t
+	case string:
+		b = []byte(t)
+	default:
+		return fmt.Errorf("couldn't scan %T into %s", src, s.v.Type())
+	}
+
+	if len(b) != s.v.Len() && s.v.Len() == 16 {
+		d, err := hex.DecodeString(strings.ReplaceAll(string(b), "-", ""))
+		if err == nil {
+			b = d
+		}
+	}
+
+	if len(b) != s.v.Len() {
+		return fmt.Errorf("couldn't scan %d bytes into %s", len(b), s.v.Type())
+	}
+
+	reflect.Copy(s.v, reflect.ValueOf(b))
+
+	return nil
+}
@@ -0,0 +1,144 @@
+package sorm
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type NullObject struct {
+	ID      int
+	Score   Null[int]
+	Label   Null[string]
+	Active  Null[bool]
+	Created Null[time.Time]
+}
+
+func TestNullScanAndWrite(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	mockDB.ExpectQuery(`select \* from null_objects`).WillReturnRows(sqlmock.NewRows([]string{"id", "score", "label", "active", "created"}).AddRow(1, int64(5), []byte("a"), int64(1), created).AddRow(2, []byte("7"), nil, nil, nil))
+	mockDB.ExpectExec(`insert into null_objects \(id, score, label, active, created\) values \(\$1, \$2, \$3, \$4, \$5\)`).WithArgs(3, 9, nil, false, nil).WillReturnResult(sqlmock.NewResult(3, 1))
+
+	var l []NullObject
+	if !a.NoError(FindAll(context.Background(), db, &l)) {
+		return
+	}
+
+	a.Equal([]NullObject{
+		{ID: 1, Score: NewNull(5), Label: NewNull("a"), Active: NewNull(true), Created: NewNull(created)},
+		{ID: 2, Score: NewNull(7)},
+	}, l)
+
+	a.NoError(CreateRecord(context.Background(), db, &NullObject{ID: 3, Score: NewNull(9), Active: NewNull(false)}))
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestNullScanError(t *testing.T) {
+	a := assert.New(t)
+
+	var n Null[int]
+	a.EqualError(n.Scan(time.Time{}), "Null: can't scan time.Time into int: unsupported conversion")
+	a.Error(n.Scan("abc"))
+	a.False(n.Valid)
+}
+
+func TestNullJSON(t *testing.T) {
+	a := assert.New(t)
+
+	d, err := json.Marshal(NullObject{ID: 1, Score: NewNull(5)})
+	if !a.NoError(err) {
+		return
+	}
+
+	a.Equal(`{"ID":1,"Score":5,"Label":null,"Active":null,"Created":null}`, string(d))
+
+	var o NullObject
+	a.NoError(json.Unmarshal([]byte(`{"ID":1,"Score":null,"Label":"b"}`), &o))
+	a.Equal(NullObject{ID: 1, Label: NewNull("b")}, o)
+
+	a.Nil(o.Score.Ptr())
+	a.Equal("b", *o.Label.Ptr())
+}
+
+func TestNullCreateTable(t *testing.T) {
+	a := assert.New(t)
+
+	s, err := CreateTableStatement(NullObject{})
+	if !a.NoError(err) {
+		return
+	}
+
+	a.Equal("create table null_objects (id integer not null, score integer, label text, active boolean, created timestamp, primary key (id))", s.Query)
+}
+
+type PointerObject struct {
+	ID       *int64
+	Nickname *string
+	Age      *int64
+}
+
+func TestPointerFieldsCreate(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`insert into pointer_objects \(nickname, age\) values \(\$1, \$2\) returning id`).WithArgs(nil, int64(0)).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	age := int64(0)
+	r := PointerObject{Age: &age}
+	a.NoError(CreateRecord(context.Background(), db, &r))
+	if a.NotNil(r.ID) {
+		a.Equal(int64(1), *r.ID)
+	}
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestPointerFieldsScanAndSave(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from pointer_objects`).WillReturnRows(sqlmock.NewRows([]string{"id", "nickname", "age"}).AddRow(1, nil, 30))
+
+	var r PointerObject
+	if !a.NoError(FindFirst(context.Background(), db, &r)) {
+		return
+	}
+	a.Nil(r.Nickname)
+	if a.NotNil(r.Age) {
+		a.Equal(int64(30), *r.Age)
+	}
+
+	mockDB.ExpectQuery(`select \* from pointer_objects where id = \$1`).WithArgs(int64(1)).WillReturnRows(sqlmock.NewRows([]string{"id", "nickname", "age"}).AddRow(1, nil, 30))
+	mockDB.ExpectExec(`update pointer_objects set nickname = \$2, age = \$3 where id = \$1`).WithArgs(int64(1), "bob", nil).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	nick := "bob"
+	r.Nickname = &nick
+	r.Age = nil
+	a.NoError(SaveRecord(context.Background(), db, &r))
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
@@ -0,0 +1,153 @@
+package sorm
+
+import (
+	"context"
+	"time"
+)
+
+// Options overrides process-wide settings for calls made with a context
+// returned by WithOptions. Zero fields fall back to the global setting.
+type Options struct {
+	QueryLogger      QueryLogger
+	QueryLoggerV2    QueryLoggerV2
+	MetricsCollector MetricsCollector
+	HookTimeout      time.Duration
+	Tracer           Tracer
+	// Schema qualifies table names that don't already name a schema.
+	Schema string
+	// SlowQueryThreshold limits logging to failed queries and those that
+	// took at least this long. Loggers without LogQueryAfter are called once
+	// the query has run.
+	SlowQueryThreshold time.Duration
+	// MaxRows makes finds fail once they scan more than this many rows.
+	MaxRows int
+	// DefaultLimit is added to FindWhere and FindAll calls that don't have
+	// a limit. With StrictLimit they fail instead.
+	DefaultLimit int
+	// StrictLimit makes finds without a limit fail with an
+	// *UnboundedQueryError instead of getting DefaultLimit.
+	StrictLimit bool
+	// Strict enables safe scanning.
+	Strict bool
+	// AllowUnmatchedColumns makes ScanRows discard result columns that don't
+	// match a field instead of failing.
+	AllowUnmatchedColumns bool
+	// Router picks the database for each call. Finds made with a Router
+	// skip the result cache.
+	Router Router
+	// CacheNamespace keeps the result cache entries of one database apart
+	// from those of others sharing the same Cache.
+	CacheNamespace string
+	// TableNamer renames the tables of every model.
+	TableNamer TableNamer
+	// NamingStrategy derives table names for models without a table tag
+	// and column names for fields without one.
+	NamingStrategy NamingStrategy
+}
+
+type optionsKey struct{}
+
+func WithOptions(ctx context.Context, o Options) context.Context {
+	if p, ok := ctx.Value(optionsKey{}).(Options); ok {
+		if o.QueryLogger == nil {
+			o.QueryLogger = p.QueryLogger
+		}
+		if o.QueryLoggerV2 == nil {
+			o.QueryLoggerV2 = p.QueryLoggerV2
+		}
+		if o.MetricsCollector == nil {
+			o.MetricsCollector = p.MetricsCollector
+		}
+		if o.HookTimeout == 0 {
+			o.HookTimeout = p.HookTimeout
+		}
+		if o.Tracer == nil {
+			o.Tracer = p.Tracer
+		}
+		if o.Schema == "" {
+			o.Schema = p.Schema
+		}
+		if o.SlowQueryThreshold == 0 {
+			o.SlowQueryThreshold = p.SlowQueryThreshold
+		}
+		if o.MaxRows == 0 {
+			o.MaxRows = p.MaxRows
+		}
+		if o.DefaultLimit == 0 {
+			o.DefaultLimit = p.DefaultLimit
+		}
+		if !o.StrictLimit {
+			o.StrictLimit = p.StrictLimit
+		}
+		if !o.Strict {
+			o.Strict = p.Strict
+		}
+		if !o.AllowUnmatchedColumns {
+			o.AllowUnmatchedColumns = p.AllowUnmatchedColumns
+		}
+		if o.Router == nil {
+			o.Router = p.Router
+		}
+		if o.CacheNamespace == "" {
+			o.CacheNamespace = p.CacheNamespace
+		}
+		if o.TableNamer == nil {
+			o.TableNamer = p.TableNamer
+		}
+		if o.NamingStrategy == nil {
+			o.NamingStrategy = p.NamingStrategy
+		}
+	}
+
+	return context.WithValue(ctx, optionsKey{}, o)
+}
+
+func optionsFrom(ctx context.Context) Options {
+	o, _ := ctx.Value(optionsKey{}).(Options)
+
+	if o.QueryLogger == nil {
+		o.QueryLogger = queryLogger
+	}
+	if o.QueryLoggerV2 == nil {
+		o.QueryLoggerV2 = queryLoggerV2
+	}
+	if o.MetricsCollector == nil {
+		o.MetricsCollector = metricsCollector
+	}
+	if o.HookTimeout == 0 {
+		o.HookTimeout = hookTimeout
+	}
+	if o.Tracer == nil {
+		o.Tracer = tracer
+	}
+	if !o.AllowUnmatchedColumns {
+		o.AllowUnmatchedColumns = allowUnmatchedColumns
+	}
+	if o.Router == nil {
+		o.Router = router
+	}
+	if o.TableNamer == nil {
+		o.TableNamer = tableNamer
+	}
+	if o.NamingStrategy == nil {
+		o.NamingStrategy = namingStrategy
+	}
+
+	return o
+}
+
+func getSQLTableNameContext(ctx context.Context, vdesc *structDescription) string {
+	o := optionsFrom(ctx)
+
+	tbl := getSQLTableNameWith(vdesc, o.NamingStrategy)
+
+	if o.TableNamer != nil {
+		tbl = o.TableNamer.TableName(vdesc.Type(), tbl)
+	}
+
+	if o.Schema != "" && !hasSchema(tbl) {
+		tbl = qualifyTable(o.Schema, tbl)
+	}
+
+	return tbl
+}
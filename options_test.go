@@ -0,0 +1,87 @@
+package sorm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithOptionsSchema(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	ctx := WithOptions(context.Background(), Options{Schema: "tenant_a"})
+
+	mockDB.ExpectQuery(`select \* from tenant_a\.simple_objects where id = \$1 limit 1`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "test1"))
+	mockDB.ExpectExec(`insert into tenant_a\.simple_objects \(id, name\) values \(\$1, \$2\)`).WithArgs(2, "test2").WillReturnResult(sqlmock.NewResult(2, 1))
+	mockDB.ExpectExec(`delete from simple_objects where id = \$1`).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	var r SimpleObject
+	a.NoError(FindFirstWhere(ctx, db, &r, "where id = $1", 1))
+	a.NoError(CreateRecord(ctx, db, &SimpleObject{ID: 2, Name: "test2"}))
+	a.NoError(DeleteRecord(context.Background(), db, &SimpleObject{ID: 2}))
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestWithOptionsQueryLogger(t *testing.T) {
+	a := assert.New(t)
+
+	var global, local []string
+	SetQueryLoggerFunc(func(query string, vars []interface{}) { global = append(global, query) })
+	defer SetQueryLogger(nil)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from simple_objects`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	mockDB.ExpectQuery(`select \* from simple_objects`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+
+	ctx := WithOptions(context.Background(), Options{QueryLogger: QueryLoggerFunc(func(query string, vars []interface{}) { local = append(local, query) })})
+	ctx = WithOptions(ctx, Options{Schema: ""})
+
+	var l []SimpleObject
+	a.NoError(FindAll(ctx, db, &l))
+	a.NoError(FindAll(context.Background(), db, &l))
+
+	a.Equal([]string{"select * from simple_objects"}, local)
+	a.Equal([]string{"select * from simple_objects"}, global)
+}
+
+func TestAllowUnmatchedColumns(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from simple_objects`).WillReturnRows(sqlmock.NewRows([]string{"id", "extra", "name"}).AddRow(1, "x", "a"))
+	mockDB.ExpectQuery(`select \* from simple_objects`).WillReturnRows(sqlmock.NewRows([]string{"id", "extra", "name"}).AddRow(2, "y", "b"))
+	mockDB.ExpectQuery(`select \* from simple_objects`).WillReturnRows(sqlmock.NewRows([]string{"id", "extra", "name"}).AddRow(3, "z", "c"))
+
+	var l []SimpleObject
+	a.NoError(FindAll(WithOptions(context.Background(), Options{AllowUnmatchedColumns: true}), db, &l))
+	a.Equal([]SimpleObject{{ID: 1, Name: "a"}}, l)
+
+	a.EqualError(FindAll(context.Background(), db, &l), "couldn't find fields on SimpleObject for these sql fields: [extra]")
+
+	SetAllowUnmatchedColumns(true)
+	defer SetAllowUnmatchedColumns(false)
+
+	a.NoError(FindAll(context.Background(), db, &l))
+	a.Equal([]SimpleObject{{ID: 3, Name: "c"}}, l)
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
@@ -0,0 +1,123 @@
+package sorm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+type PageInfo struct {
+	Page       int
+	PerPage    int
+	Total      int
+	TotalPages int
+	HasNext    bool
+}
+
+func FindPage(ctx context.Context, db Querier, out interface{}, where string, args []interface{}, page, perPage int) (PageInfo, error) {
+	ctx, db = route(ctx, db, out, OperationFind)
+
+	if page < 1 {
+		return PageInfo{}, fmt.Errorf("FindPage: page should be at least 1; was instead %d", page)
+	}
+
+	if perPage < 1 {
+		return PageInfo{}, fmt.Errorf("FindPage: per page should be at least 1; was instead %d", perPage)
+	}
+
+	vtyp, err := structTypeOf(out)
+	if err != nil {
+		return PageInfo{}, fmt.Errorf("FindPage: %w", err)
+	}
+
+	vdesc, err := getDescriptionContext(ctx, vtyp)
+	if err != nil {
+		return PageInfo{}, fmt.Errorf("FindPage: could not get detailed reflection information for type %s: %w", vtyp.String(), err)
+	}
+
+	o, err := findOptions{}.withDefaultScope(ctx, vtyp)
+	if err != nil {
+		return PageInfo{}, fmt.Errorf("FindPage: %w", err)
+	}
+
+	stmt, err := buildSelect(vdesc, getSQLTableNameContext(ctx, vdesc), "*", where, args, o)
+	if err != nil {
+		return PageInfo{}, fmt.Errorf("FindPage: %w", err)
+	}
+
+	total, err := countStatement(ctx, db, Statement{Query: "select count(*) from (" + stmt.Query + ") sorm_page", Args: stmt.Args})
+	if err != nil {
+		return PageInfo{}, fmt.Errorf("FindPage: %w", err)
+	}
+
+	if where != "" {
+		where = where + " "
+	}
+
+	if err := findWhere(ctx, db, out, fmt.Sprintf("%slimit %d offset %d", where, perPage, (page-1)*perPage), args, findOptions{}); err != nil {
+		return PageInfo{}, err
+	}
+
+	info := PageInfo{
+		Page:       page,
+		PerPage:    perPage,
+		Total:      total,
+		TotalPages: (total + perPage - 1) / perPage,
+	}
+	info.HasNext = page < info.TotalPages
+
+	return info, nil
+}
+
+func countStatement(ctx context.Context, db Querier, stmt Statement) (int, error) {
+	logQuery(ctx, stmt.Query, stmt.Args)
+
+	start := time.Now()
+
+	var n int
+	if err := db.QueryRowContext(ctx, stmt.Query, stmt.Args...).Scan(&n); err != nil {
+		logQueryAfter(ctx, stmt.Query, stmt.Args, start, err)
+
+		return 0, err
+	}
+
+	logQueryAfter(ctx, stmt.Query, stmt.Args, start, nil)
+
+	return n, nil
+}
+
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	Total      int64  `json:"total"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+func (p Page[T]) MarshalJSON() ([]byte, error) {
+	type page Page[T]
+
+	if p.Items == nil {
+		p.Items = []T{}
+	}
+
+	return json.Marshal(page(p))
+}
+
+// FindPageOf is FindPage returning a Page envelope. NextCursor holds the next
+// page number, and is empty on the last page.
+func FindPageOf[T any](ctx context.Context, db Querier, where string, args []interface{}, page, perPage int) (Page[T], error) {
+	var items []T
+
+	info, err := FindPage(ctx, db, &items, where, args, page, perPage)
+	if err != nil {
+		return Page[T]{}, err
+	}
+
+	p := Page[T]{Items: items, Total: int64(info.Total)}
+	if info.HasNext {
+		p.NextCursor = strconv.Itoa(page + 1)
+	}
+
+	return p, nil
+}
@@ -0,0 +1,81 @@
+package sorm
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindPage(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select count\(\*\) from \(select \* from objects where id > \$1 order by id\) sorm_page`).WithArgs(0).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+	mockDB.ExpectQuery(`select \* from objects where id > \$1 order by id limit 2 offset 2`).WithArgs(0).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(3, "c").AddRow(4, "d"))
+
+	var r []Object
+	info, err := FindPage(context.Background(), db, &r, "where id > $1 order by id", []interface{}{0}, 2, 2)
+	a.NoError(err)
+
+	a.Equal(PageInfo{Page: 2, PerPage: 2, Total: 5, TotalPages: 3, HasNext: true}, info)
+	a.Equal([]Object{{ID: 3, Name: "c"}, {ID: 4, Name: "d"}}, r)
+}
+
+func TestFindPageLast(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select count\(\*\) from \(select \* from objects\) sorm_page`).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(4))
+	mockDB.ExpectQuery(`select \* from objects limit 2 offset 2`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(3, "c").AddRow(4, "d"))
+
+	var r []Object
+	info, err := FindPage(context.Background(), db, &r, "", nil, 2, 2)
+	a.NoError(err)
+
+	a.Equal(PageInfo{Page: 2, PerPage: 2, Total: 4, TotalPages: 2, HasNext: false}, info)
+}
+
+func TestFindPageOf(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select count\(\*\) from \(select \* from objects\) sorm_page`).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mockDB.ExpectQuery(`select \* from objects limit 2 offset 0`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a").AddRow(2, "b"))
+
+	p, err := FindPageOf[Object](context.Background(), db, "", nil, 1, 2)
+	if !a.NoError(err) {
+		return
+	}
+
+	a.Equal(Page[Object]{Items: []Object{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}}, Total: 3, NextCursor: "2"}, p)
+}
+
+func TestPageMarshalJSON(t *testing.T) {
+	a := assert.New(t)
+
+	b, err := json.Marshal(Page[Object]{Total: 0})
+	a.NoError(err)
+	a.Equal(`{"items":[],"total":0}`, string(b))
+
+	b, err = json.Marshal(Page[Object]{Items: []Object{{ID: 1, Name: "a"}}, Total: 3, NextCursor: "2"})
+	a.NoError(err)
+	a.Equal(`{"items":[{"ID":1,"Name":"a"}],"total":3,"next_cursor":"2"}`, string(b))
+}
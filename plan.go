@@ -0,0 +1,289 @@
+package sorm
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+type ModelDescription struct {
+	Type   string             `json:"type"`
+	Name   string             `json:"name"`
+	Table  string             `json:"table"`
+	Fields []FieldDescription `json:"fields"`
+}
+
+type FieldDescription struct {
+	Name     string            `json:"name"`
+	Index    []int             `json:"index"`
+	Column   string            `json:"column,omitempty"`
+	Alias    string            `json:"alias,omitempty"`
+	Snake    string            `json:"snake"`
+	Prefix   string            `json:"prefix,omitempty"`
+	JSON     bool              `json:"json,omitempty"`
+	Compress string            `json:"compress,omitempty"`
+	Nested   *ModelDescription `json:"nested,omitempty"`
+	// Tag is the field's whole struct tag, which imported descriptions use
+	// instead of parsing the model's.
+	Tag string `json:"tag,omitempty"`
+}
+
+func (d *ModelDescription) fieldForColumn(name string) *FieldDescription {
+	var tagged *FieldDescription
+	var count int
+	for i := range d.Fields {
+		if d.Fields[i].Column == name || (d.Fields[i].Alias != "" && d.Fields[i].Alias == name) {
+			tagged = &d.Fields[i]
+			count++
+		}
+	}
+	if count == 1 {
+		return tagged
+	}
+
+	for i := range d.Fields {
+		if d.Fields[i].Name == name && d.Fields[i].Column != "-" {
+			return &d.Fields[i]
+		}
+	}
+
+	for i := range d.Fields {
+		if d.Fields[i].Snake == name && d.Fields[i].Column != "-" {
+			return &d.Fields[i]
+		}
+	}
+
+	for i := range d.Fields {
+		f := &d.Fields[i]
+		if f.Nested == nil || !strings.HasPrefix(name, f.Prefix) {
+			continue
+		}
+
+		if nf := f.Nested.fieldForColumn(strings.TrimPrefix(name, f.Prefix)); nf != nil {
+			return &FieldDescription{
+				Name:     f.Name + "." + nf.Name,
+				Index:    append(append([]int(nil), f.Index...), nf.Index...),
+				JSON:     nf.JSON,
+				Compress: nf.Compress,
+			}
+		}
+	}
+
+	return nil
+}
+
+var (
+	planCache     = map[reflect.Type]*ModelDescription{}
+	importedPlans = map[reflect.Type]bool{}
+	planCacheLock sync.RWMutex
+)
+
+func typeKey(typ reflect.Type) string {
+	if typ.PkgPath() == "" {
+		return typ.String()
+	}
+
+	return typ.PkgPath() + "." + typ.Name()
+}
+
+func buildPlan(typ reflect.Type, naming NamingStrategy) (*ModelDescription, error) {
+	vdesc, err := getDescriptionFromType(typ)
+	if err != nil {
+		return nil, err
+	}
+
+	d := ModelDescription{
+		Type:  typeKey(typ),
+		Name:  vdesc.Name(),
+		Table: getSQLTableNameWith(vdesc, naming),
+	}
+
+	for _, f := range vdesc.Fields() {
+		fd := FieldDescription{
+			Name:  f.Name(),
+			Index: f.Index(),
+			Snake: naming.ColumnName(f.Name()),
+			Tag:   string(f.raw),
+		}
+
+		if t := f.Tag("sql"); t != nil {
+			fd.Column = t.Value()
+			if isSQLRelation(f) {
+				fd.Column = "-"
+			}
+
+			if p := t.Parameter("alias"); p != nil {
+				fd.Alias = p.Value()
+			}
+
+			if t.Parameter("json") != nil {
+				fd.JSON = true
+			}
+
+			if p := t.Parameter("compress"); p != nil {
+				fd.Compress = p.Value()
+			}
+
+			if p := t.Parameter("prefix"); p != nil && p.Value() != "" {
+				ftyp := typ.FieldByIndex(f.Index()).Type
+				if ftyp.Kind() != reflect.Struct {
+					return nil, fmt.Errorf("field %s on %s has a prefix but isn't a struct", f.Name(), typ.Name())
+				}
+
+				nested, err := buildPlan(ftyp, naming)
+				if err != nil {
+					return nil, err
+				}
+
+				fd.Prefix = p.Value()
+				fd.Nested = nested
+			}
+		}
+
+		if hiddenField(vdesc, f) {
+			fd.Column = "-"
+		}
+
+		d.Fields = append(d.Fields, fd)
+	}
+
+	return &d, nil
+}
+
+func getPlanFromType(typ reflect.Type) (*ModelDescription, error) {
+	planCacheLock.RLock()
+	d, ok := planCache[typ]
+	planCacheLock.RUnlock()
+	if ok {
+		return d, nil
+	}
+
+	d, err := buildPlan(typ, namingStrategy)
+	if err != nil {
+		return nil, err
+	}
+
+	planCacheLock.Lock()
+	planCache[typ] = d
+	planCacheLock.Unlock()
+
+	return d, nil
+}
+
+func structTypeOf(v interface{}) (reflect.Type, error) {
+	typ := reflect.TypeOf(v)
+	for typ != nil && (typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Slice) {
+		typ = typ.Elem()
+	}
+
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return nil, typeErrorf(ErrNotAStruct, "expected a struct, pointer to struct, or slice of struct; was instead %T", v)
+	}
+
+	return typ, nil
+}
+
+func ExportDescriptions(models ...interface{}) ([]byte, error) {
+	var l []*ModelDescription
+
+	for _, m := range models {
+		typ, err := structTypeOf(m)
+		if err != nil {
+			return nil, fmt.Errorf("ExportDescriptions: %w", err)
+		}
+
+		d, err := getPlanFromType(typ)
+		if err != nil {
+			return nil, fmt.Errorf("ExportDescriptions: could not get detailed reflection information for type %s: %w", typ.String(), err)
+		}
+
+		l = append(l, d)
+	}
+
+	return json.Marshal(l)
+}
+
+// ImportDescriptions loads descriptions made by ExportDescriptions for
+// models, so that sorm uses them instead of reflecting over the models' tags.
+// Each model's fields have to match its description exactly.
+func ImportDescriptions(data []byte, models ...interface{}) error {
+	var l []*ModelDescription
+	if err := json.Unmarshal(data, &l); err != nil {
+		return fmt.Errorf("ImportDescriptions: %w", err)
+	}
+
+	m := make(map[string]*ModelDescription)
+	for _, d := range l {
+		m[d.Type] = d
+	}
+
+	for _, v := range models {
+		typ, err := structTypeOf(v)
+		if err != nil {
+			return fmt.Errorf("ImportDescriptions: %w", err)
+		}
+
+		d, ok := m[typeKey(typ)]
+		if !ok {
+			return fmt.Errorf("ImportDescriptions: no description found for type %s", typ.String())
+		}
+
+		if err := importDescription(typ, d); err != nil {
+			return fmt.Errorf("ImportDescriptions: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// importDescription checks d against typ and caches it, along with the
+// descriptions of the types of prefixed fields.
+func importDescription(typ reflect.Type, d *ModelDescription) error {
+	fields := reflect.VisibleFields(typ)
+	if len(fields) != len(d.Fields) {
+		return fmt.Errorf("description for type %s has %d fields, but the type has %d", typ.String(), len(d.Fields), len(fields))
+	}
+
+	for i, f := range d.Fields {
+		sf := fields[i]
+		if sf.Name != f.Name || !reflect.DeepEqual(sf.Index, f.Index) || string(sf.Tag) != f.Tag {
+			return fmt.Errorf("description for type %s doesn't match field %s", typ.String(), sf.Name)
+		}
+
+		if f.Nested != nil {
+			if sf.Type.Kind() != reflect.Struct {
+				return fmt.Errorf("description for type %s has a prefix on field %s, which isn't a struct", typ.String(), sf.Name)
+			}
+
+			if err := importDescription(sf.Type, f.Nested); err != nil {
+				return err
+			}
+		}
+	}
+
+	descriptionCacheLock.Lock()
+	descriptionCache[typ] = describeImported(typ, d)
+	descriptionCacheLock.Unlock()
+
+	planCacheLock.Lock()
+	planCache[typ] = d
+	importedPlans[typ] = true
+	planCacheLock.Unlock()
+
+	namedCacheLock.Lock()
+	for k := range namedDescriptions {
+		if k.typ == typ {
+			delete(namedDescriptions, k)
+		}
+	}
+	for k := range namedPlans {
+		if k.typ == typ {
+			delete(namedPlans, k)
+		}
+	}
+	namedCacheLock.Unlock()
+
+	return nil
+}
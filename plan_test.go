@@ -0,0 +1,181 @@
+package sorm
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type PlanObject struct {
+	ID    int
+	Title string `sql:"heading"`
+}
+
+func TestExportImportDescriptions(t *testing.T) {
+	a := assert.New(t)
+
+	data, err := ExportDescriptions(PlanObject{})
+	if !a.NoError(err) {
+		return
+	}
+
+	planCacheLock.Lock()
+	delete(planCache, reflect.TypeOf(PlanObject{}))
+	planCacheLock.Unlock()
+
+	a.NoError(ImportDescriptions(data, &PlanObject{}))
+	a.Equal("plan_objects", TableName(PlanObject{}))
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from plan_objects`).WillReturnRows(sqlmock.NewRows([]string{"id", "heading"}).AddRow(1, "test1"))
+
+	var r []PlanObject
+	a.NoError(FindAll(context.Background(), db, &r))
+
+	a.Equal([]PlanObject{{ID: 1, Title: "test1"}}, r)
+}
+
+func TestImportDescriptionsMismatch(t *testing.T) {
+	a := assert.New(t)
+
+	type OtherObject struct {
+		Name string
+	}
+
+	data, err := ExportDescriptions(PlanObject{})
+	if !a.NoError(err) {
+		return
+	}
+
+	a.Error(ImportDescriptions(data, OtherObject{}))
+
+	type PlanObjectMore struct {
+		ID    int
+		Title string `sql:"heading"`
+		Extra string
+	}
+
+	data, err = ExportDescriptions(PlanObjectMore{})
+	if !a.NoError(err) {
+		return
+	}
+
+	// pretend the description was made before Extra was added
+	var l []*ModelDescription
+	if !a.NoError(json.Unmarshal(data, &l)) {
+		return
+	}
+	l[0].Fields = l[0].Fields[:2]
+	short, _ := json.Marshal(l)
+
+	a.EqualError(ImportDescriptions(short, PlanObjectMore{}), "ImportDescriptions: description for type sorm.PlanObjectMore has 2 fields, but the type has 3")
+
+	l[0].Fields = append(l[0].Fields, FieldDescription{Name: "Extra", Index: []int{2}, Tag: `sql:"extra"`})
+	changed, _ := json.Marshal(l)
+
+	a.EqualError(ImportDescriptions(changed, PlanObjectMore{}), "ImportDescriptions: description for type sorm.PlanObjectMore doesn't match field Extra")
+}
+
+type ImportedObject struct {
+	ID    int    `sql:",id" table:"imported"`
+	Name  string `sql:"display_name,alias:name" json:"name"`
+	Email string `sql:",collate:nocase" validate:"unique"`
+	Note  string `sql:"-"`
+}
+
+func TestImportDescriptionsQueries(t *testing.T) {
+	a := assert.New(t)
+
+	typ := reflect.TypeOf(ImportedObject{})
+
+	data, err := ExportDescriptions(ImportedObject{})
+	if !a.NoError(err) {
+		return
+	}
+
+	reflected, err := describeStruct(typ)
+	if !a.NoError(err) {
+		return
+	}
+
+	descriptionCacheLock.Lock()
+	delete(descriptionCache, typ)
+	descriptionCacheLock.Unlock()
+	planCacheLock.Lock()
+	delete(planCache, typ)
+	planCacheLock.Unlock()
+
+	a.NoError(ImportDescriptions(data, ImportedObject{}))
+
+	descriptionCacheLock.RLock()
+	imported := descriptionCache[typ]
+	descriptionCacheLock.RUnlock()
+	a.Equal(reflected, imported)
+
+	SetNamingStrategy(SnakeCaseNaming{Pluralize: true})
+	defer SetNamingStrategy(nil)
+
+	planCacheLock.RLock()
+	_, kept := planCache[typ]
+	planCacheLock.RUnlock()
+	a.True(kept)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`^select 1 from imported where email = \$1 limit 1$`).WithArgs("a@example.com").WillReturnRows(sqlmock.NewRows([]string{"1"}))
+	mockDB.ExpectExec(`^insert into imported \(id, display_name, email\) values \(\$1, \$2, \$3\)$`).WithArgs(1, "a", "a@example.com").WillReturnResult(sqlmock.NewResult(1, 1))
+	mockDB.ExpectQuery(`^select \* from imported where id = \$1 limit 1$`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "name", "email"}).AddRow(1, "a", "a@example.com"))
+	mockDB.ExpectExec(`^delete from imported where id = \$1$`).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	a.NoError(CreateRecord(context.Background(), db, &ImportedObject{ID: 1, Name: "a", Email: "a@example.com"}))
+
+	var r ImportedObject
+	a.NoError(FindByID(context.Background(), db, &r, 1))
+	a.Equal(ImportedObject{ID: 1, Name: "a", Email: "a@example.com"}, r)
+
+	a.NoError(DeleteRecord(context.Background(), db, &r))
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+type AliasObject struct {
+	ID          int
+	DisplayName string `sql:"display_name,alias:name"`
+}
+
+func TestAliasScanAndWrite(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from alias_objects`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "old"))
+	mockDB.ExpectQuery(`select \* from alias_objects`).WillReturnRows(sqlmock.NewRows([]string{"id", "display_name"}).AddRow(2, "new"))
+	mockDB.ExpectExec(`insert into alias_objects \(id, display_name\) values \(\$1, \$2\)`).WithArgs(3, "test").WillReturnResult(sqlmock.NewResult(3, 1))
+
+	var r []AliasObject
+	a.NoError(FindAll(context.Background(), db, &r))
+	a.Equal([]AliasObject{{ID: 1, DisplayName: "old"}}, r)
+
+	a.NoError(FindAll(context.Background(), db, &r))
+	a.Equal([]AliasObject{{ID: 2, DisplayName: "new"}}, r)
+
+	a.NoError(CreateRecord(context.Background(), db, &AliasObject{ID: 3, DisplayName: "test"}))
+	a.NoError(mockDB.ExpectationsWereMet())
+}
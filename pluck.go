@@ -0,0 +1,122 @@
+package sorm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// PluckWhere scans one column of the records matching where into out, which
+// must be a pointer to a slice. Default scopes, tenant scoping and TTL
+// filtering apply as they do for FindWhere.
+func PluckWhere(ctx context.Context, db Querier, model interface{}, column string, out interface{}, where string, args ...interface{}) error {
+	ctx, db = route(ctx, db, model, OperationFind)
+
+	ptr := reflect.ValueOf(out)
+	if ptr.Kind() != reflect.Ptr {
+		return fmt.Errorf("PluckWhere: %w", typeErrorf(ErrNotAPointer, "expected output to be a pointer; was instead %s", ptr.Kind()))
+	}
+
+	styp := ptr.Type().Elem()
+	if styp.Kind() != reflect.Slice {
+		return fmt.Errorf("PluckWhere: expected output to be pointer to slice; was instead pointer to %s", styp.Kind())
+	}
+
+	vtyp, err := structTypeOf(model)
+	if err != nil {
+		return fmt.Errorf("PluckWhere: %w", err)
+	}
+
+	vdesc, err := getDescriptionContext(ctx, vtyp)
+	if err != nil {
+		return fmt.Errorf("PluckWhere: could not get detailed reflection information for type %s: %w", vtyp.String(), err)
+	}
+
+	if err := checkIdentifier(column); err != nil {
+		return fmt.Errorf("PluckWhere: %w", err)
+	}
+
+	o, err := findOptions{}.withDefaultScope(ctx, vtyp)
+	if err != nil {
+		return fmt.Errorf("PluckWhere: %w", err)
+	}
+
+	stmt, err := buildSelect(vdesc, getSQLTableNameContext(ctx, vdesc), column, where, args, o)
+	if err != nil {
+		return fmt.Errorf("PluckWhere: %w", err)
+	}
+
+	query, args := stmt.Query, stmt.Args
+
+	logQuery(ctx, query, args)
+
+	start := time.Now()
+
+	if err := pluckRows(ctx, db, ptr, styp, query, args); err != nil {
+		logQueryAfter(ctx, query, args, start, err)
+
+		return fmt.Errorf("PluckWhere: %w", err)
+	}
+
+	logQueryAfter(ctx, query, args, start, nil)
+
+	return nil
+}
+
+func PluckAll(ctx context.Context, db Querier, model interface{}, column string, out interface{}) error {
+	return PluckWhere(ctx, db, model, column, out, "")
+}
+
+func pluckRows(ctx context.Context, db Querier, ptr reflect.Value, styp reflect.Type, query string, args []interface{}) error {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	arr := reflect.Indirect(reflect.New(styp))
+
+	for rows.Next() {
+		p := reflect.New(styp.Elem())
+
+		if err := rows.Scan(p.Interface()); err != nil {
+			return err
+		}
+
+		arr = reflect.Append(arr, p.Elem())
+	}
+
+	if err := rows.Close(); err != nil {
+		return err
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	ptr.Elem().Set(arr)
+
+	return nil
+}
+
+func ScanScalar(ctx context.Context, db Querier, out interface{}, query string, args ...interface{}) error {
+	ptr := reflect.ValueOf(out)
+	if ptr.Kind() != reflect.Ptr {
+		return fmt.Errorf("ScanScalar: %w", typeErrorf(ErrNotAPointer, "expected output to be a pointer; was instead %s", ptr.Kind()))
+	}
+
+	logQuery(ctx, query, args)
+
+	start := time.Now()
+
+	if err := db.QueryRowContext(ctx, query, args...).Scan(out); err != nil {
+		logQueryAfter(ctx, query, args, start, err)
+
+		return fmt.Errorf("ScanScalar: %w", err)
+	}
+
+	logQueryAfter(ctx, query, args, start, nil)
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package sorm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPluckWhere(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select name from objects where id > \$1`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("test2").AddRow("test3"))
+
+	var r []string
+	a.NoError(PluckWhere(context.Background(), db, Object{}, "name", &r, "where id > $1", 1))
+
+	a.Equal([]string{"test2", "test3"}, r)
+}
+
+func TestPluckAll(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select id from objects`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+
+	var r []int
+	a.NoError(PluckAll(context.Background(), db, &Object{}, "id", &r))
+
+	a.Equal([]int{1, 2}, r)
+}
+
+func TestPluckWhereScoped(t *testing.T) {
+	a := assert.New(t)
+
+	SetTenancy(&Tenancy{})
+	defer SetTenancy(nil)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	ctx := WithTenant(context.Background(), 4)
+
+	mockDB.ExpectQuery(`^select name from \(select \* from tenant_objects where \(tenant_id = \$2\)\) tenant_objects where id > \$1$`).WithArgs(1, 4).WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("a"))
+	mockDB.ExpectQuery(`^select id from \(select \* from scoped_objects where \(archived = \$1\)\) scoped_objects$`).WithArgs(false).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	var names []string
+	a.NoError(PluckWhere(ctx, db, TenantObject{}, "name", &names, "where id > $1", 1))
+	a.Equal([]string{"a"}, names)
+
+	var ids []int
+	a.NoError(PluckAll(ctx, db, ScopedObject{}, "id", &ids))
+	a.Equal([]int{1}, ids)
+
+	a.ErrorIs(PluckAll(context.Background(), db, TenantObject{}, "name", &names), ErrNoTenant)
+
+	a.EqualError(PluckAll(ctx, db, ScopedObject{}, "id; drop table x", &ids), `PluckWhere: invalid identifier "id; drop table x"`)
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestScanScalar(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select max\(id\) from objects`).WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(5))
+
+	var n int
+	a.NoError(ScanScalar(context.Background(), db, &n, "select max(id) from objects"))
+
+	a.Equal(5, n)
+}
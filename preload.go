@@ -0,0 +1,402 @@
+package sorm
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+type relationKind int
+
+const (
+	relationHasMany relationKind = iota
+	relationHasOne
+	relationBelongsTo
+)
+
+func (k relationKind) String() string {
+	switch k {
+	case relationHasMany:
+		return "has_many"
+	case relationHasOne:
+		return "has_one"
+	case relationBelongsTo:
+		return "belongs_to"
+	default:
+		return fmt.Sprintf("relationKind(%d)", int(k))
+	}
+}
+
+type relation struct {
+	kind         relationKind
+	field        structField
+	targetType   reflect.Type
+	targetColumn string
+	localIndex   []int
+}
+
+var relationKinds = map[string]relationKind{
+	"has_many":   relationHasMany,
+	"has_one":    relationHasOne,
+	"belongs_to": relationBelongsTo,
+}
+
+// getSQLRelation finds the relation declared by a field's sql tag, written as
+// `sql:"has_many:comments,fk:post_id"`, naming the related table, or as
+// `sql:"-,has_many,fk:post_id"`. Relation fields are never columns.
+func getSQLRelation(f structField) (relationKind, string, bool) {
+	t := f.Tag("sql")
+	if t == nil {
+		return 0, "", false
+	}
+
+	name, table, _ := strings.Cut(t.Value(), ":")
+	if k, ok := relationKinds[name]; ok {
+		return k, table, true
+	}
+
+	for _, name := range []string{"has_many", "has_one", "belongs_to"} {
+		if t.Parameter(name) != nil {
+			return relationKinds[name], "", true
+		}
+	}
+
+	return 0, "", false
+}
+
+func isSQLRelation(f structField) bool {
+	_, _, ok := getSQLRelation(f)
+	return ok
+}
+
+func getRelation(ctx context.Context, vtyp reflect.Type, vdesc *structDescription, name string) (*relation, error) {
+	f := vdesc.Field(name)
+	if f == nil {
+		return nil, fmt.Errorf("type %s has no field %s", vtyp.Name(), name)
+	}
+
+	kind, table, ok := getSQLRelation(*f)
+	if !ok {
+		return nil, fmt.Errorf("field %s on %s is not a relation", name, vtyp.Name())
+	}
+
+	t := f.Tag("sql")
+	if _, isKind := relationKinds[strings.SplitN(t.Value(), ":", 2)[0]]; !isKind && t.Value() != "" && t.Value() != "-" {
+		return nil, fmt.Errorf("%s field %s on %s also names column %s; write it as sql:\"%s\" or sql:\"-,%s\"", kind, name, vtyp.Name(), t.Value(), kind, kind)
+	}
+
+	r := relation{field: *f, kind: kind}
+
+	ftyp := vtyp.FieldByIndex(f.Index()).Type
+	switch r.kind {
+	case relationHasMany:
+		if ftyp.Kind() != reflect.Slice || ftyp.Elem().Kind() != reflect.Struct {
+			return nil, fmt.Errorf("%s field %s on %s should be a slice of struct; was instead %s", r.kind, name, vtyp.Name(), ftyp.String())
+		}
+	default:
+		if ftyp.Kind() != reflect.Ptr || ftyp.Elem().Kind() != reflect.Struct {
+			return nil, fmt.Errorf("%s field %s on %s should be a pointer to struct; was instead %s", r.kind, name, vtyp.Name(), ftyp.String())
+		}
+	}
+	r.targetType = ftyp.Elem()
+
+	if table != "" {
+		tdesc, err := getDescriptionContext(ctx, r.targetType)
+		if err != nil {
+			return nil, err
+		}
+
+		if tbl := getSQLUnqualifiedTableName(tdesc, optionsFrom(ctx).NamingStrategy); tbl != table {
+			return nil, fmt.Errorf("relation %s on %s names table %s, but %s is stored in %s", name, vtyp.Name(), table, r.targetType.Name(), tbl)
+		}
+	}
+
+	var fk, key string
+	if p := t.Parameter("fk"); p != nil {
+		fk = p.Value()
+	}
+	if p := t.Parameter("key"); p != nil {
+		key = p.Value()
+	}
+
+	if r.kind == relationBelongsTo {
+		if fk == "" {
+			fk = optionsFrom(ctx).NamingStrategy.ColumnName(name + "ID")
+		}
+
+		lf, err := relationKeyIndex(ctx, vtyp, fk)
+		if err != nil {
+			return nil, err
+		}
+		r.localIndex = lf
+
+		if key == "" {
+			tdesc, err := getDescriptionContext(ctx, r.targetType)
+			if err != nil {
+				return nil, err
+			}
+
+			idFields := getSQLIDFields(tdesc)
+			if len(idFields) != 1 {
+				return nil, fmt.Errorf("relation %s on %s needs %s to have exactly one ID field or an explicit key", name, vtyp.Name(), r.targetType.Name())
+			}
+
+			key = getSQLColumnName(idFields[0])
+		}
+		r.targetColumn = key
+	} else {
+		if fk == "" {
+			fk = optionsFrom(ctx).NamingStrategy.ColumnName(vdesc.Name() + "ID")
+		}
+		r.targetColumn = fk
+
+		if key != "" {
+			lf, err := relationKeyIndex(ctx, vtyp, key)
+			if err != nil {
+				return nil, err
+			}
+			r.localIndex = lf
+		} else {
+			idFields := getSQLIDFields(vdesc)
+			if len(idFields) != 1 {
+				return nil, fmt.Errorf("relation %s on %s needs exactly one ID field or an explicit key", name, vtyp.Name())
+			}
+
+			r.localIndex = idFields[0].Index()
+		}
+	}
+
+	if err := checkIdentifier(r.targetColumn); err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+func relationKeyIndex(ctx context.Context, typ reflect.Type, column string) ([]int, error) {
+	plan, err := getPlanContext(ctx, typ)
+	if err != nil {
+		return nil, err
+	}
+
+	f := plan.fieldForColumn(column)
+	if f == nil {
+		return nil, fmt.Errorf("couldn't find field on %s for key %s", typ.Name(), column)
+	}
+
+	return f.Index, nil
+}
+
+func relationKey(v reflect.Value) (interface{}, bool) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, false
+		}
+
+		v = v.Elem()
+	}
+
+	i := v.Interface()
+	if c, err := driver.DefaultParameterConverter.ConvertValue(i); err == nil {
+		i = c
+	}
+
+	if b, ok := i.([]byte); ok {
+		return string(b), true
+	}
+
+	return i, true
+}
+
+func preloadTargets(out interface{}) (reflect.Type, []reflect.Value, error) {
+	ptr := reflect.ValueOf(out)
+	if ptr.Kind() != reflect.Ptr {
+		return nil, nil, typeErrorf(ErrNotAPointer, "expected output to be a pointer; was instead %s", ptr.Kind())
+	}
+
+	switch ptr.Elem().Kind() {
+	case reflect.Struct:
+		return ptr.Elem().Type(), []reflect.Value{ptr.Elem()}, nil
+	case reflect.Slice:
+		arr := ptr.Elem()
+
+		vtyp := arr.Type().Elem()
+		if vtyp.Kind() == reflect.Ptr && vtyp.Elem().Kind() == reflect.Struct {
+			var l []reflect.Value
+			for i := 0; i < arr.Len(); i++ {
+				if !arr.Index(i).IsNil() {
+					l = append(l, arr.Index(i).Elem())
+				}
+			}
+
+			return vtyp.Elem(), l, nil
+		}
+		if vtyp.Kind() != reflect.Struct {
+			return nil, nil, typeErrorf(ErrNotAStruct, "expected output to be pointer to slice of struct; was instead pointer to slice of %s", vtyp.Kind())
+		}
+
+		l := make([]reflect.Value, arr.Len())
+		for i := range l {
+			l[i] = arr.Index(i)
+		}
+
+		return vtyp, l, nil
+	default:
+		return nil, nil, typeErrorf(ErrNotAStruct, "expected output to be pointer to struct or slice of struct; was instead pointer to %s", ptr.Elem().Kind())
+	}
+}
+
+func Preload(ctx context.Context, db Querier, out interface{}, relations ...string) error {
+	vtyp, parents, err := preloadTargets(out)
+	if err != nil {
+		return fmt.Errorf("Preload: %w", err)
+	}
+
+	for _, path := range relations {
+		if err := preloadPath(ctx, db, vtyp, parents, strings.Split(path, "."), nil); err != nil {
+			return fmt.Errorf("Preload: %w", err)
+		}
+	}
+
+	return nil
+}
+
+type relationVisit struct {
+	typ  reflect.Type
+	name string
+}
+
+func preloadPath(ctx context.Context, db Querier, vtyp reflect.Type, parents []reflect.Value, path []string, seen []relationVisit) error {
+	name := path[0]
+
+	for _, v := range seen {
+		if v.typ == vtyp && v.name == name {
+			return fmt.Errorf("relation %s on %s appears more than once in the same path", name, vtyp.Name())
+		}
+	}
+
+	vdesc, err := getDescriptionContext(ctx, vtyp)
+	if err != nil {
+		return fmt.Errorf("could not get detailed reflection information for type %s: %w", vtyp.String(), err)
+	}
+
+	r, err := getRelation(ctx, vtyp, vdesc, name)
+	if err != nil {
+		return err
+	}
+
+	if err := preloadRelation(ctx, db, r, parents); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	if len(path) == 1 {
+		return nil
+	}
+
+	var children []reflect.Value
+	visited := make(map[uintptr]bool)
+	for _, p := range parents {
+		f := p.FieldByIndex(r.field.Index())
+
+		switch r.kind {
+		case relationHasMany:
+			for i := 0; i < f.Len(); i++ {
+				children = append(children, f.Index(i))
+			}
+		default:
+			if !f.IsNil() && !visited[f.Pointer()] {
+				visited[f.Pointer()] = true
+				children = append(children, f.Elem())
+			}
+		}
+	}
+
+	return preloadPath(ctx, db, r.targetType, children, path[1:], append(seen, relationVisit{vtyp, name}))
+}
+
+func preloadRelation(ctx context.Context, db Querier, r *relation, parents []reflect.Value) error {
+	if len(parents) == 0 {
+		return nil
+	}
+
+	targetIndex, err := relationKeyIndex(ctx, r.targetType, r.targetColumn)
+	if err != nil {
+		return err
+	}
+
+	var keys []interface{}
+	seen := make(map[interface{}]bool)
+	for _, p := range parents {
+		k, ok := relationKey(p.FieldByIndex(r.localIndex))
+		if !ok || seen[k] {
+			continue
+		}
+		seen[k] = true
+
+		keys = append(keys, k)
+	}
+
+	targets := reflect.New(reflect.SliceOf(r.targetType))
+	for len(keys) > 0 {
+		chunk := keys
+		if len(chunk) > maxBatchIDs {
+			chunk = chunk[:maxBatchIDs]
+		}
+		keys = keys[len(chunk):]
+
+		params := make([]string, len(chunk))
+		for i := range chunk {
+			params[i] = makeParameter(i + 1)
+		}
+
+		found := reflect.New(targets.Elem().Type())
+		if err := findWhere(ctx, db, found.Interface(), "where "+r.targetColumn+" in ("+strings.Join(params, ", ")+")", chunk, findOptions{}); err != nil {
+			return err
+		}
+
+		targets.Elem().Set(reflect.AppendSlice(targets.Elem(), found.Elem()))
+	}
+
+	groups := make(map[interface{}]reflect.Value)
+	for i := 0; i < targets.Elem().Len(); i++ {
+		c := targets.Elem().Index(i)
+
+		k, ok := relationKey(c.FieldByIndex(targetIndex))
+		if !ok {
+			continue
+		}
+
+		switch r.kind {
+		case relationHasMany:
+			g, ok := groups[k]
+			if !ok {
+				g = reflect.MakeSlice(targets.Elem().Type(), 0, 1)
+			}
+			groups[k] = reflect.Append(g, c)
+		default:
+			if _, ok := groups[k]; !ok {
+				groups[k] = c.Addr()
+			}
+		}
+	}
+
+	for _, p := range parents {
+		f := p.FieldByIndex(r.field.Index())
+
+		k, ok := relationKey(p.FieldByIndex(r.localIndex))
+		if !ok {
+			f.Set(reflect.Zero(f.Type()))
+			continue
+		}
+
+		if g, ok := groups[k]; ok {
+			f.Set(g)
+		} else {
+			f.Set(reflect.Zero(f.Type()))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,293 @@
+package sorm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type PreloadPost struct {
+	ID       int
+	Title    string
+	Comments []PreloadComment `sql:"-,has_many,fk:post_id"`
+}
+
+type PreloadComment struct {
+	ID     int
+	PostID int
+	Body   string
+	Post   *PreloadPost `sql:"-,belongs_to"`
+}
+
+type PreloadUser struct {
+	ID      int
+	Name    string
+	Profile *PreloadProfile `sql:"-,has_one,fk:user_id"`
+}
+
+type PreloadProfile struct {
+	ID     int
+	UserID int
+	Bio    string
+}
+
+func TestPreloadHasMany(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from preload_comments where post_id in \(\$1, \$2, \$3\)`).WithArgs(1, 2, 3).WillReturnRows(sqlmock.NewRows([]string{"id", "post_id", "body"}).AddRow(10, 1, "a").AddRow(11, 3, "b").AddRow(12, 1, "c"))
+
+	posts := []PreloadPost{{ID: 1, Title: "one"}, {ID: 2, Title: "two"}, {ID: 3, Title: "three"}}
+	a.NoError(Preload(context.Background(), db, &posts, "Comments"))
+
+	a.Equal([]PreloadPost{
+		{ID: 1, Title: "one", Comments: []PreloadComment{{ID: 10, PostID: 1, Body: "a"}, {ID: 12, PostID: 1, Body: "c"}}},
+		{ID: 2, Title: "two"},
+		{ID: 3, Title: "three", Comments: []PreloadComment{{ID: 11, PostID: 3, Body: "b"}}},
+	}, posts)
+}
+
+func TestPreloadPointers(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from preload_comments where post_id in \(\$1, \$2\)`).WithArgs(1, 2).WillReturnRows(sqlmock.NewRows([]string{"id", "post_id", "body"}).AddRow(10, 1, "a"))
+
+	posts := []*PreloadPost{{ID: 1, Title: "one"}, nil, {ID: 2, Title: "two"}}
+	a.NoError(Preload(context.Background(), db, &posts, "Comments"))
+
+	a.Equal([]*PreloadPost{
+		{ID: 1, Title: "one", Comments: []PreloadComment{{ID: 10, PostID: 1, Body: "a"}}},
+		nil,
+		{ID: 2, Title: "two"},
+	}, posts)
+}
+
+func TestPreloadSingle(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from preload_comments where post_id in \(\$1\)`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "post_id", "body"}).AddRow(10, 1, "a"))
+
+	post := PreloadPost{ID: 1, Title: "one"}
+	a.NoError(Preload(context.Background(), db, &post, "Comments"))
+
+	a.Equal(PreloadPost{ID: 1, Title: "one", Comments: []PreloadComment{{ID: 10, PostID: 1, Body: "a"}}}, post)
+}
+
+func TestPreloadUnknownRelation(t *testing.T) {
+	a := assert.New(t)
+
+	posts := []PreloadPost{{ID: 1}}
+	a.EqualError(Preload(context.Background(), nil, &posts, "Title"), "Preload: field Title on PreloadPost is not a relation")
+}
+
+func TestPreloadBelongsTo(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from preload_posts where id in \(\$1, \$2\)`).WithArgs(1, 2).WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).AddRow(1, "one").AddRow(2, "two"))
+
+	comments := []PreloadComment{{ID: 10, PostID: 1}, {ID: 11, PostID: 2}, {ID: 12, PostID: 1}}
+	a.NoError(Preload(context.Background(), db, &comments, "Post"))
+
+	a.Equal(&PreloadPost{ID: 1, Title: "one"}, comments[0].Post)
+	a.Equal(&PreloadPost{ID: 2, Title: "two"}, comments[1].Post)
+	a.True(comments[0].Post == comments[2].Post)
+}
+
+func TestPreloadHasOne(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from preload_profiles where user_id in \(\$1, \$2\)`).WithArgs(1, 2).WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "bio"}).AddRow(5, 2, "hello"))
+
+	users := []PreloadUser{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}}
+	a.NoError(Preload(context.Background(), db, &users, "Profile"))
+
+	a.Nil(users[0].Profile)
+	a.Equal(&PreloadProfile{ID: 5, UserID: 2, Bio: "hello"}, users[1].Profile)
+}
+
+func TestPreloadNested(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from preload_posts where id in \(\$1\)`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).AddRow(1, "one"))
+	mockDB.ExpectQuery(`select \* from preload_comments where post_id in \(\$1\)`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "post_id", "body"}).AddRow(10, 1, "a").AddRow(11, 1, "b"))
+
+	comment := PreloadComment{ID: 10, PostID: 1}
+	a.NoError(Preload(context.Background(), db, &comment, "Post.Comments"))
+
+	if a.NotNil(comment.Post) {
+		a.Len(comment.Post.Comments, 2)
+	}
+}
+
+func TestPreloadCycle(t *testing.T) {
+	a := assert.New(t)
+
+	a.EqualError(Preload(context.Background(), nil, &[]PreloadComment{}, "Post.Comments.Post.Comments"), "Preload: relation Post on PreloadComment appears more than once in the same path")
+}
+
+type TaggedPost struct {
+	ID       int
+	Title    string
+	Comments []PreloadComment `sql:"has_many:preload_comments,fk:post_id"`
+}
+
+func TestPreloadTaggedRelation(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`^insert into tagged_posts \(title\) values \(\$1\) returning id$`).WithArgs("one").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mockDB.ExpectQuery(`^select \* from preload_comments where post_id in \(\$1\)$`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "post_id", "body"}).AddRow(10, 1, "a"))
+
+	post := TaggedPost{Title: "one"}
+	a.NoError(CreateRecord(context.Background(), db, &post))
+	a.NoError(Preload(context.Background(), db, &post, "Comments"))
+	a.Equal([]PreloadComment{{ID: 10, PostID: 1, Body: "a"}}, post.Comments)
+
+	type WrongTable struct {
+		ID       int
+		Comments []PreloadComment `sql:"has_many:comments,fk:post_id"`
+	}
+	a.EqualError(Preload(context.Background(), db, &WrongTable{ID: 1}, "Comments"), "Preload: relation Comments on WrongTable names table comments, but PreloadComment is stored in preload_comments")
+
+	type NamedColumn struct {
+		ID       int
+		Comments []PreloadComment `sql:"comments,has_many,fk:post_id"`
+	}
+	a.EqualError(Preload(context.Background(), db, &NamedColumn{ID: 1}, "Comments"), `Preload: has_many field Comments on NamedColumn also names column comments; write it as sql:"has_many" or sql:"-,has_many"`)
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestPreloadChunked(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	posts := make([]PreloadPost, maxBatchIDs+1)
+	for i := range posts {
+		posts[i].ID = i + 1
+	}
+
+	mockDB.ExpectQuery(`^select \* from preload_comments where post_id in \(\$1, .+, \$1000\)$`).WillReturnRows(sqlmock.NewRows([]string{"id", "post_id", "body"}).AddRow(10, 1, "a"))
+	mockDB.ExpectQuery(`^select \* from preload_comments where post_id in \(\$1\)$`).WithArgs(maxBatchIDs + 1).WillReturnRows(sqlmock.NewRows([]string{"id", "post_id", "body"}).AddRow(11, maxBatchIDs+1, "b"))
+
+	a.NoError(Preload(context.Background(), db, &posts, "Comments"))
+	a.Equal([]PreloadComment{{ID: 10, PostID: 1, Body: "a"}}, posts[0].Comments)
+	a.Equal([]PreloadComment{{ID: 11, PostID: maxBatchIDs + 1, Body: "b"}}, posts[maxBatchIDs].Comments)
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+type lowerNaming struct{}
+
+func (lowerNaming) TableName(typeName string) string   { return strings.ToLower(typeName) }
+func (lowerNaming) ColumnName(fieldName string) string { return strings.ToLower(fieldName) }
+
+type NamingAuthor struct {
+	ID    int
+	Books []NamingBook `sql:"-,has_many"`
+}
+
+type NamingBook struct {
+	ID             int
+	NamingAuthorID int
+	NamingAuthor   *NamingAuthor `sql:"-,belongs_to"`
+}
+
+func TestPreloadNamingStrategy(t *testing.T) {
+	a := assert.New(t)
+
+	SetNamingStrategy(lowerNaming{})
+	defer SetNamingStrategy(nil)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from namingbook where namingauthorid in \(\$1\)`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "namingauthorid"}).AddRow(10, 1))
+	mockDB.ExpectQuery(`select \* from namingauthor where id in \(\$1\)`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	authors := []NamingAuthor{{ID: 1}}
+	a.NoError(Preload(context.Background(), db, &authors, "Books"))
+	a.Equal([]NamingBook{{ID: 10, NamingAuthorID: 1}}, authors[0].Books)
+
+	books := []NamingBook{{ID: 10, NamingAuthorID: 1}}
+	a.NoError(Preload(context.Background(), db, &books, "NamingAuthor"))
+	a.Equal(&NamingAuthor{ID: 1}, books[0].NamingAuthor)
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestPreloadNamingStrategyOption(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from namingbook where namingauthorid in \(\$1\)`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "namingauthorid"}).AddRow(10, 1))
+	mockDB.ExpectQuery(`select \* from namingauthor where id in \(\$1\)`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	ctx := WithOptions(context.Background(), Options{NamingStrategy: lowerNaming{}})
+
+	authors := []NamingAuthor{{ID: 1}}
+	a.NoError(Preload(ctx, db, &authors, "Books"))
+	a.Equal([]NamingBook{{ID: 10, NamingAuthorID: 1}}, authors[0].Books)
+
+	books := []NamingBook{{ID: 10, NamingAuthorID: 1}}
+	a.NoError(Preload(ctx, db, &books, "NamingAuthor"))
+	a.Equal(&NamingAuthor{ID: 1}, books[0].NamingAuthor)
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
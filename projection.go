@@ -0,0 +1,97 @@
+package sorm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrProjection = errors.New("projections are read-only")
+	ErrReadOnly   = errors.New("model is read-only")
+)
+
+// getSQLProjection returns the source table of a projection, which is
+// declared with a field like `_ struct{} sorm:"projection:users"`.
+func getSQLProjection(vdesc *structDescription) string {
+	for _, f := range vdesc.Fields() {
+		t := f.Tag("sorm")
+		if t == nil {
+			continue
+		}
+
+		if s := t.Value(); strings.HasPrefix(s, "projection:") {
+			return strings.TrimPrefix(s, "projection:")
+		}
+
+		if p := t.Parameter("projection"); p != nil && p.Value() != "" {
+			return p.Value()
+		}
+	}
+
+	return ""
+}
+
+// getSQLReadOnly returns "view" or "readonly" for models declared with a
+// field like `_ struct{} sorm:"view"`, and "" for everything else.
+func getSQLReadOnly(vdesc *structDescription) string {
+	for _, f := range vdesc.Fields() {
+		t := f.Tag("sorm")
+		if t == nil {
+			continue
+		}
+
+		for _, s := range []string{"view", "readonly"} {
+			if t.Value() == s || t.Parameter(s) != nil {
+				return s
+			}
+		}
+	}
+
+	return ""
+}
+
+// getSQLFrom returns where a field of a join projection comes from, as set
+// with `sql:"org_name,from:orgs.name"`. It's used verbatim, so it can also be
+// an aggregate like count(users.id).
+func getSQLFrom(f structField) string {
+	if t := f.Tag("sql"); t != nil {
+		if p := t.Parameter("from"); p != nil {
+			return p.Value()
+		}
+	}
+
+	return ""
+}
+
+func hasSQLFrom(vdesc *structDescription) bool {
+	for _, f := range getSQLWritableFields(vdesc) {
+		if getSQLFrom(f) != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkWritable stops projections, views, read-only models and structs with
+// fields read from joined tables from being used with the statement builders
+// that write.
+func checkWritable(vdesc *structDescription) error {
+	switch getSQLReadOnly(vdesc) {
+	case "view":
+		return fmt.Errorf("%s is a view: %w", vdesc.Name(), ErrReadOnly)
+	case "readonly":
+		return fmt.Errorf("%s is read-only: %w", vdesc.Name(), ErrReadOnly)
+	}
+
+	if tbl := getSQLProjection(vdesc); tbl != "" {
+		return fmt.Errorf("%s is a projection of %s: %w", vdesc.Name(), tbl, ErrProjection)
+	}
+
+	if hasSQLFrom(vdesc) {
+		return fmt.Errorf("%s has fields from other tables: %w", vdesc.Name(), ErrProjection)
+	}
+
+	return nil
+}
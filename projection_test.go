@@ -0,0 +1,147 @@
+package sorm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type UserListItem struct {
+	_ struct{} `sorm:"projection:users"`
+
+	ID   int
+	Name string
+}
+
+func TestProjectionFind(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`^select id, name from users where id > \$1$`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(2, "b"))
+
+	var l []UserListItem
+	a.NoError(FindWhere(context.Background(), db, &l, "where id > $1", 1))
+	if a.Len(l, 1) {
+		a.Equal(2, l[0].ID)
+		a.Equal("b", l[0].Name)
+	}
+
+	a.Equal("users", TableName(UserListItem{}))
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestProjectionWrite(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	err = CreateRecord(context.Background(), db, &UserListItem{Name: "a"})
+	a.EqualError(err, "CreateRecord: UserListItem is a projection of users: projections are read-only")
+	a.True(errors.Is(err, ErrProjection))
+
+	a.True(errors.Is(DeleteRecord(context.Background(), db, &UserListItem{ID: 1}), ErrProjection))
+
+	_, err = InsertStatement(&UserListItem{Name: "a"})
+	a.True(errors.Is(err, ErrProjection))
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+type UserWithOrg struct {
+	_ struct{} `sorm:"projection:users"`
+
+	ID      int
+	Name    string
+	OrgName string `sql:",from:orgs.name"`
+}
+
+func TestProjectionJoinColumns(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`^select users.id, users.name, orgs.name as org_name from users join orgs on orgs.id = users.org_id where users.id = \$1$`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "name", "org_name"}).AddRow(1, "a", "b"))
+
+	var l []UserWithOrg
+	a.NoError(FindWhere(context.Background(), db, &l, "join orgs on orgs.id = users.org_id where users.id = $1", 1))
+	a.Equal([]UserWithOrg{{ID: 1, Name: "a", OrgName: "b"}}, l)
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestProjectionJoinColumnsTableNamer(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`^select app_users.id, app_users.name, orgs.name as org_name from app_users join orgs on orgs.id = app_users.org_id where app_users.id = \$1$`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "name", "org_name"}).AddRow(1, "a", "b"))
+
+	ctx := WithOptions(context.Background(), Options{TableNamer: TableNamerFunc(func(model reflect.Type, name string) string { return "app_" + name })})
+
+	var l []UserWithOrg
+	a.NoError(FindWhere(ctx, db, &l, "join orgs on orgs.id = app_users.org_id where app_users.id = $1", 1))
+	a.Equal([]UserWithOrg{{ID: 1, Name: "a", OrgName: "b"}}, l)
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+type ActiveUser struct {
+	_    struct{} `sorm:"view"`
+	ID   int
+	Name string
+}
+
+func TestViewModel(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from active_users where id = \$1`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a"))
+
+	var l []ActiveUser
+	a.NoError(FindWhere(context.Background(), db, &l, "where id = $1", 1))
+	a.Equal([]ActiveUser{{ID: 1, Name: "a"}}, l)
+
+	err = CreateRecord(context.Background(), db, &ActiveUser{ID: 2, Name: "b"})
+	a.EqualError(err, "CreateRecord: ActiveUser is a view: model is read-only")
+	a.True(errors.Is(err, ErrReadOnly))
+
+	a.True(errors.Is(SaveRecord(context.Background(), db, &ActiveUser{ID: 1}), ErrReadOnly))
+	a.True(errors.Is(ReplaceRecord(context.Background(), db, &ActiveUser{ID: 1}), ErrReadOnly))
+	a.True(errors.Is(DeleteRecord(context.Background(), db, &ActiveUser{ID: 1}), ErrReadOnly))
+
+	_, err = DeleteClause(context.Background(), db, &ActiveUser{}, Clause("where id = $1", 1))
+	a.True(errors.Is(err, ErrReadOnly))
+
+	stmts, err := AutoMigrateStatements(context.Background(), db, &ActiveUser{})
+	a.NoError(err)
+	a.Empty(stmts)
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
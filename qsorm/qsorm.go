@@ -2,6 +2,8 @@ package qsorm
 
 import (
 	"context"
+	"fmt"
+	"regexp"
 
 	"fknsrs.biz/p/sorm"
 	"fknsrs.biz/p/sqlbuilder"
@@ -9,58 +11,230 @@ import (
 
 var dialect sqlbuilder.Dialect = sqlbuilder.DialectGeneric{}
 
+// SetDialect sets the dialect used by the package-level functions.
+//
+// Deprecated: use New to get a Handle for a particular dialect.
 func SetDialect(d sqlbuilder.Dialect) { dialect = d }
 
-func CountWhere(ctx context.Context, db sorm.Querier, out interface{}, where sqlbuilder.AsExpr) (int, error) {
-	s := sqlbuilder.NewSerializer(dialect)
+// Handle has the same functions as the package, using its own dialect
+// rather than the one set with SetDialect.
+type Handle struct {
+	dialect sqlbuilder.Dialect
+}
 
-	if where != nil {
-		s = s.D("where ").F(where.AsExpr).D(" ")
-	}
+func New(d sqlbuilder.Dialect) *Handle {
+	return &Handle{dialect: d}
+}
 
-	qs, qv, err := s.ToSQL()
-	if err != nil {
-		return 0, err
-	}
+// Clause binds c to the handle's dialect.
+func (h *Handle) Clause(c *Clause) sorm.Clauser {
+	return boundClause{c: c, d: h.dialect}
+}
 
-	return sorm.CountWhere(ctx, db, out, qs, qv...)
+type boundClause struct {
+	c *Clause
+	d sqlbuilder.Dialect
 }
 
-func FindWhere(ctx context.Context, db sorm.Querier, out interface{}, where sqlbuilder.AsExpr, order []sqlbuilder.AsOrderingTerm, offsetLimit sqlbuilder.AsOffsetLimit) error {
-	s := sqlbuilder.NewSerializer(dialect)
+func (b boundClause) Clause() (string, []interface{}, error) {
+	return b.c.serialize(b.d)
+}
 
-	if where != nil {
-		s = s.D("where ").F(where.AsExpr).D(" ")
+func (h *Handle) CountWhere(ctx context.Context, db sorm.Querier, out interface{}, where sqlbuilder.AsExpr) (int, error) {
+	return sorm.CountClause(ctx, db, out, h.Clause(&Clause{Where: where}))
+}
+
+func (h *Handle) FindWhere(ctx context.Context, db sorm.Querier, out interface{}, where sqlbuilder.AsExpr, order []sqlbuilder.AsOrderingTerm, offsetLimit sqlbuilder.AsOffsetLimit) error {
+	return sorm.FindClause(ctx, db, out, h.Clause(&Clause{Where: where, Order: order, OffsetLimit: offsetLimit}))
+}
+
+func (h *Handle) FindFirstWhere(ctx context.Context, db sorm.Querier, out interface{}, where sqlbuilder.AsExpr, order []sqlbuilder.AsOrderingTerm) error {
+	return sorm.FindFirstClause(ctx, db, out, h.Clause(&Clause{Where: where, Order: order}))
+}
+
+func (h *Handle) DeleteWhere(ctx context.Context, db sorm.Querier, model interface{}, where sqlbuilder.AsExpr) (int64, error) {
+	if where == nil {
+		return 0, fmt.Errorf("qsorm: expected a where expression")
 	}
-	for i, e := range order {
-		s = s.DC("order by ", i == 0).DC(", ", i != 0).F(e.AsOrderingTerm).D(" ")
+
+	return sorm.DeleteClause(ctx, db, model, h.Clause(&Clause{Where: where}))
+}
+
+func (h *Handle) UpdateWhere(ctx context.Context, db sorm.Querier, model interface{}, set []sqlbuilder.AsExpr, where sqlbuilder.AsExpr) (int64, error) {
+	return sorm.UpdateClause(ctx, db, model, clauseFunc(func() (string, []interface{}, error) {
+		return assignments(h.dialect, set, where)
+	}))
+}
+
+func (h *Handle) Find(ctx context.Context, db sorm.Querier, out interface{}, c *Clause) error {
+	return sorm.FindClause(ctx, db, out, h.Clause(c))
+}
+
+func (h *Handle) FindFirst(ctx context.Context, db sorm.Querier, out interface{}, c *Clause) error {
+	return sorm.FindFirstClause(ctx, db, out, h.Clause(c))
+}
+
+func (h *Handle) Count(ctx context.Context, db sorm.Querier, out interface{}, c *Clause) (int, error) {
+	return sorm.CountClause(ctx, db, out, h.Clause(c))
+}
+
+// Join adds a table to a query. Type defaults to "join"; it can also be
+// "inner join", "left join", "right join", "full join" or "cross join".
+type Join struct {
+	Type  string
+	Table string
+	As    string
+	On    sqlbuilder.AsExpr
+}
+
+// Clause is a sorm.Clauser built from sqlbuilder expressions, covering
+// everything after "from <table>". Any part can be left empty. Fields of the
+// joined tables are read into structs with `sql:",from:table.column"` tags.
+type Clause struct {
+	Joins       []Join
+	Where       sqlbuilder.AsExpr
+	GroupBy     []sqlbuilder.AsExpr
+	Having      sqlbuilder.AsExpr
+	Order       []sqlbuilder.AsOrderingTerm
+	OffsetLimit sqlbuilder.AsOffsetLimit
+}
+
+// Where returns a sorm.Clauser for the where expression alone.
+func Where(where sqlbuilder.AsExpr) *Clause {
+	return &Clause{Where: where}
+}
+
+var (
+	identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+	joinTypes         = map[string]bool{"join": true, "inner join": true, "left join": true, "right join": true, "full join": true, "cross join": true}
+)
+
+// Clause serializes c with the dialect set by SetDialect; use Handle.Clause
+// for another one.
+func (c *Clause) Clause() (string, []interface{}, error) {
+	return c.serialize(dialect)
+}
+
+func (c *Clause) serialize(d sqlbuilder.Dialect) (string, []interface{}, error) {
+	s := sqlbuilder.NewSerializer(d)
+
+	var parts int
+	part := func(str string) {
+		s = s.DC(" ", parts > 0).D(str)
+		parts++
 	}
-	if offsetLimit != nil {
-		s = s.F(offsetLimit.AsOffsetLimit)
+
+	for _, j := range c.Joins {
+		typ := j.Type
+		if typ == "" {
+			typ = "join"
+		}
+		if !joinTypes[typ] {
+			return "", nil, fmt.Errorf("qsorm: unknown join type %q", j.Type)
+		}
+
+		for _, id := range []string{j.Table, j.As} {
+			if id != "" && !identifierPattern.MatchString(id) {
+				return "", nil, fmt.Errorf("qsorm: invalid identifier %q", id)
+			}
+		}
+
+		part(typ + " " + j.Table)
+		if j.As != "" {
+			s = s.D(" " + j.As)
+		}
+		if j.On != nil {
+			s = s.D(" on ").F(j.On.AsExpr)
+		}
 	}
 
-	qs, qv, err := s.ToSQL()
-	if err != nil {
-		return err
+	if c.Where != nil {
+		part("where ")
+		s = s.F(c.Where.AsExpr)
+	}
+	for i, e := range c.GroupBy {
+		if i == 0 {
+			part("group by ")
+		} else {
+			s = s.D(", ")
+		}
+		s = s.F(e.AsExpr)
+	}
+	if c.Having != nil {
+		part("having ")
+		s = s.F(c.Having.AsExpr)
+	}
+	for i, e := range c.Order {
+		if i == 0 {
+			part("order by ")
+		} else {
+			s = s.D(", ")
+		}
+		s = s.F(e.AsOrderingTerm)
+	}
+	if c.OffsetLimit != nil {
+		part("")
+		s = s.F(c.OffsetLimit.AsOffsetLimit)
 	}
 
-	return sorm.FindWhere(ctx, db, out, qs, qv...)
+	return s.ToSQL()
 }
 
-func FindFirstWhere(ctx context.Context, db sorm.Querier, out interface{}, where sqlbuilder.AsExpr, order []sqlbuilder.AsOrderingTerm) error {
-	s := sqlbuilder.NewSerializer(dialect)
-
-	if where != nil {
-		s = s.D("where ").F(where.AsExpr)
+// assignments serializes "set a, b where w" for UpdateWhere.
+func assignments(d sqlbuilder.Dialect, set []sqlbuilder.AsExpr, where sqlbuilder.AsExpr) (string, []interface{}, error) {
+	if len(set) == 0 {
+		return "", nil, fmt.Errorf("qsorm: expected at least one assignment")
 	}
-	for i, e := range order {
-		s = s.DC("order by ", i == 0).DC(", ", i != 0).F(e.AsOrderingTerm)
+	if where == nil {
+		return "", nil, fmt.Errorf("qsorm: expected a where expression")
 	}
 
-	qs, qv, err := s.ToSQL()
-	if err != nil {
-		return err
+	s := sqlbuilder.NewSerializer(d).D("set ")
+	for i, e := range set {
+		s = s.DC(", ", i != 0).F(e.AsExpr)
 	}
+	s = s.D(" where ").F(where.AsExpr)
+
+	return s.ToSQL()
+}
+
+type clauseFunc func() (string, []interface{}, error)
+
+func (fn clauseFunc) Clause() (string, []interface{}, error) { return fn() }
+
+// DeleteWhere deletes the rows of model's table matching where, without
+// running hooks. A nil where is refused rather than emptying the table.
+func DeleteWhere(ctx context.Context, db sorm.Querier, model interface{}, where sqlbuilder.AsExpr) (int64, error) {
+	return New(dialect).DeleteWhere(ctx, db, model, where)
+}
+
+// UpdateWhere applies assignments, like "name = ?", to the rows of model's
+// table matching where, without running hooks.
+func UpdateWhere(ctx context.Context, db sorm.Querier, model interface{}, set []sqlbuilder.AsExpr, where sqlbuilder.AsExpr) (int64, error) {
+	return New(dialect).UpdateWhere(ctx, db, model, set, where)
+}
+
+// Find runs a full query into out, which may be a join projection.
+func Find(ctx context.Context, db sorm.Querier, out interface{}, c *Clause) error {
+	return sorm.FindClause(ctx, db, out, c)
+}
+
+func FindFirst(ctx context.Context, db sorm.Querier, out interface{}, c *Clause) error {
+	return sorm.FindFirstClause(ctx, db, out, c)
+}
+
+func Count(ctx context.Context, db sorm.Querier, out interface{}, c *Clause) (int, error) {
+	return sorm.CountClause(ctx, db, out, c)
+}
+
+func CountWhere(ctx context.Context, db sorm.Querier, out interface{}, where sqlbuilder.AsExpr) (int, error) {
+	return sorm.CountClause(ctx, db, out, &Clause{Where: where})
+}
 
-	return sorm.FindFirstWhere(ctx, db, out, qs, qv...)
+func FindWhere(ctx context.Context, db sorm.Querier, out interface{}, where sqlbuilder.AsExpr, order []sqlbuilder.AsOrderingTerm, offsetLimit sqlbuilder.AsOffsetLimit) error {
+	return sorm.FindClause(ctx, db, out, &Clause{Where: where, Order: order, OffsetLimit: offsetLimit})
+}
+
+func FindFirstWhere(ctx context.Context, db sorm.Querier, out interface{}, where sqlbuilder.AsExpr, order []sqlbuilder.AsOrderingTerm) error {
+	return sorm.FindFirstClause(ctx, db, out, &Clause{Where: where, Order: order})
 }
@@ -0,0 +1,139 @@
+package qsorm
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"fknsrs.biz/p/sqlbuilder"
+)
+
+type testExpr struct {
+	sql  string
+	args []interface{}
+}
+
+func (e testExpr) AsExpr(s *sqlbuilder.Serializer) {
+	s.D(e.sql)
+	for _, v := range e.args {
+		s.D(" ").V(v)
+	}
+}
+
+func (e testExpr) AsOrderingTerm(s *sqlbuilder.Serializer) { s.D(e.sql) }
+func (e testExpr) AsOffsetLimit(s *sqlbuilder.Serializer)  { s.D(e.sql) }
+
+func (e testExpr) withArgs(args ...interface{}) testExpr {
+	e.args = args
+	return e
+}
+
+type OrgCount struct {
+	_ struct{} `sorm:"projection:orgs"`
+
+	Name  string
+	Users int `sql:",from:count(users.id)"`
+}
+
+func TestClause(t *testing.T) {
+	a := assert.New(t)
+
+	c := &Clause{
+		Joins:       []Join{{Type: "left join", Table: "users", As: "u", On: testExpr{sql: "u.org_id = orgs.id"}}},
+		Where:       testExpr{sql: "orgs.active ="}.withArgs(true),
+		GroupBy:     []sqlbuilder.AsExpr{testExpr{sql: "orgs.name"}},
+		Having:      testExpr{sql: "count(u.id) >"}.withArgs(2),
+		Order:       []sqlbuilder.AsOrderingTerm{testExpr{sql: "orgs.name"}, testExpr{sql: "orgs.id desc"}},
+		OffsetLimit: testExpr{sql: "limit 10"},
+	}
+
+	q, args, err := c.Clause()
+	a.NoError(err)
+	a.Equal("left join users u on u.org_id = orgs.id where orgs.active = ? group by orgs.name having count(u.id) > ? order by orgs.name, orgs.id desc limit 10", q)
+	a.Equal([]interface{}{true, 2}, args)
+
+	_, _, err = (&Clause{Joins: []Join{{Type: "natural join; drop", Table: "x"}}}).Clause()
+	a.EqualError(err, `qsorm: unknown join type "natural join; drop"`)
+
+	_, _, err = (&Clause{Joins: []Join{{Table: "x;y"}}}).Clause()
+	a.EqualError(err, `qsorm: invalid identifier "x;y"`)
+}
+
+func TestFindJoin(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`^select orgs.name, count\(users.id\) as users from orgs join users on users.org_id = orgs.id group by orgs.name$`).WillReturnRows(sqlmock.NewRows([]string{"name", "users"}).AddRow("a", 3))
+
+	var l []OrgCount
+	a.NoError(Find(context.Background(), db, &l, &Clause{
+		Joins:   []Join{{Table: "users", On: testExpr{sql: "users.org_id = orgs.id"}}},
+		GroupBy: []sqlbuilder.AsExpr{testExpr{sql: "orgs.name"}},
+	}))
+	a.Equal([]OrgCount{{Name: "a", Users: 3}}, l)
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+type numberedDialect struct{}
+
+func (numberedDialect) Placeholder(n int) string { return fmt.Sprintf("$%d", n) }
+
+func TestHandleDialect(t *testing.T) {
+	a := assert.New(t)
+
+	h := New(numberedDialect{})
+
+	q, args, err := h.Clause(&Clause{Where: testExpr{sql: "a ="}.withArgs(1)}).Clause()
+	a.NoError(err)
+	a.Equal("where a = $1", q)
+	a.Equal([]interface{}{1}, args)
+
+	q, _, err = Where(testExpr{sql: "a ="}.withArgs(1)).Clause()
+	a.NoError(err)
+	a.Equal("where a = ?", q)
+}
+
+type Widget struct {
+	ID   int
+	Name string
+}
+
+func TestDeleteAndUpdateWhere(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectExec(`^delete from widgets where id = \$1$`).WithArgs(4).WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec(`^update widgets set name = \$1 where id > \$2$`).WithArgs("x", 2).WillReturnResult(sqlmock.NewResult(0, 5))
+
+	h := New(numberedDialect{})
+
+	n, err := h.DeleteWhere(context.Background(), db, Widget{}, testExpr{sql: "id ="}.withArgs(4))
+	a.NoError(err)
+	a.Equal(int64(1), n)
+
+	n, err = h.UpdateWhere(context.Background(), db, Widget{}, []sqlbuilder.AsExpr{testExpr{sql: "name ="}.withArgs("x")}, testExpr{sql: "id >"}.withArgs(2))
+	a.NoError(err)
+	a.Equal(int64(5), n)
+
+	_, err = h.DeleteWhere(context.Background(), db, Widget{}, nil)
+	a.EqualError(err, "qsorm: expected a where expression")
+
+	_, err = h.UpdateWhere(context.Background(), db, Widget{}, nil, testExpr{sql: "id >"}.withArgs(2))
+	a.EqualError(err, "UpdateClause: qsorm: expected at least one assignment")
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
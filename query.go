@@ -0,0 +1,93 @@
+package sorm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// QueryInto runs a hand-written query, such as a CTE or a window function,
+// and scans the result into out with ScanRowsContext. Slice and Named args
+// are expanded as they are for clauses. The query is logged and observed
+// like a generated one, but it isn't routed and doesn't run callbacks.
+func QueryInto(ctx context.Context, db Querier, out interface{}, query string, args ...interface{}) error {
+	query, args, err := expandArgs(query, args)
+	if err != nil {
+		return fmt.Errorf("QueryInto: %w", err)
+	}
+
+	var model reflect.Type
+	if typ, err := structTypeOf(out); err == nil {
+		model = typ
+	}
+
+	stmt := Statement{Query: query, Args: args}
+
+	logQuery(ctx, query, args)
+
+	start := time.Now()
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		logQueryAfter(ctx, query, args, start, err)
+		observeOperation(ctx, OperationQuery, model, "", stmt, start, 0, err)
+
+		return fmt.Errorf("QueryInto: %w", err)
+	}
+	defer rows.Close()
+
+	if err := ScanRowsContext(ctx, rows, out); err != nil {
+		logQueryAfter(ctx, query, args, start, err)
+		observeOperation(ctx, OperationQuery, model, "", stmt, start, 0, err)
+
+		return fmt.Errorf("QueryInto: %w", err)
+	}
+
+	if err := rows.Close(); err != nil {
+		logQueryAfter(ctx, query, args, start, err)
+		observeOperation(ctx, OperationQuery, model, "", stmt, start, 0, err)
+
+		return fmt.Errorf("QueryInto: %w", err)
+	}
+
+	logQueryAfter(ctx, query, args, start, nil)
+	observeOperation(ctx, OperationQuery, model, "", stmt, start, scannedRows(reflect.ValueOf(out)), nil)
+
+	return nil
+}
+
+// Exec runs a hand-written statement, logging and observing it like a
+// generated one, and returns the number of rows it affected. Errors are
+// passed through TranslateError. Exec can't tell which tables it writes to,
+// so it doesn't invalidate the result cache.
+func Exec(ctx context.Context, db Querier, query string, args ...interface{}) (int64, error) {
+	query, args, err := expandArgs(query, args)
+	if err != nil {
+		return 0, fmt.Errorf("Exec: %w", err)
+	}
+
+	stmt := Statement{Query: query, Args: args}
+
+	logQuery(ctx, query, args)
+
+	start := time.Now()
+
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		logQueryAfter(ctx, query, args, start, err)
+		observeOperation(ctx, OperationExec, nil, "", stmt, start, 0, err)
+
+		return 0, fmt.Errorf("Exec: %w", TranslateError(err))
+	}
+
+	logQueryAfter(ctx, query, args, start, nil)
+	observeOperation(ctx, OperationExec, nil, "", stmt, start, rowsAffected(res), nil)
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("Exec: %w", err)
+	}
+
+	return n, nil
+}
@@ -0,0 +1,78 @@
+package sorm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryInto(t *testing.T) {
+	a := assert.New(t)
+
+	var c testMetricsCollector
+	l := &afterLogger{}
+
+	ctx := WithOptions(context.Background(), Options{MetricsCollector: &c, QueryLogger: l})
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`with recent as \(select \* from simple_objects where id in \(\$1, \$2\)\) select \* from recent`).WithArgs(1, 2).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a").AddRow(2, "b"))
+	mockDB.ExpectQuery(`select count\(\*\) from simple_objects where name = \$1`).WithArgs("a").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	var r []SimpleObject
+	a.NoError(QueryInto(ctx, db, &r, "with recent as (select * from simple_objects where id in ($1)) select * from recent", []int{1, 2}))
+	a.Equal([]SimpleObject{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}}, r)
+
+	var n []int
+	a.NoError(QueryInto(ctx, db, &n, "select count(*) from simple_objects where name = :name", Named{"name": "a"}))
+	a.Equal([]int{1}, n)
+
+	a.Equal([]observation{
+		{OperationQuery, "", 2, nil},
+		{OperationQuery, "", 1, nil},
+	}, c.l)
+	a.Equal([]string{
+		"before: with recent as (select * from simple_objects where id in ($1, $2)) select * from recent",
+		"after: with recent as (select * from simple_objects where id in ($1, $2)) select * from recent",
+		"before: select count(*) from simple_objects where name = $1",
+		"after: select count(*) from simple_objects where name = $1",
+	}, l.queries)
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestExec(t *testing.T) {
+	a := assert.New(t)
+
+	var c testMetricsCollector
+	ctx := WithOptions(context.Background(), Options{MetricsCollector: &c})
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectExec(`update simple_objects set name = \$1 where id in \(\$2, \$3\)`).WithArgs("x", 1, 2).WillReturnResult(sqlmock.NewResult(0, 2))
+	mockDB.ExpectExec(`insert into simple_objects`).WillReturnError(&pqError{Code: "23505"})
+
+	n, err := Exec(ctx, db, "update simple_objects set name = $1 where id in ($2)", "x", []int{1, 2})
+	a.NoError(err)
+	a.Equal(int64(2), n)
+
+	_, err = Exec(ctx, db, "insert into simple_objects (id, name) values (1, 'a')")
+	a.ErrorIs(err, ErrDuplicateKey)
+
+	if a.Len(c.l, 2) {
+		a.Equal(observation{OperationExec, "", 2, nil}, c.l[0])
+		a.Equal(OperationExec, c.l[1].op)
+	}
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
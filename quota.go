@@ -0,0 +1,71 @@
+package sorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+type QuotaError struct {
+	Table  string
+	Column string
+	Value  interface{}
+	Max    int
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("quota exceeded: %s already has %d record(s) with %s = %v", e.Table, e.Max, e.Column, e.Value)
+}
+
+func (e *QuotaError) Unwrap() error {
+	return ErrQuotaExceeded
+}
+
+func EnforceQuota(model interface{}, groupByColumn string, max int) BeforeCreateFunc {
+	vtyp, err := structTypeOf(model)
+	if err != nil {
+		panic(fmt.Errorf("EnforceQuota: %w", err))
+	}
+
+	plan, err := getPlanFromType(vtyp)
+	if err != nil {
+		panic(fmt.Errorf("EnforceQuota: could not get detailed reflection information for type %s: %w", vtyp.String(), err))
+	}
+
+	f := plan.fieldForColumn(groupByColumn)
+	if f == nil {
+		panic(fmt.Errorf("EnforceQuota: couldn't find field on %s for sql field %s", vtyp.Name(), groupByColumn))
+	}
+
+	if err := checkIdentifier(groupByColumn); err != nil {
+		panic(fmt.Errorf("EnforceQuota: %w", err))
+	}
+
+	return func(ctx context.Context, tx Querier, input interface{}) error {
+		v := reflect.Indirect(reflect.ValueOf(input))
+		if v.Type() != vtyp {
+			return nil
+		}
+
+		value := v.FieldByIndex(f.Index).Interface()
+
+		n, err := CountWhere(ctx, tx, reflect.New(vtyp).Interface(), "where "+groupByColumn+" = "+makeParameter(1), value)
+		if err != nil {
+			return fmt.Errorf("EnforceQuota: %w", err)
+		}
+
+		if n >= max {
+			vdesc, err := getDescriptionContext(ctx, vtyp)
+			if err != nil {
+				return fmt.Errorf("EnforceQuota: %w", err)
+			}
+
+			return &QuotaError{Table: getSQLTableNameContext(ctx, vdesc), Column: groupByColumn, Value: value, Max: max}
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,78 @@
+package sorm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type QuotaProject struct {
+	ID    int
+	OrgID int
+	Name  string
+}
+
+func TestEnforceQuota(t *testing.T) {
+	a := assert.New(t)
+
+	defer func(l []callback) { callbacks = l }(callbacks)
+	RegisterBeforeCreate(EnforceQuota(QuotaProject{}, "org_id", 2))
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectBegin()
+	mockDB.ExpectQuery(`select count\(\*\) from quota_projects where org_id = \$1`).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mockDB.ExpectQuery(`insert into quota_projects \(org_id, name\) values \(\$1, \$2\) returning id`).WithArgs(7, "a").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mockDB.ExpectQuery(`select count\(\*\) from quota_projects where org_id = \$1`).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mockDB.ExpectCommit()
+
+	tx, _ := db.Begin()
+
+	a.NoError(CreateRecord(context.Background(), tx, &QuotaProject{OrgID: 7, Name: "a"}))
+
+	err = CreateRecord(context.Background(), tx, &QuotaProject{OrgID: 7, Name: "b"})
+	a.True(errors.Is(err, ErrQuotaExceeded))
+
+	var qerr *QuotaError
+	if a.True(errors.As(err, &qerr)) {
+		a.Equal(&QuotaError{Table: "quota_projects", Column: "org_id", Value: 7, Max: 2}, qerr)
+	}
+
+	a.NoError(tx.Commit())
+}
+
+func TestEnforceQuotaSchema(t *testing.T) {
+	a := assert.New(t)
+
+	defer func(l []callback) { callbacks = l }(callbacks)
+	RegisterBeforeCreate(EnforceQuota(QuotaProject{}, "org_id", 2))
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectBegin()
+	mockDB.ExpectQuery(`select count\(\*\) from tenant_a.quota_projects where org_id = \$1`).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mockDB.ExpectCommit()
+
+	tx, _ := db.Begin()
+
+	err = CreateRecord(WithOptions(context.Background(), Options{Schema: "tenant_a"}), tx, &QuotaProject{OrgID: 7, Name: "a"})
+
+	var qerr *QuotaError
+	if a.True(errors.As(err, &qerr)) {
+		a.Equal(&QuotaError{Table: "tenant_a.quota_projects", Column: "org_id", Value: 7, Max: 2}, qerr)
+	}
+
+	a.NoError(tx.Commit())
+	a.NoError(mockDB.ExpectationsWereMet())
+}
@@ -0,0 +1,148 @@
+package sorm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// The batch hooks are called once per CreateRecords, SaveRecords or
+// DeleteRecords call, on the first record, with the records argument as it
+// was given. Per-record hooks still run as well.
+type BeforeCreateBatcher interface {
+	BeforeCreateBatch(ctx context.Context, tx Querier, records interface{}) error
+}
+
+type AfterCreateBatcher interface {
+	AfterCreateBatch(ctx context.Context, tx Querier, records interface{}) error
+}
+
+type BeforeSaveBatcher interface {
+	BeforeSaveBatch(ctx context.Context, tx Querier, records interface{}) error
+}
+
+type AfterSaveBatcher interface {
+	AfterSaveBatch(ctx context.Context, tx Querier, records interface{}) error
+}
+
+type BeforeDeleteBatcher interface {
+	BeforeDeleteBatch(ctx context.Context, tx Querier, records interface{}) error
+}
+
+type AfterDeleteBatcher interface {
+	AfterDeleteBatch(ctx context.Context, tx Querier, records interface{}) error
+}
+
+// recordPointers returns pointers to the elements of records, which must be a
+// pointer to a slice of structs or a slice of pointers to structs.
+func recordPointers(records interface{}) ([]interface{}, error) {
+	v := reflect.ValueOf(records)
+	if v.Kind() == reflect.Ptr && v.Elem().Kind() == reflect.Slice && v.Elem().Type().Elem().Kind() == reflect.Struct {
+		v = v.Elem()
+
+		l := make([]interface{}, v.Len())
+		for i := range l {
+			l[i] = v.Index(i).Addr().Interface()
+		}
+
+		return l, nil
+	}
+
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Ptr && v.Type().Elem().Elem().Kind() == reflect.Struct {
+		l := make([]interface{}, v.Len())
+		for i := range l {
+			if v.Index(i).IsNil() {
+				return nil, fmt.Errorf("record %d is nil", i)
+			}
+
+			l[i] = v.Index(i).Interface()
+		}
+
+		return l, nil
+	}
+
+	return nil, typeErrorf(ErrNotAStruct, "expected records to be pointer to slice of struct or slice of pointer to struct; was instead %T", records)
+}
+
+func runRecords(ctx context.Context, tx Querier, name string, records interface{}, before, after func(first interface{}) (string, func(ctx context.Context) error), fn func(ctx context.Context, tx Querier, input interface{}) error) error {
+	l, err := recordPointers(records)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	if len(l) == 0 {
+		return nil
+	}
+
+	if hook, call := before(l[0]); call != nil {
+		if err := callHook(ctx, "sorm hook "+hook, call); err != nil {
+			return fmt.Errorf("%s: %s callback returned an error: %w", name, hook, err)
+		}
+	}
+
+	for i, input := range l {
+		if err := fn(ctx, tx, input); err != nil {
+			return fmt.Errorf("%s: record %d: %w", name, i, err)
+		}
+	}
+
+	if hook, call := after(l[0]); call != nil {
+		if err := callHook(ctx, "sorm hook "+hook, call); err != nil {
+			return fmt.Errorf("%s: %s callback returned an error: %w", name, hook, err)
+		}
+	}
+
+	return nil
+}
+
+// CreateRecords creates each of records, which is a pointer to a slice of
+// structs or a slice of pointers to structs, stopping at the first error.
+func CreateRecords(ctx context.Context, tx Querier, records interface{}) error {
+	return runRecords(ctx, tx, "CreateRecords", records, func(first interface{}) (string, func(ctx context.Context) error) {
+		if v, ok := first.(BeforeCreateBatcher); ok {
+			return "BeforeCreateBatch", func(ctx context.Context) error { return v.BeforeCreateBatch(ctx, tx, records) }
+		}
+
+		return "", nil
+	}, func(first interface{}) (string, func(ctx context.Context) error) {
+		if v, ok := first.(AfterCreateBatcher); ok {
+			return "AfterCreateBatch", func(ctx context.Context) error { return v.AfterCreateBatch(ctx, tx, records) }
+		}
+
+		return "", nil
+	}, CreateRecord)
+}
+
+// SaveRecords is CreateRecords for SaveRecord.
+func SaveRecords(ctx context.Context, tx Querier, records interface{}) error {
+	return runRecords(ctx, tx, "SaveRecords", records, func(first interface{}) (string, func(ctx context.Context) error) {
+		if v, ok := first.(BeforeSaveBatcher); ok {
+			return "BeforeSaveBatch", func(ctx context.Context) error { return v.BeforeSaveBatch(ctx, tx, records) }
+		}
+
+		return "", nil
+	}, func(first interface{}) (string, func(ctx context.Context) error) {
+		if v, ok := first.(AfterSaveBatcher); ok {
+			return "AfterSaveBatch", func(ctx context.Context) error { return v.AfterSaveBatch(ctx, tx, records) }
+		}
+
+		return "", nil
+	}, SaveRecord)
+}
+
+// DeleteRecords is CreateRecords for DeleteRecord.
+func DeleteRecords(ctx context.Context, tx Querier, records interface{}) error {
+	return runRecords(ctx, tx, "DeleteRecords", records, func(first interface{}) (string, func(ctx context.Context) error) {
+		if v, ok := first.(BeforeDeleteBatcher); ok {
+			return "BeforeDeleteBatch", func(ctx context.Context) error { return v.BeforeDeleteBatch(ctx, tx, records) }
+		}
+
+		return "", nil
+	}, func(first interface{}) (string, func(ctx context.Context) error) {
+		if v, ok := first.(AfterDeleteBatcher); ok {
+			return "AfterDeleteBatch", func(ctx context.Context) error { return v.AfterDeleteBatch(ctx, tx, records) }
+		}
+
+		return "", nil
+	}, DeleteRecord)
+}
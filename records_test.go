@@ -0,0 +1,86 @@
+package sorm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type BatchHookObject struct {
+	ID    int
+	Name  string
+	Stamp string
+
+	calls *[]string `sql:"-"`
+}
+
+func (b *BatchHookObject) BeforeCreateBatch(ctx context.Context, tx Querier, records interface{}) error {
+	l := records.([]*BatchHookObject)
+	for _, e := range l {
+		if e.Name == "" {
+			return errors.New("name is required")
+		}
+
+		e.Stamp = "batch"
+	}
+
+	*b.calls = append(*b.calls, "before")
+
+	return nil
+}
+
+func (b *BatchHookObject) AfterCreateBatch(ctx context.Context, tx Querier, records interface{}) error {
+	*b.calls = append(*b.calls, "after")
+	return nil
+}
+
+func TestCreateRecordsBatchHooks(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`insert into batch_hook_objects \(name, stamp\) values \(\$1, \$2\) returning id`).WithArgs("a", "batch").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mockDB.ExpectQuery(`insert into batch_hook_objects \(name, stamp\) values \(\$1, \$2\) returning id`).WithArgs("b", "batch").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+
+	var calls []string
+	l := []*BatchHookObject{{Name: "a", calls: &calls}, {Name: "b", calls: &calls}}
+	a.NoError(CreateRecords(context.Background(), db, l))
+	a.Equal([]string{"before", "after"}, calls)
+	a.Equal(1, l[0].ID)
+	a.Equal(2, l[1].ID)
+
+	a.EqualError(CreateRecords(context.Background(), db, []*BatchHookObject{{Name: "a", calls: &calls}, {calls: &calls}}), "CreateRecords: BeforeCreateBatch callback returned an error: name is required")
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestSaveAndDeleteRecords(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from simple_objects where id = \$1 limit 1`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a"))
+	mockDB.ExpectExec(`update simple_objects set name = \$2 where id = \$1`).WithArgs(1, "x").WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec(`delete from simple_objects where id = \$1`).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec(`delete from simple_objects where id = \$1`).WithArgs(2).WillReturnError(errors.New("boom"))
+
+	l := []SimpleObject{{ID: 1, Name: "x"}}
+	a.NoError(SaveRecords(context.Background(), db, &l))
+
+	a.EqualError(DeleteRecords(context.Background(), db, &[]SimpleObject{{ID: 1}, {ID: 2}}), "DeleteRecords: record 1: DeleteRecord: boom")
+
+	a.EqualError(CreateRecords(context.Background(), db, []SimpleObject{}), "CreateRecords: expected records to be pointer to slice of struct or slice of pointer to struct; was instead []sorm.SimpleObject")
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
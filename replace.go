@@ -0,0 +1,251 @@
+package sorm
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+type ReplaceMode int
+
+const (
+	ReplaceInsertOrReplace ReplaceMode = iota
+	ReplaceMerge
+	ReplaceOnDuplicateKey
+)
+
+var (
+	replaceMode ReplaceMode
+)
+
+// SetReplaceMode picks the statement ReplaceRecord generates. The default is
+// "insert or replace"; ReplaceMerge produces a SQL Server MERGE statement and
+// ReplaceOnDuplicateKey a MySQL "on duplicate key update".
+func SetReplaceMode(m ReplaceMode) {
+	replaceMode = m
+}
+
+type ReplaceOptions struct {
+	// UpdateColumns lists the columns overwritten when the record already
+	// exists. By default that's every column that isn't an ID or readonly.
+	UpdateColumns []string
+	// NewRowAlias makes ReplaceOnDuplicateKey refer to the incoming row as
+	// "alias.col" (MySQL 8.0.19+) instead of "values(col)".
+	NewRowAlias string
+}
+
+// replaceUpdateColumns lists the columns to overwrite on conflict. The tenant
+// column never is, since a row only matches when it's the same already.
+func replaceUpdateColumns(vdesc *structDescription, idFields []structField, o *ReplaceOptions, tenant string) ([]string, error) {
+	isID := make(map[string]bool)
+	for _, f := range idFields {
+		isID[f.Name()] = true
+	}
+
+	var l []string
+	writable := make(map[string]bool)
+	for _, f := range getSQLWritableFields(vdesc) {
+		if isID[f.Name()] {
+			continue
+		}
+
+		col := getSQLColumnName(f)
+		if col == tenant {
+			continue
+		}
+		writable[col] = true
+
+		if t := f.Tag("sql"); t != nil && t.Parameter("readonly") != nil {
+			continue
+		}
+		if t := f.Tag("readonly"); t != nil && t.Value() != "" {
+			continue
+		}
+
+		l = append(l, col)
+	}
+
+	if o == nil || o.UpdateColumns == nil {
+		return l, nil
+	}
+
+	for _, c := range o.UpdateColumns {
+		if !writable[c] {
+			return nil, fmt.Errorf("can't update %s on conflict; it isn't a writable non-ID column of %s", c, vdesc.Name())
+		}
+	}
+
+	return o.UpdateColumns, nil
+}
+
+func buildOnDuplicateKey(vdesc *structDescription, tbl string, idFields []structField, v reflect.Value, o *ReplaceOptions, tenant string) (Statement, error) {
+	update, err := replaceUpdateColumns(vdesc, idFields, o, tenant)
+	if err != nil {
+		return Statement{}, err
+	}
+
+	var alias string
+	if o != nil && o.NewRowAlias != "" {
+		if err := checkIdentifier(o.NewRowAlias); err != nil {
+			return Statement{}, err
+		}
+
+		alias = o.NewRowAlias
+	}
+
+	var cols, params []string
+	var values []interface{}
+
+	for _, f := range getSQLWritableFields(vdesc) {
+		col := getSQLColumnName(f)
+		if err := checkIdentifier(col); err != nil {
+			return Statement{}, err
+		}
+
+		cols = append(cols, col)
+		params = append(params, makeParameter(len(cols)))
+		values = append(values, fieldValue(f, v))
+	}
+
+	if err := checkIdentifier(tbl); err != nil {
+		return Statement{}, err
+	}
+
+	query := fmt.Sprintf("insert into %s (%s) values (%s)", tbl, strings.Join(cols, ", "), strings.Join(params, ", "))
+	if alias != "" {
+		query += " as " + alias
+	}
+
+	incoming := func(c string) string {
+		if alias != "" {
+			return alias + "." + c
+		}
+
+		return "values(" + c + ")"
+	}
+
+	var set []string
+	for _, c := range update {
+		if tenant != "" {
+			// there's no where for on duplicate key, so another tenant's
+			// row keeps its values
+			set = append(set, c+" = if("+tenant+" = "+incoming(tenant)+", "+incoming(c)+", "+c+")")
+		} else {
+			set = append(set, c+" = "+incoming(c))
+		}
+	}
+
+	if len(set) == 0 {
+		// a no-op update keeps the existing row without raising an error
+		set = append(set, getSQLColumnName(idFields[0])+" = "+getSQLColumnName(idFields[0]))
+	}
+
+	query += " on duplicate key update " + strings.Join(set, ", ")
+
+	return Statement{Query: query, Args: values}, nil
+}
+
+func buildMerge(vdesc *structDescription, tbl string, idFields []structField, v reflect.Value, o *ReplaceOptions, tenant string) (Statement, error) {
+	update, err := replaceUpdateColumns(vdesc, idFields, o, tenant)
+	if err != nil {
+		return Statement{}, err
+	}
+
+	var on []string
+	for _, f := range idFields {
+		col := getSQLColumnName(f)
+		if err := checkIdentifier(col); err != nil {
+			return Statement{}, err
+		}
+
+		on = append(on, "t."+col+" = s."+col)
+	}
+
+	var cols, params, set, insert []string
+	var values []interface{}
+
+	for _, f := range getSQLWritableFields(vdesc) {
+		col := getSQLColumnName(f)
+		if err := checkIdentifier(col); err != nil {
+			return Statement{}, err
+		}
+
+		cols = append(cols, col)
+		params = append(params, makeParameter(len(cols)))
+		insert = append(insert, "s."+col)
+		values = append(values, fieldValue(f, v))
+	}
+
+	for _, col := range update {
+		set = append(set, "t."+col+" = s."+col)
+	}
+
+	if err := checkIdentifier(tbl); err != nil {
+		return Statement{}, err
+	}
+
+	query := fmt.Sprintf("merge into %s with (holdlock) as t using (values (%s)) as s (%s) on %s", tbl, strings.Join(params, ", "), strings.Join(cols, ", "), strings.Join(on, " and "))
+	if len(set) > 0 {
+		query += " when matched"
+		if tenant != "" {
+			query += " and t." + tenant + " = s." + tenant
+		}
+		query += " then update set " + strings.Join(set, ", ")
+	}
+	query += fmt.Sprintf(" when not matched then insert (%s) values (%s);", strings.Join(cols, ", "), strings.Join(insert, ", "))
+
+	return Statement{Query: query, Args: values}, nil
+}
+
+// buildOnConflict is the default replace for tenant scoped models, since
+// "insert or replace" can't leave another tenant's row alone.
+func buildOnConflict(vdesc *structDescription, tbl string, idFields []structField, v reflect.Value, o *ReplaceOptions, tenant string) (Statement, error) {
+	update, err := replaceUpdateColumns(vdesc, idFields, o, tenant)
+	if err != nil {
+		return Statement{}, err
+	}
+
+	var ids []string
+	for _, f := range idFields {
+		col := getSQLColumnName(f)
+		if err := checkIdentifier(col); err != nil {
+			return Statement{}, err
+		}
+
+		ids = append(ids, col)
+	}
+
+	var cols, params []string
+	var values []interface{}
+
+	for _, f := range getSQLWritableFields(vdesc) {
+		col := getSQLColumnName(f)
+		if err := checkIdentifier(col); err != nil {
+			return Statement{}, err
+		}
+
+		cols = append(cols, col)
+		params = append(params, makeParameter(len(cols)))
+		values = append(values, fieldValue(f, v))
+	}
+
+	if err := checkIdentifier(tbl); err != nil {
+		return Statement{}, err
+	}
+
+	_, name := splitTableName(tbl)
+
+	query := fmt.Sprintf("insert into %s (%s) values (%s) on conflict (%s)", tbl, strings.Join(cols, ", "), strings.Join(params, ", "), strings.Join(ids, ", "))
+	if len(update) == 0 {
+		query += " do nothing"
+	} else {
+		var set []string
+		for _, c := range update {
+			set = append(set, c+" = excluded."+c)
+		}
+
+		query += " do update set " + strings.Join(set, ", ") + " where " + name + "." + tenant + " = excluded." + tenant
+	}
+
+	return Statement{Query: query, Args: values}, nil
+}
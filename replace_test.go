@@ -0,0 +1,127 @@
+package sorm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplaceStatementMerge(t *testing.T) {
+	a := assert.New(t)
+
+	SetReplaceMode(ReplaceMerge)
+	defer SetReplaceMode(ReplaceInsertOrReplace)
+
+	SetParameterPrefix("@p")
+	defer SetParameterPrefix("")
+
+	s, err := ReplaceStatement(SimpleObject{ID: 1, Name: "test1"})
+	if !a.NoError(err) {
+		return
+	}
+
+	a.Equal("merge into simple_objects with (holdlock) as t using (values (@p1, @p2)) as s (id, name) on t.id = s.id when matched then update set t.name = s.name when not matched then insert (id, name) values (s.id, s.name);", s.Query)
+	a.Equal([]interface{}{1, "test1"}, s.Args)
+	a.NoError(s.Validate())
+}
+
+func TestReplaceStatementMergeOnlyIDs(t *testing.T) {
+	a := assert.New(t)
+
+	SetReplaceMode(ReplaceMerge)
+	defer SetReplaceMode(ReplaceInsertOrReplace)
+
+	type MergeLink struct {
+		LeftID  int `sql:",id"`
+		RightID int `sql:",id"`
+	}
+
+	s, err := ReplaceStatement(MergeLink{LeftID: 1, RightID: 2})
+	if !a.NoError(err) {
+		return
+	}
+
+	a.Equal("merge into merge_links with (holdlock) as t using (values ($1, $2)) as s (left_id, right_id) on t.left_id = s.left_id and t.right_id = s.right_id when not matched then insert (left_id, right_id) values (s.left_id, s.right_id);", s.Query)
+}
+
+func TestReplaceRecordMerge(t *testing.T) {
+	a := assert.New(t)
+
+	SetReplaceMode(ReplaceMerge)
+	defer SetReplaceMode(ReplaceInsertOrReplace)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectExec(`merge into simple_objects with \(holdlock\) as t using \(values \(\$1, \$2\)\) as s \(id, name\) on t\.id = s\.id`).WithArgs(1, "test1").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	a.NoError(ReplaceRecord(context.Background(), db, &SimpleObject{ID: 1, Name: "test1"}))
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+type UpsertObject struct {
+	ID        int
+	Name      string
+	Count     int
+	CreatedAt string `sql:",readonly"`
+}
+
+func TestReplaceStatementOnDuplicateKey(t *testing.T) {
+	a := assert.New(t)
+
+	SetReplaceMode(ReplaceOnDuplicateKey)
+	defer SetReplaceMode(ReplaceInsertOrReplace)
+
+	SetParameterPrefix("?")
+	defer SetParameterPrefix("")
+
+	s, err := ReplaceStatement(UpsertObject{ID: 1, Name: "a", Count: 2, CreatedAt: "now"})
+	if !a.NoError(err) {
+		return
+	}
+
+	a.Equal("insert into upsert_objects (id, name, count, created_at) values (?1, ?2, ?3, ?4) on duplicate key update name = values(name), count = values(count)", s.Query)
+	a.Equal([]interface{}{1, "a", 2, "now"}, s.Args)
+}
+
+func TestReplaceRecordOnDuplicateKeyColumns(t *testing.T) {
+	a := assert.New(t)
+
+	SetReplaceMode(ReplaceOnDuplicateKey)
+	defer SetReplaceMode(ReplaceInsertOrReplace)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectExec(`insert into upsert_objects \(id, name, count, created_at\) values \(\$1, \$2, \$3, \$4\) as new on duplicate key update count = new\.count$`).WithArgs(1, "a", 2, "now").WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec(`insert into upsert_objects \(id, name, count, created_at\) values \(\$1, \$2, \$3, \$4\) on duplicate key update id = id$`).WithArgs(1, "a", 2, "now").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	r := UpsertObject{ID: 1, Name: "a", Count: 2, CreatedAt: "now"}
+	a.NoError(ReplaceRecordWithOptions(context.Background(), db, &r, &ReplaceOptions{UpdateColumns: []string{"count"}, NewRowAlias: "new"}))
+	a.NoError(ReplaceRecordWithOptions(context.Background(), db, &r, &ReplaceOptions{UpdateColumns: []string{}}))
+
+	a.EqualError(ReplaceRecordWithOptions(context.Background(), db, &r, &ReplaceOptions{UpdateColumns: []string{"id"}}), "ReplaceRecord: can't update id on conflict; it isn't a writable non-ID column of UpsertObject")
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestReplaceStatementMergeColumns(t *testing.T) {
+	a := assert.New(t)
+
+	SetReplaceMode(ReplaceMerge)
+	defer SetReplaceMode(ReplaceInsertOrReplace)
+
+	s, err := ReplaceStatement(UpsertObject{ID: 1})
+	if !a.NoError(err) {
+		return
+	}
+
+	a.Equal("merge into upsert_objects with (holdlock) as t using (values ($1, $2, $3, $4)) as s (id, name, count, created_at) on t.id = s.id when matched then update set t.name = s.name, t.count = s.count when not matched then insert (id, name, count, created_at) values (s.id, s.name, s.count, s.created_at);", s.Query)
+}
@@ -0,0 +1,63 @@
+package sorm
+
+import (
+	"context"
+)
+
+// Repository is the common reads and writes for records of type T. Code that
+// takes a Repository can be tested with sormtest.MemoryRepo instead of a
+// database. where is the same text that FindWhere and CountWhere take.
+type Repository[T any] interface {
+	Find(ctx context.Context, where string, args ...interface{}) ([]T, error)
+	FindByID(ctx context.Context, id ...interface{}) (*T, error)
+	Create(ctx context.Context, v *T) error
+	Save(ctx context.Context, v *T) error
+	Delete(ctx context.Context, v *T) error
+	Count(ctx context.Context, where string, args ...interface{}) (int, error)
+}
+
+// DBRepository is a Repository that calls the package functions with DB, so
+// hooks, callbacks and Options all apply as usual.
+type DBRepository[T any] struct {
+	DB Querier
+}
+
+var _ Repository[struct{}] = (*DBRepository[struct{}])(nil)
+
+func NewRepository[T any](db Querier) *DBRepository[T] {
+	return &DBRepository[T]{DB: db}
+}
+
+func (r *DBRepository[T]) Find(ctx context.Context, where string, args ...interface{}) ([]T, error) {
+	var l []T
+	if err := FindWhere(ctx, r.DB, &l, where, args...); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+func (r *DBRepository[T]) FindByID(ctx context.Context, id ...interface{}) (*T, error) {
+	var v T
+	if err := FindByID(ctx, r.DB, &v, id...); err != nil {
+		return nil, err
+	}
+
+	return &v, nil
+}
+
+func (r *DBRepository[T]) Create(ctx context.Context, v *T) error {
+	return CreateRecord(ctx, r.DB, v)
+}
+
+func (r *DBRepository[T]) Save(ctx context.Context, v *T) error {
+	return SaveRecord(ctx, r.DB, v)
+}
+
+func (r *DBRepository[T]) Delete(ctx context.Context, v *T) error {
+	return DeleteRecord(ctx, r.DB, v)
+}
+
+func (r *DBRepository[T]) Count(ctx context.Context, where string, args ...interface{}) (int, error) {
+	return CountWhere(ctx, r.DB, new(T), where, args...)
+}
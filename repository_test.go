@@ -0,0 +1,44 @@
+package sorm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBRepository(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectExec(`^insert into simple_objects \(id, name\) values \(\$1, \$2\)$`).WithArgs(1, "a").WillReturnResult(sqlmock.NewResult(1, 1))
+	mockDB.ExpectQuery(`^select \* from simple_objects where name = \$1$`).WithArgs("a").WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a"))
+	mockDB.ExpectQuery(`^select \* from simple_objects where id = \$1 limit 1$`).WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	mockDB.ExpectQuery(`^select count\(\*\) from simple_objects where name = \$1$`).WithArgs("a").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mockDB.ExpectExec(`^delete from simple_objects where id = \$1$`).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	var r Repository[SimpleObject] = NewRepository[SimpleObject](db)
+
+	a.NoError(r.Create(context.Background(), &SimpleObject{ID: 1, Name: "a"}))
+
+	l, err := r.Find(context.Background(), "where name = $1", "a")
+	a.NoError(err)
+	a.Equal([]SimpleObject{{ID: 1, Name: "a"}}, l)
+
+	_, err = r.FindByID(context.Background(), 2)
+	a.ErrorIs(err, ErrRecordNotFound)
+
+	n, err := r.Count(context.Background(), "where name = $1", "a")
+	a.NoError(err)
+	a.Equal(1, n)
+
+	a.NoError(r.Delete(context.Background(), &SimpleObject{ID: 1}))
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
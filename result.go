@@ -0,0 +1,46 @@
+package sorm
+
+import (
+	"context"
+	"time"
+)
+
+// Result describes a write that ran. GeneratedID is only set by CreateRecord
+// when the database assigned the ID.
+type Result struct {
+	Op           Operation
+	Table        string
+	RowsAffected int64
+	GeneratedID  interface{}
+	Duration     time.Duration
+	Query        string
+}
+
+type resultKey struct{}
+
+// WithResult makes the next CreateRecord, SaveRecord, ReplaceRecord or
+// DeleteRecord made with ctx fill in r when it succeeds. Writes made by its
+// hooks and callbacks don't touch r.
+func WithResult(ctx context.Context, r *Result) context.Context {
+	return context.WithValue(ctx, resultKey{}, r)
+}
+
+func resultFrom(ctx context.Context) *Result {
+	r, _ := ctx.Value(resultKey{}).(*Result)
+	return r
+}
+
+func setResult(r *Result, op Operation, table string, stmt Statement, start time.Time, rows int64, id interface{}) {
+	if r == nil {
+		return
+	}
+
+	*r = Result{
+		Op:           op,
+		Table:        table,
+		RowsAffected: rows,
+		GeneratedID:  id,
+		Duration:     time.Since(start),
+		Query:        stmt.Query,
+	}
+}
@@ -0,0 +1,45 @@
+package sorm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type ResultHookObject struct {
+	ID   int
+	Name string
+}
+
+func (r *ResultHookObject) AfterCreate(ctx context.Context, tx Querier) error {
+	return DeleteRecord(ctx, tx, &SimpleObject{ID: 9})
+}
+
+func TestWithResult(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`insert into result_hook_objects \(name\) values \(\$1\) returning id`).WithArgs("a").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mockDB.ExpectExec(`delete from simple_objects where id = \$1`).WithArgs(9).WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec(`delete from simple_objects where id = \$1`).WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	var r Result
+	a.NoError(CreateRecord(WithResult(context.Background(), &r), db, &ResultHookObject{Name: "a"}))
+	a.Equal(OperationCreate, r.Op)
+	a.Equal("result_hook_objects", r.Table)
+	a.Equal(int64(1), r.RowsAffected)
+	a.Equal(7, r.GeneratedID)
+	a.Equal("insert into result_hook_objects (name) values ($1) returning id", r.Query)
+
+	a.NoError(DeleteRecord(WithResult(context.Background(), &r), db, &SimpleObject{ID: 3}))
+	a.Equal(Result{Op: OperationDelete, Table: "simple_objects", RowsAffected: 1, Query: "delete from simple_objects where id = $1", Duration: r.Duration}, r)
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
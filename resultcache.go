@@ -0,0 +1,241 @@
+package sorm
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Cache stores encoded find results for SetResultCache. A ttl of 0 means the
+// entry doesn't expire. Implementations must be safe for concurrent use; a
+// Redis adapter only needs GET, SET with PX and DEL.
+type Cache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte, ttl time.Duration)
+	Delete(key string)
+}
+
+var (
+	resultCache     Cache
+	resultCacheTTL  time.Duration
+	resultCacheOnce sync.Once
+)
+
+// SetResultCache makes FindByID and FindFirstWhere keep their results in c
+// for ttl. Passing nil turns caching off.
+//
+// Entries are keyed on the table, the query and its args, plus a generation
+// for the table that's also kept in c. Both keys include the context's
+// Options.CacheNamespace, which must be set to tell databases apart when
+// more than one of them reads through c. Writes made through sorm delete the
+// table's generation, so nothing cached before the write is read again. The
+// generation goes when the write runs rather than when its transaction
+// commits, so a read racing the commit can still cache the old row until
+// ttl passes.
+//
+// Reads made in a *sql.Tx or with a Router, reads of models with AfterFind
+// hooks and results that gob can't encode aren't cached.
+func SetResultCache(c Cache, ttl time.Duration) {
+	resultCache, resultCacheTTL = c, ttl
+
+	resultCacheOnce.Do(func() {
+		fn := func(ctx context.Context, db Querier, e *CallbackEvent) error {
+			invalidateResultCache(ctx, e.Table)
+			return nil
+		}
+
+		for _, op := range []Operation{OperationCreate, OperationSave, OperationReplace, OperationDelete} {
+			RegisterCallback(op, PhaseAfter, fn)
+		}
+	})
+}
+
+type noCacheKey struct{}
+
+// WithoutCache makes finds made with the returned context skip the result
+// cache, e.g. for transactions that aren't a *sql.Tx.
+func WithoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noCacheKey{}, true)
+}
+
+func cacheable(ctx context.Context, db Querier, vtyp reflect.Type) bool {
+	if resultCache == nil {
+		return false
+	}
+
+	if b, _ := ctx.Value(noCacheKey{}).(bool); b {
+		return false
+	}
+
+	if _, ok := db.(*sql.Tx); ok {
+		return false
+	}
+
+	if optionsFrom(ctx).Router != nil {
+		return false
+	}
+
+	return !reflect.PtrTo(vtyp).Implements(reflect.TypeOf((*AfterFinder)(nil)).Elem())
+}
+
+func resultGenerationKey(ns, tbl string) string {
+	return "sorm:generation:" + ns + ":" + tbl
+}
+
+func invalidateResultCache(ctx context.Context, tbl string) {
+	if c := resultCache; c != nil {
+		c.Delete(resultGenerationKey(optionsFrom(ctx).CacheNamespace, tbl))
+	}
+}
+
+// resultCacheKey returns the key for stmt's result, or false if one of its
+// args can't be turned into a driver value.
+func resultCacheKey(ctx context.Context, c Cache, tbl string, stmt Statement) (string, bool) {
+	ns := optionsFrom(ctx).CacheNamespace
+
+	gen, ok := c.Get(resultGenerationKey(ns, tbl))
+	if !ok {
+		b := make([]byte, 8)
+		if _, err := rand.Read(b); err != nil {
+			return "", false
+		}
+
+		gen = []byte(hex.EncodeToString(b))
+		c.Set(resultGenerationKey(ns, tbl), gen, 0)
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s", gen, stmt.Query)
+
+	for _, a := range stmt.Args {
+		v, err := driver.DefaultParameterConverter.ConvertValue(a)
+		if err != nil {
+			return "", false
+		}
+
+		fmt.Fprintf(h, "\x00%T:%v", v, v)
+	}
+
+	return "sorm:result:" + ns + ":" + tbl + ":" + hex.EncodeToString(h.Sum(nil)), true
+}
+
+func loadCachedResult(c Cache, key string, ptr reflect.Value) bool {
+	b, ok := c.Get(key)
+	if !ok {
+		return false
+	}
+
+	v := reflect.New(ptr.Elem().Type())
+	if err := gob.NewDecoder(bytes.NewReader(b)).DecodeValue(v); err != nil {
+		c.Delete(key)
+		return false
+	}
+
+	ptr.Elem().Set(v.Elem())
+
+	return true
+}
+
+func storeCachedResult(c Cache, key string, ptr reflect.Value) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).EncodeValue(ptr.Elem()); err != nil {
+		return
+	}
+
+	c.Set(key, buf.Bytes(), resultCacheTTL)
+}
+
+// LRUCache is an in-memory Cache that holds at most size entries, dropping
+// the least recently used first.
+type LRUCache struct {
+	mu    sync.Mutex
+	size  int
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type lruEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+func NewLRUCache(size int) *LRUCache {
+	return &LRUCache{size: size, ll: list.New(), items: make(map[string]*list.Element)}
+}
+
+func (c *LRUCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	e := el.Value.(*lruEntry)
+	if !e.expires.IsZero() && !timeNow().Before(e.expires) {
+		c.remove(el)
+		return nil, false
+	}
+
+	c.ll.MoveToFront(el)
+
+	return e.value, true
+}
+
+func (c *LRUCache) Set(key string, value []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var expires time.Time
+	if ttl > 0 {
+		expires = timeNow().Add(ttl)
+	}
+
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*lruEntry)
+		e.value, e.expires = value, expires
+		c.ll.MoveToFront(el)
+		return
+	}
+
+	c.items[key] = c.ll.PushFront(&lruEntry{key: key, value: value, expires: expires})
+
+	for c.size > 0 && c.ll.Len() > c.size {
+		c.remove(c.ll.Back())
+	}
+}
+
+func (c *LRUCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+}
+
+// Len returns the number of entries, including expired ones that haven't
+// been dropped yet.
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.ll.Len()
+}
+
+func (c *LRUCache) remove(el *list.Element) {
+	c.ll.Remove(el)
+	delete(c.items, el.Value.(*lruEntry).key)
+}
@@ -0,0 +1,206 @@
+package sorm
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func withResultCache(c Cache) func() {
+	l := callbacks
+
+	SetResultCache(c, time.Minute)
+
+	return func() {
+		SetResultCache(nil, 0)
+		callbacks = l
+		resultCacheOnce = sync.Once{}
+	}
+}
+
+func TestFindByIDCached(t *testing.T) {
+	a := assert.New(t)
+
+	defer withResultCache(NewLRUCache(10))()
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from simple_objects where id = \$1 limit 1`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a"))
+	mockDB.ExpectQuery(`select \* from simple_objects where id = \$1 limit 1`).WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(2, "b"))
+	mockDB.ExpectExec(`delete from simple_objects where id = \$1`).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectQuery(`select \* from simple_objects where id = \$1 limit 1`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a"))
+
+	for i := 0; i < 2; i++ {
+		var r SimpleObject
+		if a.NoError(FindByID(context.Background(), db, &r, 1)) {
+			a.Equal(SimpleObject{ID: 1, Name: "a"}, r)
+		}
+	}
+
+	var r SimpleObject
+	a.NoError(FindByID(context.Background(), db, &r, 2))
+	a.Equal(SimpleObject{ID: 2, Name: "b"}, r)
+
+	a.NoError(DeleteRecord(context.Background(), db, &SimpleObject{ID: 2}))
+
+	a.NoError(FindByID(context.Background(), db, &r, 1))
+	a.Equal(SimpleObject{ID: 1, Name: "a"}, r)
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestFindFirstWhereCacheBypass(t *testing.T) {
+	a := assert.New(t)
+
+	defer withResultCache(NewLRUCache(10))()
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from simple_objects where name = \$1 limit 1`).WithArgs("a").WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a"))
+	mockDB.ExpectQuery(`select \* from simple_objects where name = \$1 limit 1`).WithArgs("a").WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a"))
+	mockDB.ExpectBegin()
+	mockDB.ExpectQuery(`select \* from simple_objects where name = \$1 limit 1`).WithArgs("a").WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a"))
+	mockDB.ExpectRollback()
+
+	var r SimpleObject
+	a.NoError(FindFirstWhere(context.Background(), db, &r, "where name = $1", "a"))
+	a.NoError(FindFirstWhere(WithoutCache(context.Background()), db, &r, "where name = $1", "a"))
+
+	tx, err := db.Begin()
+	if !a.NoError(err) {
+		return
+	}
+	a.NoError(FindFirstWhere(context.Background(), tx, &r, "where name = $1", "a"))
+	a.NoError(tx.Rollback())
+
+	a.NoError(FindFirstWhere(context.Background(), db, &r, "where name = $1", "a"))
+	a.Equal(SimpleObject{ID: 1, Name: "a"}, r)
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestFindByIDCacheNamespace(t *testing.T) {
+	a := assert.New(t)
+
+	defer withResultCache(NewLRUCache(10))()
+
+	db1, mockDB1, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db1.Close()
+
+	db2, mockDB2, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db2.Close()
+
+	mockDB1.ExpectQuery(`select \* from simple_objects where id = \$1 limit 1`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a"))
+	mockDB2.ExpectQuery(`select \* from simple_objects where id = \$1 limit 1`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "b"))
+	mockDB2.ExpectExec(`delete from simple_objects where id = \$1`).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB2.ExpectQuery(`select \* from simple_objects where id = \$1 limit 1`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+
+	ctx1 := WithOptions(context.Background(), Options{CacheNamespace: "one"})
+	ctx2 := WithOptions(context.Background(), Options{CacheNamespace: "two"})
+
+	var r SimpleObject
+	a.NoError(FindByID(ctx1, db1, &r, 1))
+	a.Equal(SimpleObject{ID: 1, Name: "a"}, r)
+
+	a.NoError(FindByID(ctx2, db2, &r, 1))
+	a.Equal(SimpleObject{ID: 1, Name: "b"}, r)
+
+	a.NoError(DeleteRecord(ctx2, db2, &SimpleObject{ID: 1}))
+	a.ErrorIs(FindByID(ctx2, db2, &r, 1), ErrRecordNotFound)
+
+	a.NoError(FindByID(ctx1, db1, &r, 1))
+	a.Equal(SimpleObject{ID: 1, Name: "a"}, r)
+
+	a.NoError(mockDB1.ExpectationsWereMet())
+	a.NoError(mockDB2.ExpectationsWereMet())
+}
+
+func TestFindByIDCacheRouter(t *testing.T) {
+	a := assert.New(t)
+
+	defer withResultCache(NewLRUCache(10))()
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from simple_objects where id = \$1 limit 1`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a"))
+	mockDB.ExpectQuery(`select \* from simple_objects where id = \$1 limit 1`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a"))
+
+	ctx := WithOptions(context.Background(), Options{Router: RouterFunc(func(ctx context.Context, model interface{}, op Operation) Querier {
+		return db
+	})})
+
+	for i := 0; i < 2; i++ {
+		var r SimpleObject
+		if a.NoError(FindByID(ctx, nil, &r, 1)) {
+			a.Equal(SimpleObject{ID: 1, Name: "a"}, r)
+		}
+	}
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestFindByIDArgs(t *testing.T) {
+	a := assert.New(t)
+
+	var r SimpleObject
+	a.EqualError(FindByID(context.Background(), nil, &r, 1, 2), "FindByID: SimpleObject has 1 ID field(s); got 2 value(s)")
+}
+
+func TestLRUCache(t *testing.T) {
+	a := assert.New(t)
+
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	c := NewLRUCache(2)
+
+	c.Set("a", []byte("1"), 0)
+	c.Set("b", []byte("2"), time.Second)
+
+	v, ok := c.Get("a")
+	a.True(ok)
+	a.Equal([]byte("1"), v)
+
+	c.Set("c", []byte("3"), 0)
+	a.Equal(2, c.Len())
+
+	_, ok = c.Get("b")
+	a.False(ok)
+
+	c.Set("b", []byte("2"), time.Second)
+	now = now.Add(time.Second)
+
+	_, ok = c.Get("b")
+	a.False(ok)
+
+	_, ok = c.Get("a")
+	a.False(ok)
+
+	c.Delete("c")
+	_, ok = c.Get("c")
+	a.False(ok)
+	a.Equal(0, c.Len())
+}
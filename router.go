@@ -0,0 +1,64 @@
+package sorm
+
+import (
+	"context"
+)
+
+// Router picks the database for an operation on model, which is the record
+// being written or the output of a find. Returning nil keeps the database
+// the caller passed in. Routing happens once per call, so hooks and reads
+// made by a write use the same database as the write itself.
+type Router interface {
+	Resolve(ctx context.Context, model interface{}, op Operation) Querier
+}
+
+type RouterFunc func(ctx context.Context, model interface{}, op Operation) Querier
+
+func (fn RouterFunc) Resolve(ctx context.Context, model interface{}, op Operation) Querier {
+	return fn(ctx, model, op)
+}
+
+var (
+	router Router
+)
+
+// SetRouter sets the router used when the context's Options don't have one.
+func SetRouter(r Router) {
+	router = r
+}
+
+type noRouteKey struct{}
+
+// route returns the database for op, marking ctx so nothing further down
+// is routed again.
+func route(ctx context.Context, db Querier, model interface{}, op Operation) (context.Context, Querier) {
+	if b, _ := ctx.Value(noRouteKey{}).(bool); b {
+		return ctx, db
+	}
+
+	r := optionsFrom(ctx).Router
+	if r == nil {
+		return ctx, db
+	}
+
+	ctx = context.WithValue(ctx, noRouteKey{}, true)
+
+	if q := r.Resolve(ctx, model, op); q != nil {
+		return ctx, q
+	}
+
+	return ctx, db
+}
+
+// ShardLister is a Router that can list every database it routes to.
+type ShardLister interface {
+	Router
+	Shards() []Querier
+}
+
+// FindWhereShards runs FindWhere on every shard of r and merges the results
+// as FindWhereFanoutWithOptions does. The router isn't consulted for the
+// individual finds.
+func FindWhereShards(ctx context.Context, r ShardLister, out interface{}, opts *FanoutOptions, where string, args ...interface{}) error {
+	return FindWhereFanoutWithOptions(context.WithValue(ctx, noRouteKey{}, true), r.Shards(), out, opts, where, args...)
+}
@@ -0,0 +1,68 @@
+package sorm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type shardRouter struct {
+	shards []Querier
+}
+
+func (r *shardRouter) Resolve(ctx context.Context, model interface{}, op Operation) Querier {
+	if v, ok := model.(*SimpleObject); ok {
+		return r.shards[v.ID%len(r.shards)]
+	}
+
+	return nil
+}
+
+func (r *shardRouter) Shards() []Querier { return r.shards }
+
+func TestRouter(t *testing.T) {
+	a := assert.New(t)
+
+	fallback, fallbackMock, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer fallback.Close()
+
+	shard0, shard0Mock, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer shard0.Close()
+
+	shard1, shard1Mock, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer shard1.Close()
+
+	r := &shardRouter{shards: []Querier{shard0, shard1}}
+	ctx := WithOptions(context.Background(), Options{Router: r})
+
+	shard1Mock.ExpectExec(`^insert into simple_objects \(id, name\) values \(\$1, \$2\)$`).WithArgs(3, "a").WillReturnResult(sqlmock.NewResult(0, 1))
+	shard0Mock.ExpectQuery(`^select \* from simple_objects where id = \$1`).WithArgs(4).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(4, "b"))
+	shard0Mock.ExpectExec(`^update simple_objects set name = \$2 where id = \$1$`).WithArgs(4, "c").WillReturnResult(sqlmock.NewResult(0, 1))
+	fallbackMock.ExpectQuery(`^select \* from simple_objects$`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	shard0Mock.ExpectQuery(`^select \* from simple_objects$`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(4, "c"))
+	shard1Mock.ExpectQuery(`^select \* from simple_objects$`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(3, "a"))
+
+	a.NoError(CreateRecord(ctx, fallback, &SimpleObject{ID: 3, Name: "a"}))
+	a.NoError(SaveRecord(ctx, fallback, &SimpleObject{ID: 4, Name: "c"}))
+
+	var l []SimpleObject
+	a.NoError(FindAll(ctx, fallback, &l))
+
+	a.NoError(FindWhereShards(ctx, r, &l, &FanoutOptions{OrderBy: []string{"id"}}, ""))
+	a.Equal([]SimpleObject{{ID: 3, Name: "a"}, {ID: 4, Name: "c"}}, l)
+
+	a.NoError(fallbackMock.ExpectationsWereMet())
+	a.NoError(shard0Mock.ExpectationsWereMet())
+	a.NoError(shard1Mock.ExpectationsWereMet())
+}
@@ -0,0 +1,85 @@
+package sorm
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+type HookPolicy int
+
+const (
+	// HookAbort makes a hook error fail the write it was called for. It's
+	// the default.
+	HookAbort HookPolicy = iota
+	// HookSandbox runs the hook inside a savepoint. If the hook fails, its
+	// writes are rolled back to the savepoint, the error is passed to the
+	// sandbox error logger and the original write carries on.
+	HookSandbox
+)
+
+// HookPolicier lets a model pick a policy for each of its AfterCreate and
+// AfterSave hooks. hook is the name of the hook method.
+type HookPolicier interface {
+	HookPolicy(hook string) HookPolicy
+}
+
+// SandboxErrorFunc is told about errors from sandboxed hooks.
+type SandboxErrorFunc func(ctx context.Context, hook string, err error)
+
+var (
+	sandboxErrorFunc SandboxErrorFunc
+	savepointCounter uint64
+)
+
+// SetSandboxErrorLogger replaces the default handling of sandboxed hook
+// errors, which is to write them to the standard logger.
+func SetSandboxErrorLogger(fn SandboxErrorFunc) {
+	sandboxErrorFunc = fn
+}
+
+func callAfterHook(ctx context.Context, tx Querier, input interface{}, hook string, fn func(ctx context.Context) error) error {
+	if p, ok := input.(HookPolicier); !ok || p.HookPolicy(hook) != HookSandbox {
+		return callHook(ctx, "sorm hook "+hook, fn)
+	}
+
+	name := fmt.Sprintf("sorm_hook_%d", atomic.AddUint64(&savepointCounter, 1))
+
+	if err := execSavepoint(ctx, tx, "savepoint "+name); err != nil {
+		return fmt.Errorf("couldn't create savepoint for %s: %w", hook, err)
+	}
+
+	if err := callHook(ctx, "sorm hook "+hook, fn); err != nil {
+		if err := execSavepoint(ctx, tx, "rollback to savepoint "+name); err != nil {
+			return fmt.Errorf("couldn't roll back savepoint for %s: %w", hook, err)
+		}
+
+		if sandboxErrorFunc != nil {
+			sandboxErrorFunc(ctx, hook, err)
+		} else {
+			log.Printf("sorm: sandboxed %s hook failed and was rolled back: %v", hook, err)
+		}
+
+		return nil
+	}
+
+	if err := execSavepoint(ctx, tx, "release savepoint "+name); err != nil {
+		return fmt.Errorf("couldn't release savepoint for %s: %w", hook, err)
+	}
+
+	return nil
+}
+
+func execSavepoint(ctx context.Context, tx Querier, query string) error {
+	logQuery(ctx, query, nil)
+
+	start := time.Now()
+
+	_, err := tx.ExecContext(ctx, query)
+
+	logQueryAfter(ctx, query, nil, start, err)
+
+	return err
+}
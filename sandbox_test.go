@@ -0,0 +1,99 @@
+package sorm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type SandboxObject struct {
+	ID   int
+	Name string
+
+	err    error      `sql:"-"`
+	policy HookPolicy `sql:"-"`
+}
+
+func (s *SandboxObject) HookPolicy(hook string) HookPolicy {
+	if hook == "AfterCreate" {
+		return s.policy
+	}
+
+	return HookAbort
+}
+
+func (s *SandboxObject) AfterCreate(ctx context.Context, tx Querier) error {
+	if _, err := tx.ExecContext(ctx, "insert into audit_log (message) values ('created')"); err != nil {
+		return err
+	}
+
+	return s.err
+}
+
+func TestSandboxedHookFailure(t *testing.T) {
+	a := assert.New(t)
+
+	defer SetSandboxErrorLogger(nil)
+
+	var logged []string
+	SetSandboxErrorLogger(func(ctx context.Context, hook string, err error) {
+		logged = append(logged, hook+": "+err.Error())
+	})
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`insert into sandbox_objects \(name\) values \(\$1\) returning id`).WithArgs("a").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mockDB.ExpectExec(`savepoint sorm_hook_\d+`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mockDB.ExpectExec(`insert into audit_log`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec(`rollback to savepoint sorm_hook_\d+`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	r := SandboxObject{Name: "a", err: errors.New("boom"), policy: HookSandbox}
+	a.NoError(CreateRecord(context.Background(), db, &r))
+	a.Equal(1, r.ID)
+	a.Equal([]string{"AfterCreate: boom"}, logged)
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestSandboxedHookSuccess(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`insert into sandbox_objects`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mockDB.ExpectExec(`savepoint sorm_hook_\d+`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mockDB.ExpectExec(`insert into audit_log`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec(`release savepoint sorm_hook_\d+`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	a.NoError(CreateRecord(context.Background(), db, &SandboxObject{Name: "a", policy: HookSandbox}))
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestUnsandboxedHookFailure(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`insert into sandbox_objects`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mockDB.ExpectExec(`insert into audit_log`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	a.EqualError(CreateRecord(context.Background(), db, &SandboxObject{Name: "a", err: errors.New("boom")}), "CreateRecord: AfterCreate callback returned an error: boom")
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
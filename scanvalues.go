@@ -0,0 +1,159 @@
+package sorm
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+)
+
+var (
+	mapType = reflect.TypeOf(map[string]interface{}(nil))
+)
+
+// isScalarType reports whether typ holds a single column's value rather than
+// a record.
+func isScalarType(typ reflect.Type) bool {
+	if reflect.PtrTo(typ).Implements(scannerType) {
+		return true
+	}
+
+	switch typ.Kind() {
+	case reflect.Bool, reflect.String, reflect.Interface,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Slice:
+		return typ.Elem().Kind() == reflect.Uint8
+	case reflect.Struct:
+		return typ == timeType
+	case reflect.Ptr:
+		return isScalarType(typ.Elem())
+	default:
+		return false
+	}
+}
+
+func scannedRows(ptr reflect.Value) int64 {
+	if ptr.Elem().Kind() == reflect.Slice {
+		return int64(ptr.Elem().Len())
+	}
+
+	return 1
+}
+
+func scanScalars(ctx context.Context, rows *sql.Rows, ptr reflect.Value) error {
+	styp := ptr.Type().Elem()
+
+	if styp.Elem() == rawBytesType {
+		return fmt.Errorf("ScanRows: can't scan into a slice of sql.RawBytes; the driver reuses their memory")
+	}
+
+	names, err := rows.Columns()
+	if err != nil {
+		return fmt.Errorf("ScanRows: %w", err)
+	}
+
+	if len(names) != 1 {
+		return fmt.Errorf("ScanRows: expected exactly one column to scan into %s; got %d", styp, len(names))
+	}
+
+	o := optionsFrom(ctx)
+
+	arr := reflect.Indirect(reflect.New(styp))
+
+	for rows.Next() {
+		if o.MaxRows > 0 && arr.Len() >= o.MaxRows {
+			return fmt.Errorf("ScanRows: query returned more than %d rows", o.MaxRows)
+		}
+
+		p := reflect.New(styp.Elem())
+		if err := rows.Scan(p.Interface()); err != nil {
+			return fmt.Errorf("ScanRows: %w", err)
+		}
+
+		arr = reflect.Append(arr, p.Elem())
+	}
+
+	ptr.Elem().Set(arr)
+
+	return nil
+}
+
+func scanMapRow(rows *sql.Rows, names []string) (map[string]interface{}, error) {
+	values := make([]interface{}, len(names))
+	args := make([]interface{}, len(names))
+	for i := range values {
+		args[i] = &values[i]
+	}
+
+	if err := rows.Scan(args...); err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]interface{}, len(names))
+	for i, name := range names {
+		m[name] = values[i]
+	}
+
+	return m, nil
+}
+
+func scanMaps(ctx context.Context, rows *sql.Rows, ptr reflect.Value) error {
+	names, err := rows.Columns()
+	if err != nil {
+		return fmt.Errorf("ScanRows: %w", err)
+	}
+
+	o := optionsFrom(ctx)
+
+	var l []map[string]interface{}
+
+	for rows.Next() {
+		if o.MaxRows > 0 && len(l) >= o.MaxRows {
+			return fmt.Errorf("ScanRows: query returned more than %d rows", o.MaxRows)
+		}
+
+		m, err := scanMapRow(rows, names)
+		if err != nil {
+			return fmt.Errorf("ScanRows: %w", err)
+		}
+
+		l = append(l, m)
+	}
+
+	ptr.Elem().Set(reflect.ValueOf(l))
+
+	return nil
+}
+
+// scanMap scans the only row into a map. It returns ErrRecordNotFound if
+// there are no rows.
+func scanMap(rows *sql.Rows, ptr reflect.Value) error {
+	names, err := rows.Columns()
+	if err != nil {
+		return fmt.Errorf("ScanRows: %w", err)
+	}
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("ScanRows: %w", err)
+		}
+
+		return ErrRecordNotFound
+	}
+
+	m, err := scanMapRow(rows, names)
+	if err != nil {
+		return fmt.Errorf("ScanRows: %w", err)
+	}
+
+	if rows.Next() {
+		return fmt.Errorf("ScanRows: expected one row to scan into a map; got more")
+	}
+
+	ptr.Elem().Set(reflect.ValueOf(m))
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package sorm
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScanRowsScalars(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select id from simple_objects`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+	mockDB.ExpectQuery(`select name from simple_objects`).WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("a").AddRow(nil))
+	mockDB.ExpectQuery(`select id, name from simple_objects`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a"))
+
+	rows, err := db.Query("select id from simple_objects")
+	if !a.NoError(err) {
+		return
+	}
+
+	var ids []int
+	a.NoError(ScanRows(rows, &ids))
+	a.Equal([]int{1, 2}, ids)
+
+	rows, err = db.Query("select name from simple_objects")
+	if !a.NoError(err) {
+		return
+	}
+
+	var names []sql.NullString
+	a.NoError(ScanRows(rows, &names))
+	a.Equal([]sql.NullString{{String: "a", Valid: true}, {}}, names)
+
+	rows, err = db.Query("select id, name from simple_objects")
+	if !a.NoError(err) {
+		return
+	}
+
+	var strs []string
+	a.EqualError(ScanRows(rows, &strs), "ScanRows: expected exactly one column to scan into []string; got 2")
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestScanRowsMaps(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select id, name from simple_objects`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(int64(1), "a").AddRow(int64(2), "b"))
+	mockDB.ExpectQuery(`select id, name from simple_objects`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(int64(1), "a"))
+	mockDB.ExpectQuery(`select id, name from simple_objects`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+
+	rows, err := db.Query("select id, name from simple_objects")
+	if !a.NoError(err) {
+		return
+	}
+
+	var l []map[string]interface{}
+	a.NoError(ScanRows(rows, &l))
+	a.Equal([]map[string]interface{}{{"id": int64(1), "name": "a"}, {"id": int64(2), "name": "b"}}, l)
+
+	rows, err = db.Query("select id, name from simple_objects")
+	if !a.NoError(err) {
+		return
+	}
+
+	var m map[string]interface{}
+	a.NoError(ScanRows(rows, &m))
+	a.Equal(map[string]interface{}{"id": int64(1), "name": "a"}, m)
+
+	rows, err = db.Query("select id, name from simple_objects")
+	if !a.NoError(err) {
+		return
+	}
+
+	a.ErrorIs(ScanRows(rows, &m), ErrRecordNotFound)
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestFindWhereInto(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from simple_objects where id = \$1`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(int64(1), "a"))
+
+	var m map[string]interface{}
+	a.NoError(FindWhereInto(context.Background(), db, SimpleObject{}, &m, "where id = $1", 1))
+	a.Equal(map[string]interface{}{"id": int64(1), "name": "a"}, m)
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
@@ -0,0 +1,127 @@
+package sorm
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Query is built up by scopes. Conditions are joined with "and", and each
+// one numbers its own placeholders from 1 (or uses "?"), so scopes don't need
+// to know about each other.
+type Query struct {
+	conds  []string
+	args   [][]interface{}
+	order  []string
+	limit  int
+	offset int
+}
+
+// Scope is a reusable piece of a query, e.g.
+//
+//	func Active() sorm.Scope {
+//		return func(q sorm.Query) sorm.Query { return q.Where("archived_at is null") }
+//	}
+type Scope func(Query) Query
+
+func (q Query) Where(cond string, args ...interface{}) Query {
+	q.conds = append(append([]string(nil), q.conds...), cond)
+	q.args = append(append([][]interface{}(nil), q.args...), args)
+	return q
+}
+
+func (q Query) OrderBy(terms ...string) Query {
+	q.order = append(append([]string(nil), q.order...), terms...)
+	return q
+}
+
+func (q Query) Limit(n int) Query {
+	q.limit = n
+	return q
+}
+
+func (q Query) Offset(n int) Query {
+	q.offset = n
+	return q
+}
+
+func (q Query) where() (string, []interface{}, error) {
+	var conds []string
+	var args []interface{}
+
+	for i, c := range q.conds {
+		s, a, err := renumberArgs(c, q.args[i], len(args))
+		if err != nil {
+			return "", nil, err
+		}
+
+		conds = append(conds, "("+s+")")
+		args = append(args, a...)
+	}
+
+	if len(conds) == 0 {
+		return "", nil, nil
+	}
+
+	return "where " + strings.Join(conds, " and "), args, nil
+}
+
+func (q Query) Clause() (string, []interface{}, error) {
+	s, args, err := q.where()
+	if err != nil {
+		return "", nil, err
+	}
+
+	var parts []string
+	if s != "" {
+		parts = append(parts, s)
+	}
+	if len(q.order) > 0 {
+		parts = append(parts, "order by "+strings.Join(q.order, ", "))
+	}
+	if q.limit > 0 {
+		parts = append(parts, "limit "+strconv.Itoa(q.limit))
+	}
+	if q.offset > 0 {
+		parts = append(parts, "offset "+strconv.Itoa(q.offset))
+	}
+
+	return strings.Join(parts, " "), args, nil
+}
+
+func applyScopes(scopes []Scope) Query {
+	var q Query
+	for _, s := range scopes {
+		q = s(q)
+	}
+
+	return q
+}
+
+func FindScoped(ctx context.Context, db Querier, out interface{}, scopes ...Scope) error {
+	if err := FindClause(ctx, db, out, applyScopes(scopes)); err != nil {
+		return fmt.Errorf("FindScoped: %w", err)
+	}
+
+	return nil
+}
+
+func FindFirstScoped(ctx context.Context, db Querier, out interface{}, scopes ...Scope) error {
+	if err := FindFirstClause(ctx, db, out, applyScopes(scopes)); err != nil {
+		return fmt.Errorf("FindFirstScoped: %w", err)
+	}
+
+	return nil
+}
+
+// CountScoped counts the records matching the scopes' conditions; their
+// order, limit and offset are ignored.
+func CountScoped(ctx context.Context, db Querier, val interface{}, scopes ...Scope) (int, error) {
+	where, args, err := applyScopes(scopes).where()
+	if err != nil {
+		return 0, fmt.Errorf("CountScoped: %w", err)
+	}
+
+	return CountWhere(ctx, db, val, where, args...)
+}
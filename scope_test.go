@@ -0,0 +1,44 @@
+package sorm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func scopeByName(name string) Scope {
+	return func(q Query) Query { return q.Where("name = $1", name) }
+}
+
+func scopeIDs(ids ...int) Scope {
+	return func(q Query) Query { return q.Where("id in (?)", ids) }
+}
+
+func scopeNewest() Scope {
+	return func(q Query) Query { return q.OrderBy("id desc").Limit(10) }
+}
+
+func TestFindScoped(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`^select \* from simple_objects where \(id in \(\$1, \$2\)\) and \(name = \$3\) order by id desc limit 10$`).WithArgs(1, 2, "a").WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(2, "a"))
+	mockDB.ExpectQuery(`^select count\(\*\) from simple_objects where \(name = \$1\)$`).WithArgs("a").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(4))
+
+	var l []SimpleObject
+	a.NoError(FindScoped(context.Background(), db, &l, scopeIDs(1, 2), scopeByName("a"), scopeNewest()))
+	a.Equal([]SimpleObject{{ID: 2, Name: "a"}}, l)
+
+	n, err := CountScoped(context.Background(), db, &SimpleObject{}, scopeByName("a"), scopeNewest())
+	a.NoError(err)
+	a.Equal(4, n)
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
@@ -0,0 +1,147 @@
+package sorm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// getSQLSequence finds the ID field tagged like `sql:",id,sequence:seq"`.
+func getSQLSequence(vdesc *structDescription) (structField, string, bool) {
+	for _, f := range getSQLIDFields(vdesc) {
+		if t := f.Tag("sql"); t != nil {
+			if p := t.Parameter("sequence"); p != nil && p.Value() != "" {
+				return f, p.Value(), true
+			}
+		}
+	}
+
+	return structField{}, "", false
+}
+
+func setIntField(fv reflect.Value, n int64) error {
+	if fv.Kind() == reflect.Ptr {
+		p := reflect.New(fv.Type().Elem())
+		if err := setIntField(p.Elem(), n); err != nil {
+			return err
+		}
+
+		fv.Set(p)
+
+		return nil
+	}
+
+	switch fv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		fv.SetUint(uint64(n))
+	default:
+		return fmt.Errorf("sequence values can't be stored in a %s", fv.Type())
+	}
+
+	return nil
+}
+
+func nextSequenceValues(ctx context.Context, db Querier, seq string, n int) ([]int64, error) {
+	if err := checkIdentifier(seq); err != nil {
+		return nil, err
+	}
+
+	query := "select nextval('" + seq + "') from generate_series(1, " + makeParameter(1) + ")"
+	args := []interface{}{n}
+	if n == 1 {
+		query, args = "select nextval('"+seq+"')", nil
+	}
+
+	// nextval changes the sequence, so it has to run on the primary
+	ctx = UsePrimary(ctx)
+
+	logQuery(ctx, query, args)
+
+	start := time.Now()
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		logQueryAfter(ctx, query, args, start, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	l := make([]int64, 0, n)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			logQueryAfter(ctx, query, args, start, err)
+			return nil, err
+		}
+
+		l = append(l, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		logQueryAfter(ctx, query, args, start, err)
+		return nil, err
+	}
+
+	logQueryAfter(ctx, query, args, start, nil)
+
+	if len(l) != n {
+		return nil, fmt.Errorf("expected %d values from %s; got %d", n, seq, len(l))
+	}
+
+	return l, nil
+}
+
+// applySequence fills a zero sequence ID field of v with the next value of
+// its sequence.
+func applySequence(ctx context.Context, db Querier, vdesc *structDescription, v reflect.Value) error {
+	f, seq, ok := getSQLSequence(vdesc)
+	if !ok {
+		return nil
+	}
+
+	fv := v.FieldByIndex(f.Index())
+	if !isZero(fv.Interface()) {
+		return nil
+	}
+
+	l, err := nextSequenceValues(ctx, db, seq, 1)
+	if err != nil {
+		return fmt.Errorf("couldn't get next value of %s: %w", seq, err)
+	}
+
+	return setIntField(fv, l[0])
+}
+
+// ReserveIDs takes n values from the sequence of model's ID field, as set by
+// `sql:",id,sequence:name"`, so that a batch of records can be given IDs
+// before they're inserted.
+func ReserveIDs(ctx context.Context, db Querier, model interface{}, n int) ([]int64, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("ReserveIDs: n should be at least 1; was instead %d", n)
+	}
+
+	vtyp, err := structTypeOf(model)
+	if err != nil {
+		return nil, fmt.Errorf("ReserveIDs: %w", err)
+	}
+
+	vdesc, err := getDescriptionContext(ctx, vtyp)
+	if err != nil {
+		return nil, fmt.Errorf("ReserveIDs: could not get detailed reflection information for type %s: %w", vtyp.String(), err)
+	}
+
+	_, seq, ok := getSQLSequence(vdesc)
+	if !ok {
+		return nil, fmt.Errorf("ReserveIDs: %s has no ID field with a sequence", vtyp.Name())
+	}
+
+	l, err := nextSequenceValues(ctx, db, seq, n)
+	if err != nil {
+		return nil, fmt.Errorf("ReserveIDs: %w", err)
+	}
+
+	return l, nil
+}
@@ -0,0 +1,57 @@
+package sorm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type SequenceObject struct {
+	ID   int64 `sql:",id,sequence:sequence_objects_id_seq"`
+	Name string
+}
+
+func TestSequenceCreate(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`^select nextval\('sequence_objects_id_seq'\)$`).WillReturnRows(sqlmock.NewRows([]string{"nextval"}).AddRow(41))
+	mockDB.ExpectExec(`^insert into sequence_objects \(id, name\) values \(\$1, \$2\)$`).WithArgs(int64(41), "a").WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec(`^insert into sequence_objects \(id, name\) values \(\$1, \$2\)$`).WithArgs(int64(7), "b").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	r := SequenceObject{Name: "a"}
+	a.NoError(CreateRecord(context.Background(), db, &r))
+	a.Equal(int64(41), r.ID)
+
+	a.NoError(CreateRecord(context.Background(), db, &SequenceObject{ID: 7, Name: "b"}))
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestReserveIDs(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`^select nextval\('sequence_objects_id_seq'\) from generate_series\(1, \$1\)$`).WithArgs(3).WillReturnRows(sqlmock.NewRows([]string{"nextval"}).AddRow(10).AddRow(11).AddRow(12))
+
+	l, err := ReserveIDs(context.Background(), db, &SequenceObject{}, 3)
+	a.NoError(err)
+	a.Equal([]int64{10, 11, 12}, l)
+
+	_, err = ReserveIDs(context.Background(), db, &SimpleObject{}, 3)
+	a.EqualError(err, "ReserveIDs: SimpleObject has no ID field with a sequence")
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
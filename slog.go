@@ -0,0 +1,73 @@
+package sorm
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+type SlogOptions struct {
+	// Level is used for successful queries; failed ones are logged at
+	// slog.LevelError. The zero value is slog.LevelInfo.
+	Level slog.Level
+	// RedactArgs replaces query arguments with their count.
+	RedactArgs bool
+	// LogBefore also logs each query before it runs, at slog.LevelDebug.
+	LogBefore bool
+}
+
+type SlogQueryLogger struct {
+	logger *slog.Logger
+	opts   SlogOptions
+}
+
+var (
+	_ QueryLogger             = (*SlogQueryLogger)(nil)
+	_ QueryLoggerAfter        = (*SlogQueryLogger)(nil)
+	_ QueryLoggerContext      = (*SlogQueryLogger)(nil)
+	_ QueryLoggerAfterContext = (*SlogQueryLogger)(nil)
+)
+
+func NewSlogQueryLogger(logger *slog.Logger, opts *SlogOptions) *SlogQueryLogger {
+	l := SlogQueryLogger{logger: logger}
+	if opts != nil {
+		l.opts = *opts
+	}
+
+	return &l
+}
+
+func (l *SlogQueryLogger) args(vars []interface{}) slog.Attr {
+	if l.opts.RedactArgs {
+		return slog.Int("args", len(vars))
+	}
+
+	return slog.Any("args", vars)
+}
+
+func (l *SlogQueryLogger) LogQuery(query string, vars []interface{}) {
+	l.LogQueryContext(context.Background(), query, vars)
+}
+
+func (l *SlogQueryLogger) LogQueryContext(ctx context.Context, query string, vars []interface{}) {
+	if !l.opts.LogBefore {
+		return
+	}
+
+	l.logger.LogAttrs(ctx, slog.LevelDebug, "sorm query", slog.String("query", query), l.args(vars))
+}
+
+func (l *SlogQueryLogger) LogQueryAfter(query string, vars []interface{}, duration time.Duration, err error) {
+	l.LogQueryAfterContext(context.Background(), query, vars, duration, err)
+}
+
+func (l *SlogQueryLogger) LogQueryAfterContext(ctx context.Context, query string, vars []interface{}, duration time.Duration, err error) {
+	attrs := []slog.Attr{slog.String("query", query), l.args(vars), slog.Duration("duration", duration)}
+
+	if err != nil {
+		l.logger.LogAttrs(ctx, slog.LevelError, "sorm query failed", append(attrs, slog.String("error", err.Error()))...)
+		return
+	}
+
+	l.logger.LogAttrs(ctx, l.opts.Level, "sorm query", attrs...)
+}
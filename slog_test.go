@@ -0,0 +1,53 @@
+package sorm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSlogger(b *bytes.Buffer) *slog.Logger {
+	return slog.New(slog.NewTextHandler(b, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+}
+
+func TestSlogQueryLogger(t *testing.T) {
+	a := assert.New(t)
+
+	var b bytes.Buffer
+	l := NewSlogQueryLogger(newTestSlogger(&b), nil)
+
+	l.LogQueryContext(context.Background(), "select 1", nil)
+	l.LogQueryAfterContext(context.Background(), "select * from a where id = $1", []interface{}{1}, time.Millisecond, nil)
+	l.LogQueryAfter("delete from a", nil, 2*time.Millisecond, errors.New("boom"))
+
+	a.Equal(`level=INFO msg="sorm query" query="select * from a where id = $1" args=[1] duration=1ms
+level=ERROR msg="sorm query failed" query="delete from a" args=[] duration=2ms error=boom
+`, b.String())
+}
+
+func TestSlogQueryLoggerOptions(t *testing.T) {
+	a := assert.New(t)
+
+	var b bytes.Buffer
+	l := NewSlogQueryLogger(newTestSlogger(&b), &SlogOptions{Level: slog.LevelDebug, RedactArgs: true, LogBefore: true})
+
+	l.LogQuery("select * from users where email = $1", []interface{}{"a@example.com"})
+	l.LogQueryAfter("select * from users where email = $1", []interface{}{"a@example.com"}, time.Millisecond, nil)
+
+	a.Equal(`level=DEBUG msg="sorm query" query="select * from users where email = $1" args=1
+level=DEBUG msg="sorm query" query="select * from users where email = $1" args=1 duration=1ms
+`, b.String())
+}
@@ -3,13 +3,12 @@ package sorm
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"reflect"
 	"strings"
+	"sync"
 	"time"
-
-	"fknsrs.biz/p/reflectutil"
-	"github.com/serenize/snaker"
 )
 
 var (
@@ -46,6 +45,128 @@ func SetQueryLoggerFunc(fn QueryLoggerFunc) {
 	SetQueryLogger(fn)
 }
 
+// QueryLogEntry describes a completed find, count, create, save, replace or
+// delete. Rows is the number of rows returned or affected.
+type QueryLogEntry struct {
+	Context   context.Context
+	Operation Operation
+	Table     string
+	Model     reflect.Type
+	Query     string
+	Args      []interface{}
+	Rows      int64
+	Duration  time.Duration
+	Err       error
+}
+
+type QueryLoggerV2 interface {
+	LogQueryV2(e QueryLogEntry)
+}
+
+var (
+	queryLoggerV2 QueryLoggerV2
+)
+
+func SetQueryLoggerV2(l QueryLoggerV2) {
+	queryLoggerV2 = l
+}
+
+type QueryLoggerContext interface {
+	LogQueryContext(ctx context.Context, query string, vars []interface{})
+}
+
+type QueryLoggerAfterContext interface {
+	LogQueryAfterContext(ctx context.Context, query string, vars []interface{}, duration time.Duration, err error)
+}
+
+// logQuery waits for logQueryAfter when there's a SlowQueryThreshold, since
+// it can't know yet whether the query is slow.
+func logQuery(ctx context.Context, query string, vars []interface{}) {
+	o := optionsFrom(ctx)
+	if o.SlowQueryThreshold > 0 {
+		return
+	}
+
+	logQueryBefore(ctx, o.QueryLogger, query, vars)
+}
+
+func logQueryBefore(ctx context.Context, l QueryLogger, query string, vars []interface{}) {
+	switch l := l.(type) {
+	case nil:
+	case QueryLoggerContext:
+		l.LogQueryContext(ctx, query, vars)
+	default:
+		l.LogQuery(query, vars)
+	}
+}
+
+// logQueryAfter passes slow and failed queries to loggers that only log
+// before a query when logQuery held them back.
+func logQueryAfter(ctx context.Context, query string, vars []interface{}, start time.Time, err error) {
+	o := optionsFrom(ctx)
+
+	d := time.Now().Sub(start)
+	if err == nil && d < o.SlowQueryThreshold {
+		return
+	}
+
+	switch l := o.QueryLogger.(type) {
+	case QueryLoggerAfterContext:
+		l.LogQueryAfterContext(ctx, query, vars, d, err)
+	case QueryLoggerAfter:
+		l.LogQueryAfter(query, vars, d, err)
+	default:
+		if o.SlowQueryThreshold > 0 {
+			logQueryBefore(ctx, l, query, vars)
+		}
+	}
+}
+
+var (
+	hookTimeout time.Duration
+)
+
+// SetHookTimeout bounds how long each Before* and After* hook may run. Hooks
+// receive a context carrying the deadline and are expected to respect it; a
+// hook that returns after the context has expired is treated as failed.
+func SetHookTimeout(d time.Duration) {
+	hookTimeout = d
+}
+
+func callHook(ctx context.Context, name string, fn func(ctx context.Context) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	o := optionsFrom(ctx)
+
+	if o.Tracer != nil {
+		var span Span
+		ctx, span = o.Tracer.StartSpan(ctx, name)
+
+		err := runHook(ctx, o.HookTimeout, fn)
+		span.End(err)
+
+		return err
+	}
+
+	return runHook(ctx, o.HookTimeout, fn)
+}
+
+func runHook(ctx context.Context, d time.Duration, fn func(ctx context.Context) error) error {
+	if d > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d)
+		defer cancel()
+	}
+
+	if err := fn(ctx); err != nil {
+		return err
+	}
+
+	return ctx.Err()
+}
+
 func makeParameter(n int) string {
 	s := parameterPrefix
 	if s == "" {
@@ -56,23 +177,50 @@ func makeParameter(n int) string {
 }
 
 var (
-	descriptionCache = map[reflect.Type]*reflectutil.StructDescription{}
+	descriptionCache     = map[reflect.Type]*structDescription{}
+	descriptionCacheLock sync.RWMutex
 )
 
-func getDescriptionFromType(typ reflect.Type) (*reflectutil.StructDescription, error) {
-	if _, ok := descriptionCache[typ]; !ok {
-		d, err := reflectutil.GetDescriptionFromType(typ)
-		if err != nil {
-			return nil, err
-		}
+func getDescriptionFromType(typ reflect.Type) (*structDescription, error) {
+	descriptionCacheLock.RLock()
+	d, ok := descriptionCache[typ]
+	descriptionCacheLock.RUnlock()
+
+	if ok {
+		return d, nil
+	}
+
+	d, err := describeStruct(typ)
+	if err != nil {
+		return nil, err
+	}
+
+	descriptionCacheLock.Lock()
+	descriptionCache[typ] = d
+	descriptionCacheLock.Unlock()
+
+	return d, nil
+}
+
+func getSQLTableName(vdesc *structDescription) string {
+	if vdesc.naming != nil {
+		return getSQLTableNameWith(vdesc, vdesc.naming)
+	}
 
-		descriptionCache[typ] = d
+	return getSQLTableNameWith(vdesc, namingStrategy)
+}
+
+func getSQLTableNameWith(vdesc *structDescription, naming NamingStrategy) string {
+	tbl := getSQLUnqualifiedTableName(vdesc, naming)
+
+	if schema := getSQLSchema(vdesc); schema != "" && !hasSchema(tbl) {
+		tbl = qualifyTable(schema, tbl)
 	}
 
-	return descriptionCache[typ], nil
+	return tbl
 }
 
-func getSQLTableName(vdesc *reflectutil.StructDescription) string {
+func getSQLUnqualifiedTableName(vdesc *structDescription, naming NamingStrategy) string {
 	for _, f := range vdesc.Fields() {
 		if t := f.Tag("table"); t != nil && t.Value() != "" {
 			return t.Value()
@@ -85,21 +233,29 @@ func getSQLTableName(vdesc *reflectutil.StructDescription) string {
 		}
 	}
 
-	return snaker.CamelToSnake(vdesc.Name()) + "s"
+	if tbl := getSQLProjection(vdesc); tbl != "" {
+		return tbl
+	}
+
+	return naming.TableName(vdesc.Name())
 }
 
-func getSQLColumnName(f reflectutil.Field) string {
+func getSQLColumnName(f structField) string {
 	if t := f.Tag("sql"); t != nil && t.Value() != "" {
 		return t.Value()
 	}
 
-	return snaker.CamelToSnake(f.Name())
+	if f.naming != nil {
+		return f.naming.ColumnName(f.Name())
+	}
+
+	return namingStrategy.ColumnName(f.Name())
 }
 
-func getSQLIDFields(vdesc *reflectutil.StructDescription) []reflectutil.Field {
-	var r []reflectutil.Field
+func getSQLIDFields(vdesc *structDescription) []structField {
+	var r []structField
 
-	for _, f := range vdesc.Fields().WithoutTagValue("sql", "-") {
+	for _, f := range sqlFields(vdesc) {
 		if t := f.Tag("sql"); t != nil && t.Parameter("id") != nil {
 			r = append(r, f)
 		}
@@ -107,7 +263,7 @@ func getSQLIDFields(vdesc *reflectutil.StructDescription) []reflectutil.Field {
 
 	if len(r) == 0 {
 		if f := vdesc.Field("ID"); f != nil {
-			if t := f.Tag("sql"); t == nil || t.Value() != "-" {
+			if !hiddenField(vdesc, *f) {
 				r = append(r, *f)
 			}
 		}
@@ -117,12 +273,12 @@ func getSQLIDFields(vdesc *reflectutil.StructDescription) []reflectutil.Field {
 }
 
 func TableName(v interface{}) string {
-	d, err := getDescriptionFromType(reflect.TypeOf(v))
+	d, err := getPlanFromType(reflect.TypeOf(v))
 	if err != nil {
 		panic(err)
 	}
 
-	return getSQLTableName(d)
+	return d.Table
 }
 
 type OverrideScanner interface {
@@ -131,27 +287,81 @@ type OverrideScanner interface {
 
 var (
 	overrideScannerType = reflect.TypeOf((*OverrideScanner)(nil)).Elem()
+	rawBytesType        = reflect.TypeOf(sql.RawBytes(nil))
+)
+
+var (
+	safeScanning          bool
+	allowUnmatchedColumns bool
 )
 
+func SetSafeScanning(b bool) {
+	safeScanning = b
+}
+
+// SetAllowUnmatchedColumns makes ScanRows discard result columns that have no
+// matching field, for structs that deliberately model part of a table.
+func SetAllowUnmatchedColumns(b bool) {
+	allowUnmatchedColumns = b
+}
+
+type copyingScanner struct{ s sql.Scanner }
+
+func (c copyingScanner) Scan(src interface{}) error {
+	if b, ok := src.([]byte); ok && b != nil {
+		src = append([]byte(nil), b...)
+	}
+
+	return c.s.Scan(src)
+}
+
+type discardScanner struct{}
+
+func (discardScanner) Scan(src interface{}) error { return nil }
+
+type AfterFinder interface {
+	AfterFind(ctx context.Context) error
+}
+
 func ScanRows(rows *sql.Rows, out interface{}) error {
+	return ScanRowsContext(context.Background(), rows, out)
+}
+
+// ScanRowsContext scans rows into out, which is usually a pointer to a slice
+// of structs or of pointers to structs. It can also be a pointer to a slice of a single column's type,
+// such as *[]int or *[]sql.NullString, a *[]map[string]interface{}, or a
+// *map[string]interface{} for exactly one row.
+func ScanRowsContext(ctx context.Context, rows *sql.Rows, out interface{}) error {
 	ptr := reflect.ValueOf(out)
 	if ptr.Kind() != reflect.Ptr {
-		return fmt.Errorf("expected output to be a pointer; was instead %s", ptr.Kind())
+		return typeErrorf(ErrNotAPointer, "expected output to be a pointer; was instead %s", ptr.Kind())
 	}
 
 	styp := ptr.Type().Elem()
+	if styp == mapType {
+		return scanMap(rows, ptr)
+	}
 	if styp.Kind() != reflect.Slice {
 		return fmt.Errorf("expected output to be pointer to slice; was instead pointer to %s", styp.Kind())
 	}
 
 	vtyp := styp.Elem()
-	if vtyp.Kind() != reflect.Struct {
-		return fmt.Errorf("expected output to be pointer to slice of struct; was instead pointer to slice of %s", vtyp.Kind())
+	var isPointers bool
+	switch {
+	case vtyp == mapType:
+		return scanMaps(ctx, rows, ptr)
+	case isScalarType(vtyp):
+		return scanScalars(ctx, rows, ptr)
+	case vtyp.Kind() == reflect.Ptr && vtyp.Elem().Kind() == reflect.Struct:
+		isPointers = true
+		vtyp = vtyp.Elem()
+	case vtyp.Kind() != reflect.Struct:
+		return typeErrorf(ErrNotAStruct, "expected output to be pointer to slice of struct; was instead pointer to slice of %s", vtyp.Kind())
 	}
 
 	isOverrideScanner := reflect.PtrTo(vtyp).Implements(overrideScannerType)
 
-	vdesc, err := getDescriptionFromType(vtyp)
+	plan, err := getPlanContext(ctx, vtyp)
 	if err != nil {
 		return fmt.Errorf("could not get detailed reflection information for type %s: %w", vtyp.String(), err)
 	}
@@ -166,46 +376,46 @@ func ScanRows(rows *sql.Rows, out interface{}) error {
 		goNames = make([]string, len(names))
 	}
 	indexes := make([][]int, len(names))
+	isJSON := make([]bool, len(names))
+	isCompressed := make([]bool, len(names))
 	missing := make([]string, 0)
 
-outer:
 	for i, name := range names {
-		if l := vdesc.Fields().WithTagValue("sql", name); len(l) == 1 {
-			if isOverrideScanner {
-				goNames[i] = l[0].Name()
-			}
-			indexes[i] = l[0].Index()
-			continue outer
+		f := plan.fieldForColumn(name)
+		if f == nil {
+			missing = append(missing, name)
+			continue
 		}
 
-		if f := vdesc.Field(name); f != nil {
-			if isOverrideScanner {
-				goNames[i] = f.Name()
-			}
-			indexes[i] = f.Index()
-			continue outer
+		if isOverrideScanner {
+			goNames[i] = f.Name
 		}
+		indexes[i] = f.Index
+		isJSON[i] = f.JSON
+		isCompressed[i] = f.Compress != ""
+	}
 
-		for _, f := range vdesc.Fields() {
-			if snaker.CamelToSnake(f.Name()) == name {
-				if isOverrideScanner {
-					goNames[i] = f.Name()
-				}
-				indexes[i] = f.Index()
-				continue outer
-			}
-		}
+	o := optionsFrom(ctx)
 
-		missing = append(missing, name)
+	if len(missing) > 0 && !o.AllowUnmatchedColumns {
+		return &MissingColumnsError{Model: vtyp.Name(), Columns: missing}
 	}
 
-	if len(missing) > 0 {
-		return fmt.Errorf("couldn't find fields on %s for these sql fields: %v", vtyp.Name(), missing)
+	if safeScanning || o.Strict {
+		for i, index := range indexes {
+			if index != nil && vtyp.FieldByIndex(index).Type == rawBytesType {
+				return fmt.Errorf("ScanRows: field for sql field %s on %s is sql.RawBytes, which is not allowed with safe scanning enabled", names[i], vtyp.Name())
+			}
+		}
 	}
 
 	arr := reflect.Indirect(reflect.New(styp))
 
 	for rows.Next() {
+		if o.MaxRows > 0 && arr.Len() >= o.MaxRows {
+			return fmt.Errorf("ScanRows: query returned more than %d rows", o.MaxRows)
+		}
+
 		p := reflect.New(vtyp)
 		v := p.Elem()
 
@@ -219,18 +429,45 @@ outer:
 
 		args := make([]interface{}, len(indexes))
 		for i, index := range indexes {
+			if index == nil {
+				args[i] = discardScanner{}
+				continue
+			}
+
 			if isOverrideScanner && scanners[i] != nil {
 				args[i] = scanners[i]
+			} else if isJSON[i] {
+				args[i] = jsonScanner{v.FieldByIndex(index)}
+			} else if isByteArray(vtyp.FieldByIndex(index).Type) {
+				args[i] = byteArrayScanner{v.FieldByIndex(index)}
 			} else {
 				args[i] = v.FieldByIndex(index).Addr().Interface()
 			}
+
+			if isCompressed[i] {
+				args[i] = decompressScanner{args[i]}
+			}
+
+			if s, ok := args[i].(sql.Scanner); ok && (safeScanning || o.Strict) {
+				args[i] = copyingScanner{s}
+			}
 		}
 
 		if err := rows.Scan(args...); err != nil {
 			return fmt.Errorf("ScanRows: %w", err)
 		}
 
-		arr.Set(reflect.Append(arr, v))
+		if h, ok := p.Interface().(AfterFinder); ok {
+			if err := callHook(ctx, "sorm hook AfterFind", h.AfterFind); err != nil {
+				return fmt.Errorf("ScanRows: AfterFind callback returned an error for row %d: %w", arr.Len(), err)
+			}
+		}
+
+		if isPointers {
+			arr.Set(reflect.Append(arr, p))
+		} else {
+			arr.Set(reflect.Append(arr, v))
+		}
 	}
 
 	ptr.Elem().Set(arr)
@@ -245,51 +482,51 @@ type Querier interface {
 }
 
 func CountWhere(ctx context.Context, db Querier, val interface{}, where string, args ...interface{}) (int, error) {
+	ctx, db = route(ctx, db, val, OperationCount)
+
 	ptr := reflect.ValueOf(val)
 	if ptr.Kind() != reflect.Ptr {
-		return 0, fmt.Errorf("expected output to be a pointer; was instead %s", ptr.Kind())
+		return 0, typeErrorf(ErrNotAPointer, "expected output to be a pointer; was instead %s", ptr.Kind())
 	}
 
 	vtyp := ptr.Type().Elem()
 	if vtyp.Kind() != reflect.Struct {
-		return 0, fmt.Errorf("expected output to be pointer to struct; was instead pointer to %s", vtyp.Kind())
+		return 0, typeErrorf(ErrNotAStruct, "expected output to be pointer to struct; was instead pointer to %s", vtyp.Kind())
 	}
 
-	vdesc, err := getDescriptionFromType(vtyp)
+	vdesc, err := getDescriptionContext(ctx, vtyp)
 	if err != nil {
 		return 0, fmt.Errorf("CountWhere: could not get detailed reflection information for type %s: %w", vtyp.String(), err)
 	}
 
-	tbl := getSQLTableName(vdesc)
+	o, err := findOptions{}.withDefaultScope(ctx, vtyp)
+	if err != nil {
+		return 0, fmt.Errorf("CountWhere: %w", err)
+	}
 
-	if where != "" {
-		where = " " + where
+	stmt, err := buildSelect(vdesc, getSQLTableNameContext(ctx, vdesc), "count(*)", where, args, o)
+	if err != nil {
+		return 0, fmt.Errorf("CountWhere: %w", err)
 	}
 
-	query := "select count(*) from " + tbl + where
+	query, args := stmt.Query, stmt.Args
 
-	if queryLogger != nil {
-		queryLogger.LogQuery(query, args)
-	}
+	explainQuery(ctx, db, query, args)
+
+	logQuery(ctx, query, args)
 
 	start := time.Now()
 
 	var n int
 	if err := db.QueryRowContext(ctx, query, args...).Scan(&n); err != nil {
-		if queryLogger != nil {
-			if queryLogger, ok := queryLogger.(QueryLoggerAfter); ok {
-				queryLogger.LogQueryAfter(query, args, time.Now().Sub(start), err)
-			}
-		}
+		logQueryAfter(ctx, query, args, start, err)
+		observeOperation(ctx, OperationCount, vtyp, getSQLTableNameContext(ctx, vdesc), stmt, start, 0, err)
 
 		return 0, fmt.Errorf("CountWhere: %w", err)
 	}
 
-	if queryLogger != nil {
-		if queryLogger, ok := queryLogger.(QueryLoggerAfter); ok {
-			queryLogger.LogQueryAfter(query, args, time.Now().Sub(start), nil)
-		}
-	}
+	logQueryAfter(ctx, query, args, start, nil)
+	observeOperation(ctx, OperationCount, vtyp, getSQLTableNameContext(ctx, vdesc), stmt, start, 1, nil)
 
 	return n, nil
 }
@@ -298,77 +535,183 @@ func CountAll(ctx context.Context, db Querier, val interface{}) (int, error) {
 	return CountWhere(ctx, db, val, "")
 }
 
+type findOptions struct {
+	includeExpired bool
+	columns        []string
+	guardLimit     bool
+	scope          string
+	scopeArgs      []interface{}
+	cache          bool
+	lock           Lock
+	model          reflect.Type
+}
+
+// FindWhere finds the records matching where. If a default limit is set with
+// Options.DefaultLimit or a DefaultLimiter and where has no limit, the default
+// is added; with Options.StrictLimit an *UnboundedQueryError is returned
+// instead.
 func FindWhere(ctx context.Context, db Querier, out interface{}, where string, args ...interface{}) error {
+	return findWhere(ctx, db, out, where, args, findOptions{guardLimit: true})
+}
+
+// FindWhereColumns is FindWhere selecting only the named columns; the other
+// fields of each record are left zero.
+func FindWhereColumns(ctx context.Context, db Querier, out interface{}, columns []string, where string, args ...interface{}) error {
+	if len(columns) == 0 {
+		return fmt.Errorf("FindWhereColumns: expected at least one column")
+	}
+
+	return findWhere(ctx, db, out, where, args, findOptions{columns: columns, guardLimit: true})
+}
+
+// FindWhereInto is FindWhere for model's table, scanning into any output
+// ScanRows accepts, e.g. a *[]map[string]interface{} or a single
+// *map[string]interface{}. PluckWhere is simpler for a single column.
+func FindWhereInto(ctx context.Context, db Querier, model, out interface{}, where string, args ...interface{}) error {
+	vtyp, err := structTypeOf(model)
+	if err != nil {
+		return fmt.Errorf("FindWhereInto: %w", err)
+	}
+
+	return findWhere(ctx, db, out, where, args, findOptions{model: vtyp, guardLimit: true})
+}
+
+func findWhere(ctx context.Context, db Querier, out interface{}, where string, args []interface{}, o findOptions) error {
+	model := out
+	if o.model != nil {
+		model = reflect.New(o.model).Interface()
+	}
+
+	if o.lock != 0 {
+		// replicas can't lock rows
+		ctx = UsePrimary(ctx)
+	}
+
+	ctx, db = route(ctx, db, model, OperationFind)
+
 	ptr := reflect.ValueOf(out)
 	if ptr.Kind() != reflect.Ptr {
-		return fmt.Errorf("expected output to be a pointer; was instead %s", ptr.Kind())
+		return typeErrorf(ErrNotAPointer, "expected output to be a pointer; was instead %s", ptr.Kind())
 	}
 
-	styp := ptr.Type().Elem()
-	if styp.Kind() != reflect.Slice {
-		return fmt.Errorf("expected output to be pointer to slice; was instead pointer to %s", styp.Kind())
-	}
+	vtyp := o.model
+	if vtyp == nil {
+		styp := ptr.Type().Elem()
+		if styp.Kind() != reflect.Slice {
+			return fmt.Errorf("expected output to be pointer to slice; was instead pointer to %s", styp.Kind())
+		}
 
-	vtyp := styp.Elem()
-	if vtyp.Kind() != reflect.Struct {
-		return fmt.Errorf("expected output to be pointer to slice of struct; was instead pointer to slice of %s", vtyp.Kind())
+		vtyp = styp.Elem()
+		if vtyp.Kind() == reflect.Ptr {
+			vtyp = vtyp.Elem()
+		}
+		if vtyp.Kind() != reflect.Struct {
+			return typeErrorf(ErrNotAStruct, "expected output to be pointer to slice of struct; was instead pointer to slice of %s", vtyp.Kind())
+		}
 	}
 
-	vdesc, err := getDescriptionFromType(vtyp)
+	vdesc, err := getDescriptionContext(ctx, vtyp)
 	if err != nil {
 		return fmt.Errorf("FindWhere: could not get detailed reflection information for type %s: %w", vtyp.String(), err)
 	}
 
-	tbl := getSQLTableName(vdesc)
+	if o.guardLimit {
+		w, err := guardLimit(ctx, vtyp, where)
+		if err != nil {
+			return fmt.Errorf("FindWhere: %w", err)
+		}
 
-	if where != "" {
-		where = " " + where
+		where = w
 	}
 
-	query := "select * from " + tbl + where
+	columns, err := selectColumns(vdesc, getSQLTableNameContext(ctx, vdesc))
+	if err != nil {
+		return fmt.Errorf("FindWhere: %w", err)
+	}
 
-	if queryLogger != nil {
-		queryLogger.LogQuery(query, args)
+	if len(o.columns) > 0 {
+		if err := ValidateWhereColumns(model, o.columns...); err != nil {
+			return fmt.Errorf("FindWhere: %w", err)
+		}
+
+		for _, col := range o.columns {
+			if err := checkIdentifier(col); err != nil {
+				return fmt.Errorf("FindWhere: %w", err)
+			}
+		}
+
+		columns = strings.Join(o.columns, ", ")
 	}
 
-	start := time.Now()
+	o, err = o.withDefaultScope(ctx, vtyp)
+	if err != nil {
+		return fmt.Errorf("FindWhere: %w", err)
+	}
 
-	rows, err := db.QueryContext(ctx, query, args...)
+	stmt, err := buildSelect(vdesc, getSQLTableNameContext(ctx, vdesc), columns, where, args, o)
 	if err != nil {
-		if queryLogger != nil {
-			if queryLogger, ok := queryLogger.(QueryLoggerAfter); ok {
-				queryLogger.LogQueryAfter(query, args, time.Now().Sub(start), err)
+		return fmt.Errorf("FindWhere: %w", err)
+	}
+
+	if err := runCallbacks(ctx, db, OperationFind, PhaseBefore, out, getSQLTableNameContext(ctx, vdesc), stmt); err != nil {
+		return fmt.Errorf("FindWhere: %w", err)
+	}
+
+	var cacheKey string
+	if o.cache && cacheable(ctx, db, vtyp) {
+		if k, ok := resultCacheKey(ctx, resultCache, getSQLTableNameContext(ctx, vdesc), stmt); ok {
+			if loadCachedResult(resultCache, k, ptr) {
+				if err := runCallbacks(ctx, db, OperationFind, PhaseAfter, out, getSQLTableNameContext(ctx, vdesc), stmt); err != nil {
+					return fmt.Errorf("FindWhere: %w", err)
+				}
+
+				return nil
 			}
+
+			cacheKey = k
 		}
+	}
+
+	query, args := stmt.Query, stmt.Args
+
+	explainQuery(ctx, db, query, args)
+
+	logQuery(ctx, query, args)
+
+	start := time.Now()
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		logQueryAfter(ctx, query, args, start, err)
+		observeOperation(ctx, OperationFind, vtyp, getSQLTableNameContext(ctx, vdesc), stmt, start, 0, err)
 
 		return fmt.Errorf("FindWhere: %w", err)
 	}
 	defer rows.Close()
 
-	if err := ScanRows(rows, out); err != nil {
-		if queryLogger != nil {
-			if queryLogger, ok := queryLogger.(QueryLoggerAfter); ok {
-				queryLogger.LogQueryAfter(query, args, time.Now().Sub(start), err)
-			}
-		}
+	if err := ScanRowsContext(ctx, rows, out); err != nil {
+		logQueryAfter(ctx, query, args, start, err)
+		observeOperation(ctx, OperationFind, vtyp, getSQLTableNameContext(ctx, vdesc), stmt, start, 0, err)
 
 		return err
 	}
 
 	if err := rows.Close(); err != nil {
-		if queryLogger != nil {
-			if queryLogger, ok := queryLogger.(QueryLoggerAfter); ok {
-				queryLogger.LogQueryAfter(query, args, time.Now().Sub(start), err)
-			}
-		}
+		logQueryAfter(ctx, query, args, start, err)
+		observeOperation(ctx, OperationFind, vtyp, getSQLTableNameContext(ctx, vdesc), stmt, start, 0, err)
 
 		return fmt.Errorf("FindWhere: %w", err)
 	}
 
-	if queryLogger != nil {
-		if queryLogger, ok := queryLogger.(QueryLoggerAfter); ok {
-			queryLogger.LogQueryAfter(query, args, time.Now().Sub(start), nil)
-		}
+	logQueryAfter(ctx, query, args, start, nil)
+	observeOperation(ctx, OperationFind, vtyp, getSQLTableNameContext(ctx, vdesc), stmt, start, scannedRows(ptr), nil)
+
+	if cacheKey != "" {
+		storeCachedResult(resultCache, cacheKey, ptr)
+	}
+
+	if err := runCallbacks(ctx, db, OperationFind, PhaseAfter, out, getSQLTableNameContext(ctx, vdesc), stmt); err != nil {
+		return fmt.Errorf("FindWhere: %w", err)
 	}
 
 	return nil
@@ -379,14 +722,18 @@ func FindAll(ctx context.Context, db Querier, out interface{}) error {
 }
 
 func FindFirstWhere(ctx context.Context, db Querier, out interface{}, where string, args ...interface{}) error {
+	return findFirstWhere(ctx, db, out, where, args, findOptions{cache: true})
+}
+
+func findFirstWhere(ctx context.Context, db Querier, out interface{}, where string, args []interface{}, o findOptions) error {
 	ptr := reflect.ValueOf(out)
 	if ptr.Kind() != reflect.Ptr {
-		return fmt.Errorf("expected output to be a pointer; was instead %s", ptr.Kind())
+		return typeErrorf(ErrNotAPointer, "expected output to be a pointer; was instead %s", ptr.Kind())
 	}
 
 	vtyp := ptr.Elem().Type()
 	if vtyp.Kind() != reflect.Struct {
-		return fmt.Errorf("expected output to be pointer to struct; was instead pointer to %s", vtyp.Kind())
+		return typeErrorf(ErrNotAStruct, "expected output to be pointer to struct; was instead pointer to %s", vtyp.Kind())
 	}
 
 	arr := reflect.New(reflect.SliceOf(vtyp))
@@ -395,12 +742,12 @@ func FindFirstWhere(ctx context.Context, db Querier, out interface{}, where stri
 		where = where + " "
 	}
 
-	if err := FindWhere(ctx, db, arr.Interface(), where+"limit 1", args...); err != nil {
+	if err := findWhere(ctx, db, arr.Interface(), where+"limit 1", args, o); err != nil {
 		return err
 	}
 
 	if arr.Elem().Len() == 0 {
-		return sql.ErrNoRows
+		return ErrRecordNotFound
 	}
 
 	ptr.Elem().Set(arr.Elem().Index(0))
@@ -412,12 +759,61 @@ func FindFirst(ctx context.Context, db Querier, out interface{}) error {
 	return FindFirstWhere(ctx, db, out, "")
 }
 
+// FindByID finds the record whose ID fields, in declaration order, equal id.
+// It returns ErrRecordNotFound if there isn't one.
+func FindByID(ctx context.Context, db Querier, out interface{}, id ...interface{}) error {
+	vtyp, err := structTypeOf(out)
+	if err != nil {
+		return fmt.Errorf("FindByID: %w", err)
+	}
+
+	vdesc, err := getDescriptionContext(ctx, vtyp)
+	if err != nil {
+		return fmt.Errorf("FindByID: could not get detailed reflection information for type %s: %w", vtyp.String(), err)
+	}
+
+	idFields := getSQLIDFields(vdesc)
+	if len(idFields) == 0 {
+		return fmt.Errorf("FindByID: %w", ErrNoIDFields)
+	}
+
+	if len(id) != len(idFields) {
+		return fmt.Errorf("FindByID: %s has %d ID field(s); got %d value(s)", vtyp.Name(), len(idFields), len(id))
+	}
+
+	var cols []string
+	for _, f := range idFields {
+		col := getSQLColumnName(f)
+		if err := checkIdentifier(col); err != nil {
+			return fmt.Errorf("FindByID: %w", err)
+		}
+
+		cols = append(cols, columnComparison(f, col))
+	}
+
+	if l := loaderFrom(ctx); l != nil && len(idFields) == 1 && db != nil && reflect.TypeOf(db).Comparable() {
+		ptr := reflect.ValueOf(out)
+		if ptr.Kind() != reflect.Ptr || ptr.Elem().Kind() != reflect.Struct {
+			return fmt.Errorf("FindByID: %w", typeErrorf(ErrNotAStruct, "expected output to be pointer to struct; was instead %T", out))
+		}
+
+		return l.load(ctx, db, ptr, idFields[0], cols[0], id[0])
+	}
+
+	var conds []string
+	for i, col := range cols {
+		conds = append(conds, col+" = "+makeParameter(i+1))
+	}
+
+	return findFirstWhere(ctx, db, out, "where "+strings.Join(conds, " and "), id, findOptions{cache: true})
+}
+
 type BeforeSaver interface {
-	BeforeSave(ctx context.Context, tx *sql.Tx) error
+	BeforeSave(ctx context.Context, tx Querier) error
 }
 
 type AfterSaver interface {
-	AfterSave(ctx context.Context, tx *sql.Tx) error
+	AfterSave(ctx context.Context, tx Querier) error
 }
 
 func SaveRecordWithTransaction(ctx context.Context, db *sql.DB, input interface{}) error {
@@ -438,111 +834,119 @@ func SaveRecordWithTransaction(ctx context.Context, db *sql.DB, input interface{
 	return nil
 }
 
-func SaveRecord(ctx context.Context, tx *sql.Tx, input interface{}) error {
+func SaveRecord(ctx context.Context, tx Querier, input interface{}) error {
+	ctx, tx = route(ctx, tx, input, OperationSave)
+
+	if key := idempotencyKeyFrom(ctx); key != "" {
+		return runIdempotent(ctx, tx, "SaveRecord", key, OperationSave, input, func(ctx context.Context, tx Querier) error {
+			return SaveRecord(ctx, tx, input)
+		})
+	}
+
+	result := resultFrom(ctx)
+	if result != nil {
+		ctx = WithResult(ctx, nil)
+	}
+
 	if v, ok := input.(BeforeSaver); ok {
-		if err := v.BeforeSave(ctx, tx); err != nil {
+		if err := callHook(ctx, "sorm hook BeforeSave", func(ctx context.Context) error { return v.BeforeSave(ctx, tx) }); err != nil {
 			return fmt.Errorf("SaveRecord: BeforeSave callback returned an error: %w", err)
 		}
 	}
 
 	ptr := reflect.ValueOf(input)
 	if ptr.Kind() != reflect.Ptr {
-		return fmt.Errorf("SaveRecord: expected input to be a pointer; was instead %s", ptr.Kind())
+		return fmt.Errorf("SaveRecord: %w", typeErrorf(ErrNotAPointer, "expected input to be a pointer; was instead %s", ptr.Kind()))
 	}
 
 	vtyp := ptr.Elem().Type()
 	if vtyp.Kind() != reflect.Struct {
-		return fmt.Errorf("SaveRecord: expected input to be pointer to struct; was instead pointer to %s", vtyp.Kind())
+		return fmt.Errorf("SaveRecord: %w", typeErrorf(ErrNotAStruct, "expected input to be pointer to struct; was instead pointer to %s", vtyp.Kind()))
 	}
 
-	vdesc, err := getDescriptionFromType(vtyp)
+	vdesc, err := getDescriptionContext(ctx, vtyp)
 	if err != nil {
 		return fmt.Errorf("SaveRecord: could not get detailed reflection information for type %s: %w", vtyp.String(), err)
 	}
 
+	if err := checkWritable(vdesc); err != nil {
+		return fmt.Errorf("SaveRecord: %w", err)
+	}
+
 	idFields := getSQLIDFields(vdesc)
 	if len(idFields) == 0 {
-		return fmt.Errorf("SaveRecord: couldn't determine ID field(s)")
+		return fmt.Errorf("SaveRecord: %w", ErrNoIDFields)
 	}
 
-	var values []interface{}
-
-	var where string
-	for _, idField := range idFields {
-		if where == "" {
-			where += "where "
-		} else {
-			where += " and "
-		}
-
-		where += getSQLColumnName(idField) + " = " + makeParameter(len(values)+1)
-		values = append(values, ptr.Elem().FieldByIndex(idField.Index()).Interface())
+	where, values, err := buildIDWhere(idFields, ptr.Elem())
+	if err != nil {
+		return fmt.Errorf("SaveRecord: %w", err)
 	}
 
 	previous := reflect.New(vtyp)
-	if err := FindFirstWhere(ctx, tx, previous.Interface(), where, values...); err != nil {
+	if err := findFirstWhere(UsePrimary(ctx), tx, previous.Interface(), where, values, findOptions{includeExpired: true}); err != nil {
 		return fmt.Errorf("SaveRecord: couldn't find record: %w", err)
 	}
 
-	var fields string
-	var modify bool
-	for _, f := range vdesc.Fields().WithoutTagValue("sql", "-") {
-		if t := f.Tag("sql"); t != nil && t.Parameter("readonly") != nil {
-			continue
-		}
+	if err := applyTenant(ctx, vdesc, ptr.Elem()); err != nil {
+		return fmt.Errorf("SaveRecord: %w", err)
+	}
 
-		if t := f.Tag("readonly"); t != nil && t.Value() != "" {
-			continue
-		}
+	if err := applyHashes(vdesc, ptr.Elem(), previous.Elem()); err != nil {
+		return fmt.Errorf("SaveRecord: %w", err)
+	}
 
-		if reflect.DeepEqual(previous.Elem().FieldByIndex(f.Index()).Interface(), ptr.Elem().FieldByIndex(f.Index()).Interface()) {
-			continue
-		}
+	stmt, err := buildUpdate(vdesc, getSQLTableNameContext(ctx, vdesc), idFields, previous.Elem(), ptr.Elem())
+	if err != nil {
+		return fmt.Errorf("SaveRecord: %w", err)
+	}
 
-		if fields == "" {
-			fields += "set "
-		} else {
-			fields += ", "
-		}
+	if stmt.Query == "" {
+		setResult(result, OperationSave, getSQLTableNameContext(ctx, vdesc), stmt, time.Now(), 0, nil)
 
-		fields += getSQLColumnName(f) + " = " + makeParameter(len(values)+1)
-		values = append(values, ptr.Elem().FieldByIndex(f.Index()).Interface())
+		return nil
+	}
 
-		modify = true
+	if err := checkEnums(vdesc, ptr.Elem()); err != nil {
+		return fmt.Errorf("SaveRecord: %w", err)
 	}
 
-	if !modify {
-		return nil
+	if err := checkUnique(UsePrimary(ctx), tx, vdesc, idFields, ptr.Elem(), previous.Elem()); err != nil {
+		return fmt.Errorf("SaveRecord: %w", err)
 	}
 
-	tbl := getSQLTableName(vdesc)
+	if err := runCallbackEvent(ctx, tx, &CallbackEvent{Operation: OperationSave, Phase: PhaseBefore, Value: input, Previous: previous.Interface(), Table: getSQLTableNameContext(ctx, vdesc), Query: stmt.Query, Args: stmt.Args}); err != nil {
+		return fmt.Errorf("SaveRecord: %w", err)
+	}
 
-	query := fmt.Sprintf("update %s %s %s", tbl, fields, where)
+	query, values := stmt.Query, stmt.Args
 
-	if queryLogger != nil {
-		queryLogger.LogQuery(query, values)
-	}
+	logQuery(ctx, query, values)
 
 	start := time.Now()
 
-	if _, err := tx.ExecContext(ctx, query, values...); err != nil {
-		if queryLogger != nil {
-			if queryLogger, ok := queryLogger.(QueryLoggerAfter); ok {
-				queryLogger.LogQueryAfter(query, values, time.Now().Sub(start), err)
-			}
-		}
+	res, err := tx.ExecContext(ctx, query, values...)
+	if err != nil {
+		logQueryAfter(ctx, query, values, start, err)
+		observeOperation(ctx, OperationSave, vtyp, getSQLTableNameContext(ctx, vdesc), stmt, start, 0, err)
 
+		return fmt.Errorf("SaveRecord: %w", TranslateError(err))
+	}
+
+	logQueryAfter(ctx, query, values, start, nil)
+	observeOperation(ctx, OperationSave, vtyp, getSQLTableNameContext(ctx, vdesc), stmt, start, rowsAffected(res), nil)
+	setResult(result, OperationSave, getSQLTableNameContext(ctx, vdesc), stmt, start, rowsAffected(res), nil)
+
+	if err := checkRowsAffected(res, OperationSave, getSQLTableNameContext(ctx, vdesc), 1); err != nil {
 		return fmt.Errorf("SaveRecord: %w", err)
 	}
 
-	if queryLogger != nil {
-		if queryLogger, ok := queryLogger.(QueryLoggerAfter); ok {
-			queryLogger.LogQueryAfter(query, values, time.Now().Sub(start), nil)
-		}
+	if err := runCallbackEvent(ctx, tx, &CallbackEvent{Operation: OperationSave, Phase: PhaseAfter, Value: input, Previous: previous.Interface(), Table: getSQLTableNameContext(ctx, vdesc), Query: stmt.Query, Args: stmt.Args}); err != nil {
+		return fmt.Errorf("SaveRecord: %w", err)
 	}
 
 	if v, ok := input.(AfterSaver); ok {
-		if err := v.AfterSave(ctx, tx); err != nil {
+		if err := callAfterHook(ctx, tx, input, "AfterSave", func(ctx context.Context) error { return v.AfterSave(ctx, tx) }); err != nil {
 			return fmt.Errorf("SaveRecord: AfterSave callback returned an error: %w", err)
 		}
 	}
@@ -551,99 +955,139 @@ func SaveRecord(ctx context.Context, tx *sql.Tx, input interface{}) error {
 }
 
 type BeforeCreater interface {
-	BeforeCreate(ctx context.Context, tx *sql.Tx) error
+	BeforeCreate(ctx context.Context, tx Querier) error
 }
 
 type AfterCreater interface {
-	AfterCreate(ctx context.Context, tx *sql.Tx) error
+	AfterCreate(ctx context.Context, tx Querier) error
 }
 
-func CreateRecord(ctx context.Context, tx *sql.Tx, input interface{}) error {
+type BeforeCreateFunc func(ctx context.Context, tx Querier, input interface{}) error
+
+func RegisterBeforeCreate(fn BeforeCreateFunc) {
+	RegisterCallback(OperationCreate, PhaseBefore, func(ctx context.Context, db Querier, e *CallbackEvent) error {
+		return fn(ctx, db, e.Value)
+	})
+}
+
+func CreateRecord(ctx context.Context, tx Querier, input interface{}) error {
+	ctx, tx = route(ctx, tx, input, OperationCreate)
+
+	if key := idempotencyKeyFrom(ctx); key != "" {
+		return runIdempotent(ctx, tx, "CreateRecord", key, OperationCreate, input, func(ctx context.Context, tx Querier) error {
+			return CreateRecord(ctx, tx, input)
+		})
+	}
+
+	result := resultFrom(ctx)
+	if result != nil {
+		ctx = WithResult(ctx, nil)
+	}
+
 	if v, ok := input.(BeforeCreater); ok {
-		if err := v.BeforeCreate(ctx, tx); err != nil {
+		if err := callHook(ctx, "sorm hook BeforeCreate", func(ctx context.Context) error { return v.BeforeCreate(ctx, tx) }); err != nil {
 			return fmt.Errorf("CreateRecord: BeforeCreate callback returned an error: %w", err)
 		}
 	}
 
 	ptr := reflect.ValueOf(input)
 	if ptr.Kind() != reflect.Ptr {
-		return fmt.Errorf("CreateRecord: expected input to be a pointer; was instead %s", ptr.Kind())
+		return fmt.Errorf("CreateRecord: %w", typeErrorf(ErrNotAPointer, "expected input to be a pointer; was instead %s", ptr.Kind()))
 	}
 
 	vtyp := ptr.Elem().Type()
 	if vtyp.Kind() != reflect.Struct {
-		return fmt.Errorf("CreateRecord: expected input to be pointer to struct; was instead pointer to %s", vtyp.Kind())
+		return fmt.Errorf("CreateRecord: %w", typeErrorf(ErrNotAStruct, "expected input to be pointer to struct; was instead pointer to %s", vtyp.Kind()))
 	}
 
-	vdesc, err := getDescriptionFromType(vtyp)
+	vdesc, err := getDescriptionContext(ctx, vtyp)
 	if err != nil {
 		return fmt.Errorf("CreateRecord: could not get detailed reflection information for type %s: %w", vtyp.String(), err)
 	}
 
+	if err := checkWritable(vdesc); err != nil {
+		return fmt.Errorf("CreateRecord: %w", err)
+	}
+
 	idFields := getSQLIDFields(vdesc)
 	if len(idFields) == 0 {
-		return fmt.Errorf("CreateRecord: couldn't determine ID field(s)")
+		return fmt.Errorf("CreateRecord: %w", ErrNoIDFields)
 	}
 
-	var a1, a2 []string
-	var values []interface{}
-	var basicID, fetchID bool
-
-	if len(idFields) == 1 && idFields[0].Name() == "ID" {
-		basicID = true
+	if err := applySequence(UsePrimary(ctx), tx, vdesc, ptr.Elem()); err != nil {
+		return fmt.Errorf("CreateRecord: %w", err)
 	}
 
-	for _, f := range vdesc.Fields().WithoutTagValue("sql", "-") {
-		if basicID && f.Name() == "ID" && isZero(ptr.Elem().FieldByIndex(f.Index()).Interface()) {
-			fetchID = true
-			continue
-		}
+	if err := applyTenant(ctx, vdesc, ptr.Elem()); err != nil {
+		return fmt.Errorf("CreateRecord: %w", err)
+	}
 
-		a1 = append(a1, getSQLColumnName(f))
-		a2 = append(a2, makeParameter(len(a1)))
+	if err := applyDefaults(vdesc, ptr.Elem()); err != nil {
+		return fmt.Errorf("CreateRecord: %w", err)
+	}
 
-		values = append(values, ptr.Elem().FieldByIndex(f.Index()).Interface())
+	if err := applyHashes(vdesc, ptr.Elem(), reflect.Value{}); err != nil {
+		return fmt.Errorf("CreateRecord: %w", err)
 	}
 
-	tbl := getSQLTableName(vdesc)
+	stmt, fetchID, err := buildInsert(vdesc, getSQLTableNameContext(ctx, vdesc), idFields, ptr.Elem())
+	if err != nil {
+		return fmt.Errorf("CreateRecord: %w", err)
+	}
 
-	var insertSuffix string
-	if basicID && fetchID {
-		insertSuffix = " returning id"
+	if err := checkEnums(vdesc, ptr.Elem()); err != nil {
+		return fmt.Errorf("CreateRecord: %w", err)
 	}
 
-	query := fmt.Sprintf("insert into %s (%s) values (%s)%s", tbl, strings.Join(a1, ", "), strings.Join(a2, ", "), insertSuffix)
+	if err := checkUnique(UsePrimary(ctx), tx, vdesc, idFields, ptr.Elem(), reflect.Value{}); err != nil {
+		return fmt.Errorf("CreateRecord: %w", err)
+	}
 
-	if queryLogger != nil {
-		queryLogger.LogQuery(query, values)
+	if err := runCallbacks(ctx, tx, OperationCreate, PhaseBefore, input, getSQLTableNameContext(ctx, vdesc), stmt); err != nil {
+		return fmt.Errorf("CreateRecord: %w", err)
 	}
 
+	query, values := stmt.Query, stmt.Args
+
+	logQuery(ctx, query, values)
+
 	start := time.Now()
 
-	if basicID && fetchID {
+	affected := int64(1)
+	if fetchID {
 		if err := tx.QueryRowContext(ctx, query, values...).Scan(ptr.Elem().FieldByName("ID").Addr().Interface()); err != nil {
-			if queryLogger != nil {
-				if queryLogger, ok := queryLogger.(QueryLoggerAfter); ok {
-					queryLogger.LogQueryAfter(query, values, time.Now().Sub(start), err)
-				}
-			}
+			logQueryAfter(ctx, query, values, start, err)
+			observeOperation(ctx, OperationCreate, vtyp, getSQLTableNameContext(ctx, vdesc), stmt, start, 0, err)
 
-			return fmt.Errorf("CreateRecord: %w", err)
+			return fmt.Errorf("CreateRecord: %w", TranslateError(err))
 		}
 	} else {
-		if _, err := tx.ExecContext(ctx, query, values...); err != nil {
-			if queryLogger != nil {
-				if queryLogger, ok := queryLogger.(QueryLoggerAfter); ok {
-					queryLogger.LogQueryAfter(query, values, time.Now().Sub(start), err)
-				}
-			}
+		res, err := tx.ExecContext(ctx, query, values...)
+		if err != nil {
+			logQueryAfter(ctx, query, values, start, err)
+			observeOperation(ctx, OperationCreate, vtyp, getSQLTableNameContext(ctx, vdesc), stmt, start, 0, err)
 
-			return fmt.Errorf("CreateRecord: %w", err)
+			return fmt.Errorf("CreateRecord: %w", TranslateError(err))
 		}
+
+		affected = rowsAffected(res)
+	}
+
+	logQueryAfter(ctx, query, values, start, nil)
+	observeOperation(ctx, OperationCreate, vtyp, getSQLTableNameContext(ctx, vdesc), stmt, start, affected, nil)
+
+	var generatedID interface{}
+	if fetchID {
+		generatedID = ptr.Elem().FieldByName("ID").Interface()
+	}
+	setResult(result, OperationCreate, getSQLTableNameContext(ctx, vdesc), stmt, start, affected, generatedID)
+
+	if err := runCallbacks(ctx, tx, OperationCreate, PhaseAfter, input, getSQLTableNameContext(ctx, vdesc), stmt); err != nil {
+		return fmt.Errorf("CreateRecord: %w", err)
 	}
 
 	if v, ok := input.(AfterCreater); ok {
-		if err := v.AfterCreate(ctx, tx); err != nil {
+		if err := callAfterHook(ctx, tx, input, "AfterCreate", func(ctx context.Context) error { return v.AfterCreate(ctx, tx) }); err != nil {
 			return fmt.Errorf("CreateRecord: AfterCreate callback returned an error: %w", err)
 		}
 	}
@@ -652,78 +1096,138 @@ func CreateRecord(ctx context.Context, tx *sql.Tx, input interface{}) error {
 }
 
 type BeforeReplacer interface {
-	BeforeReplace(ctx context.Context, tx *sql.Tx) error
+	BeforeReplace(ctx context.Context, tx Querier) error
 }
 
 type AfterReplacer interface {
-	AfterReplace(ctx context.Context, tx *sql.Tx) error
+	AfterReplace(ctx context.Context, tx Querier) error
+}
+
+func ReplaceRecord(ctx context.Context, tx Querier, input interface{}) error {
+	return replaceRecord(ctx, tx, input, nil)
 }
 
-func ReplaceRecord(ctx context.Context, tx *sql.Tx, input interface{}) error {
+func ReplaceRecordWithOptions(ctx context.Context, tx Querier, input interface{}, o *ReplaceOptions) error {
+	return replaceRecord(ctx, tx, input, o)
+}
+
+func replaceRecord(ctx context.Context, tx Querier, input interface{}, o *ReplaceOptions) error {
+	ctx, tx = route(ctx, tx, input, OperationReplace)
+
+	if key := idempotencyKeyFrom(ctx); key != "" {
+		return runIdempotent(ctx, tx, "ReplaceRecord", key, OperationReplace, input, func(ctx context.Context, tx Querier) error {
+			return replaceRecord(ctx, tx, input, o)
+		})
+	}
+
+	result := resultFrom(ctx)
+	if result != nil {
+		ctx = WithResult(ctx, nil)
+	}
+
 	if v, ok := input.(BeforeReplacer); ok {
-		if err := v.BeforeReplace(ctx, tx); err != nil {
+		if err := callHook(ctx, "sorm hook BeforeReplace", func(ctx context.Context) error { return v.BeforeReplace(ctx, tx) }); err != nil {
 			return fmt.Errorf("ReplaceRecord: BeforeReplace callback returned an error: %w", err)
 		}
 	}
 
 	ptr := reflect.ValueOf(input)
 	if ptr.Kind() != reflect.Ptr {
-		return fmt.Errorf("ReplaceRecord: expected input to be a pointer; was instead %s", ptr.Kind())
+		return fmt.Errorf("ReplaceRecord: %w", typeErrorf(ErrNotAPointer, "expected input to be a pointer; was instead %s", ptr.Kind()))
 	}
 
 	vtyp := ptr.Elem().Type()
 	if vtyp.Kind() != reflect.Struct {
-		return fmt.Errorf("ReplaceRecord: expected input to be pointer to struct; was instead pointer to %s", vtyp.Kind())
+		return fmt.Errorf("ReplaceRecord: %w", typeErrorf(ErrNotAStruct, "expected input to be pointer to struct; was instead pointer to %s", vtyp.Kind()))
 	}
 
-	vdesc, err := getDescriptionFromType(vtyp)
+	vdesc, err := getDescriptionContext(ctx, vtyp)
 	if err != nil {
 		return fmt.Errorf("ReplaceRecord: could not get detailed reflection information for type %s: %w", vtyp.String(), err)
 	}
 
+	if err := checkWritable(vdesc); err != nil {
+		return fmt.Errorf("ReplaceRecord: %w", err)
+	}
+
 	idFields := getSQLIDFields(vdesc)
 	if len(idFields) == 0 {
-		return fmt.Errorf("ReplaceRecord: couldn't determine ID field(s)")
+		return fmt.Errorf("ReplaceRecord: %w", ErrNoIDFields)
 	}
 
-	var a1, a2 []string
-	var values []interface{}
+	if err := applyTenant(ctx, vdesc, ptr.Elem()); err != nil {
+		return fmt.Errorf("ReplaceRecord: %w", err)
+	}
 
-	for _, f := range vdesc.Fields().WithoutTagValue("sql", "-") {
-		a1 = append(a1, getSQLColumnName(f))
-		a2 = append(a2, makeParameter(len(a1)))
+	stored, err := storedHashes(ctx, tx, vdesc, idFields, ptr.Elem())
+	if err != nil {
+		return fmt.Errorf("ReplaceRecord: %w", err)
+	}
 
-		values = append(values, ptr.Elem().FieldByIndex(f.Index()).Interface())
+	if err := applyHashes(vdesc, ptr.Elem(), stored); err != nil {
+		return fmt.Errorf("ReplaceRecord: %w", err)
 	}
 
-	tbl := getSQLTableName(vdesc)
+	tenantField, _, err := currentTenant(ctx, vdesc)
+	if err != nil {
+		return fmt.Errorf("ReplaceRecord: %w", err)
+	}
 
-	query := fmt.Sprintf("insert or replace into %s (%s) values (%s)", tbl, strings.Join(a1, ", "), strings.Join(a2, ", "))
+	var tenant string
+	if tenantField != nil {
+		tenant = getSQLColumnName(*tenantField)
+	}
 
-	if queryLogger != nil {
-		queryLogger.LogQuery(query, values)
+	stmt, err := buildReplace(vdesc, getSQLTableNameContext(ctx, vdesc), idFields, ptr.Elem(), o, tenant)
+	if err != nil {
+		return fmt.Errorf("ReplaceRecord: %w", err)
 	}
 
+	if err := runCallbacks(ctx, tx, OperationReplace, PhaseBefore, input, getSQLTableNameContext(ctx, vdesc), stmt); err != nil {
+		return fmt.Errorf("ReplaceRecord: %w", err)
+	}
+
+	query, values := stmt.Query, stmt.Args
+
+	logQuery(ctx, query, values)
+
 	start := time.Now()
 
-	if _, err := tx.ExecContext(ctx, query, values...); err != nil {
-		if queryLogger != nil {
-			if queryLogger, ok := queryLogger.(QueryLoggerAfter); ok {
-				queryLogger.LogQueryAfter(query, values, time.Now().Sub(start), err)
-			}
-		}
+	res, err := tx.ExecContext(ctx, query, values...)
+	if err != nil {
+		logQueryAfter(ctx, query, values, start, err)
+		observeOperation(ctx, OperationReplace, vtyp, getSQLTableNameContext(ctx, vdesc), stmt, start, 0, err)
 
-		return fmt.Errorf("ReplaceRecord: %w", err)
+		return fmt.Errorf("ReplaceRecord: %w", TranslateError(err))
 	}
 
-	if queryLogger != nil {
-		if queryLogger, ok := queryLogger.(QueryLoggerAfter); ok {
-			queryLogger.LogQueryAfter(query, values, time.Now().Sub(start), nil)
+	logQueryAfter(ctx, query, values, start, nil)
+	observeOperation(ctx, OperationReplace, vtyp, getSQLTableNameContext(ctx, vdesc), stmt, start, rowsAffected(res), nil)
+	setResult(result, OperationReplace, getSQLTableNameContext(ctx, vdesc), stmt, start, rowsAffected(res), nil)
+
+	// nothing affected is either an unchanged row of this tenant or a row of
+	// another tenant that the statement left alone
+	if tenant != "" && rowsAffected(res) <= 0 {
+		where, values, err := buildIDWhere(idFields, ptr.Elem())
+		if err != nil {
+			return fmt.Errorf("ReplaceRecord: %w", err)
+		}
+
+		if err := findFirstWhere(UsePrimary(ctx), tx, reflect.New(vtyp).Interface(), where, values, findOptions{includeExpired: true}); err != nil {
+			if errors.Is(err, ErrRecordNotFound) {
+				return fmt.Errorf("ReplaceRecord: %w", ErrOtherTenant)
+			}
+
+			return fmt.Errorf("ReplaceRecord: %w", err)
 		}
 	}
 
+	if err := runCallbacks(ctx, tx, OperationReplace, PhaseAfter, input, getSQLTableNameContext(ctx, vdesc), stmt); err != nil {
+		return fmt.Errorf("ReplaceRecord: %w", err)
+	}
+
 	if v, ok := input.(AfterReplacer); ok {
-		if err := v.AfterReplace(ctx, tx); err != nil {
+		if err := callHook(ctx, "sorm hook AfterReplace", func(ctx context.Context) error { return v.AfterReplace(ctx, tx) }); err != nil {
 			return fmt.Errorf("ReplaceRecord: AfterReplace callback returned an error: %w", err)
 		}
 	}
@@ -732,82 +1236,102 @@ func ReplaceRecord(ctx context.Context, tx *sql.Tx, input interface{}) error {
 }
 
 type BeforeDeleter interface {
-	BeforeDelete(ctx context.Context, tx *sql.Tx) error
+	BeforeDelete(ctx context.Context, tx Querier) error
 }
 
 type AfterDeleter interface {
-	AfterDelete(ctx context.Context, tx *sql.Tx) error
+	AfterDelete(ctx context.Context, tx Querier) error
 }
 
-func DeleteRecord(ctx context.Context, tx *sql.Tx, input interface{}) error {
+func DeleteRecord(ctx context.Context, tx Querier, input interface{}) error {
+	ctx, tx = route(ctx, tx, input, OperationDelete)
+
+	if key := idempotencyKeyFrom(ctx); key != "" {
+		return runIdempotent(ctx, tx, "DeleteRecord", key, OperationDelete, input, func(ctx context.Context, tx Querier) error {
+			return DeleteRecord(ctx, tx, input)
+		})
+	}
+
+	result := resultFrom(ctx)
+	if result != nil {
+		ctx = WithResult(ctx, nil)
+	}
+
 	if v, ok := input.(BeforeDeleter); ok {
-		if err := v.BeforeDelete(ctx, tx); err != nil {
+		if err := callHook(ctx, "sorm hook BeforeDelete", func(ctx context.Context) error { return v.BeforeDelete(ctx, tx) }); err != nil {
 			return fmt.Errorf("DeleteRecord: BeforeDelete callback returned an error: %w", err)
 		}
 	}
 
 	ptr := reflect.ValueOf(input)
 	if ptr.Kind() != reflect.Ptr {
-		return fmt.Errorf("DeleteRecord: expected input to be a pointer; was instead %s", ptr.Kind())
+		return fmt.Errorf("DeleteRecord: %w", typeErrorf(ErrNotAPointer, "expected input to be a pointer; was instead %s", ptr.Kind()))
 	}
 
 	vtyp := ptr.Elem().Type()
 	if vtyp.Kind() != reflect.Struct {
-		return fmt.Errorf("DeleteRecord: expected input to be pointer to struct; was instead pointer to %s", vtyp.Kind())
+		return fmt.Errorf("DeleteRecord: %w", typeErrorf(ErrNotAStruct, "expected input to be pointer to struct; was instead pointer to %s", vtyp.Kind()))
 	}
 
-	vdesc, err := getDescriptionFromType(vtyp)
+	vdesc, err := getDescriptionContext(ctx, vtyp)
 	if err != nil {
 		return fmt.Errorf("DeleteRecord: could not get detailed reflection information for type %s: %w", vtyp.String(), err)
 	}
 
+	if err := checkWritable(vdesc); err != nil {
+		return fmt.Errorf("DeleteRecord: %w", err)
+	}
+
 	idFields := getSQLIDFields(vdesc)
 	if len(idFields) == 0 {
-		return fmt.Errorf("DeleteRecord: couldn't determine ID field(s)")
+		return fmt.Errorf("DeleteRecord: %w", ErrNoIDFields)
 	}
 
-	var values []interface{}
-
-	var where string
-	for _, f := range idFields {
-		if where == "" {
-			where += "where "
-		} else {
-			where += "and "
-		}
+	stmt, err := buildDelete(vdesc, getSQLTableNameContext(ctx, vdesc), idFields, ptr.Elem())
+	if err != nil {
+		return fmt.Errorf("DeleteRecord: %w", err)
+	}
 
-		where += getSQLColumnName(f) + " = " + makeParameter(len(values)+1)
-		values = append(values, ptr.Elem().FieldByIndex(f.Index()).Interface())
+	scope, scopeArgs, err := defaultScope(ctx, vtyp, len(stmt.Args))
+	if err != nil {
+		return fmt.Errorf("DeleteRecord: %w", err)
+	}
+	if scope != "" {
+		stmt = Statement{Query: stmt.Query + " and " + scope, Args: append(stmt.Args, scopeArgs...)}
 	}
 
-	tbl := getSQLTableName(vdesc)
+	if err := runCallbacks(ctx, tx, OperationDelete, PhaseBefore, input, getSQLTableNameContext(ctx, vdesc), stmt); err != nil {
+		return fmt.Errorf("DeleteRecord: %w", err)
+	}
 
-	query := fmt.Sprintf("delete from %s %s", tbl, where)
+	query, values := stmt.Query, stmt.Args
 
-	if queryLogger != nil {
-		queryLogger.LogQuery(query, values)
-	}
+	logQuery(ctx, query, values)
 
 	start := time.Now()
 
-	if _, err := tx.ExecContext(ctx, query, values...); err != nil {
-		if queryLogger != nil {
-			if queryLogger, ok := queryLogger.(QueryLoggerAfter); ok {
-				queryLogger.LogQueryAfter(query, values, time.Now().Sub(start), err)
-			}
-		}
+	res, err := tx.ExecContext(ctx, query, values...)
+	if err != nil {
+		logQueryAfter(ctx, query, values, start, err)
+		observeOperation(ctx, OperationDelete, vtyp, getSQLTableNameContext(ctx, vdesc), stmt, start, 0, err)
+
+		return fmt.Errorf("DeleteRecord: %w", TranslateError(err))
+	}
+
+	logQueryAfter(ctx, query, values, start, nil)
+	observeOperation(ctx, OperationDelete, vtyp, getSQLTableNameContext(ctx, vdesc), stmt, start, rowsAffected(res), nil)
+	setResult(result, OperationDelete, getSQLTableNameContext(ctx, vdesc), stmt, start, rowsAffected(res), nil)
 
+	if err := checkRowsAffected(res, OperationDelete, getSQLTableNameContext(ctx, vdesc), 1); err != nil {
 		return fmt.Errorf("DeleteRecord: %w", err)
 	}
 
-	if queryLogger != nil {
-		if queryLogger, ok := queryLogger.(QueryLoggerAfter); ok {
-			queryLogger.LogQueryAfter(query, values, time.Now().Sub(start), nil)
-		}
+	if err := runCallbacks(ctx, tx, OperationDelete, PhaseAfter, input, getSQLTableNameContext(ctx, vdesc), stmt); err != nil {
+		return fmt.Errorf("DeleteRecord: %w", err)
 	}
 
 	if v, ok := input.(AfterDeleter); ok {
-		if err := v.AfterDelete(ctx, tx); err != nil {
+		if err := callHook(ctx, "sorm hook AfterDelete", func(ctx context.Context) error { return v.AfterDelete(ctx, tx) }); err != nil {
 			return fmt.Errorf("DeleteRecord: AfterDelete callback returned an error: %w", err)
 		}
 	}
@@ -831,7 +1355,14 @@ func isZero(i interface{}) bool {
 		return v == ""
 	case nil:
 		return true
-	default:
-		return reflect.ValueOf(i).IsZero()
 	}
+
+	// a pointer is only empty when it's nil; a pointer to a zero value is a
+	// value that has been set
+	v := reflect.ValueOf(i)
+	if v.Kind() == reflect.Ptr {
+		return v.IsNil()
+	}
+
+	return v.IsZero()
 }
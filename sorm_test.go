@@ -4,8 +4,10 @@ import (
 	"context"
 	"database/sql"
 	"database/sql/driver"
+	"errors"
 	"fmt"
 	"io"
+	"strings"
 	"testing"
 	"time"
 
@@ -89,6 +91,23 @@ func TestFindAll(t *testing.T) {
 	a.Equal([]Object{{ID: 1, Name: "test1"}, {ID: 2, Name: "test2"}}, r)
 }
 
+func TestFindAllPointers(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from objects`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "test1").AddRow(2, "test2"))
+
+	var r []*Object
+	a.NoError(FindAll(context.Background(), db, &r))
+
+	a.Equal([]*Object{{ID: 1, Name: "test1"}, {ID: 2, Name: "test2"}}, r)
+}
+
 func TestFindFirstWhere(t *testing.T) {
 	a := assert.New(t)
 
@@ -157,6 +176,25 @@ func TestCreateRecord(t *testing.T) {
 	_ = tx.Commit()
 }
 
+func TestCreateRecordQuerier(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectExec(`insert into simple_objects \(id, name\) values \(\$1, \$2\)`).WithArgs(1, "test1").WillReturnResult(sqlmock.NewResult(1, 1))
+	mockDB.ExpectExec(`delete from simple_objects where id = \$1`).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	r := SimpleObject{ID: 1, Name: "test1"}
+	a.NoError(CreateRecord(context.Background(), db, &r))
+	a.NoError(DeleteRecord(context.Background(), db, &r))
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
 func TestCreateRecordEmptyID(t *testing.T) {
 	a := assert.New(t)
 
@@ -354,7 +392,7 @@ type TestBeforeCreateObject struct {
 	Name string
 }
 
-func (t *TestBeforeCreateObject) BeforeCreate(ctx context.Context, tx *sql.Tx) error {
+func (t *TestBeforeCreateObject) BeforeCreate(ctx context.Context, tx Querier) error {
 	return t.m.MethodCalled("BeforeCreate", ctx, tx).Error(0)
 }
 
@@ -422,7 +460,7 @@ type TestAfterCreateObject struct {
 	Name string
 }
 
-func (t *TestAfterCreateObject) AfterCreate(ctx context.Context, tx *sql.Tx) error {
+func (t *TestAfterCreateObject) AfterCreate(ctx context.Context, tx Querier) error {
 	return t.m.MethodCalled("AfterCreate", ctx, tx).Error(0)
 }
 
@@ -868,3 +906,295 @@ func TestQueryLoggerFunc(t *testing.T) {
 		{query: "select * from objects where id = $1", args: []interface{}{1}},
 	}, logs)
 }
+
+type retainingScanner struct{ Value []byte }
+
+func (s *retainingScanner) Scan(src interface{}) error {
+	if src, ok := src.([]byte); ok {
+		s.Value = src
+		return nil
+	}
+
+	return fmt.Errorf("retainingScanner: unexpected value type: %T", src)
+}
+
+func TestSafeScanningCopiesBytes(t *testing.T) {
+	type TestObject struct {
+		ID    int
+		Value retainingScanner
+	}
+
+	a := assert.New(t)
+
+	SetSafeScanning(true)
+	defer SetSafeScanning(false)
+
+	buf := []byte("row_0")
+
+	db := sql.OpenDB(&MockConnector{
+		driver: &MockDriver{
+			columns: []string{"id", "value"},
+			results: 2,
+			fillRow: func(current, total int, values []driver.Value) error {
+				if current >= total {
+					return io.EOF
+				}
+
+				copy(buf, fmt.Sprintf("row_%d", current))
+
+				values[0] = int64(current)
+				values[1] = buf
+
+				return nil
+			},
+		},
+	})
+
+	var l []TestObject
+	a.NoError(FindAll(context.Background(), db, &l))
+
+	a.Equal([]TestObject{
+		{ID: 0, Value: retainingScanner{Value: []byte("row_0")}},
+		{ID: 1, Value: retainingScanner{Value: []byte("row_1")}},
+	}, l)
+}
+
+func TestSafeScanningRejectsRawBytes(t *testing.T) {
+	type TestObject struct {
+		ID    int
+		Value sql.RawBytes
+	}
+
+	a := assert.New(t)
+
+	SetSafeScanning(true)
+	defer SetSafeScanning(false)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from test_objects`).WillReturnRows(sqlmock.NewRows([]string{"id", "value"}).AddRow(1, []byte("test1")))
+
+	var r []TestObject
+	a.EqualError(FindAll(context.Background(), db, &r), "ScanRows: field for sql field value on TestObject is sql.RawBytes, which is not allowed with safe scanning enabled")
+}
+
+type TestSlowAfterCreateObject struct {
+	ID   int
+	Name string
+}
+
+func (t *TestSlowAfterCreateObject) AfterCreate(ctx context.Context, tx Querier) error {
+	<-ctx.Done()
+	return nil
+}
+
+func TestHookTimeout(t *testing.T) {
+	a := assert.New(t)
+
+	SetHookTimeout(time.Millisecond * 10)
+	defer SetHookTimeout(0)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectBegin()
+	mockDB.ExpectQuery(`insert into test_slow_after_create_objects \(name\) values \(\$1\) returning id`).WithArgs("a").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mockDB.ExpectRollback()
+
+	ctx := context.Background()
+	tx, _ := db.BeginTx(ctx, nil)
+
+	r := TestSlowAfterCreateObject{Name: "a"}
+	a.EqualError(CreateRecord(ctx, tx, &r), "CreateRecord: AfterCreate callback returned an error: context deadline exceeded")
+
+	a.NoError(tx.Rollback())
+}
+
+type contextQueryLogger struct {
+	keys []interface{}
+}
+
+func (l *contextQueryLogger) LogQuery(query string, args []interface{}) {}
+
+func (l *contextQueryLogger) LogQueryContext(ctx context.Context, query string, args []interface{}) {
+	l.keys = append(l.keys, ctx.Value(contextQueryLoggerKey{}))
+}
+
+func (l *contextQueryLogger) LogQueryAfterContext(ctx context.Context, query string, args []interface{}, duration time.Duration, err error) {
+	l.keys = append(l.keys, ctx.Value(contextQueryLoggerKey{}))
+}
+
+type contextQueryLoggerKey struct{}
+
+func TestQueryLoggerContext(t *testing.T) {
+	a := assert.New(t)
+
+	var logger contextQueryLogger
+
+	SetQueryLogger(&logger)
+	defer func() { SetQueryLogger(nil) }()
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from objects`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "test1"))
+
+	ctx := context.WithValue(context.Background(), contextQueryLoggerKey{}, "request-1")
+
+	var r []Object
+	a.NoError(FindAll(ctx, db, &r))
+
+	a.Equal([]interface{}{"request-1", "request-1"}, logger.keys)
+}
+
+func TestScanRowsPrefixedNestedStruct(t *testing.T) {
+	type User struct {
+		ID   int
+		Name string
+	}
+
+	type PostWithUser struct {
+		ID     int
+		Title  string
+		Author User `sql:",prefix:u_"`
+	}
+
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select p\.\*, u\.id as u_id, u\.name as u_name from posts p join users u on u\.id = p\.user_id`).WillReturnRows(sqlmock.NewRows([]string{"id", "title", "u_id", "u_name"}).AddRow(1, "post1", 5, "user5"))
+
+	rows, err := db.QueryContext(context.Background(), "select p.*, u.id as u_id, u.name as u_name from posts p join users u on u.id = p.user_id")
+	if !a.NoError(err) {
+		return
+	}
+	defer rows.Close()
+
+	var r []PostWithUser
+	a.NoError(ScanRows(rows, &r))
+
+	a.Equal([]PostWithUser{{ID: 1, Title: "post1", Author: User{ID: 5, Name: "user5"}}}, r)
+}
+
+func TestFindWhereExplicitColumns(t *testing.T) {
+	a := assert.New(t)
+
+	SetExplicitColumns(true)
+	defer SetExplicitColumns(false)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select id, name from simple_objects where id = \$1`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "test1"))
+
+	var l []SimpleObject
+	a.NoError(FindWhere(context.Background(), db, &l, "where id = $1", 1))
+	a.Equal([]SimpleObject{{ID: 1, Name: "test1"}}, l)
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestFindWhereColumns(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select id from simple_objects where name = \$1`).WithArgs("test1").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+
+	var l []SimpleObject
+	a.NoError(FindWhereColumns(context.Background(), db, &l, []string{"id"}, "where name = $1", "test1"))
+	a.Equal([]SimpleObject{{ID: 1}, {ID: 2}}, l)
+
+	a.EqualError(FindWhereColumns(context.Background(), db, &l, []string{"id", "blob"}, ""), "FindWhere: unknown column: SimpleObject has no column blob")
+	a.EqualError(FindWhereColumns(context.Background(), db, &l, nil, ""), "FindWhereColumns: expected at least one column")
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestCreateRecordSkipsPrefixedFields(t *testing.T) {
+	type User struct {
+		ID   int
+		Name string
+	}
+
+	type PostWithUser struct {
+		ID     int
+		Title  string
+		Author User `sql:",prefix:u_"`
+	}
+
+	a := assert.New(t)
+
+	s, err := InsertStatement(&PostWithUser{ID: 1, Title: "post1"})
+	a.NoError(err)
+	a.Equal("insert into post_with_users (id, title) values ($1, $2)", s.Query)
+}
+
+type TestAfterFindObject struct {
+	ID    int
+	Name  string
+	Upper string `sql:"-"`
+}
+
+func (t *TestAfterFindObject) AfterFind(ctx context.Context) error {
+	if t.Name == "bad" {
+		return errors.New("can't load bad")
+	}
+
+	t.Upper = strings.ToUpper(t.Name)
+
+	return nil
+}
+
+func TestAfterFind(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from test_after_find_objects`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "test1").AddRow(2, "test2"))
+
+	var l []TestAfterFindObject
+	a.NoError(FindAll(context.Background(), db, &l))
+	a.Equal([]TestAfterFindObject{{ID: 1, Name: "test1", Upper: "TEST1"}, {ID: 2, Name: "test2", Upper: "TEST2"}}, l)
+}
+
+func TestAfterFindError(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from test_after_find_objects`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "test1").AddRow(2, "bad"))
+
+	var l []TestAfterFindObject
+	a.EqualError(FindAll(context.Background(), db, &l), "ScanRows: AfterFind callback returned an error for row 1: can't load bad")
+}
@@ -0,0 +1,271 @@
+// Package sormigrate runs versioned schema migrations, recording the applied
+// versions in a schema_migrations table through sorm so that the parameter
+// style and schema options configured for sorm apply here too.
+package sormigrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"fknsrs.biz/p/sorm"
+)
+
+var ErrNoDown = errors.New("migration can't be rolled back")
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      func(ctx context.Context, tx *sql.Tx) error
+	// Down may be nil, in which case Down refuses to roll the migration
+	// back.
+	Down func(ctx context.Context, tx *sql.Tx) error
+}
+
+// SQL makes a migration that executes up and down as single statements. An
+// empty down makes it irreversible.
+func SQL(version int64, name, up, down string) Migration {
+	m := Migration{
+		Version: version,
+		Name:    name,
+		Up: func(ctx context.Context, tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, up)
+			return err
+		},
+	}
+
+	if down != "" {
+		m.Down = func(ctx context.Context, tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, down)
+			return err
+		}
+	}
+
+	return m
+}
+
+type SchemaMigration struct {
+	Version   int64 `sql:",id,table:schema_migrations"`
+	Name      string
+	AppliedAt time.Time
+}
+
+// DefaultLimit stops sorm's default limit from truncating the list of
+// applied migrations.
+func (SchemaMigration) DefaultLimit() int {
+	return -1
+}
+
+// LockFunc is called at the start of every migration's transaction and
+// should block until no other runner holds the same lock.
+type LockFunc func(ctx context.Context, tx *sql.Tx) error
+
+// AdvisoryLock takes a Postgres transaction-level advisory lock on key.
+func AdvisoryLock(key int64) LockFunc {
+	return func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, fmt.Sprintf("select pg_advisory_xact_lock(%d)", key))
+		return err
+	}
+}
+
+// TableLock locks the schema_migrations table in exclusive mode, which
+// Postgres and Oracle understand.
+func TableLock() LockFunc {
+	return func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, "lock table "+sorm.TableName(SchemaMigration{})+" in exclusive mode")
+		return err
+	}
+}
+
+// Migrator applies its registered migrations in version order, each in its
+// own transaction. After taking Lock the applied versions are read again
+// inside the transaction, so a runner that waited on the lock skips what the
+// other one did. Without a Lock a concurrent runner fails on the
+// schema_migrations primary key instead and its transaction is rolled back.
+type Migrator struct {
+	DB   *sql.DB
+	Lock LockFunc
+
+	migrations []Migration
+}
+
+func New(db *sql.DB, lock LockFunc) *Migrator {
+	return &Migrator{DB: db, Lock: lock}
+}
+
+func (m *Migrator) Register(migrations ...Migration) error {
+	for _, mig := range migrations {
+		if mig.Up == nil {
+			return fmt.Errorf("Register: migration %d has no Up function", mig.Version)
+		}
+
+		for _, e := range m.migrations {
+			if e.Version == mig.Version {
+				return fmt.Errorf("Register: migration %d is already registered as %q", mig.Version, e.Name)
+			}
+		}
+
+		m.migrations = append(m.migrations, mig)
+	}
+
+	sort.Slice(m.migrations, func(i, j int) bool { return m.migrations[i].Version < m.migrations[j].Version })
+
+	return nil
+}
+
+// Applied lists the migrations recorded in schema_migrations, oldest version
+// first, creating the table if it doesn't exist yet.
+func (m *Migrator) Applied(ctx context.Context) ([]SchemaMigration, error) {
+	if err := sorm.AutoMigrate(ctx, m.DB, &SchemaMigration{}); err != nil {
+		return nil, fmt.Errorf("Applied: %w", err)
+	}
+
+	l, err := applied(ctx, m.DB)
+	if err != nil {
+		return nil, fmt.Errorf("Applied: %w", err)
+	}
+
+	return l, nil
+}
+
+func applied(ctx context.Context, db sorm.Querier) ([]SchemaMigration, error) {
+	var l []SchemaMigration
+	if err := sorm.FindWhere(ctx, db, &l, "order by version"); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// Pending lists the registered migrations that haven't been applied.
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	l, err := m.Applied(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Pending: %w", err)
+	}
+
+	done := make(map[int64]bool)
+	for _, e := range l {
+		done[e.Version] = true
+	}
+
+	var r []Migration
+	for _, mig := range m.migrations {
+		if !done[mig.Version] {
+			r = append(r, mig)
+		}
+	}
+
+	return r, nil
+}
+
+// Up applies every pending migration.
+func (m *Migrator) Up(ctx context.Context) error {
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		return fmt.Errorf("Up: %w", err)
+	}
+
+	for _, mig := range pending {
+		mig := mig
+
+		if err := m.run(ctx, mig.Version, false, func(tx *sql.Tx) error {
+			if err := mig.Up(ctx, tx); err != nil {
+				return err
+			}
+
+			return sorm.CreateRecord(ctx, tx, &SchemaMigration{Version: mig.Version, Name: mig.Name, AppliedAt: time.Now().UTC()})
+		}); err != nil {
+			return fmt.Errorf("Up: migration %d (%s): %w", mig.Version, mig.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// Down rolls back the most recently applied migration. It does nothing if no
+// migrations have been applied.
+func (m *Migrator) Down(ctx context.Context) error {
+	l, err := m.Applied(ctx)
+	if err != nil {
+		return fmt.Errorf("Down: %w", err)
+	}
+
+	if len(l) == 0 {
+		return nil
+	}
+
+	last := l[len(l)-1]
+
+	var mig *Migration
+	for i := range m.migrations {
+		if m.migrations[i].Version == last.Version {
+			mig = &m.migrations[i]
+		}
+	}
+
+	if mig == nil {
+		return fmt.Errorf("Down: migration %d (%s) isn't registered", last.Version, last.Name)
+	}
+
+	if mig.Down == nil {
+		return fmt.Errorf("Down: migration %d (%s): %w", mig.Version, mig.Name, ErrNoDown)
+	}
+
+	if err := m.run(ctx, mig.Version, true, func(tx *sql.Tx) error {
+		if err := mig.Down(ctx, tx); err != nil {
+			return err
+		}
+
+		return sorm.DeleteRecord(ctx, tx, &last)
+	}); err != nil {
+		return fmt.Errorf("Down: migration %d (%s): %w", mig.Version, mig.Name, err)
+	}
+
+	return nil
+}
+
+// run calls fn in a transaction once the lock is held, unless another runner
+// has changed whether version is applied in the meantime.
+func (m *Migrator) run(ctx context.Context, version int64, wantApplied bool, fn func(tx *sql.Tx) error) error {
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("couldn't open a transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if m.Lock != nil {
+		if err := m.Lock(ctx, tx); err != nil {
+			return fmt.Errorf("couldn't take migration lock: %w", err)
+		}
+
+		l, err := applied(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		isApplied := false
+		for _, e := range l {
+			if e.Version == version {
+				isApplied = true
+			}
+		}
+
+		if isApplied != wantApplied {
+			return nil
+		}
+	}
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("couldn't commit transaction: %w", err)
+	}
+
+	return nil
+}
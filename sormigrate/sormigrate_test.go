@@ -0,0 +1,131 @@
+package sormigrate
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func expectTable(mockDB sqlmock.Sqlmock) {
+	mockDB.ExpectQuery(`information_schema\.columns where table_name = \$1`).WithArgs("schema_migrations").WillReturnRows(sqlmock.NewRows([]string{"column_name", "data_type", "is_nullable"}).AddRow("version", "bigint", "NO").AddRow("name", "text", "NO").AddRow("applied_at", "timestamp", "NO"))
+}
+
+func TestRegisterDuplicate(t *testing.T) {
+	a := assert.New(t)
+
+	m := New(nil, nil)
+	a.NoError(m.Register(SQL(2, "b", "create table b (id integer)", ""), SQL(1, "a", "create table a (id integer)", "")))
+	a.EqualError(m.Register(SQL(1, "c", "select 1", "")), `Register: migration 1 is already registered as "a"`)
+	a.Equal(int64(1), m.migrations[0].Version)
+}
+
+func TestUp(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	m := New(db, AdvisoryLock(42))
+	a.NoError(m.Register(
+		SQL(1, "create users", "create table users (id integer)", "drop table users"),
+		SQL(2, "create posts", "create table posts (id integer)", "drop table posts"),
+	))
+
+	expectTable(mockDB)
+	mockDB.ExpectQuery(`select .+ from schema_migrations .*order by version`).WillReturnRows(sqlmock.NewRows([]string{"version", "name", "applied_at"}).AddRow(1, "create users", time.Now()))
+	mockDB.ExpectBegin()
+	mockDB.ExpectExec(`select pg_advisory_xact_lock\(42\)`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mockDB.ExpectQuery(`select .+ from schema_migrations .*order by version`).WillReturnRows(sqlmock.NewRows([]string{"version", "name", "applied_at"}).AddRow(1, "create users", time.Now()))
+	mockDB.ExpectExec(`create table posts \(id integer\)`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mockDB.ExpectExec(`insert into schema_migrations \(version, name, applied_at\)`).WithArgs(int64(2), "create posts", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectCommit()
+
+	a.NoError(m.Up(context.Background()))
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestUpAlreadyAppliedByOtherRunner(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	m := New(db, AdvisoryLock(42))
+	a.NoError(m.Register(SQL(1, "create users", "create table users (id integer)", "")))
+
+	expectTable(mockDB)
+	mockDB.ExpectQuery(`select .+ from schema_migrations`).WillReturnRows(sqlmock.NewRows([]string{"version", "name", "applied_at"}))
+	mockDB.ExpectBegin()
+	mockDB.ExpectExec(`select pg_advisory_xact_lock\(42\)`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mockDB.ExpectQuery(`select .+ from schema_migrations`).WillReturnRows(sqlmock.NewRows([]string{"version", "name", "applied_at"}).AddRow(1, "create users", time.Now()))
+	mockDB.ExpectRollback()
+
+	a.NoError(m.Up(context.Background()))
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestUpFailure(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	m := New(db, nil)
+	a.NoError(m.Register(SQL(1, "create users", "create table users (id integer)", "")))
+
+	expectTable(mockDB)
+	mockDB.ExpectQuery(`select .+ from schema_migrations`).WillReturnRows(sqlmock.NewRows([]string{"version", "name", "applied_at"}))
+	mockDB.ExpectBegin()
+	mockDB.ExpectExec(`create table users`).WillReturnError(errors.New("boom"))
+	mockDB.ExpectRollback()
+
+	a.EqualError(m.Up(context.Background()), "Up: migration 1 (create users): boom")
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestDown(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	m := New(db, nil)
+	a.NoError(m.Register(
+		SQL(1, "create users", "create table users (id integer)", "drop table users"),
+		SQL(2, "create posts", "create table posts (id integer)", ""),
+	))
+
+	expectTable(mockDB)
+	mockDB.ExpectQuery(`select .+ from schema_migrations`).WillReturnRows(sqlmock.NewRows([]string{"version", "name", "applied_at"}).AddRow(1, "create users", time.Now()))
+	mockDB.ExpectBegin()
+	mockDB.ExpectExec(`drop table users`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mockDB.ExpectExec(`delete from schema_migrations where version = \$1`).WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectCommit()
+
+	a.NoError(m.Down(context.Background()))
+
+	expectTable(mockDB)
+	mockDB.ExpectQuery(`select .+ from schema_migrations`).WillReturnRows(sqlmock.NewRows([]string{"version", "name", "applied_at"}).AddRow(1, "create users", time.Now()).AddRow(2, "create posts", time.Now()))
+
+	err = m.Down(context.Background())
+	a.EqualError(err, "Down: migration 2 (create posts): migration can't be rolled back")
+	a.True(errors.Is(err, ErrNoDown))
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
@@ -0,0 +1,247 @@
+// Package sormmock provides testify mocks for sorm's interfaces. The hook
+// mocks are meant to be embedded in a model with an `sql:"-"` tag.
+package sormmock
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"fknsrs.biz/p/sorm"
+	"github.com/stretchr/testify/mock"
+)
+
+var (
+	_ sorm.Querier                 = (*Querier)(nil)
+	_ sorm.QueryLogger             = (*QueryLogger)(nil)
+	_ sorm.QueryLoggerAfter        = (*QueryLogger)(nil)
+	_ sorm.QueryLoggerContext      = (*ContextQueryLogger)(nil)
+	_ sorm.QueryLoggerAfterContext = (*ContextQueryLogger)(nil)
+	_ sorm.QueryLoggerV2           = (*QueryLoggerV2)(nil)
+	_ sorm.OverrideScanner         = (*OverrideScanner)(nil)
+	_ sorm.BeforeSaver             = (*BeforeSaver)(nil)
+	_ sorm.AfterSaver              = (*AfterSaver)(nil)
+	_ sorm.BeforeCreater           = (*BeforeCreater)(nil)
+	_ sorm.AfterCreater            = (*AfterCreater)(nil)
+	_ sorm.BeforeReplacer          = (*BeforeReplacer)(nil)
+	_ sorm.AfterReplacer           = (*AfterReplacer)(nil)
+	_ sorm.BeforeDeleter           = (*BeforeDeleter)(nil)
+	_ sorm.AfterDeleter            = (*AfterDeleter)(nil)
+	_ sorm.AfterFinder             = (*AfterFinder)(nil)
+	_ sorm.BeforeCreateBatcher     = (*BeforeCreateBatcher)(nil)
+	_ sorm.AfterCreateBatcher      = (*AfterCreateBatcher)(nil)
+	_ sorm.BeforeSaveBatcher       = (*BeforeSaveBatcher)(nil)
+	_ sorm.AfterSaveBatcher        = (*AfterSaveBatcher)(nil)
+	_ sorm.BeforeDeleteBatcher     = (*BeforeDeleteBatcher)(nil)
+	_ sorm.AfterDeleteBatcher      = (*AfterDeleteBatcher)(nil)
+	_ sorm.MetricsCollector        = (*MetricsCollector)(nil)
+)
+
+type Querier struct {
+	mock.Mock
+}
+
+func (m *Querier) ExecContext(ctx context.Context, s string, args ...interface{}) (sql.Result, error) {
+	ret := m.MethodCalled("ExecContext", append([]interface{}{ctx, s}, args...)...)
+
+	var r0 sql.Result
+	if v := ret.Get(0); v != nil {
+		r0 = v.(sql.Result)
+	}
+
+	return r0, ret.Error(1)
+}
+
+func (m *Querier) QueryContext(ctx context.Context, s string, args ...interface{}) (*sql.Rows, error) {
+	ret := m.MethodCalled("QueryContext", append([]interface{}{ctx, s}, args...)...)
+
+	var r0 *sql.Rows
+	if v := ret.Get(0); v != nil {
+		r0 = v.(*sql.Rows)
+	}
+
+	return r0, ret.Error(1)
+}
+
+func (m *Querier) QueryRowContext(ctx context.Context, s string, args ...interface{}) *sql.Row {
+	ret := m.MethodCalled("QueryRowContext", append([]interface{}{ctx, s}, args...)...)
+
+	var r0 *sql.Row
+	if v := ret.Get(0); v != nil {
+		r0 = v.(*sql.Row)
+	}
+
+	return r0
+}
+
+type QueryLogger struct {
+	mock.Mock
+}
+
+func (m *QueryLogger) LogQuery(query string, vars []interface{}) {
+	m.MethodCalled("LogQuery", query, vars)
+}
+
+func (m *QueryLogger) LogQueryAfter(query string, vars []interface{}, duration time.Duration, err error) {
+	m.MethodCalled("LogQueryAfter", query, vars, duration, err)
+}
+
+type ContextQueryLogger struct {
+	mock.Mock
+}
+
+func (m *ContextQueryLogger) LogQuery(query string, vars []interface{}) {
+	m.MethodCalled("LogQuery", query, vars)
+}
+
+func (m *ContextQueryLogger) LogQueryContext(ctx context.Context, query string, vars []interface{}) {
+	m.MethodCalled("LogQueryContext", ctx, query, vars)
+}
+
+func (m *ContextQueryLogger) LogQueryAfterContext(ctx context.Context, query string, vars []interface{}, duration time.Duration, err error) {
+	m.MethodCalled("LogQueryAfterContext", ctx, query, vars, duration, err)
+}
+
+type OverrideScanner struct {
+	mock.Mock
+}
+
+func (m *OverrideScanner) OverrideScan(names []string, out []sql.Scanner) error {
+	return m.MethodCalled("OverrideScan", names, out).Error(0)
+}
+
+type BeforeSaver struct {
+	mock.Mock
+}
+
+func (m *BeforeSaver) BeforeSave(ctx context.Context, tx sorm.Querier) error {
+	return m.MethodCalled("BeforeSave", ctx, tx).Error(0)
+}
+
+type AfterSaver struct {
+	mock.Mock
+}
+
+func (m *AfterSaver) AfterSave(ctx context.Context, tx sorm.Querier) error {
+	return m.MethodCalled("AfterSave", ctx, tx).Error(0)
+}
+
+type BeforeCreater struct {
+	mock.Mock
+}
+
+func (m *BeforeCreater) BeforeCreate(ctx context.Context, tx sorm.Querier) error {
+	return m.MethodCalled("BeforeCreate", ctx, tx).Error(0)
+}
+
+type AfterCreater struct {
+	mock.Mock
+}
+
+func (m *AfterCreater) AfterCreate(ctx context.Context, tx sorm.Querier) error {
+	return m.MethodCalled("AfterCreate", ctx, tx).Error(0)
+}
+
+type BeforeReplacer struct {
+	mock.Mock
+}
+
+func (m *BeforeReplacer) BeforeReplace(ctx context.Context, tx sorm.Querier) error {
+	return m.MethodCalled("BeforeReplace", ctx, tx).Error(0)
+}
+
+type AfterReplacer struct {
+	mock.Mock
+}
+
+func (m *AfterReplacer) AfterReplace(ctx context.Context, tx sorm.Querier) error {
+	return m.MethodCalled("AfterReplace", ctx, tx).Error(0)
+}
+
+type BeforeDeleter struct {
+	mock.Mock
+}
+
+func (m *BeforeDeleter) BeforeDelete(ctx context.Context, tx sorm.Querier) error {
+	return m.MethodCalled("BeforeDelete", ctx, tx).Error(0)
+}
+
+type AfterDeleter struct {
+	mock.Mock
+}
+
+func (m *AfterDeleter) AfterDelete(ctx context.Context, tx sorm.Querier) error {
+	return m.MethodCalled("AfterDelete", ctx, tx).Error(0)
+}
+
+type AfterFinder struct {
+	mock.Mock
+}
+
+func (m *AfterFinder) AfterFind(ctx context.Context) error {
+	return m.MethodCalled("AfterFind", ctx).Error(0)
+}
+
+type BeforeCreateBatcher struct {
+	mock.Mock
+}
+
+func (m *BeforeCreateBatcher) BeforeCreateBatch(ctx context.Context, tx sorm.Querier, records interface{}) error {
+	return m.MethodCalled("BeforeCreateBatch", ctx, tx, records).Error(0)
+}
+
+type AfterCreateBatcher struct {
+	mock.Mock
+}
+
+func (m *AfterCreateBatcher) AfterCreateBatch(ctx context.Context, tx sorm.Querier, records interface{}) error {
+	return m.MethodCalled("AfterCreateBatch", ctx, tx, records).Error(0)
+}
+
+type BeforeSaveBatcher struct {
+	mock.Mock
+}
+
+func (m *BeforeSaveBatcher) BeforeSaveBatch(ctx context.Context, tx sorm.Querier, records interface{}) error {
+	return m.MethodCalled("BeforeSaveBatch", ctx, tx, records).Error(0)
+}
+
+type AfterSaveBatcher struct {
+	mock.Mock
+}
+
+func (m *AfterSaveBatcher) AfterSaveBatch(ctx context.Context, tx sorm.Querier, records interface{}) error {
+	return m.MethodCalled("AfterSaveBatch", ctx, tx, records).Error(0)
+}
+
+type BeforeDeleteBatcher struct {
+	mock.Mock
+}
+
+func (m *BeforeDeleteBatcher) BeforeDeleteBatch(ctx context.Context, tx sorm.Querier, records interface{}) error {
+	return m.MethodCalled("BeforeDeleteBatch", ctx, tx, records).Error(0)
+}
+
+type AfterDeleteBatcher struct {
+	mock.Mock
+}
+
+func (m *AfterDeleteBatcher) AfterDeleteBatch(ctx context.Context, tx sorm.Querier, records interface{}) error {
+	return m.MethodCalled("AfterDeleteBatch", ctx, tx, records).Error(0)
+}
+
+type MetricsCollector struct {
+	mock.Mock
+}
+
+func (m *MetricsCollector) ObserveOperation(op sorm.Operation, table string, duration time.Duration, rowsAffected int64, err error) {
+	m.MethodCalled("ObserveOperation", op, table, duration, rowsAffected, err)
+}
+
+type QueryLoggerV2 struct {
+	mock.Mock
+}
+
+func (m *QueryLoggerV2) LogQueryV2(e sorm.QueryLogEntry) {
+	m.MethodCalled("LogQueryV2", e)
+}
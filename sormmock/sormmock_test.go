@@ -0,0 +1,71 @@
+package sormmock
+
+import (
+	"context"
+	"testing"
+
+	"fknsrs.biz/p/sorm"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type hookedUser struct {
+	BeforeCreater `sql:"-"`
+	AfterCreater  `sql:"-"`
+
+	ID   int
+	Name string
+}
+
+func TestEmbeddedHooks(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`^insert into hooked_users \(name\) values \(\$1\) returning id$`).WithArgs("a").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	u := hookedUser{Name: "a"}
+	u.BeforeCreater.On("BeforeCreate", mock.Anything, db).Return(nil)
+	u.AfterCreater.On("AfterCreate", mock.Anything, db).Return(nil)
+
+	a.NoError(sorm.CreateRecord(context.Background(), db, &u))
+	a.Equal(1, u.ID)
+	u.BeforeCreater.AssertExpectations(t)
+	u.AfterCreater.AssertExpectations(t)
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestEmbeddedHooksExplicitColumns(t *testing.T) {
+	a := assert.New(t)
+
+	sorm.SetExplicitColumns(true)
+	defer sorm.SetExplicitColumns(false)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`^select id, name from hooked_users where id = \$1 limit 1$`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a"))
+
+	info, err := sorm.DescribeModel(hookedUser{})
+	if a.NoError(err) {
+		a.Equal([]string{"id"}, info.IDColumns)
+		a.Len(info.Columns, 2)
+	}
+
+	var u hookedUser
+	if a.NoError(sorm.FindByID(context.Background(), db, &u, 1)) {
+		a.Equal(1, u.ID)
+		a.Equal("a", u.Name)
+	}
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
@@ -0,0 +1,138 @@
+// Package sormprom exposes sorm operation metrics in the Prometheus text
+// exposition format without depending on the Prometheus client library.
+package sormprom
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"fknsrs.biz/p/sorm"
+)
+
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type series struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+	rows   int64
+	errors uint64
+	op     string
+	table  string
+}
+
+// Collector is a sorm.MetricsCollector that serves its metrics over HTTP.
+type Collector struct {
+	Namespace string
+	Buckets   []float64
+
+	m      sync.Mutex
+	series map[string]*series
+}
+
+var (
+	_ sorm.MetricsCollector = (*Collector)(nil)
+	_ http.Handler          = (*Collector)(nil)
+)
+
+func New() *Collector {
+	return &Collector{Namespace: "sorm", Buckets: DefaultBuckets}
+}
+
+func (c *Collector) ObserveOperation(op sorm.Operation, table string, duration time.Duration, rowsAffected int64, err error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if c.series == nil {
+		c.series = make(map[string]*series)
+	}
+
+	k := op.String() + "\x00" + table
+	s, ok := c.series[k]
+	if !ok {
+		s = &series{counts: make([]uint64, len(c.Buckets)), op: op.String(), table: table}
+		c.series[k] = s
+	}
+
+	d := duration.Seconds()
+	for i, b := range c.Buckets {
+		if d <= b {
+			s.counts[i]++
+		}
+	}
+	s.sum += d
+	s.count++
+
+	if rowsAffected > 0 {
+		s.rows += rowsAffected
+	}
+
+	if err != nil {
+		s.errors++
+	}
+}
+
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	_, _ = c.WriteTo(w)
+}
+
+func (c *Collector) WriteTo(w io.Writer) (int64, error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	var keys []string
+	for k := range c.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	ns := c.Namespace
+	if ns == "" {
+		ns = "sorm"
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# HELP %s_operation_duration_seconds Time taken by sorm operations.\n", ns)
+	fmt.Fprintf(&b, "# TYPE %s_operation_duration_seconds histogram\n", ns)
+	for _, k := range keys {
+		s := c.series[k]
+		l := labels(s)
+		for i, bound := range c.Buckets {
+			fmt.Fprintf(&b, "%s_operation_duration_seconds_bucket{%s,le=%q} %d\n", ns, l, strconv.FormatFloat(bound, 'g', -1, 64), s.counts[i])
+		}
+		fmt.Fprintf(&b, "%s_operation_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", ns, l, s.count)
+		fmt.Fprintf(&b, "%s_operation_duration_seconds_sum{%s} %s\n", ns, l, strconv.FormatFloat(s.sum, 'g', -1, 64))
+		fmt.Fprintf(&b, "%s_operation_duration_seconds_count{%s} %d\n", ns, l, s.count)
+	}
+
+	fmt.Fprintf(&b, "# HELP %s_operation_rows_affected_total Rows written or read by sorm operations.\n", ns)
+	fmt.Fprintf(&b, "# TYPE %s_operation_rows_affected_total counter\n", ns)
+	for _, k := range keys {
+		s := c.series[k]
+		fmt.Fprintf(&b, "%s_operation_rows_affected_total{%s} %d\n", ns, labels(s), s.rows)
+	}
+
+	fmt.Fprintf(&b, "# HELP %s_operation_errors_total Failed sorm operations.\n", ns)
+	fmt.Fprintf(&b, "# TYPE %s_operation_errors_total counter\n", ns)
+	for _, k := range keys {
+		s := c.series[k]
+		fmt.Fprintf(&b, "%s_operation_errors_total{%s} %d\n", ns, labels(s), s.errors)
+	}
+
+	n, err := io.WriteString(w, b.String())
+
+	return int64(n), err
+}
+
+func labels(s *series) string {
+	return "operation=" + strconv.Quote(s.op) + ",table=" + strconv.Quote(s.table)
+}
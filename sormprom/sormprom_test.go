@@ -0,0 +1,47 @@
+package sormprom
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"fknsrs.biz/p/sorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollector(t *testing.T) {
+	a := assert.New(t)
+
+	c := New()
+	c.Buckets = []float64{0.01, 0.1}
+
+	c.ObserveOperation(sorm.OperationCreate, "users", 5*time.Millisecond, 1, nil)
+	c.ObserveOperation(sorm.OperationCreate, "users", 50*time.Millisecond, 0, errors.New("boom"))
+	c.ObserveOperation(sorm.OperationFind, "users", 200*time.Millisecond, 3, nil)
+
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	a.Equal(`# HELP sorm_operation_duration_seconds Time taken by sorm operations.
+# TYPE sorm_operation_duration_seconds histogram
+sorm_operation_duration_seconds_bucket{operation="create",table="users",le="0.01"} 1
+sorm_operation_duration_seconds_bucket{operation="create",table="users",le="0.1"} 2
+sorm_operation_duration_seconds_bucket{operation="create",table="users",le="+Inf"} 2
+sorm_operation_duration_seconds_sum{operation="create",table="users"} 0.055
+sorm_operation_duration_seconds_count{operation="create",table="users"} 2
+sorm_operation_duration_seconds_bucket{operation="find",table="users",le="0.01"} 0
+sorm_operation_duration_seconds_bucket{operation="find",table="users",le="0.1"} 0
+sorm_operation_duration_seconds_bucket{operation="find",table="users",le="+Inf"} 1
+sorm_operation_duration_seconds_sum{operation="find",table="users"} 0.2
+sorm_operation_duration_seconds_count{operation="find",table="users"} 1
+# HELP sorm_operation_rows_affected_total Rows written or read by sorm operations.
+# TYPE sorm_operation_rows_affected_total counter
+sorm_operation_rows_affected_total{operation="create",table="users"} 1
+sorm_operation_rows_affected_total{operation="find",table="users"} 3
+# HELP sorm_operation_errors_total Failed sorm operations.
+# TYPE sorm_operation_errors_total counter
+sorm_operation_errors_total{operation="create",table="users"} 1
+sorm_operation_errors_total{operation="find",table="users"} 0
+`, rec.Body.String())
+}
@@ -0,0 +1,165 @@
+// Package sormtest contains helpers for testing code that uses sorm.
+package sormtest
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"fknsrs.biz/p/sorm"
+)
+
+type RecordedQuery struct {
+	Query string
+	Args  []interface{}
+}
+
+// Recorder is a sorm.QueryLogger that keeps the first occurrence of every
+// distinct query it sees.
+type Recorder struct {
+	m       sync.Mutex
+	seen    map[string]bool
+	queries []RecordedQuery
+}
+
+var _ sorm.QueryLogger = (*Recorder)(nil)
+
+func (r *Recorder) LogQuery(query string, vars []interface{}) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if r.seen == nil {
+		r.seen = make(map[string]bool)
+	}
+
+	if r.seen[query] {
+		return
+	}
+	r.seen[query] = true
+
+	r.queries = append(r.queries, RecordedQuery{Query: query, Args: append([]interface{}(nil), vars...)})
+}
+
+func (r *Recorder) Queries() []RecordedQuery {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	return append([]RecordedQuery(nil), r.queries...)
+}
+
+type ExplainOptions struct {
+	// Prefix is prepended to each query; it defaults to "explain ". Use
+	// "explain query plan " for SQLite.
+	Prefix      string
+	LargeTables []string
+}
+
+// CheckPlans runs EXPLAIN for every query captured by rec and reports a test
+// failure for each plan that does a full scan of one of opts.LargeTables.
+// Postgres, SQLite and MySQL plan output are understood.
+func CheckPlans(t testing.TB, db sorm.Querier, rec *Recorder, opts ExplainOptions) {
+	t.Helper()
+
+	prefix := opts.Prefix
+	if prefix == "" {
+		prefix = "explain "
+	}
+
+	for _, q := range rec.Queries() {
+		plan, err := explain(db, prefix+q.Query, q.Args)
+		if err != nil {
+			t.Errorf("CheckPlans: couldn't explain %q: %s", q.Query, err)
+			continue
+		}
+
+		for _, tbl := range opts.LargeTables {
+			if hasFullScan(plan, tbl) {
+				t.Errorf("CheckPlans: query %q does a full scan of %s:\n%s", q.Query, tbl, formatPlan(plan))
+			}
+		}
+	}
+}
+
+type planRow map[string]string
+
+func explain(db sorm.Querier, query string, args []interface{}) ([]planRow, error) {
+	rows, err := db.QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	var plan []planRow
+	for rows.Next() {
+		vals := make([]sql.NullString, len(cols))
+		ptrs := make([]interface{}, len(cols))
+		for i := range vals {
+			ptrs[i] = &vals[i]
+		}
+
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+
+		r := make(planRow)
+		for i, c := range cols {
+			r[strings.ToLower(c)] = vals[i].String
+		}
+
+		plan = append(plan, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+func hasFullScan(plan []planRow, tbl string) bool {
+	q := regexp.QuoteMeta(tbl)
+	pg := regexp.MustCompile(`Seq Scan on ` + q + `\b`)
+	sqlite := regexp.MustCompile(`^SCAN (TABLE )?` + q + `( AS \w+)?$`)
+
+	for _, r := range plan {
+		if r["table"] == tbl && strings.EqualFold(r["type"], "ALL") {
+			return true
+		}
+
+		for k, v := range r {
+			if k == "query plan" && pg.MatchString(v) {
+				return true
+			}
+
+			if k == "detail" && sqlite.MatchString(strings.TrimSpace(v)) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func formatPlan(plan []planRow) string {
+	var lines []string
+	for _, r := range plan {
+		if v, ok := r["query plan"]; ok {
+			lines = append(lines, v)
+		} else if v, ok := r["detail"]; ok {
+			lines = append(lines, v)
+		} else {
+			lines = append(lines, fmt.Sprint(map[string]string(r)))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,66 @@
+package sormtest
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeT struct {
+	testing.TB
+	errors []string
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Errorf(format string, args ...interface{}) {
+	f.errors = append(f.errors, format)
+}
+
+func TestRecorder(t *testing.T) {
+	a := assert.New(t)
+
+	var r Recorder
+	r.LogQuery("select * from a where id = $1", []interface{}{1})
+	r.LogQuery("select * from a where id = $1", []interface{}{2})
+	r.LogQuery("select * from b", nil)
+
+	a.Equal([]RecordedQuery{
+		{Query: "select * from a where id = $1", Args: []interface{}{1}},
+		{Query: "select * from b", Args: []interface{}(nil)},
+	}, r.Queries())
+}
+
+func TestCheckPlans(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	var r Recorder
+	r.LogQuery("select * from events where id = $1", []interface{}{1})
+	r.LogQuery("select * from events where kind = $1", []interface{}{"x"})
+
+	mockDB.ExpectQuery(`explain select \* from events where id = \$1`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"QUERY PLAN"}).AddRow("Index Scan using events_pkey on events  (cost=0.15..8.17 rows=1 width=40)"))
+	mockDB.ExpectQuery(`explain select \* from events where kind = \$1`).WithArgs("x").WillReturnRows(sqlmock.NewRows([]string{"QUERY PLAN"}).AddRow("Seq Scan on events  (cost=0.00..25.88 rows=6 width=40)").AddRow("  Filter: (kind = 'x'::text)"))
+
+	ft := &fakeT{TB: t}
+	CheckPlans(ft, db, &r, ExplainOptions{LargeTables: []string{"events"}})
+
+	a.Len(ft.errors, 1)
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestHasFullScan(t *testing.T) {
+	a := assert.New(t)
+
+	a.True(hasFullScan([]planRow{{"detail": "SCAN events"}}, "events"))
+	a.False(hasFullScan([]planRow{{"detail": "SEARCH events USING INDEX events_kind (kind=?)"}}, "events"))
+	a.True(hasFullScan([]planRow{{"table": "events", "type": "ALL"}}, "events"))
+	a.False(hasFullScan([]planRow{{"table": "events", "type": "ref"}}, "events"))
+	a.False(hasFullScan([]planRow{{"query plan": "Seq Scan on events_archive"}}, "events"))
+}
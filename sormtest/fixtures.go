@@ -0,0 +1,313 @@
+package sormtest
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"fknsrs.biz/p/sorm"
+)
+
+// FixtureDecoder decodes a fixture file into v, like json.Unmarshal.
+type FixtureDecoder func(data []byte, v interface{}) error
+
+var (
+	fixtureFormats = map[string]FixtureDecoder{
+		".json": json.Unmarshal,
+	}
+	fixtureFormatsLock sync.RWMutex
+)
+
+// RegisterFixtureFormat adds a decoder for fixture files with extension ext.
+// JSON is built in; to keep sorm free of a YAML dependency, YAML fixtures need
+// e.g.
+//
+//	sormtest.RegisterFixtureFormat(".yaml", yaml.Unmarshal)
+//	sormtest.RegisterFixtureFormat(".yml", yaml.Unmarshal)
+func RegisterFixtureFormat(ext string, fn FixtureDecoder) {
+	fixtureFormatsLock.Lock()
+	defer fixtureFormatsLock.Unlock()
+
+	fixtureFormats[ext] = fn
+}
+
+func getFixtureFormat(ext string) FixtureDecoder {
+	fixtureFormatsLock.RLock()
+	defer fixtureFormatsLock.RUnlock()
+
+	return fixtureFormats[strings.ToLower(ext)]
+}
+
+type FixtureOptions struct {
+	// Replace writes records with ReplaceRecord instead of CreateRecord, so
+	// fixtures can be loaded over existing rows.
+	Replace bool
+	// Truncate deletes every row of the models' tables first.
+	Truncate bool
+}
+
+type fixtureModel struct {
+	typ  reflect.Type
+	info sorm.ModelInfo
+}
+
+// LoadFixtures writes the records in a fixture file, or in every fixture file
+// in a directory, to db. A fixture file maps table names to lists of rows,
+// each mapping column names to values:
+//
+//	{"users": [{"id": 1, "email": "a@example.com"}], "posts": [{"user_id": 1}]}
+//
+// models are the types to write the rows as, e.g. User{}. Tables are written
+// so that the targets of belongs_to and has_many relations come first.
+func LoadFixtures(ctx context.Context, db sorm.Querier, path string, models ...interface{}) error {
+	return LoadFixturesWithOptions(ctx, db, path, nil, models...)
+}
+
+func LoadFixturesWithOptions(ctx context.Context, db sorm.Querier, path string, opts *FixtureOptions, models ...interface{}) error {
+	ordered, err := orderFixtureModels(models)
+	if err != nil {
+		return fmt.Errorf("LoadFixtures: %w", err)
+	}
+
+	rows, err := readFixtures(path)
+	if err != nil {
+		return fmt.Errorf("LoadFixtures: %w", err)
+	}
+
+	byTable := make(map[string]bool)
+	for _, m := range ordered {
+		byTable[m.info.Table] = true
+	}
+	for tbl := range rows {
+		if !byTable[tbl] {
+			return fmt.Errorf("LoadFixtures: %s has fixtures for table %s, which isn't the table of any of the models", path, tbl)
+		}
+	}
+
+	if opts != nil && opts.Truncate {
+		if err := truncate(ctx, db, ordered); err != nil {
+			return fmt.Errorf("LoadFixtures: %w", err)
+		}
+	}
+
+	for _, m := range ordered {
+		for i, row := range rows[m.info.Table] {
+			v := reflect.New(m.typ)
+			if err := setFixtureRow(m.info, v.Elem(), row); err != nil {
+				return fmt.Errorf("LoadFixtures: row %d of %s: %w", i+1, m.info.Table, err)
+			}
+
+			if opts != nil && opts.Replace {
+				err = sorm.ReplaceRecord(ctx, db, v.Interface())
+			} else {
+				err = sorm.CreateRecord(ctx, db, v.Interface())
+			}
+			if err != nil {
+				return fmt.Errorf("LoadFixtures: row %d of %s: %w", i+1, m.info.Table, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Seed replaces the contents of the models' tables with the fixtures at path,
+// failing the test if it can't.
+func Seed(t testing.TB, db sorm.Querier, path string, models ...interface{}) {
+	t.Helper()
+
+	if err := LoadFixturesWithOptions(context.Background(), db, path, &FixtureOptions{Truncate: true}, models...); err != nil {
+		t.Fatalf("Seed: %s", err)
+	}
+}
+
+// Truncate deletes every row of the models' tables, children first.
+func Truncate(ctx context.Context, db sorm.Querier, models ...interface{}) error {
+	ordered, err := orderFixtureModels(models)
+	if err != nil {
+		return fmt.Errorf("Truncate: %w", err)
+	}
+
+	if err := truncate(ctx, db, ordered); err != nil {
+		return fmt.Errorf("Truncate: %w", err)
+	}
+
+	return nil
+}
+
+func truncate(ctx context.Context, db sorm.Querier, ordered []fixtureModel) error {
+	for i := len(ordered) - 1; i >= 0; i-- {
+		if _, err := sorm.Exec(ctx, db, "delete from "+ordered[i].info.Table); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// orderFixtureModels sorts models so that each comes after the models it
+// depends on, keeping the given order otherwise.
+func orderFixtureModels(models []interface{}) ([]fixtureModel, error) {
+	var l []fixtureModel
+	index := make(map[reflect.Type]int)
+
+	for _, model := range models {
+		info, err := sorm.DescribeModel(model)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := index[info.Type]; ok {
+			continue
+		}
+
+		index[info.Type] = len(l)
+		l = append(l, fixtureModel{typ: info.Type, info: info})
+	}
+
+	deps := make([][]int, len(l))
+	for i, m := range l {
+		for _, r := range m.info.Relations {
+			j, ok := index[r.Target]
+			if !ok || j == i {
+				continue
+			}
+
+			switch r.Kind {
+			case "belongs_to":
+				deps[i] = append(deps[i], j)
+			case "has_many", "has_one":
+				deps[j] = append(deps[j], i)
+			}
+		}
+	}
+
+	var ordered []fixtureModel
+	state := make([]int, len(l))
+
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case 1:
+			return fmt.Errorf("relations of %s form a cycle", l[i].info.Name)
+		case 2:
+			return nil
+		}
+
+		state[i] = 1
+		for _, j := range deps[i] {
+			if err := visit(j); err != nil {
+				return err
+			}
+		}
+		state[i] = 2
+
+		ordered = append(ordered, l[i])
+
+		return nil
+	}
+
+	for i := range l {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+	}
+
+	return ordered, nil
+}
+
+func readFixtures(path string) (map[string][]map[string]interface{}, error) {
+	st, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{path}
+	if st.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+
+		files = nil
+		for _, e := range entries {
+			if !e.IsDir() && getFixtureFormat(filepath.Ext(e.Name())) != nil {
+				files = append(files, filepath.Join(path, e.Name()))
+			}
+		}
+		sort.Strings(files)
+	}
+
+	rows := make(map[string][]map[string]interface{})
+
+	for _, file := range files {
+		decode := getFixtureFormat(filepath.Ext(file))
+		if decode == nil {
+			return nil, fmt.Errorf("no fixture format registered for %s", file)
+		}
+
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		var m map[string][]map[string]interface{}
+		if err := decode(data, &m); err != nil {
+			return nil, fmt.Errorf("couldn't decode %s: %w", file, err)
+		}
+
+		for tbl, l := range m {
+			rows[tbl] = append(rows[tbl], l...)
+		}
+	}
+
+	return rows, nil
+}
+
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+// setFixtureRow sets the fields of v from row. Values go through the field's
+// Scan method if it has one, and through JSON otherwise, so e.g. timestamps
+// can be written as strings.
+func setFixtureRow(info sorm.ModelInfo, v reflect.Value, row map[string]interface{}) error {
+	for col, val := range row {
+		var c *sorm.ColumnInfo
+		for i := range info.Columns {
+			if info.Columns[i].Column == col {
+				c = &info.Columns[i]
+			}
+		}
+		if c == nil {
+			return fmt.Errorf("%s has no column %s", info.Name, col)
+		}
+
+		fv := v.FieldByIndex(c.Index)
+
+		if fv.Addr().Type().Implements(scannerType) {
+			if err := fv.Addr().Interface().(sql.Scanner).Scan(val); err != nil {
+				return fmt.Errorf("column %s: %w", col, err)
+			}
+
+			continue
+		}
+
+		b, err := json.Marshal(val)
+		if err != nil {
+			return fmt.Errorf("column %s: %w", col, err)
+		}
+
+		if err := json.Unmarshal(b, fv.Addr().Interface()); err != nil {
+			return fmt.Errorf("column %s: %w", col, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,146 @@
+package sormtest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type fixtureAuthor struct {
+	ID    int
+	Name  string
+	Books []fixtureBook `sql:"-,has_many,fk:author_id"`
+}
+
+type fixtureBook struct {
+	ID        int
+	AuthorID  int
+	Title     string
+	Published time.Time
+	Author    *fixtureAuthor `sql:"-,belongs_to"`
+}
+
+func writeFixture(t *testing.T, dir, name, data string) string {
+	p := filepath.Join(dir, name)
+	if err := os.WriteFile(p, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return p
+}
+
+func TestLoadFixtures(t *testing.T) {
+	a := assert.New(t)
+
+	dir := t.TempDir()
+	p := writeFixture(t, dir, "library.json", `{
+		"fixture_books": [{"id": 10, "author_id": 1, "title": "b", "published": "2020-01-02T00:00:00Z"}],
+		"fixture_authors": [{"id": 1, "name": "a"}]
+	}`)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectExec(`^insert into fixture_authors \(id, name\) values \(\$1, \$2\)$`).WithArgs(1, "a").WillReturnResult(sqlmock.NewResult(1, 1))
+	mockDB.ExpectExec(`^insert into fixture_books \(id, author_id, title, published\) values \(\$1, \$2, \$3, \$4\)$`).WithArgs(10, 1, "b", time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)).WillReturnResult(sqlmock.NewResult(10, 1))
+
+	// books come after authors whichever order the models are given in
+	a.NoError(LoadFixtures(context.Background(), db, p, fixtureBook{}, fixtureAuthor{}))
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestLoadFixturesDirectory(t *testing.T) {
+	a := assert.New(t)
+
+	dir := t.TempDir()
+	writeFixture(t, dir, "b.json", `{"fixture_authors": [{"id": 2, "name": "b"}]}`)
+	writeFixture(t, dir, "a.json", `{"fixture_authors": [{"id": 1, "name": "a"}]}`)
+	writeFixture(t, dir, "README", `not a fixture`)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectExec(`^delete from fixture_authors$`).WillReturnResult(sqlmock.NewResult(0, 3))
+	mockDB.ExpectExec(`^insert into fixture_authors \(id, name\) values \(\$1, \$2\)$`).WithArgs(1, "a").WillReturnResult(sqlmock.NewResult(1, 1))
+	mockDB.ExpectExec(`^insert into fixture_authors \(id, name\) values \(\$1, \$2\)$`).WithArgs(2, "b").WillReturnResult(sqlmock.NewResult(2, 1))
+
+	Seed(t, db, dir, fixtureAuthor{})
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestLoadFixturesErrors(t *testing.T) {
+	a := assert.New(t)
+	ctx := context.Background()
+
+	dir := t.TempDir()
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	err = LoadFixtures(ctx, db, writeFixture(t, dir, "table.json", `{"missing": [{"id": 1}]}`), fixtureAuthor{})
+	a.ErrorContains(err, "table missing")
+
+	err = LoadFixtures(ctx, db, writeFixture(t, dir, "column.json", `{"fixture_authors": [{"id": 1, "age": 3}]}`), fixtureAuthor{})
+	a.ErrorContains(err, "no column age")
+
+	err = LoadFixtures(ctx, db, writeFixture(t, dir, "fixtures.txt", `{}`), fixtureAuthor{})
+	a.ErrorContains(err, "no fixture format")
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestLoadFixturesRegisteredFormat(t *testing.T) {
+	a := assert.New(t)
+
+	RegisterFixtureFormat(".test", func(data []byte, v interface{}) error {
+		*(v.(*map[string][]map[string]interface{})) = map[string][]map[string]interface{}{
+			"fixture_authors": {{"id": 1, "name": string(data)}},
+		}
+		return nil
+	})
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectExec(`^insert into fixture_authors \(id, name\) values \(\$1, \$2\)$`).WithArgs(1, "a").WillReturnResult(sqlmock.NewResult(1, 1))
+
+	a.NoError(LoadFixtures(context.Background(), db, writeFixture(t, t.TempDir(), "authors.test", "a"), fixtureAuthor{}))
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestTruncate(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectExec(`^delete from fixture_books$`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectExec(`^delete from fixture_authors$`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	a.NoError(Truncate(context.Background(), db, fixtureAuthor{}, fixtureBook{}))
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
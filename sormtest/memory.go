@@ -0,0 +1,436 @@
+package sormtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"fknsrs.biz/p/sorm"
+)
+
+// MemoryRepo is a sorm.Repository that keeps copies of its records in memory,
+// for testing code without a database. It follows sorm's rules for IDs and
+// errors: a zero int ID named ID is filled in by Create, missing records are
+// sorm.ErrRecordNotFound, fields tagged validate:"unique" give a *sorm.ValidationError
+// and duplicate IDs or sql:",unique" columns a *sorm.ConstraintError.
+//
+// It can't run SQL, so where clauses are limited to "col = $1" conditions
+// joined with "and". Set Where to handle anything else.
+type MemoryRepo[T any] struct {
+	// Where turns a where clause and its arguments into a filter. If it's
+	// nil, or returns a nil filter, the built-in matching is used.
+	Where func(where string, args []interface{}) (func(v *T) bool, error)
+
+	m       sync.Mutex
+	records []T
+	nextID  int64
+}
+
+var _ sorm.Repository[struct{}] = (*MemoryRepo[struct{}])(nil)
+
+// NewMemoryRepo returns a MemoryRepo holding copies of records.
+func NewMemoryRepo[T any](records ...T) *MemoryRepo[T] {
+	r := &MemoryRepo[T]{}
+	for _, v := range records {
+		r.records = append(r.records, v)
+
+		if id, ok := r.intID(reflect.ValueOf(&v).Elem()); ok && id.Int() > r.nextID {
+			r.nextID = id.Int()
+		}
+	}
+
+	return r
+}
+
+// Records returns copies of everything in the repo, in the order it was
+// created.
+func (r *MemoryRepo[T]) Records() []T {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	return append([]T(nil), r.records...)
+}
+
+func (r *MemoryRepo[T]) describe() sorm.ModelInfo {
+	info, err := sorm.DescribeModel(new(T))
+	if err != nil {
+		panic(err)
+	}
+
+	return info
+}
+
+func (r *MemoryRepo[T]) intID(v reflect.Value) (reflect.Value, bool) {
+	info := r.describe()
+	if len(info.IDColumns) != 1 {
+		return reflect.Value{}, false
+	}
+
+	for _, c := range info.Columns {
+		if c.ID && c.Field == "ID" {
+			f := v.FieldByIndex(c.Index)
+			if f.Kind() >= reflect.Int && f.Kind() <= reflect.Int64 {
+				return f, true
+			}
+		}
+	}
+
+	return reflect.Value{}, false
+}
+
+func (r *MemoryRepo[T]) sameID(info sorm.ModelInfo, a, b reflect.Value) bool {
+	for _, c := range info.Columns {
+		if c.ID && !reflect.DeepEqual(a.FieldByIndex(c.Index).Interface(), b.FieldByIndex(c.Index).Interface()) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (r *MemoryRepo[T]) indexOf(info sorm.ModelInfo, v reflect.Value) int {
+	for i := range r.records {
+		if r.sameID(info, reflect.ValueOf(&r.records[i]).Elem(), v) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// validateUnique reports whether tag has a validate:"unique" check, and the
+// column named by its within parameter, if any.
+func validateUnique(tag reflect.StructTag) (bool, string) {
+	l := strings.Split(tag.Get("validate"), ",")
+	if l[0] != "unique" {
+		return false, ""
+	}
+
+	for _, p := range l[1:] {
+		if s, ok := strings.CutPrefix(p, "within:"); ok {
+			return true, s
+		}
+	}
+
+	return true, ""
+}
+
+func (r *MemoryRepo[T]) checkUnique(info sorm.ModelInfo, v reflect.Value, self int) error {
+	for _, c := range info.Columns {
+		if !c.Unique || c.ID {
+			continue
+		}
+
+		validated, within := validateUnique(c.Tag)
+
+		var scope *sorm.ColumnInfo
+		if s := within; s != "" {
+			for i := range info.Columns {
+				if info.Columns[i].Column == s {
+					scope = &info.Columns[i]
+				}
+			}
+		}
+
+		for i := range r.records {
+			if i == self {
+				continue
+			}
+
+			o := reflect.ValueOf(&r.records[i]).Elem()
+			if !reflect.DeepEqual(o.FieldByIndex(c.Index).Interface(), v.FieldByIndex(c.Index).Interface()) {
+				continue
+			}
+			if scope != nil && !reflect.DeepEqual(o.FieldByIndex(scope.Index).Interface(), v.FieldByIndex(scope.Index).Interface()) {
+				continue
+			}
+
+			if !validated {
+				return duplicateKey([]string{c.Column})
+			}
+
+			msg := "must be unique"
+			if scope != nil {
+				msg += " within " + scope.Column
+			}
+
+			return &sorm.ValidationError{Field: c.Field, Column: c.Column, Message: msg}
+		}
+	}
+
+	return nil
+}
+
+// duplicateKey is the error a database would give for a unique index.
+func duplicateKey(columns []string) error {
+	return &sorm.ConstraintError{Kind: sorm.ErrDuplicateKey, Columns: columns, Err: errors.New("an existing record has the same value")}
+}
+
+var (
+	memoryAnd       = regexp.MustCompile(`(?i)\s+and\s+`)
+	memoryCondition = regexp.MustCompile(`^([a-zA-Z_][a-zA-Z0-9_.]*)\s*=\s*(\?|[$:@a-z]*\d+)$`)
+	memoryParameter = regexp.MustCompile(`\d+$`)
+)
+
+func (r *MemoryRepo[T]) filter(info sorm.ModelInfo, where string, args []interface{}) (func(v *T) bool, error) {
+	if r.Where != nil {
+		fn, err := r.Where(where, args)
+		if err != nil || fn != nil {
+			return fn, err
+		}
+	}
+
+	s := strings.TrimSpace(where)
+	if s == "" {
+		return func(v *T) bool { return true }, nil
+	}
+
+	if !strings.HasPrefix(strings.ToLower(s), "where ") {
+		return nil, fmt.Errorf("can't evaluate %q; set Where to handle it", where)
+	}
+
+	type condition struct {
+		index []int
+		arg   interface{}
+	}
+
+	var conds []condition
+	next := 0
+	for _, part := range memoryAnd.Split(strings.TrimSpace(s[len("where "):]), -1) {
+		m := memoryCondition.FindStringSubmatch(part)
+		if m == nil {
+			return nil, fmt.Errorf("can't evaluate %q; set Where to handle it", where)
+		}
+
+		col := m[1][strings.LastIndexByte(m[1], '.')+1:]
+
+		var c *sorm.ColumnInfo
+		for i := range info.Columns {
+			if info.Columns[i].Column == col {
+				c = &info.Columns[i]
+			}
+		}
+		if c == nil {
+			return nil, fmt.Errorf("%s has no column %s", info.Name, col)
+		}
+
+		n := next
+		if m[2] != "?" {
+			n, _ = strconv.Atoi(memoryParameter.FindString(m[2]))
+			n--
+		}
+		next++
+
+		if n < 0 || n >= len(args) {
+			return nil, fmt.Errorf("%q refers to argument %d of %d", where, n+1, len(args))
+		}
+
+		conds = append(conds, condition{index: c.Index, arg: args[n]})
+	}
+
+	return func(v *T) bool {
+		rv := reflect.ValueOf(v).Elem()
+		for _, c := range conds {
+			if !equalValue(rv.FieldByIndex(c.index), c.arg) {
+				return false
+			}
+		}
+
+		return true
+	}, nil
+}
+
+// equalValue compares a field to an argument the way a database would, so
+// that e.g. an int64 argument matches an int field.
+func equalValue(fv reflect.Value, arg interface{}) bool {
+	for fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			return arg == nil
+		}
+		fv = fv.Elem()
+	}
+
+	if arg == nil {
+		return false
+	}
+
+	av := reflect.ValueOf(arg)
+	for av.Kind() == reflect.Ptr && !av.IsNil() {
+		av = av.Elem()
+	}
+
+	switch {
+	case av.Type() == fv.Type():
+		return reflect.DeepEqual(av.Interface(), fv.Interface())
+	case av.CanInt() && fv.CanInt():
+		return av.Int() == fv.Int()
+	case av.CanUint() && fv.CanUint():
+		return av.Uint() == fv.Uint()
+	case av.CanInt() && fv.CanUint():
+		return av.Int() >= 0 && uint64(av.Int()) == fv.Uint()
+	case av.CanUint() && fv.CanInt():
+		return fv.Int() >= 0 && av.Uint() == uint64(fv.Int())
+	case av.CanFloat() && fv.CanFloat():
+		return av.Float() == fv.Float()
+	case av.Kind() == reflect.String && fv.Kind() == reflect.String:
+		return av.String() == fv.String()
+	}
+
+	return false
+}
+
+func (r *MemoryRepo[T]) Find(ctx context.Context, where string, args ...interface{}) ([]T, error) {
+	l, err := r.find(where, args)
+	if err != nil {
+		return nil, fmt.Errorf("MemoryRepo.Find: %w", err)
+	}
+
+	return l, nil
+}
+
+func (r *MemoryRepo[T]) find(where string, args []interface{}) ([]T, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	fn, err := r.filter(r.describe(), where, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var l []T
+	for i := range r.records {
+		if fn(&r.records[i]) {
+			l = append(l, r.records[i])
+		}
+	}
+
+	return l, nil
+}
+
+func (r *MemoryRepo[T]) FindByID(ctx context.Context, id ...interface{}) (*T, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	info := r.describe()
+
+	var ids []sorm.ColumnInfo
+	for _, c := range info.Columns {
+		if c.ID {
+			ids = append(ids, c)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("MemoryRepo.FindByID: %w", sorm.ErrNoIDFields)
+	}
+	if len(ids) != len(id) {
+		return nil, fmt.Errorf("MemoryRepo.FindByID: %s has %d ID field(s); got %d value(s)", info.Name, len(ids), len(id))
+	}
+
+outer:
+	for i := range r.records {
+		rv := reflect.ValueOf(&r.records[i]).Elem()
+		for j, c := range ids {
+			if !equalValue(rv.FieldByIndex(c.Index), id[j]) {
+				continue outer
+			}
+		}
+
+		v := r.records[i]
+
+		return &v, nil
+	}
+
+	return nil, fmt.Errorf("MemoryRepo.FindByID: %w", sorm.ErrRecordNotFound)
+}
+
+func (r *MemoryRepo[T]) Create(ctx context.Context, v *T) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	info := r.describe()
+	if info.ReadOnly {
+		return fmt.Errorf("MemoryRepo.Create: %w", sorm.ErrReadOnly)
+	}
+
+	rv := reflect.ValueOf(v).Elem()
+
+	id, intID := r.intID(rv)
+	generate := intID && id.Int() == 0
+
+	if !generate && r.indexOf(info, rv) != -1 {
+		return fmt.Errorf("MemoryRepo.Create: %w", duplicateKey(info.IDColumns))
+	}
+
+	if err := r.checkUnique(info, rv, -1); err != nil {
+		return fmt.Errorf("MemoryRepo.Create: %w", err)
+	}
+
+	if generate {
+		r.nextID++
+		id.SetInt(r.nextID)
+	} else if intID && id.Int() > r.nextID {
+		r.nextID = id.Int()
+	}
+
+	r.records = append(r.records, *v)
+
+	return nil
+}
+
+func (r *MemoryRepo[T]) Save(ctx context.Context, v *T) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	info := r.describe()
+	if info.ReadOnly {
+		return fmt.Errorf("MemoryRepo.Save: %w", sorm.ErrReadOnly)
+	}
+
+	rv := reflect.ValueOf(v).Elem()
+
+	i := r.indexOf(info, rv)
+	if i == -1 {
+		return fmt.Errorf("MemoryRepo.Save: %w", sorm.ErrRecordNotFound)
+	}
+
+	if err := r.checkUnique(info, rv, i); err != nil {
+		return fmt.Errorf("MemoryRepo.Save: %w", err)
+	}
+
+	r.records[i] = *v
+
+	return nil
+}
+
+func (r *MemoryRepo[T]) Delete(ctx context.Context, v *T) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	info := r.describe()
+	if info.ReadOnly {
+		return fmt.Errorf("MemoryRepo.Delete: %w", sorm.ErrReadOnly)
+	}
+
+	i := r.indexOf(info, reflect.ValueOf(v).Elem())
+	if i == -1 {
+		return fmt.Errorf("MemoryRepo.Delete: %w", sorm.ErrRecordNotFound)
+	}
+
+	r.records = append(r.records[:i], r.records[i+1:]...)
+
+	return nil
+}
+
+func (r *MemoryRepo[T]) Count(ctx context.Context, where string, args ...interface{}) (int, error) {
+	l, err := r.find(where, args)
+	if err != nil {
+		return 0, fmt.Errorf("MemoryRepo.Count: %w", err)
+	}
+
+	return len(l), nil
+}
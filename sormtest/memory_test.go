@@ -0,0 +1,105 @@
+package sormtest
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"fknsrs.biz/p/sorm"
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryUser struct {
+	ID    int
+	OrgID int
+	Email string `validate:"unique"`
+	Name  string
+}
+
+type memoryMember struct {
+	OrgID  int    `sql:",id"`
+	UserID int    `sql:",id"`
+	Slug   string `sql:",unique"`
+}
+
+func TestMemoryRepo(t *testing.T) {
+	a := assert.New(t)
+	ctx := context.Background()
+
+	var r sorm.Repository[memoryUser] = NewMemoryRepo(memoryUser{ID: 5, OrgID: 1, Email: "a@example.com", Name: "a"})
+
+	u := memoryUser{OrgID: 1, Email: "b@example.com", Name: "b"}
+	a.NoError(r.Create(ctx, &u))
+	a.Equal(6, u.ID)
+
+	var verr *sorm.ValidationError
+	a.ErrorAs(r.Create(ctx, &memoryUser{Email: "a@example.com"}), &verr)
+	a.ErrorIs(r.Create(ctx, &memoryUser{ID: 5}), sorm.ErrDuplicateKey)
+
+	l, err := r.Find(ctx, "where org_id = $1 and name = $2", int64(1), "b")
+	a.NoError(err)
+	a.Equal([]memoryUser{u}, l)
+
+	n, err := r.Count(ctx, "where org_id = ?", 1)
+	a.NoError(err)
+	a.Equal(2, n)
+
+	_, err = r.Find(ctx, "where name like $1", "a%")
+	a.EqualError(err, `MemoryRepo.Find: can't evaluate "where name like $1"; set Where to handle it`)
+
+	u.Name = "c"
+	a.NoError(r.Save(ctx, &u))
+
+	found, err := r.FindByID(ctx, 6)
+	if a.NoError(err) {
+		a.Equal("c", found.Name)
+	}
+
+	a.NoError(r.Delete(ctx, &u))
+	a.ErrorIs(r.Delete(ctx, &u), sorm.ErrRecordNotFound)
+	a.ErrorIs(r.Save(ctx, &u), sorm.ErrRecordNotFound)
+
+	_, err = r.FindByID(ctx, 6)
+	a.ErrorIs(err, sorm.ErrRecordNotFound)
+}
+
+func TestMemoryRepoCompositeID(t *testing.T) {
+	a := assert.New(t)
+	ctx := context.Background()
+
+	r := NewMemoryRepo[memoryMember]()
+
+	a.NoError(r.Create(ctx, &memoryMember{OrgID: 1, UserID: 2, Slug: "a"}))
+	a.ErrorIs(r.Create(ctx, &memoryMember{OrgID: 1, UserID: 3, Slug: "a"}), sorm.ErrDuplicateKey)
+
+	m, err := r.FindByID(ctx, 1, 2)
+	if a.NoError(err) {
+		a.Equal("a", m.Slug)
+	}
+
+	_, err = r.FindByID(ctx, 1)
+	a.EqualError(err, "MemoryRepo.FindByID: memoryMember has 2 ID field(s); got 1 value(s)")
+
+	a.Len(r.Records(), 1)
+}
+
+func TestMemoryRepoWhere(t *testing.T) {
+	a := assert.New(t)
+
+	r := NewMemoryRepo(memoryUser{ID: 1, Name: "alice"}, memoryUser{ID: 2, Name: "bob"})
+	r.Where = func(where string, args []interface{}) (func(v *memoryUser) bool, error) {
+		if where != "where name like $1" {
+			return nil, nil
+		}
+
+		return func(v *memoryUser) bool { return strings.HasPrefix(v.Name, strings.TrimSuffix(args[0].(string), "%")) }, nil
+	}
+
+	l, err := r.Find(context.Background(), "where name like $1", "al%")
+	a.NoError(err)
+	a.Equal([]memoryUser{{ID: 1, Name: "alice"}}, l)
+
+	l, err = r.Find(context.Background(), "where id = $1", 2)
+	a.NoError(err)
+	a.Equal([]memoryUser{{ID: 2, Name: "bob"}}, l)
+}
@@ -0,0 +1,286 @@
+package sorm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SerializedWriter is a Querier for SQLite that runs one write at a time and
+// retries writes that fail with SQLITE_BUSY or SQLITE_LOCKED. Reads aren't
+// serialized; with WAL enabled they don't block writers.
+type SerializedWriter struct {
+	DB         *sql.DB
+	MaxRetries int
+	Backoff    time.Duration
+
+	m sync.Mutex
+}
+
+var _ Querier = (*SerializedWriter)(nil)
+
+func NewSerializedWriter(db *sql.DB) *SerializedWriter {
+	return &SerializedWriter{DB: db, MaxRetries: 5, Backoff: 10 * time.Millisecond}
+}
+
+// IsBusyError reports whether err is SQLITE_BUSY or SQLITE_LOCKED from either
+// mattn/go-sqlite3 or modernc.org/sqlite.
+func IsBusyError(err error) bool {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if c, ok := err.(interface{ Code() int }); ok && isBusyCode(int64(c.Code())) {
+			return true
+		}
+
+		v := reflect.ValueOf(err)
+		if v.Kind() == reflect.Ptr && !v.IsNil() {
+			v = v.Elem()
+		}
+
+		if v.Kind() == reflect.Struct {
+			if f := v.FieldByName("Code"); f.IsValid() && f.Kind() >= reflect.Int && f.Kind() <= reflect.Int64 && isBusyCode(f.Int()) {
+				return true
+			}
+		}
+
+		if s := err.Error(); strings.Contains(s, "SQLITE_BUSY") || strings.Contains(s, "database is locked") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isBusyCode(c int64) bool {
+	// the primary result code is the low byte of an extended one
+	switch c & 0xff {
+	case 5, 6:
+		return true
+	}
+
+	return false
+}
+
+func (w *SerializedWriter) retry(ctx context.Context, fn func() error) error {
+	backoff := w.Backoff
+
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= w.MaxRetries || !IsBusyError(err) {
+			return err
+		}
+
+		if backoff > 0 {
+			t := time.NewTimer(backoff)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return ctx.Err()
+			case <-t.C:
+			}
+
+			backoff *= 2
+		}
+	}
+}
+
+func (w *SerializedWriter) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	var res sql.Result
+	err := w.retry(ctx, func() error {
+		var err error
+		res, err = w.DB.ExecContext(ctx, query, args...)
+		return err
+	})
+
+	return res, err
+}
+
+// QueryContext runs reads directly. Writes, like inserts with a returning
+// clause, are run and read to the end while holding the lock, since SQLite
+// only steps the statement as rows are read; the rows returned are a copy.
+func (w *SerializedWriter) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if isReadQuery(query) {
+		return w.DB.QueryContext(ctx, query, args...)
+	}
+
+	return getBufferedDB().QueryContext(ctx, "", w.bufferQuery(ctx, query, args))
+}
+
+func (w *SerializedWriter) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	if isReadQuery(query) {
+		return w.DB.QueryRowContext(ctx, query, args...)
+	}
+
+	return getBufferedDB().QueryRowContext(ctx, "", w.bufferQuery(ctx, query, args))
+}
+
+// bufferQuery runs query under the lock, reading every row, and returns the
+// key of the result for bufferedDB to replay.
+func (w *SerializedWriter) bufferQuery(ctx context.Context, query string, args []interface{}) int64 {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	var r *bufferedResult
+	err := w.retry(ctx, func() error {
+		var err error
+		r, err = readBufferedResult(ctx, w.DB, query, args)
+		return err
+	})
+	if err != nil {
+		r = &bufferedResult{err: err}
+	}
+
+	key := atomic.AddInt64(&bufferedKey, 1)
+	bufferedResults.Store(key, r)
+
+	return key
+}
+
+func readBufferedResult(ctx context.Context, db *sql.DB, query string, args []interface{}) (*bufferedResult, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	r := bufferedResult{columns: columns}
+
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		ptrs := make([]interface{}, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+
+		row := make([]driver.Value, len(values))
+		for i, v := range values {
+			row[i] = v
+		}
+
+		r.rows = append(r.rows, row)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// bufferedDB replays results read by bufferQuery, so that SerializedWriter can
+// hand out *sql.Rows after releasing its lock.
+var (
+	bufferedDB      *sql.DB
+	bufferedDBOnce  sync.Once
+	bufferedKey     int64
+	bufferedResults sync.Map
+)
+
+func getBufferedDB() *sql.DB {
+	bufferedDBOnce.Do(func() {
+		bufferedDB = sql.OpenDB(bufferedConnector{})
+	})
+
+	return bufferedDB
+}
+
+type bufferedResult struct {
+	columns []string
+	rows    [][]driver.Value
+	err     error
+}
+
+type bufferedConnector struct{}
+
+func (bufferedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return bufferedConn{}, nil
+}
+func (bufferedConnector) Driver() driver.Driver { return bufferedDriver{} }
+
+type bufferedDriver struct{}
+
+func (bufferedDriver) Open(name string) (driver.Conn, error) { return bufferedConn{}, nil }
+
+type bufferedConn struct{}
+
+func (bufferedConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("buffered results can't be prepared")
+}
+
+func (bufferedConn) Close() error { return nil }
+
+func (bufferedConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("buffered results can't be used in a transaction")
+}
+
+func (bufferedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if len(args) != 1 {
+		return nil, errors.New("expected a buffered result key")
+	}
+
+	v, ok := bufferedResults.LoadAndDelete(args[0].Value)
+	if !ok {
+		return nil, errors.New("buffered result is missing")
+	}
+
+	r := v.(*bufferedResult)
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	return &bufferedRows{result: r}, nil
+}
+
+type bufferedRows struct {
+	result *bufferedResult
+	next   int
+}
+
+func (r *bufferedRows) Columns() []string { return r.result.columns }
+func (r *bufferedRows) Close() error      { return nil }
+
+func (r *bufferedRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.result.rows) {
+		return io.EOF
+	}
+
+	copy(dest, r.result.rows[r.next])
+	r.next++
+
+	return nil
+}
+
+// WithTransaction runs fn in a transaction while holding the write lock,
+// retrying the whole transaction if SQLite reports it's busy.
+func (w *SerializedWriter) WithTransaction(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	return WithTransaction(ctx, w.DB, &TransactionOptions{
+		MaxRetries: w.MaxRetries,
+		Backoff:    w.Backoff,
+		Retryable:  IsBusyError,
+	}, fn)
+}
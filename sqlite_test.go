@@ -0,0 +1,107 @@
+package sorm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type mattnSQLiteError struct {
+	Code         int
+	ExtendedCode int
+}
+
+func (e mattnSQLiteError) Error() string { return fmt.Sprintf("sqlite error %d", e.Code) }
+
+type modernSQLiteError struct{ code int }
+
+func (e *modernSQLiteError) Error() string { return "sqlite error" }
+func (e *modernSQLiteError) Code() int     { return e.code }
+
+func TestIsBusyError(t *testing.T) {
+	a := assert.New(t)
+
+	a.True(IsBusyError(mattnSQLiteError{Code: 5}))
+	a.True(IsBusyError(mattnSQLiteError{Code: 6}))
+	a.True(IsBusyError(&modernSQLiteError{code: 517}))
+	a.True(IsBusyError(fmt.Errorf("wrapped: %w", errors.New("database is locked"))))
+	a.False(IsBusyError(mattnSQLiteError{Code: 19}))
+	a.False(IsBusyError(errors.New("nope")))
+	a.False(IsBusyError(nil))
+}
+
+func TestSerializedWriterRetry(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectExec(`insert into simple_objects`).WillReturnError(mattnSQLiteError{Code: 5})
+	mockDB.ExpectExec(`insert into simple_objects`).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	w := NewSerializedWriter(db)
+	w.Backoff = 0
+
+	a.NoError(CreateRecord(context.Background(), w, &SimpleObject{ID: 1, Name: "test1"}))
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestSerializedWriterTransaction(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectBegin()
+	mockDB.ExpectExec(`insert into simple_objects`).WillReturnError(mattnSQLiteError{Code: 5})
+	mockDB.ExpectRollback()
+	mockDB.ExpectBegin()
+	mockDB.ExpectExec(`insert into simple_objects`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mockDB.ExpectCommit()
+
+	w := NewSerializedWriter(db)
+	w.Backoff = 0
+
+	a.NoError(w.WithTransaction(context.Background(), func(tx *sql.Tx) error {
+		return CreateRecord(context.Background(), tx, &SimpleObject{ID: 1, Name: "test1"})
+	}))
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestSerializedWriterReturning(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	// SQLite reports busy when the row is read, not when the query is sent
+	mockDB.ExpectQuery(`^insert into simple_objects \(name\) values \(\$1\) returning id$`).WithArgs("test1").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).RowError(0, mattnSQLiteError{Code: 5}))
+	mockDB.ExpectQuery(`^insert into simple_objects \(name\) values \(\$1\) returning id$`).WithArgs("test1").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	mockDB.ExpectQuery(`^insert into simple_objects \(name\) values \(\$1\) returning id$`).WithArgs("test2").WillReturnError(mattnSQLiteError{Code: 19})
+
+	w := NewSerializedWriter(db)
+	w.Backoff = 0
+
+	r := SimpleObject{Name: "test1"}
+	a.NoError(CreateRecord(context.Background(), w, &r))
+	a.Equal(2, r.ID)
+
+	var serr mattnSQLiteError
+	a.ErrorAs(CreateRecord(context.Background(), w, &SimpleObject{Name: "test2"}), &serr)
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
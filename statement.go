@@ -0,0 +1,538 @@
+package sorm
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+type Statement struct {
+	Query string
+	Args  []interface{}
+}
+
+func (s Statement) Validate() error {
+	prefix := parameterPrefix
+	if prefix == "" {
+		prefix = "$"
+	}
+
+	var depth int
+	var quote byte
+	used := make(map[int]bool)
+
+	for i := 0; i < len(s.Query); i++ {
+		c := s.Query[i]
+
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+			continue
+		}
+
+		switch c {
+		case '\'', '"', '`':
+			quote = c
+			continue
+		case '(':
+			depth++
+			continue
+		case ')':
+			depth--
+			if depth < 0 {
+				return fmt.Errorf("unbalanced parentheses at offset %d", i)
+			}
+			continue
+		}
+
+		if strings.HasPrefix(s.Query[i:], prefix) {
+			j := i + len(prefix)
+			for j < len(s.Query) && s.Query[j] >= '0' && s.Query[j] <= '9' {
+				j++
+			}
+
+			if j > i+len(prefix) {
+				n, err := strconv.Atoi(s.Query[i+len(prefix) : j])
+				if err != nil {
+					return fmt.Errorf("invalid parameter at offset %d: %w", i, err)
+				}
+				used[n] = true
+				i = j - 1
+			}
+		}
+	}
+
+	if quote != 0 {
+		return fmt.Errorf("unterminated quoted string or identifier")
+	}
+
+	if depth != 0 {
+		return fmt.Errorf("unbalanced parentheses")
+	}
+
+	for n := range used {
+		if n < 1 || n > len(s.Args) {
+			return fmt.Errorf("parameter %s%d doesn't match any of the %d argument(s)", prefix, n, len(s.Args))
+		}
+	}
+
+	if len(used) != len(s.Args) {
+		return fmt.Errorf("query references %d parameter(s) but has %d argument(s)", len(used), len(s.Args))
+	}
+
+	return nil
+}
+
+func checkIdentifier(s string) error {
+	if s == "" {
+		return fmt.Errorf("invalid identifier: empty")
+	}
+
+	for i, part := range splitIdentifier(s) {
+		if isQuotedIdentifier(part) {
+			if strings.IndexByte(part[1:len(part)-1], part[0]) != -1 {
+				return fmt.Errorf("invalid identifier %q", s)
+			}
+
+			continue
+		}
+
+		if part == "" {
+			return fmt.Errorf("invalid identifier %q", s)
+		}
+
+		for j := 0; j < len(part); j++ {
+			c := part[j]
+			if c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || ((i > 0 || j > 0) && c >= '0' && c <= '9') {
+				continue
+			}
+
+			return fmt.Errorf("invalid identifier %q", s)
+		}
+	}
+
+	return nil
+}
+
+func getSQLWritableFields(vdesc *structDescription) []structField {
+	var r []structField
+
+	for _, f := range sqlFields(vdesc) {
+		if t := f.Tag("sql"); t != nil && t.Parameter("prefix") != nil {
+			continue
+		}
+
+		if isSQLRelation(f) {
+			continue
+		}
+
+		r = append(r, f)
+	}
+
+	return r
+}
+
+var (
+	explicitColumns bool
+)
+
+// SetExplicitColumns makes finds name the model's columns, in field order,
+// instead of using "select *". Columns added to the table later are then
+// ignored rather than breaking ScanRows.
+func SetExplicitColumns(b bool) {
+	explicitColumns = b
+}
+
+// selectColumns names the columns to select. When some fields come from
+// joined tables, the rest are qualified with tbl, the model's own table as
+// the query names it, so they can't be ambiguous.
+func selectColumns(vdesc *structDescription, tbl string) (string, error) {
+	joined := hasSQLFrom(vdesc)
+
+	if !explicitColumns && !joined && getSQLProjection(vdesc) == "" {
+		return "*", nil
+	}
+
+	var qualifier string
+	if joined {
+		qualifier = tableAlias(tbl) + "."
+	}
+
+	var cols []string
+	for _, f := range getSQLWritableFields(vdesc) {
+		col := getSQLColumnName(f)
+		if err := checkIdentifier(col); err != nil {
+			return "", err
+		}
+
+		if from := getSQLFrom(f); from != "" {
+			cols = append(cols, from+" as "+col)
+			continue
+		}
+
+		cols = append(cols, qualifier+col)
+	}
+
+	return strings.Join(cols, ", "), nil
+}
+
+func buildSelect(vdesc *structDescription, tbl string, columns, where string, args []interface{}, o findOptions) (Statement, error) {
+	if err := checkIdentifier(tbl); err != nil {
+		return Statement{}, err
+	}
+
+	where, args, err := expandArgs(where, args)
+	if err != nil {
+		return Statement{}, err
+	}
+
+	if o.scope != "" {
+		// the scope's numbered placeholders can't be mixed with bare ones
+		where, args, err = renumberArgs(where, args, 0)
+		if err != nil {
+			return Statement{}, err
+		}
+	}
+
+	from := tbl
+	if o.lock != 0 {
+		from += o.lock.tableHints()
+	}
+
+	var filters []string
+
+	if f := getSQLTTLField(vdesc); f != nil && !o.includeExpired {
+		col := getSQLColumnName(*f)
+		if err := checkIdentifier(col); err != nil {
+			return Statement{}, err
+		}
+
+		args = append(append([]interface{}(nil), args...), timeNow())
+		p := makeParameter(len(args))
+
+		filters = append(filters, col+" is null or "+col+" > "+p)
+	}
+
+	if o.scope != "" {
+		scope, scopeArgs, err := renumberArgs(o.scope, o.scopeArgs, len(args))
+		if err != nil {
+			return Statement{}, err
+		}
+
+		args = append(append([]interface{}(nil), args...), scopeArgs...)
+		filters = append(filters, scope)
+	}
+
+	if len(filters) > 1 {
+		for i := range filters {
+			filters[i] = "(" + filters[i] + ")"
+		}
+	}
+
+	if len(filters) > 0 {
+		from = "(select * from " + from + " where " + strings.Join(filters, " and ") + ") " + tableAlias(tbl)
+	}
+
+	if where != "" {
+		where = " " + where
+	}
+
+	if o.lock != 0 {
+		where += o.lock.suffix()
+	}
+
+	return Statement{Query: "select " + columns + " from " + from + where, Args: args}, nil
+}
+
+func tableAlias(tbl string) string {
+	parts := splitIdentifier(tbl)
+
+	return parts[len(parts)-1]
+}
+
+func buildIDWhere(idFields []structField, v reflect.Value) (string, []interface{}, error) {
+	var where string
+	var values []interface{}
+
+	for _, f := range idFields {
+		col := getSQLColumnName(f)
+		if err := checkIdentifier(col); err != nil {
+			return "", nil, err
+		}
+
+		if where == "" {
+			where += "where "
+		} else {
+			where += " and "
+		}
+
+		where += columnComparison(f, col) + " = " + makeParameter(len(values)+1)
+		values = append(values, fieldValue(f, v))
+	}
+
+	return where, values, nil
+}
+
+func buildInsert(vdesc *structDescription, tbl string, idFields []structField, v reflect.Value) (Statement, bool, error) {
+	if err := checkWritable(vdesc); err != nil {
+		return Statement{}, false, err
+	}
+
+	var a1, a2 []string
+	var values []interface{}
+	var basicID, fetchID bool
+
+	if len(idFields) == 1 && idFields[0].Name() == "ID" {
+		basicID = true
+	}
+
+	for _, f := range getSQLWritableFields(vdesc) {
+		if basicID && f.Name() == "ID" && isZero(v.FieldByIndex(f.Index()).Interface()) {
+			fetchID = true
+			continue
+		}
+
+		// leave the column out so that the database's default applies
+		if getSQLDefaultExpression(f) != "" && isZero(v.FieldByIndex(f.Index()).Interface()) {
+			continue
+		}
+
+		col := getSQLColumnName(f)
+		if err := checkIdentifier(col); err != nil {
+			return Statement{}, false, err
+		}
+
+		a1 = append(a1, col)
+		a2 = append(a2, makeParameter(len(a1)))
+
+		values = append(values, fieldValue(f, v))
+	}
+
+	if err := checkIdentifier(tbl); err != nil {
+		return Statement{}, false, err
+	}
+
+	var insertSuffix string
+	if basicID && fetchID {
+		insertSuffix = " returning id"
+	}
+
+	query := fmt.Sprintf("insert into %s (%s) values (%s)%s", tbl, strings.Join(a1, ", "), strings.Join(a2, ", "), insertSuffix)
+
+	return Statement{Query: query, Args: values}, basicID && fetchID, nil
+}
+
+// buildReplace builds ReplaceRecord's statement. With a tenant column, an
+// existing row is only overwritten if it has the incoming row's tenant; a row
+// of another tenant is left alone, so nothing is affected.
+func buildReplace(vdesc *structDescription, tbl string, idFields []structField, v reflect.Value, o *ReplaceOptions, tenant string) (Statement, error) {
+	if err := checkWritable(vdesc); err != nil {
+		return Statement{}, err
+	}
+
+	switch replaceMode {
+	case ReplaceMerge:
+		return buildMerge(vdesc, tbl, idFields, v, o, tenant)
+	case ReplaceOnDuplicateKey:
+		return buildOnDuplicateKey(vdesc, tbl, idFields, v, o, tenant)
+	}
+
+	if tenant != "" {
+		return buildOnConflict(vdesc, tbl, idFields, v, o, tenant)
+	}
+
+	var a1, a2 []string
+	var values []interface{}
+
+	for _, f := range getSQLWritableFields(vdesc) {
+		col := getSQLColumnName(f)
+		if err := checkIdentifier(col); err != nil {
+			return Statement{}, err
+		}
+
+		a1 = append(a1, col)
+		a2 = append(a2, makeParameter(len(a1)))
+
+		values = append(values, fieldValue(f, v))
+	}
+
+	if err := checkIdentifier(tbl); err != nil {
+		return Statement{}, err
+	}
+
+	query := fmt.Sprintf("insert or replace into %s (%s) values (%s)", tbl, strings.Join(a1, ", "), strings.Join(a2, ", "))
+
+	return Statement{Query: query, Args: values}, nil
+}
+
+func buildUpdate(vdesc *structDescription, tbl string, idFields []structField, previous, current reflect.Value) (Statement, error) {
+	if err := checkWritable(vdesc); err != nil {
+		return Statement{}, err
+	}
+
+	where, values, err := buildIDWhere(idFields, current)
+	if err != nil {
+		return Statement{}, err
+	}
+
+	var fields string
+	for _, f := range getSQLWritableFields(vdesc) {
+		if t := f.Tag("sql"); t != nil && t.Parameter("readonly") != nil {
+			continue
+		}
+
+		if t := f.Tag("readonly"); t != nil && t.Value() != "" {
+			continue
+		}
+
+		if reflect.DeepEqual(previous.FieldByIndex(f.Index()).Interface(), current.FieldByIndex(f.Index()).Interface()) {
+			continue
+		}
+
+		col := getSQLColumnName(f)
+		if err := checkIdentifier(col); err != nil {
+			return Statement{}, err
+		}
+
+		if fields == "" {
+			fields += "set "
+		} else {
+			fields += ", "
+		}
+
+		fields += col + " = " + makeParameter(len(values)+1)
+		values = append(values, fieldValue(f, current))
+	}
+
+	if fields == "" {
+		return Statement{}, nil
+	}
+
+	if err := checkIdentifier(tbl); err != nil {
+		return Statement{}, err
+	}
+
+	return Statement{Query: fmt.Sprintf("update %s %s %s", tbl, fields, where), Args: values}, nil
+}
+
+func buildDelete(vdesc *structDescription, tbl string, idFields []structField, v reflect.Value) (Statement, error) {
+	if err := checkWritable(vdesc); err != nil {
+		return Statement{}, err
+	}
+
+	where, values, err := buildIDWhere(idFields, v)
+	if err != nil {
+		return Statement{}, err
+	}
+
+	if err := checkIdentifier(tbl); err != nil {
+		return Statement{}, err
+	}
+
+	return Statement{Query: fmt.Sprintf("delete from %s %s", tbl, where), Args: values}, nil
+}
+
+func statementTarget(name string, input interface{}) (reflect.Value, *structDescription, []structField, error) {
+	ptr := reflect.ValueOf(input)
+	if ptr.Kind() == reflect.Ptr {
+		ptr = ptr.Elem()
+	}
+
+	if ptr.Kind() != reflect.Struct {
+		return reflect.Value{}, nil, nil, fmt.Errorf("%s: expected input to be struct or pointer to struct; was instead %s", name, ptr.Kind())
+	}
+
+	vdesc, err := getDescriptionFromType(ptr.Type())
+	if err != nil {
+		return reflect.Value{}, nil, nil, fmt.Errorf("%s: could not get detailed reflection information for type %s: %w", name, ptr.Type().String(), err)
+	}
+
+	idFields := getSQLIDFields(vdesc)
+	if len(idFields) == 0 {
+		return reflect.Value{}, nil, nil, fmt.Errorf("%s: %w", name, ErrNoIDFields)
+	}
+
+	return ptr, vdesc, idFields, nil
+}
+
+func SelectStatement(model interface{}, where string, args ...interface{}) (Statement, error) {
+	vtyp, err := structTypeOf(model)
+	if err != nil {
+		return Statement{}, fmt.Errorf("SelectStatement: %w", err)
+	}
+
+	vdesc, err := getDescriptionFromType(vtyp)
+	if err != nil {
+		return Statement{}, fmt.Errorf("SelectStatement: could not get detailed reflection information for type %s: %w", vtyp.String(), err)
+	}
+
+	tbl := getSQLTableName(vdesc)
+
+	columns, err := selectColumns(vdesc, tbl)
+	if err != nil {
+		return Statement{}, fmt.Errorf("SelectStatement: %w", err)
+	}
+
+	return buildSelect(vdesc, tbl, columns, where, args, findOptions{})
+}
+
+func CountStatement(model interface{}, where string, args ...interface{}) (Statement, error) {
+	vtyp, err := structTypeOf(model)
+	if err != nil {
+		return Statement{}, fmt.Errorf("CountStatement: %w", err)
+	}
+
+	vdesc, err := getDescriptionFromType(vtyp)
+	if err != nil {
+		return Statement{}, fmt.Errorf("CountStatement: could not get detailed reflection information for type %s: %w", vtyp.String(), err)
+	}
+
+	return buildSelect(vdesc, getSQLTableName(vdesc), "count(*)", where, args, findOptions{})
+}
+
+func InsertStatement(input interface{}) (Statement, error) {
+	v, vdesc, idFields, err := statementTarget("InsertStatement", input)
+	if err != nil {
+		return Statement{}, err
+	}
+
+	s, _, err := buildInsert(vdesc, getSQLTableName(vdesc), idFields, v)
+
+	return s, err
+}
+
+func ReplaceStatement(input interface{}) (Statement, error) {
+	v, vdesc, idFields, err := statementTarget("ReplaceStatement", input)
+	if err != nil {
+		return Statement{}, err
+	}
+
+	return buildReplace(vdesc, getSQLTableName(vdesc), idFields, v, nil, "")
+}
+
+// UpdateStatement returns a statement updating the columns that differ
+// between previous and current. Query is empty if nothing changed.
+func UpdateStatement(previous, current interface{}) (Statement, error) {
+	v, vdesc, idFields, err := statementTarget("UpdateStatement", current)
+	if err != nil {
+		return Statement{}, err
+	}
+
+	p := reflect.Indirect(reflect.ValueOf(previous))
+	if p.Type() != v.Type() {
+		return Statement{}, fmt.Errorf("UpdateStatement: expected previous to be %s; was instead %s", v.Type().String(), p.Type().String())
+	}
+
+	return buildUpdate(vdesc, getSQLTableName(vdesc), idFields, p, v)
+}
+
+func DeleteStatement(input interface{}) (Statement, error) {
+	v, vdesc, idFields, err := statementTarget("DeleteStatement", input)
+	if err != nil {
+		return Statement{}, err
+	}
+
+	return buildDelete(vdesc, getSQLTableName(vdesc), idFields, v)
+}
@@ -0,0 +1,129 @@
+package sorm
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectStatement(t *testing.T) {
+	a := assert.New(t)
+
+	s, err := SelectStatement(Object{}, "where id = $1", 1)
+	a.NoError(err)
+	a.Equal(Statement{Query: "select * from objects where id = $1", Args: []interface{}{1}}, s)
+	a.NoError(s.Validate())
+}
+
+func TestSelectStatementExplicitColumns(t *testing.T) {
+	a := assert.New(t)
+
+	SetExplicitColumns(true)
+	defer SetExplicitColumns(false)
+
+	type Author struct {
+		ID int
+	}
+
+	type ExplicitObject struct {
+		ID      int
+		Name    string `sql:"display_name"`
+		Ignored string `sql:"-"`
+		Author  Author `sql:",prefix:a_"`
+	}
+
+	s, err := SelectStatement(ExplicitObject{}, "where id = $1", 1)
+	a.NoError(err)
+	a.Equal(Statement{Query: "select id, display_name from explicit_objects where id = $1", Args: []interface{}{1}}, s)
+}
+
+func TestInsertStatement(t *testing.T) {
+	a := assert.New(t)
+
+	s, err := InsertStatement(&SimpleObject{Name: "test1"})
+	a.NoError(err)
+	a.Equal(Statement{Query: "insert into simple_objects (name) values ($1) returning id", Args: []interface{}{"test1"}}, s)
+	a.NoError(s.Validate())
+}
+
+func TestUpdateStatement(t *testing.T) {
+	a := assert.New(t)
+
+	s, err := UpdateStatement(SimpleObject{ID: 1, Name: "test1"}, &SimpleObject{ID: 1, Name: "test2"})
+	a.NoError(err)
+	a.Equal(Statement{Query: "update simple_objects set name = $2 where id = $1", Args: []interface{}{1, "test2"}}, s)
+	a.NoError(s.Validate())
+
+	s, err = UpdateStatement(SimpleObject{ID: 1, Name: "test1"}, &SimpleObject{ID: 1, Name: "test1"})
+	a.NoError(err)
+	a.Equal(Statement{}, s)
+}
+
+func TestDeleteStatementCompositeID(t *testing.T) {
+	a := assert.New(t)
+
+	s, err := DeleteStatement(CompositeIDObject{ID1: 1, ID2: 2})
+	a.NoError(err)
+	a.Equal(Statement{Query: "delete from composite_id_objects where id_1 = $1 and id_2 = $2", Args: []interface{}{1, 2}}, s)
+	a.NoError(s.Validate())
+}
+
+func TestStatementInvalidIdentifier(t *testing.T) {
+	type BadObject struct {
+		ID   int
+		Name string `sql:"name) values (1); --"`
+	}
+
+	a := assert.New(t)
+
+	_, err := InsertStatement(&BadObject{ID: 1})
+	a.EqualError(err, `invalid identifier "name) values (1); --"`)
+}
+
+func TestStatementValidate(t *testing.T) {
+	a := assert.New(t)
+
+	a.NoError(Statement{Query: "select * from a where b = '(' and c = $1", Args: []interface{}{1}}.Validate())
+	a.Error(Statement{Query: "select * from a where (b = $1", Args: []interface{}{1}}.Validate())
+	a.Error(Statement{Query: "select * from a where b = $1 and c = $2", Args: []interface{}{1}}.Validate())
+	a.Error(Statement{Query: "select * from a where b = $1", Args: []interface{}{1, 2}}.Validate())
+}
+
+func FuzzStatements(f *testing.F) {
+	f.Add("name", "value")
+	f.Add("na(me", "va)lue")
+	f.Add(`"quoted"`, "'")
+	f.Add("", "$1")
+
+	f.Fuzz(func(t *testing.T, column, value string) {
+		typ := reflect.StructOf([]reflect.StructField{
+			{Name: "ID", Type: reflect.TypeOf(0)},
+			{Name: "Value", Type: reflect.TypeOf(""), Tag: reflect.StructTag(fmt.Sprintf("sql:%q", column))},
+		})
+
+		current := reflect.New(typ)
+		current.Elem().Field(0).SetInt(1)
+		current.Elem().Field(1).SetString(value)
+
+		previous := reflect.New(typ)
+		previous.Elem().Field(0).SetInt(1)
+
+		for name, fn := range map[string]func() (Statement, error){
+			"insert":  func() (Statement, error) { return InsertStatement(current.Interface()) },
+			"replace": func() (Statement, error) { return ReplaceStatement(current.Interface()) },
+			"update":  func() (Statement, error) { return UpdateStatement(previous.Interface(), current.Interface()) },
+			"delete":  func() (Statement, error) { return DeleteStatement(current.Interface()) },
+		} {
+			s, err := fn()
+			if err != nil || s.Query == "" {
+				continue
+			}
+
+			if err := s.Validate(); err != nil {
+				t.Errorf("%s: generated invalid statement %q: %v", name, s.Query, err)
+			}
+		}
+	})
+}
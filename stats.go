@@ -0,0 +1,123 @@
+package sorm
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// ModelStats summarises one operation against one model's table.
+type ModelStats struct {
+	Table         string
+	Operation     Operation
+	Count         int64
+	Errors        int64
+	TotalDuration time.Duration
+	TotalRows     int64
+}
+
+func (s ModelStats) ErrorRate() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+
+	return float64(s.Errors) / float64(s.Count)
+}
+
+func (s ModelStats) AverageDuration() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+
+	return s.TotalDuration / time.Duration(s.Count)
+}
+
+func (s ModelStats) AverageRows() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+
+	return float64(s.TotalRows) / float64(s.Count)
+}
+
+type statsKey struct {
+	table string
+	op    Operation
+}
+
+// Stats is an in-process MetricsCollector that keeps running totals per
+// table and operation. Install it with SetMetricsCollector, alongside other
+// collectors with MultiMetricsCollector if needed.
+type Stats struct {
+	m sync.Mutex
+	l map[statsKey]*ModelStats
+}
+
+var _ MetricsCollector = (*Stats)(nil)
+
+func NewStats() *Stats {
+	return &Stats{l: make(map[statsKey]*ModelStats)}
+}
+
+func (s *Stats) ObserveOperation(op Operation, table string, duration time.Duration, rowsAffected int64, err error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	k := statsKey{table, op}
+
+	e, ok := s.l[k]
+	if !ok {
+		e = &ModelStats{Table: table, Operation: op}
+		s.l[k] = e
+	}
+
+	e.Count++
+	e.TotalDuration += duration
+	if err != nil {
+		e.Errors++
+	}
+	if rowsAffected > 0 {
+		e.TotalRows += rowsAffected
+	}
+}
+
+// Snapshot returns the current totals ordered by table then operation.
+func (s *Stats) Snapshot() []ModelStats {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	l := make([]ModelStats, 0, len(s.l))
+	for _, e := range s.l {
+		l = append(l, *e)
+	}
+
+	sort.Slice(l, func(i, j int) bool {
+		if l[i].Table != l[j].Table {
+			return l[i].Table < l[j].Table
+		}
+
+		return l[i].Operation < l[j].Operation
+	})
+
+	return l
+}
+
+func (s *Stats) Reset() {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.l = make(map[statsKey]*ModelStats)
+}
+
+type multiMetricsCollector []MetricsCollector
+
+func (l multiMetricsCollector) ObserveOperation(op Operation, table string, duration time.Duration, rowsAffected int64, err error) {
+	for _, c := range l {
+		c.ObserveOperation(op, table, duration, rowsAffected, err)
+	}
+}
+
+// MultiMetricsCollector passes every observation to each of l in turn.
+func MultiMetricsCollector(l ...MetricsCollector) MetricsCollector {
+	return multiMetricsCollector(l)
+}
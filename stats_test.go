@@ -0,0 +1,61 @@
+package sorm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStats(t *testing.T) {
+	a := assert.New(t)
+
+	s := NewStats()
+
+	var c testMetricsCollector
+	SetMetricsCollector(MultiMetricsCollector(s, &c))
+	defer SetMetricsCollector(nil)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from simple_objects`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a").AddRow(2, "b"))
+	mockDB.ExpectQuery(`select \* from simple_objects`).WillReturnError(errors.New("broken"))
+	mockDB.ExpectExec(`delete from simple_objects`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	var l []SimpleObject
+	a.NoError(FindAll(context.Background(), db, &l))
+	a.Error(FindAll(context.Background(), db, &l))
+	a.NoError(DeleteRecord(context.Background(), db, &SimpleObject{ID: 1}))
+
+	a.NoError(mockDB.ExpectationsWereMet())
+
+	snap := s.Snapshot()
+	if !a.Len(snap, 2) {
+		return
+	}
+
+	a.Equal(OperationDelete, snap[0].Operation)
+	a.Equal(int64(1), snap[0].Count)
+	a.Equal(float64(1), snap[0].AverageRows())
+
+	a.Equal("simple_objects", snap[1].Table)
+	a.Equal(OperationFind, snap[1].Operation)
+	a.Equal(int64(2), snap[1].Count)
+	a.Equal(int64(1), snap[1].Errors)
+	a.Equal(0.5, snap[1].ErrorRate())
+	a.Equal(float64(1), snap[1].AverageRows())
+	a.Equal(snap[1].TotalDuration/2, snap[1].AverageDuration())
+
+	a.Len(c.l, 3)
+
+	s.Reset()
+	a.Empty(s.Snapshot())
+	a.Equal(time.Duration(0), ModelStats{}.AverageDuration())
+}
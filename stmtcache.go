@@ -0,0 +1,181 @@
+package sorm
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"sync"
+)
+
+type Preparer interface {
+	Querier
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+type StmtCacheStats struct {
+	Hits      int64
+	Misses    int64
+	Evictions int64
+	Size      int
+}
+
+// StmtCache is a Querier that prepares each distinct query once and reuses
+// the *sql.Stmt afterwards. Generated statements only differ by model and
+// operation (and, for updates, the changed columns), so the cache stays small.
+// The least recently used statement is closed once there are more than Size.
+type StmtCache struct {
+	DB   Preparer
+	Size int
+
+	m       sync.Mutex
+	lru     *list.List
+	byQuery map[string]*list.Element
+	stats   StmtCacheStats
+}
+
+var _ Querier = (*StmtCache)(nil)
+
+// stmtCacheEntry counts the callers using stmt, so that one evicted while
+// it's in use is only closed once the last of them is done with it.
+type stmtCacheEntry struct {
+	query   string
+	stmt    *sql.Stmt
+	refs    int
+	evicted bool
+}
+
+func NewStmtCache(db Preparer, size int) *StmtCache {
+	return &StmtCache{DB: db, Size: size, lru: list.New(), byQuery: make(map[string]*list.Element)}
+}
+
+// get returns the entry for query with a reference taken, which the caller
+// must give back with release.
+func (c *StmtCache) get(ctx context.Context, query string) (*stmtCacheEntry, error) {
+	c.m.Lock()
+	if e, ok := c.byQuery[query]; ok {
+		c.lru.MoveToFront(e)
+		c.stats.Hits++
+		ent := e.Value.(*stmtCacheEntry)
+		ent.refs++
+		c.m.Unlock()
+		return ent, nil
+	}
+	c.stats.Misses++
+	c.m.Unlock()
+
+	stmt, err := c.DB.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	// another caller may have prepared the same query in the meantime
+	if e, ok := c.byQuery[query]; ok {
+		stmt.Close()
+		c.lru.MoveToFront(e)
+		ent := e.Value.(*stmtCacheEntry)
+		ent.refs++
+		return ent, nil
+	}
+
+	ent := &stmtCacheEntry{query: query, stmt: stmt, refs: 1}
+	c.byQuery[query] = c.lru.PushFront(ent)
+
+	for c.Size > 0 && c.lru.Len() > c.Size {
+		e := c.lru.Back()
+		c.lru.Remove(e)
+		delete(c.byQuery, e.Value.(*stmtCacheEntry).query)
+		c.evict(e.Value.(*stmtCacheEntry))
+		c.stats.Evictions++
+	}
+
+	return ent, nil
+}
+
+// evict marks ent as no longer cached, closing its statement unless someone
+// is still using it. c.m must be held.
+func (c *StmtCache) evict(ent *stmtCacheEntry) error {
+	ent.evicted = true
+
+	if ent.refs > 0 {
+		return nil
+	}
+
+	return ent.stmt.Close()
+}
+
+func (c *StmtCache) release(ent *stmtCacheEntry) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	ent.refs--
+
+	if ent.evicted && ent.refs == 0 {
+		ent.stmt.Close()
+	}
+}
+
+func (c *StmtCache) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ent, err := c.get(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer c.release(ent)
+
+	return ent.stmt.ExecContext(ctx, args...)
+}
+
+// QueryContext gives back its reference once the query has run. A *sql.Stmt
+// closed while its rows are open isn't finalized until they're closed.
+func (c *StmtCache) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ent, err := c.get(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer c.release(ent)
+
+	return ent.stmt.QueryContext(ctx, args...)
+}
+
+func (c *StmtCache) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ent, err := c.get(ctx, query)
+	if err != nil {
+		// there's no way to build a *sql.Row carrying err, so let the driver
+		// report it again
+		return c.DB.QueryRowContext(ctx, query, args...)
+	}
+	defer c.release(ent)
+
+	return ent.stmt.QueryRowContext(ctx, args...)
+}
+
+func (c *StmtCache) Stats() StmtCacheStats {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	s := c.stats
+	s.Size = c.lru.Len()
+
+	return s
+}
+
+// Close closes every cached statement and empties the cache. Statements
+// still in use are closed when their callers are done with them.
+func (c *StmtCache) Close() error {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	var first error
+	for e := c.lru.Front(); e != nil; e = e.Next() {
+		if err := c.evict(e.Value.(*stmtCacheEntry)); err != nil && first == nil {
+			first = err
+		}
+	}
+
+	c.lru.Init()
+	c.byQuery = make(map[string]*list.Element)
+
+	return first
+}
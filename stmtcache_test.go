@@ -0,0 +1,135 @@
+package sorm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStmtCache(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	find := mockDB.ExpectPrepare(`select \* from simple_objects where id = \$1`).WillBeClosed()
+	find.ExpectQuery().WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a"))
+	find.ExpectQuery().WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(2, "b"))
+	mockDB.ExpectPrepare(`delete from simple_objects where id = \$1`).WillBeClosed().ExpectExec().WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	c := NewStmtCache(db, 1)
+
+	var l []SimpleObject
+	a.NoError(FindWhere(context.Background(), c, &l, "where id = $1", 1))
+	a.NoError(FindWhere(context.Background(), c, &l, "where id = $1", 2))
+	a.Equal([]SimpleObject{{ID: 2, Name: "b"}}, l)
+
+	a.NoError(DeleteRecord(context.Background(), c, &SimpleObject{ID: 1}))
+
+	a.Equal(StmtCacheStats{Hits: 1, Misses: 2, Evictions: 1, Size: 1}, c.Stats())
+
+	a.NoError(c.Close())
+	a.Equal(0, c.Stats().Size)
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestStmtCacheEvictInUse(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.MatchExpectationsInOrder(false)
+
+	mockDB.ExpectPrepare(`select 1`).WillBeClosed().ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"a"}).AddRow(1))
+	mockDB.ExpectPrepare(`select 2`)
+
+	c := NewStmtCache(db, 1)
+
+	ent, err := c.get(context.Background(), "select 1")
+	if !a.NoError(err) {
+		return
+	}
+
+	other, err := c.get(context.Background(), "select 2")
+	if !a.NoError(err) {
+		return
+	}
+	c.release(other)
+
+	rows, err := ent.stmt.QueryContext(context.Background())
+	if a.NoError(err) {
+		a.NoError(rows.Close())
+	}
+
+	c.release(ent)
+
+	a.Equal(StmtCacheStats{Misses: 2, Evictions: 1, Size: 1}, c.Stats())
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestStmtCacheConcurrent(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	// a *sql.Stmt is prepared again on each connection it runs on, which
+	// would need more expectations than there are calls
+	db.SetMaxOpenConns(1)
+
+	mockDB.MatchExpectationsInOrder(false)
+
+	const workers, rounds, queries = 8, 10, 3
+
+	for i := 0; i < workers*rounds; i++ {
+		for q := 0; q < queries; q++ {
+			mockDB.ExpectPrepare(fmt.Sprintf(`^select %d$`, q)).ExpectExec().WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+	}
+
+	c := NewStmtCache(db, 1)
+	defer c.Close()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*rounds*queries)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < rounds; i++ {
+				for q := 0; q < queries; q++ {
+					if _, err := c.ExecContext(context.Background(), fmt.Sprintf("select %d", q)); err != nil {
+						errs <- err
+					}
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		a.NoError(err)
+	}
+
+	a.Equal(int64(workers*rounds*queries), c.Stats().Hits+c.Stats().Misses)
+}
@@ -0,0 +1,240 @@
+package sorm
+
+import (
+	"go/token"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"fknsrs.biz/p/reflectutil"
+)
+
+// structDescription is the field and tag metadata sorm reads from a model. It
+// comes from reflectutil, or from ImportDescriptions, so that builds that can't
+// afford to parse tags at run time don't have to.
+type structDescription struct {
+	name   string
+	typ    reflect.Type
+	fields fieldList
+	// naming is set on descriptions from getDescriptionContext whose naming
+	// strategy isn't the global one.
+	naming NamingStrategy
+}
+
+func (s *structDescription) Name() string       { return s.name }
+func (s *structDescription) Type() reflect.Type { return s.typ }
+func (s *structDescription) Fields() fieldList  { return s.fields }
+
+func (s *structDescription) Field(name string) *structField { return s.fields.Get(name) }
+
+// withNaming returns a copy of s whose table and columns are named with n.
+func (s *structDescription) withNaming(n NamingStrategy) *structDescription {
+	c := *s
+	c.naming = n
+	c.fields = make(fieldList, len(s.fields))
+
+	for i, f := range s.fields {
+		f.naming = n
+		c.fields[i] = f
+	}
+
+	return &c
+}
+
+type structField struct {
+	name  string
+	index []int
+	typ   reflect.Type
+	raw   reflect.StructTag
+	tags  []structTag
+	// naming is copied from the description by withNaming.
+	naming NamingStrategy
+}
+
+func (f *structField) Name() string       { return f.name }
+func (f *structField) Index() []int       { return f.index }
+func (f *structField) Type() reflect.Type { return f.typ }
+
+func (f *structField) Tag(name string) *structTag {
+	for i := range f.tags {
+		if f.tags[i].name == name {
+			return &f.tags[i]
+		}
+	}
+
+	return nil
+}
+
+type fieldList []structField
+
+func (l fieldList) Get(name string) *structField {
+	for i := range l {
+		if l[i].name == name {
+			return &l[i]
+		}
+	}
+
+	return nil
+}
+
+type structTag struct {
+	name       string
+	value      string
+	parameters []tagParameter
+}
+
+func (t *structTag) Name() string  { return t.name }
+func (t *structTag) Value() string { return t.value }
+
+func (t *structTag) Parameter(name string) *tagParameter {
+	for i := range t.parameters {
+		if t.parameters[i].name == name {
+			return &t.parameters[i]
+		}
+	}
+
+	return nil
+}
+
+type tagParameter struct {
+	name  string
+	value string
+}
+
+func (p *tagParameter) Name() string  { return p.name }
+func (p *tagParameter) Value() string { return p.value }
+
+// hiddenField reports whether f can't be a column: it's unexported, tagged
+// sql:"-", or promoted through a field tagged sql:"-", like the fields of an
+// embedded mock.
+func hiddenField(vdesc *structDescription, f structField) bool {
+	if !token.IsExported(f.name) {
+		return true
+	}
+
+	for _, o := range vdesc.fields {
+		if t := o.Tag("sql"); t == nil || t.value != "-" {
+			continue
+		}
+
+		if len(o.index) <= len(f.index) && reflect.DeepEqual(o.index, f.index[:len(o.index)]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sqlFields lists the fields that can be columns.
+func sqlFields(vdesc *structDescription) fieldList {
+	var r fieldList
+
+	for _, f := range vdesc.fields {
+		if !hiddenField(vdesc, f) {
+			r = append(r, f)
+		}
+	}
+
+	return r
+}
+
+func describeStruct(typ reflect.Type) (*structDescription, error) {
+	d, err := reflectutil.GetDescriptionFromType(typ)
+	if err != nil {
+		return nil, err
+	}
+
+	s := structDescription{name: d.Name(), typ: d.Type()}
+
+	for _, f := range d.Fields() {
+		sf := structField{
+			name:  f.Name(),
+			index: f.Index(),
+			typ:   f.Type(),
+			raw:   typ.FieldByIndex(f.Index()).Tag,
+		}
+
+		for _, t := range f.Tags() {
+			st := structTag{name: t.Name(), value: t.Value()}
+			for _, p := range t.Parameters() {
+				st.parameters = append(st.parameters, tagParameter{name: p.Name(), value: p.Value()})
+			}
+
+			sf.tags = append(sf.tags, st)
+		}
+
+		s.fields = append(s.fields, sf)
+	}
+
+	return &s, nil
+}
+
+// describeImported builds the description of typ from an imported one,
+// parsing the exported tags instead of reflecting over typ's.
+func describeImported(typ reflect.Type, d *ModelDescription) *structDescription {
+	s := structDescription{name: d.Name, typ: typ}
+
+	for _, f := range d.Fields {
+		s.fields = append(s.fields, structField{
+			name:  f.Name,
+			index: f.Index,
+			typ:   typ.FieldByIndex(f.Index).Type,
+			raw:   reflect.StructTag(f.Tag),
+			tags:  parseStructTag(f.Tag),
+		})
+	}
+
+	return &s
+}
+
+// parseStructTag splits a tag like reflectutil does: each key:"value" pair
+// becomes a tag whose value is split on commas into a value and parameters,
+// which are split on the first colon into a name and value.
+func parseStructTag(tag string) []structTag {
+	var l []structTag
+
+	for tag != "" {
+		tag = strings.TrimLeft(tag, " ")
+
+		i := strings.Index(tag, ":\"")
+		if i <= 0 {
+			break
+		}
+		name := tag[:i]
+		tag = tag[i+1:]
+
+		i = 1
+		for i < len(tag) && tag[i] != '"' {
+			if tag[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(tag) {
+			break
+		}
+
+		value, err := strconv.Unquote(tag[:i+1])
+		if err != nil {
+			break
+		}
+		tag = tag[i+1:]
+
+		t := structTag{name: name}
+
+		v, params, _ := strings.Cut(value, ",")
+		t.value = v
+		for _, p := range strings.Split(params, ",") {
+			if p == "" {
+				continue
+			}
+
+			k, pv, _ := strings.Cut(p, ":")
+			t.parameters = append(t.parameters, tagParameter{name: k, value: pv})
+		}
+
+		l = append(l, t)
+	}
+
+	return l
+}
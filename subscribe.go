@@ -0,0 +1,78 @@
+package sorm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+type ColumnChange struct {
+	Column string
+	Old    interface{}
+	New    interface{}
+}
+
+type ChangeFunc func(ctx context.Context, db Querier, record interface{}, changes []ColumnChange) error
+
+type watchedColumn struct {
+	name  string
+	index []int
+}
+
+// SubscribeColumns calls fn after SaveRecord writes a record of model's type
+// where at least one of columns changed. An empty column list watches every
+// writable column.
+func SubscribeColumns(model interface{}, columns []string, fn ChangeFunc) {
+	vtyp, err := structTypeOf(model)
+	if err != nil {
+		panic(fmt.Errorf("SubscribeColumns: %w", err))
+	}
+
+	vdesc, err := getDescriptionFromType(vtyp)
+	if err != nil {
+		panic(fmt.Errorf("SubscribeColumns: could not get detailed reflection information for type %s: %w", vtyp.String(), err))
+	}
+
+	plan, err := getPlanFromType(vtyp)
+	if err != nil {
+		panic(fmt.Errorf("SubscribeColumns: could not get detailed reflection information for type %s: %w", vtyp.String(), err))
+	}
+
+	var watched []watchedColumn
+	if len(columns) == 0 {
+		for _, f := range getSQLWritableFields(vdesc) {
+			watched = append(watched, watchedColumn{name: getSQLColumnName(f), index: f.Index()})
+		}
+	}
+	for _, c := range columns {
+		f := plan.fieldForColumn(c)
+		if f == nil {
+			panic(fmt.Errorf("SubscribeColumns: couldn't find field on %s for sql field %s", vtyp.Name(), c))
+		}
+
+		watched = append(watched, watchedColumn{name: c, index: f.Index})
+	}
+
+	RegisterCallback(OperationSave, PhaseAfter, func(ctx context.Context, db Querier, e *CallbackEvent) error {
+		v := reflect.Indirect(reflect.ValueOf(e.Value))
+		if v.Type() != vtyp || e.Previous == nil {
+			return nil
+		}
+
+		p := reflect.Indirect(reflect.ValueOf(e.Previous))
+
+		var changes []ColumnChange
+		for _, c := range watched {
+			o, n := p.FieldByIndex(c.index).Interface(), v.FieldByIndex(c.index).Interface()
+			if !reflect.DeepEqual(o, n) {
+				changes = append(changes, ColumnChange{Column: c.name, Old: o, New: n})
+			}
+		}
+
+		if len(changes) == 0 {
+			return nil
+		}
+
+		return fn(ctx, db, e.Value, changes)
+	})
+}
@@ -0,0 +1,52 @@
+package sorm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type SubscribeOrder struct {
+	ID     int
+	Status string
+	Note   string
+}
+
+func TestSubscribeColumns(t *testing.T) {
+	a := assert.New(t)
+
+	defer func(l []callback) { callbacks = l }(callbacks)
+
+	var got [][]ColumnChange
+	SubscribeColumns(SubscribeOrder{}, []string{"status"}, func(ctx context.Context, db Querier, record interface{}, changes []ColumnChange) error {
+		got = append(got, changes)
+		return nil
+	})
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from subscribe_orders where id = \$1 limit 1`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "status", "note"}).AddRow(1, "new", ""))
+	mockDB.ExpectExec(`update subscribe_orders set note = \$2 where id = \$1`).WithArgs(1, "hi").WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectQuery(`select \* from subscribe_orders where id = \$1 limit 1`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "status", "note"}).AddRow(1, "new", "hi"))
+	mockDB.ExpectExec(`update subscribe_orders set status = \$2 where id = \$1`).WithArgs(1, "paid").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	a.NoError(SaveRecord(context.Background(), db, &SubscribeOrder{ID: 1, Status: "new", Note: "hi"}))
+	a.NoError(SaveRecord(context.Background(), db, &SubscribeOrder{ID: 1, Status: "paid", Note: "hi"}))
+
+	a.Equal([][]ColumnChange{{{Column: "status", Old: "new", New: "paid"}}}, got)
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestSubscribeColumnsUnknownColumn(t *testing.T) {
+	a := assert.New(t)
+
+	a.Panics(func() {
+		SubscribeColumns(SubscribeOrder{}, []string{"nope"}, nil)
+	})
+}
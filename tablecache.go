@@ -0,0 +1,162 @@
+package sorm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TableCache holds every row of a small reference table in memory. Each
+// refresh builds a complete new generation and swaps it in at once, so
+// readers never see a partial table.
+type TableCache[T any] struct {
+	// OnError is called with refresh errors from AutoRefresh.
+	OnError func(err error)
+
+	snap        atomic.Pointer[tableSnapshot[T]]
+	once        sync.Once
+	invalidated chan struct{}
+}
+
+type tableSnapshot[T any] struct {
+	generation uint64
+	rows       []T
+	byID       map[string]int
+}
+
+// PreloadTable loads every row of T's table into cache. The first call also
+// registers callbacks so that writes to T made through sorm wake up
+// AutoRefresh.
+func PreloadTable[T any](ctx context.Context, db Querier, cache *TableCache[T]) error {
+	vtyp := reflect.TypeOf((*T)(nil)).Elem()
+	if vtyp.Kind() != reflect.Struct {
+		return fmt.Errorf("PreloadTable: expected a struct type; was instead %s", vtyp.Kind())
+	}
+
+	vdesc, err := getDescriptionContext(ctx, vtyp)
+	if err != nil {
+		return fmt.Errorf("PreloadTable: could not get detailed reflection information for type %s: %w", vtyp.String(), err)
+	}
+
+	cache.once.Do(func() {
+		cache.invalidated = make(chan struct{}, 1)
+
+		fn := func(ctx context.Context, db Querier, e *CallbackEvent) error {
+			if reflect.TypeOf(e.Value) == reflect.PtrTo(vtyp) {
+				cache.invalidate()
+			}
+
+			return nil
+		}
+
+		for _, op := range []Operation{OperationCreate, OperationSave, OperationReplace, OperationDelete} {
+			RegisterCallback(op, PhaseAfter, fn)
+		}
+	})
+
+	var rows []T
+	if err := findWhere(ctx, db, &rows, "", nil, findOptions{}); err != nil {
+		return fmt.Errorf("PreloadTable: %w", err)
+	}
+
+	idFields := getSQLIDFields(vdesc)
+
+	s := tableSnapshot[T]{rows: rows, byID: make(map[string]int)}
+	for i := range rows {
+		v := reflect.ValueOf(&rows[i]).Elem()
+
+		var ids []interface{}
+		for _, f := range idFields {
+			ids = append(ids, v.FieldByIndex(f.Index()).Interface())
+		}
+
+		s.byID[tableCacheKey(ids)] = i
+	}
+
+	if prev := cache.snap.Load(); prev != nil {
+		s.generation = prev.generation
+	}
+	s.generation++
+
+	cache.snap.Store(&s)
+
+	return nil
+}
+
+func tableCacheKey(ids []interface{}) string {
+	l := make([]string, len(ids))
+	for i, id := range ids {
+		l[i] = fmt.Sprint(id)
+	}
+
+	return strings.Join(l, "\x00")
+}
+
+func (c *TableCache[T]) invalidate() {
+	select {
+	case c.invalidated <- struct{}{}:
+	default:
+	}
+}
+
+// All returns the rows of the current generation. The slice is shared and
+// must not be modified.
+func (c *TableCache[T]) All() []T {
+	if s := c.snap.Load(); s != nil {
+		return s.rows
+	}
+
+	return nil
+}
+
+// Get looks up a row by its ID field values.
+func (c *TableCache[T]) Get(id ...interface{}) (T, bool) {
+	var zero T
+
+	s := c.snap.Load()
+	if s == nil {
+		return zero, false
+	}
+
+	i, ok := s.byID[tableCacheKey(id)]
+	if !ok {
+		return zero, false
+	}
+
+	return s.rows[i], true
+}
+
+// Generation counts the loads that have completed; it's 0 before the first.
+func (c *TableCache[T]) Generation() uint64 {
+	if s := c.snap.Load(); s != nil {
+		return s.generation
+	}
+
+	return 0
+}
+
+// AutoRefresh reloads the cache every interval, and soon after any write to
+// the table made through sorm, until ctx is done. A write's transaction may
+// not have committed by the time the refresh runs, so the next tick is what
+// bounds staleness. Call PreloadTable first.
+func (c *TableCache[T]) AutoRefresh(ctx context.Context, db Querier, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		case <-c.invalidated:
+		}
+
+		if err := PreloadTable(ctx, db, c); err != nil && c.OnError != nil && ctx.Err() == nil {
+			c.OnError(err)
+		}
+	}
+}
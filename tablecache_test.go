@@ -0,0 +1,62 @@
+package sorm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreloadTable(t *testing.T) {
+	a := assert.New(t)
+
+	defer func(l []callback) { callbacks = l }(callbacks)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from simple_objects`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a").AddRow(2, "b"))
+	mockDB.ExpectExec(`delete from simple_objects where id = \$1`).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectQuery(`select \* from simple_objects`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a"))
+
+	var cache TableCache[SimpleObject]
+	a.Equal(uint64(0), cache.Generation())
+
+	if !a.NoError(PreloadTable(context.Background(), db, &cache)) {
+		return
+	}
+
+	a.Equal(uint64(1), cache.Generation())
+	a.Equal([]SimpleObject{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}}, cache.All())
+
+	r, ok := cache.Get(2)
+	a.True(ok)
+	a.Equal(SimpleObject{ID: 2, Name: "b"}, r)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		cache.AutoRefresh(ctx, db, time.Hour)
+		close(done)
+	}()
+
+	a.NoError(DeleteRecord(context.Background(), db, &SimpleObject{ID: 2}))
+
+	for i := 0; i < 100 && cache.Generation() < 2; i++ {
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	cancel()
+	<-done
+
+	a.Equal(uint64(2), cache.Generation())
+	_, ok = cache.Get(2)
+	a.False(ok)
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
@@ -0,0 +1,130 @@
+package sorm
+
+import (
+	"reflect"
+	"strings"
+)
+
+// TableNamer renames the table of every model used with a context whose
+// Options carry it, e.g. to map models onto per-database prefixes or
+// schemas. name is the table sorm would otherwise use, which already
+// includes any schema tag. The result is used verbatim.
+type TableNamer interface {
+	TableName(model reflect.Type, name string) string
+}
+
+type TableNamerFunc func(model reflect.Type, name string) string
+
+func (fn TableNamerFunc) TableName(model reflect.Type, name string) string {
+	return fn(model, name)
+}
+
+var (
+	tableNamer      TableNamer
+	identifierQuote = `"`
+)
+
+// SetTableNamer sets the namer used when the context's Options don't have
+// one.
+func SetTableNamer(n TableNamer) {
+	tableNamer = n
+}
+
+// SetIdentifierQuote sets the character used to quote schema and table names
+// that aren't plain lower case identifiers. Config.Apply uses a backtick for
+// MySQL; the default is a double quote.
+func SetIdentifierQuote(q string) {
+	identifierQuote = q
+}
+
+// getSQLSchema returns the schema set with a field like
+// `_ struct{} schema:"analytics"`.
+func getSQLSchema(vdesc *structDescription) string {
+	for _, f := range vdesc.Fields() {
+		if t := f.Tag("schema"); t != nil && t.Value() != "" {
+			return t.Value()
+		}
+	}
+
+	return ""
+}
+
+func isPlainIdentifier(s string) bool {
+	if s == "" || (s[0] >= '0' && s[0] <= '9') {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c != '_' && (c < 'a' || c > 'z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isQuotedIdentifier(s string) bool {
+	return len(s) >= 2 && (s[0] == '"' || s[0] == '`') && s[len(s)-1] == s[0]
+}
+
+// quoteIdentifier quotes s unless it's already quoted or would mean the same
+// thing without quotes.
+func quoteIdentifier(s string) string {
+	if isPlainIdentifier(s) || isQuotedIdentifier(s) {
+		return s
+	}
+
+	return identifierQuote + s + identifierQuote
+}
+
+func qualifyTable(schema, tbl string) string {
+	return quoteIdentifier(schema) + "." + quoteIdentifier(tbl)
+}
+
+// splitIdentifier splits a possibly schema-qualified name at the dots that
+// aren't inside quotes.
+func splitIdentifier(s string) []string {
+	var parts []string
+	var quote byte
+
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '`':
+			quote = c
+		case c == '.':
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(parts, s[start:])
+}
+
+func unquoteIdentifier(s string) string {
+	if isQuotedIdentifier(s) {
+		return s[1 : len(s)-1]
+	}
+
+	return s
+}
+
+// splitTableName returns the unquoted schema and name of tbl.
+func splitTableName(tbl string) (string, string) {
+	parts := splitIdentifier(tbl)
+	if len(parts) == 1 {
+		return "", unquoteIdentifier(tbl)
+	}
+
+	name := parts[len(parts)-1]
+
+	return unquoteIdentifier(strings.Join(parts[:len(parts)-1], ".")), unquoteIdentifier(name)
+}
+
+func hasSchema(tbl string) bool {
+	return len(splitIdentifier(tbl)) > 1
+}
@@ -0,0 +1,92 @@
+package sorm
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type AnalyticsEvent struct {
+	_    struct{} `schema:"analytics"`
+	ID   int
+	Name string
+}
+
+type ReportingEvent struct {
+	_    struct{} `schema:"Reporting" table:"events"`
+	ID   int
+	Name string
+}
+
+func TestSchemaTag(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from analytics\.analytics_events`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a"))
+	mockDB.ExpectExec(`insert into "Reporting"\.events \(id, name\) values \(\$1, \$2\)`).WithArgs(1, "a").WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectQuery(`information_schema\.columns where table_name = \$1 and table_schema = \$2`).WithArgs("events", "Reporting").WillReturnRows(sqlmock.NewRows([]string{"column_name", "data_type", "is_nullable"}))
+
+	var l []AnalyticsEvent
+	a.NoError(FindAll(context.Background(), db, &l))
+	a.Equal([]AnalyticsEvent{{ID: 1, Name: "a"}}, l)
+
+	a.NoError(CreateRecord(context.Background(), db, &ReportingEvent{ID: 1, Name: "a"}))
+
+	stmts, err := AutoMigrateStatements(context.Background(), db, &ReportingEvent{})
+	if a.NoError(err) && a.Len(stmts, 1) {
+		a.Equal(`create table "Reporting".events (id integer not null, name text not null, primary key (id))`, stmts[0].Query)
+	}
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestTableNamer(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from acme_simple_objects where id = \$1`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a"))
+	mockDB.ExpectQuery(`select \* from archive\.acme_simple_objects`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+
+	ctx := WithOptions(context.Background(), Options{TableNamer: TableNamerFunc(func(model reflect.Type, name string) string {
+		a.Equal(reflect.TypeOf(SimpleObject{}), model)
+		return "acme_" + name
+	})})
+
+	var l []SimpleObject
+	a.NoError(FindWhere(ctx, db, &l, "where id = $1", 1))
+	a.NoError(FindAll(WithOptions(ctx, Options{Schema: "archive"}), db, &l))
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestQualifiedIdentifiers(t *testing.T) {
+	a := assert.New(t)
+
+	a.NoError(checkIdentifier(`"Reporting"."Events"`))
+	a.NoError(checkIdentifier("analytics.events"))
+	a.Error(checkIdentifier(`"Reporting"."Ev"ents"`))
+	a.Error(checkIdentifier("analytics..events"))
+
+	schema, name := splitTableName(`"Reporting"."Events"`)
+	a.Equal("Reporting", schema)
+	a.Equal("Events", name)
+
+	a.Equal(`"Events"`, tableAlias(`"Reporting"."Events"`))
+
+	SetIdentifierQuote("`")
+	defer SetIdentifierQuote(`"`)
+	a.Equal("`Reporting`.events", qualifyTable("Reporting", "events"))
+}
@@ -0,0 +1,138 @@
+package sorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var (
+	ErrNoTenant    = errors.New("no tenant in context")
+	ErrOtherTenant = errors.New("record belongs to another tenant")
+)
+
+// Tenancy scopes models with a `sql:",tenant"` field to the tenant of the
+// context: finds, counts and deletes only see that tenant's rows, and writes
+// fill in the tenant field, refusing ones for another tenant.
+type Tenancy struct {
+	// TenantFrom gets the tenant ID from ctx, reporting false if there isn't
+	// one. It defaults to reading the ID stored with WithTenant.
+	TenantFrom func(ctx context.Context) (interface{}, bool)
+}
+
+var (
+	tenancy *Tenancy
+)
+
+// SetTenancy turns tenancy on, or off again with nil. Contexts made with
+// Unscoped aren't limited to a tenant.
+func SetTenancy(t *Tenancy) {
+	tenancy = t
+}
+
+type tenantKey struct{}
+
+func WithTenant(ctx context.Context, id interface{}) context.Context {
+	return context.WithValue(ctx, tenantKey{}, id)
+}
+
+func tenantFromContext(ctx context.Context) (interface{}, bool) {
+	id := ctx.Value(tenantKey{})
+	return id, id != nil
+}
+
+func getSQLTenantField(vdesc *structDescription) *structField {
+	for _, f := range getSQLWritableFields(vdesc) {
+		if t := f.Tag("sql"); t != nil && t.Parameter("tenant") != nil {
+			return &f
+		}
+	}
+
+	return nil
+}
+
+// currentTenant returns the tenant field of vdesc and the tenant of ctx, or a
+// nil field if tenancy doesn't apply.
+func currentTenant(ctx context.Context, vdesc *structDescription) (*structField, interface{}, error) {
+	t := tenancy
+	if t == nil || isUnscoped(ctx) {
+		return nil, nil, nil
+	}
+
+	f := getSQLTenantField(vdesc)
+	if f == nil {
+		return nil, nil, nil
+	}
+
+	from := t.TenantFrom
+	if from == nil {
+		from = tenantFromContext
+	}
+
+	id, ok := from(ctx)
+	if !ok {
+		return nil, nil, fmt.Errorf("%s is tenant scoped: %w", vdesc.Name(), ErrNoTenant)
+	}
+
+	return f, id, nil
+}
+
+func tenantScope(ctx context.Context, vtyp reflect.Type) (string, []interface{}, error) {
+	vdesc, err := getDescriptionContext(ctx, vtyp)
+	if err != nil {
+		return "", nil, err
+	}
+
+	f, id, err := currentTenant(ctx, vdesc)
+	if err != nil || f == nil {
+		return "", nil, err
+	}
+
+	col := getSQLColumnName(*f)
+	if err := checkIdentifier(col); err != nil {
+		return "", nil, err
+	}
+
+	return col + " = " + makeParameter(1), []interface{}{id}, nil
+}
+
+// applyTenant sets the tenant field of v to the context's tenant, or checks
+// that it already is.
+func applyTenant(ctx context.Context, vdesc *structDescription, v reflect.Value) error {
+	f, id, err := currentTenant(ctx, vdesc)
+	if err != nil || f == nil {
+		return err
+	}
+
+	fv := v.FieldByIndex(f.Index())
+
+	typ := fv.Type()
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	rv := reflect.ValueOf(id)
+	if !rv.Type().ConvertibleTo(typ) {
+		return fmt.Errorf("tenant %v can't be stored in %s.%s, a %s", id, vdesc.Name(), f.Name(), typ)
+	}
+	rv = rv.Convert(typ)
+
+	if !isZero(fv.Interface()) {
+		if cur := reflect.Indirect(fv); cur.Interface() != rv.Interface() {
+			return fmt.Errorf("%s.%s is %v but the context's tenant is %v", vdesc.Name(), f.Name(), cur.Interface(), id)
+		}
+
+		return nil
+	}
+
+	if fv.Kind() == reflect.Ptr {
+		p := reflect.New(typ)
+		p.Elem().Set(rv)
+		rv = p
+	}
+
+	fv.Set(rv)
+
+	return nil
+}
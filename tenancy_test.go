@@ -0,0 +1,103 @@
+package sorm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type TenantObject struct {
+	ID       int
+	TenantID int `sql:",tenant"`
+	Name     string
+}
+
+func TestTenancy(t *testing.T) {
+	a := assert.New(t)
+
+	SetTenancy(&Tenancy{})
+	defer SetTenancy(nil)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	ctx := WithTenant(context.Background(), 4)
+
+	mockDB.ExpectQuery(`^select \* from \(select \* from tenant_objects where \(tenant_id = \$2\)\) tenant_objects where name = \$1$`).WithArgs("a", 4).WillReturnRows(sqlmock.NewRows([]string{"id", "tenant_id", "name"}).AddRow(1, 4, "a"))
+	mockDB.ExpectQuery(`^insert into tenant_objects \(tenant_id, name\) values \(\$1, \$2\) returning id$`).WithArgs(4, "b").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	mockDB.ExpectQuery(`^select \* from simple_objects$`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+
+	var l []TenantObject
+	a.NoError(FindWhere(ctx, db, &l, "where name = $1", "a"))
+	a.Equal([]TenantObject{{ID: 1, TenantID: 4, Name: "a"}}, l)
+
+	r := TenantObject{Name: "b"}
+	a.NoError(CreateRecord(ctx, db, &r))
+	a.Equal(4, r.TenantID)
+
+	a.EqualError(CreateRecord(ctx, db, &TenantObject{TenantID: 5, Name: "c"}), "CreateRecord: TenantObject.TenantID is 5 but the context's tenant is 4")
+
+	err = FindAll(context.Background(), db, &l)
+	a.True(errors.Is(err, ErrNoTenant))
+	a.EqualError(err, "FindWhere: TenantObject is tenant scoped: no tenant in context")
+
+	var s []SimpleObject
+	a.NoError(FindAll(context.Background(), db, &s))
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestTenancyReplace(t *testing.T) {
+	a := assert.New(t)
+
+	SetTenancy(&Tenancy{})
+	defer SetTenancy(nil)
+	defer SetReplaceMode(ReplaceInsertOrReplace)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	ctx := WithTenant(context.Background(), 1)
+
+	// row 99 belongs to tenant 2, so the conflict path skips it and the
+	// scoped lookup can't see it
+	const scopedFind = `^select \* from \(select \* from tenant_objects where \(tenant_id = \$2\)\) tenant_objects where id = \$1 limit 1$`
+
+	mockDB.ExpectExec(`^insert into tenant_objects \(id, tenant_id, name\) values \(\$1, \$2, \$3\) on conflict \(id\) do update set name = excluded\.name where tenant_objects\.tenant_id = excluded\.tenant_id$`).WithArgs(99, 1, "pwned").WillReturnResult(sqlmock.NewResult(0, 0))
+	mockDB.ExpectQuery(scopedFind).WithArgs(99, 1).WillReturnRows(sqlmock.NewRows([]string{"id", "tenant_id", "name"}))
+
+	err = ReplaceRecord(ctx, db, &TenantObject{ID: 99, Name: "pwned"})
+	a.EqualError(err, "ReplaceRecord: record belongs to another tenant")
+	a.True(errors.Is(err, ErrOtherTenant))
+
+	SetReplaceMode(ReplaceOnDuplicateKey)
+
+	mockDB.ExpectExec(`^insert into tenant_objects \(id, tenant_id, name\) values \(\$1, \$2, \$3\) on duplicate key update name = if\(tenant_id = values\(tenant_id\), values\(name\), name\)$`).WithArgs(99, 1, "pwned").WillReturnResult(sqlmock.NewResult(0, 0))
+	mockDB.ExpectQuery(scopedFind).WithArgs(99, 1).WillReturnRows(sqlmock.NewRows([]string{"id", "tenant_id", "name"}))
+
+	a.True(errors.Is(ReplaceRecord(ctx, db, &TenantObject{ID: 99, Name: "pwned"}), ErrOtherTenant))
+
+	SetReplaceMode(ReplaceMerge)
+
+	mockDB.ExpectExec(`^merge into tenant_objects with \(holdlock\) as t using \(values \(\$1, \$2, \$3\)\) as s \(id, tenant_id, name\) on t\.id = s\.id when matched and t\.tenant_id = s\.tenant_id then update set t\.name = s\.name when not matched`).WithArgs(99, 1, "pwned").WillReturnResult(sqlmock.NewResult(0, 0))
+	mockDB.ExpectQuery(scopedFind).WithArgs(99, 1).WillReturnRows(sqlmock.NewRows([]string{"id", "tenant_id", "name"}))
+
+	a.True(errors.Is(ReplaceRecord(ctx, db, &TenantObject{ID: 99, Name: "pwned"}), ErrOtherTenant))
+
+	// an unchanged row of this tenant affects nothing either, but is found
+	mockDB.ExpectExec(`^merge into tenant_objects`).WithArgs(5, 1, "same").WillReturnResult(sqlmock.NewResult(0, 0))
+	mockDB.ExpectQuery(scopedFind).WithArgs(5, 1).WillReturnRows(sqlmock.NewRows([]string{"id", "tenant_id", "name"}).AddRow(5, 1, "same"))
+
+	a.NoError(ReplaceRecord(ctx, db, &TenantObject{ID: 5, Name: "same"}))
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
@@ -0,0 +1,24 @@
+package sorm
+
+import (
+	"context"
+)
+
+// Tracer starts a span around each model hook and registered callback, so
+// slow hooks show up separately from the SQL they surround. Span names look
+// like "sorm hook BeforeSave" or "sorm callback before save".
+type Tracer interface {
+	StartSpan(ctx context.Context, name string) (context.Context, Span)
+}
+
+type Span interface {
+	End(err error)
+}
+
+var (
+	tracer Tracer
+)
+
+func SetTracer(t Tracer) {
+	tracer = t
+}
@@ -0,0 +1,79 @@
+package sorm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type spanKey struct{}
+
+type recordedSpan struct {
+	name  string
+	err   error
+	ended bool
+}
+
+type recordingTracer struct{ spans []*recordedSpan }
+
+func (r *recordingTracer) StartSpan(ctx context.Context, name string) (context.Context, Span) {
+	s := &recordedSpan{name: name}
+	r.spans = append(r.spans, s)
+	return context.WithValue(ctx, spanKey{}, name), s
+}
+
+func (s *recordedSpan) End(err error) {
+	s.err = err
+	s.ended = true
+}
+
+type TracedObject struct {
+	ID   int
+	Name string
+}
+
+func (o *TracedObject) BeforeCreate(ctx context.Context, tx Querier) error {
+	if ctx.Value(spanKey{}) != "sorm hook BeforeCreate" {
+		return errors.New("hook didn't get the span context")
+	}
+
+	return nil
+}
+
+func (o *TracedObject) AfterCreate(ctx context.Context, tx Querier) error {
+	return errors.New("after create failed")
+}
+
+func TestTracerHookSpans(t *testing.T) {
+	a := assert.New(t)
+
+	defer func(l []callback) { callbacks = l }(callbacks)
+
+	RegisterCallback(OperationCreate, PhaseBefore, func(ctx context.Context, db Querier, e *CallbackEvent) error { return nil })
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectExec(`insert into traced_objects \(id, name\) values \(\$1, \$2\)`).WithArgs(1, "a").WillReturnResult(sqlmock.NewResult(1, 1))
+
+	var tr recordingTracer
+	ctx := WithOptions(context.Background(), Options{Tracer: &tr})
+
+	a.EqualError(CreateRecord(ctx, db, &TracedObject{ID: 1, Name: "a"}), "CreateRecord: AfterCreate callback returned an error: after create failed")
+
+	if !a.Len(tr.spans, 3) {
+		return
+	}
+
+	a.Equal(&recordedSpan{name: "sorm hook BeforeCreate", ended: true}, tr.spans[0])
+	a.Equal(&recordedSpan{name: "sorm callback before create", ended: true}, tr.spans[1])
+	a.Equal(&recordedSpan{name: "sorm hook AfterCreate", ended: true, err: errors.New("after create failed")}, tr.spans[2])
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
@@ -0,0 +1,152 @@
+package sorm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	timeNow = time.Now
+)
+
+// PurgeSyntax picks how PurgeExpired deletes a batch of expired rows.
+type PurgeSyntax int
+
+const (
+	// PurgeSubquery deletes the IDs picked by a subquery with a limit, as
+	// Postgres and SQLite allow.
+	PurgeSubquery PurgeSyntax = iota
+	// PurgeOrderLimit uses MySQL's "delete ... order by ... limit", since
+	// MySQL doesn't allow a limit in a subquery used with in.
+	PurgeOrderLimit
+	// PurgeTop uses SQL Server's "delete top (n) from".
+	PurgeTop
+)
+
+var (
+	purgeSyntax PurgeSyntax
+)
+
+// SetPurgeSyntax picks how batched purges are written. Config.Apply picks
+// one for the dialect.
+func SetPurgeSyntax(s PurgeSyntax) {
+	purgeSyntax = s
+}
+
+func getSQLTTLField(vdesc *structDescription) *structField {
+	for _, f := range sqlFields(vdesc) {
+		if t := f.Tag("sql"); t != nil && t.Parameter("ttl") != nil {
+			return &f
+		}
+	}
+
+	return nil
+}
+
+func PurgeExpired(ctx context.Context, db Querier, model interface{}, batchSize int) (int64, error) {
+	return PurgeExpiredWithOptions(ctx, db, model, batchSize, nil)
+}
+
+func PurgeExpiredWithOptions(ctx context.Context, db Querier, model interface{}, batchSize int, opts *BatchOptions) (int64, error) {
+	vtyp, err := structTypeOf(model)
+	if err != nil {
+		return 0, fmt.Errorf("PurgeExpired: %w", err)
+	}
+
+	vdesc, err := getDescriptionContext(ctx, vtyp)
+	if err != nil {
+		return 0, fmt.Errorf("PurgeExpired: could not get detailed reflection information for type %s: %w", vtyp.String(), err)
+	}
+
+	ttlField := getSQLTTLField(vdesc)
+	if ttlField == nil {
+		return 0, fmt.Errorf("PurgeExpired: type %s has no ttl field", vtyp.Name())
+	}
+
+	idFields := getSQLIDFields(vdesc)
+	if len(idFields) == 0 {
+		return 0, fmt.Errorf("PurgeExpired: %w", ErrNoIDFields)
+	}
+
+	tbl := getSQLTableNameContext(ctx, vdesc)
+	col := getSQLColumnName(*ttlField)
+
+	var ids []string
+	for _, f := range idFields {
+		ids = append(ids, getSQLColumnName(f))
+	}
+
+	for _, s := range append([]string{tbl, col}, ids...) {
+		if err := checkIdentifier(s); err != nil {
+			return 0, fmt.Errorf("PurgeExpired: %w", err)
+		}
+	}
+
+	var query string
+	var args []interface{}
+	if batchSize > 0 {
+		switch purgeSyntax {
+		case PurgeOrderLimit:
+			query = fmt.Sprintf("delete from %s where %s <= %s order by %s limit %s", tbl, col, makeParameter(1), col, makeParameter(2))
+		case PurgeTop:
+			query = fmt.Sprintf("delete top (%s) from %s where %s <= %s", makeParameter(2), tbl, col, makeParameter(1))
+		default:
+			key := strings.Join(ids, ", ")
+			if len(ids) > 1 {
+				key = "(" + key + ")"
+			}
+
+			query = fmt.Sprintf("delete from %s where %s in (select %s from %s where %s <= %s limit %s)", tbl, key, strings.Join(ids, ", "), tbl, col, makeParameter(1), makeParameter(2))
+		}
+
+		args = []interface{}{timeNow(), batchSize}
+	} else {
+		query = fmt.Sprintf("delete from %s where %s <= %s", tbl, col, makeParameter(1))
+		args = []interface{}{timeNow()}
+	}
+
+	tracker := newBatchTracker(opts)
+
+	var total int64
+	for {
+		n, err := purgeBatch(ctx, db, query, args)
+		total += n
+
+		invalidateResultCache(ctx, tbl)
+
+		if err != nil {
+			return total, fmt.Errorf("PurgeExpired: %w", err)
+		}
+
+		tracker.done(n)
+
+		if batchSize <= 0 || n < int64(batchSize) {
+			return total, nil
+		}
+
+		if err := tracker.wait(ctx); err != nil {
+			return total, fmt.Errorf("PurgeExpired: %w", err)
+		}
+	}
+}
+
+func purgeBatch(ctx context.Context, db Querier, query string, args []interface{}) (int64, error) {
+	logQuery(ctx, query, args)
+
+	start := time.Now()
+
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		logQueryAfter(ctx, query, args, start, err)
+
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+
+	logQueryAfter(ctx, query, args, start, err)
+
+	return n, err
+}
@@ -0,0 +1,146 @@
+package sorm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type TTLSession struct {
+	ID        int
+	Token     string
+	ExpiresAt time.Time `sql:",ttl"`
+}
+
+func withFixedTime(t time.Time) func() {
+	timeNow = func() time.Time { return t }
+	return func() { timeNow = time.Now }
+}
+
+func TestTTLFindWhere(t *testing.T) {
+	a := assert.New(t)
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	defer withFixedTime(now)()
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select \* from \(select \* from ttl_sessions where expires_at is null or expires_at > \$2\) ttl_sessions where token = \$1`).WithArgs("abc", now).WillReturnRows(sqlmock.NewRows([]string{"id", "token", "expires_at"}).AddRow(1, "abc", now.Add(time.Hour)))
+
+	var r []TTLSession
+	a.NoError(FindWhere(context.Background(), db, &r, "where token = $1", "abc"))
+
+	a.Equal([]TTLSession{{ID: 1, Token: "abc", ExpiresAt: now.Add(time.Hour)}}, r)
+}
+
+func TestTTLCountAll(t *testing.T) {
+	a := assert.New(t)
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	defer withFixedTime(now)()
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select count\(\*\) from \(select \* from ttl_sessions where expires_at is null or expires_at > \$1\) ttl_sessions`).WithArgs(now).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	n, err := CountAll(context.Background(), db, &TTLSession{})
+	a.NoError(err)
+	a.Equal(3, n)
+}
+
+func TestPurgeExpired(t *testing.T) {
+	a := assert.New(t)
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	defer withFixedTime(now)()
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectExec(`delete from ttl_sessions where id in \(select id from ttl_sessions where expires_at <= \$1 limit \$2\)`).WithArgs(now, 10).WillReturnResult(sqlmock.NewResult(0, 10))
+	mockDB.ExpectExec(`delete from ttl_sessions where id in \(select id from ttl_sessions where expires_at <= \$1 limit \$2\)`).WithArgs(now, 10).WillReturnResult(sqlmock.NewResult(0, 4))
+
+	n, err := PurgeExpired(context.Background(), db, TTLSession{}, 10)
+	a.NoError(err)
+	a.Equal(int64(14), n)
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestPurgeExpiredSyntax(t *testing.T) {
+	a := assert.New(t)
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	defer withFixedTime(now)()
+	defer SetPurgeSyntax(PurgeSubquery)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectExec(`^delete from ttl_sessions where expires_at <= \$1 order by expires_at limit \$2$`).WithArgs(now, 10).WillReturnResult(sqlmock.NewResult(0, 4))
+	mockDB.ExpectExec(`^delete top \(\$2\) from ttl_sessions where expires_at <= \$1$`).WithArgs(now, 10).WillReturnResult(sqlmock.NewResult(0, 4))
+
+	SetPurgeSyntax(PurgeOrderLimit)
+	n, err := PurgeExpired(context.Background(), db, TTLSession{}, 10)
+	a.NoError(err)
+	a.Equal(int64(4), n)
+
+	SetPurgeSyntax(PurgeTop)
+	n, err = PurgeExpired(context.Background(), db, TTLSession{}, 10)
+	a.NoError(err)
+	a.Equal(int64(4), n)
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestPurgeExpiredInvalidatesCache(t *testing.T) {
+	a := assert.New(t)
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	defer withFixedTime(now)()
+
+	defer withResultCache(NewLRUCache(10))()
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	rows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "token", "expires_at"}).AddRow(1, "abc", now.Add(time.Hour))
+	}
+
+	mockDB.ExpectQuery(`ttl_sessions where id = \$1 limit 1`).WithArgs(1, now).WillReturnRows(rows())
+	mockDB.ExpectExec(`delete from ttl_sessions where expires_at <= \$1`).WithArgs(now).WillReturnResult(sqlmock.NewResult(0, 2))
+	mockDB.ExpectQuery(`ttl_sessions where id = \$1 limit 1`).WithArgs(1, now).WillReturnRows(rows())
+
+	var r TTLSession
+	a.NoError(FindByID(context.Background(), db, &r, 1))
+	a.NoError(FindByID(context.Background(), db, &r, 1))
+
+	n, err := PurgeExpired(context.Background(), db, TTLSession{}, 0)
+	a.NoError(err)
+	a.Equal(int64(2), n)
+
+	a.NoError(FindByID(context.Background(), db, &r, 1))
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
@@ -0,0 +1,282 @@
+package sorm
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type TwoPhaseMode int
+
+const (
+	TwoPhasePostgres TwoPhaseMode = iota
+	TwoPhaseXA
+)
+
+type TwoPhaseParticipant struct {
+	Name string
+	DB   *sql.DB
+	Mode TwoPhaseMode
+}
+
+// TwoPhaseLog durably records commit decisions so that transactions left
+// prepared by a crash can be finished by RecoverTwoPhase.
+type TwoPhaseLog interface {
+	LogCommit(ctx context.Context, gid string, branches map[string]string) error
+	LogDone(ctx context.Context, gid string) error
+	Pending(ctx context.Context) (map[string]map[string]string, error)
+}
+
+func (p TwoPhaseParticipant) statements(gid string) (begin, prepare, rollback, commitPrepared, rollbackPrepared []string) {
+	q := "'" + gid + "'"
+
+	switch p.Mode {
+	case TwoPhaseXA:
+		return []string{"xa start " + q},
+			[]string{"xa end " + q, "xa prepare " + q},
+			[]string{"xa end " + q, "xa rollback " + q},
+			[]string{"xa commit " + q},
+			[]string{"xa rollback " + q}
+	default:
+		return []string{"begin"},
+			[]string{"prepare transaction " + q},
+			[]string{"rollback"},
+			[]string{"commit prepared " + q},
+			[]string{"rollback prepared " + q}
+	}
+}
+
+func twoPhaseExec(ctx context.Context, db Querier, queries []string) error {
+	for _, q := range queries {
+		logQuery(ctx, q, nil)
+
+		start := time.Now()
+
+		if _, err := db.ExecContext(ctx, q); err != nil {
+			logQueryAfter(ctx, q, nil, start, err)
+
+			return err
+		}
+
+		logQueryAfter(ctx, q, nil, start, nil)
+	}
+
+	return nil
+}
+
+func newTwoPhaseID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return "sorm_" + hex.EncodeToString(b), nil
+}
+
+type twoPhaseBranch struct {
+	p        TwoPhaseParticipant
+	conn     *sql.Conn
+	gid      string
+	prepared bool
+}
+
+// RunTwoPhase runs fn with one session per participant, then prepares every
+// branch and commits them all once the decision has been written to log.
+func RunTwoPhase(ctx context.Context, participants []TwoPhaseParticipant, log TwoPhaseLog, fn func(sessions map[string]Querier) error) error {
+	base, err := newTwoPhaseID()
+	if err != nil {
+		return fmt.Errorf("RunTwoPhase: couldn't generate transaction id: %w", err)
+	}
+
+	var branches []*twoPhaseBranch
+	defer func() {
+		for _, b := range branches {
+			b.conn.Close()
+		}
+	}()
+
+	abort := func(cause error) error {
+		for _, b := range branches {
+			_, _, rollback, _, rollbackPrepared := b.p.statements(b.gid)
+			if b.prepared {
+				_ = twoPhaseExec(context.Background(), b.conn, rollbackPrepared)
+			} else {
+				_ = twoPhaseExec(context.Background(), b.conn, rollback)
+			}
+		}
+
+		return fmt.Errorf("RunTwoPhase: %w", cause)
+	}
+
+	sessions := make(map[string]Querier)
+	names := make(map[string]string)
+
+	for _, p := range participants {
+		if _, ok := sessions[p.Name]; ok {
+			return abort(fmt.Errorf("participant %s appears more than once", p.Name))
+		}
+
+		conn, err := p.DB.Conn(ctx)
+		if err != nil {
+			return abort(fmt.Errorf("%s: couldn't get a connection: %w", p.Name, err))
+		}
+
+		b := &twoPhaseBranch{p: p, conn: conn, gid: base + "_" + fmt.Sprint(len(branches))}
+
+		begin, _, _, _, _ := p.statements(b.gid)
+		if err := twoPhaseExec(ctx, conn, begin); err != nil {
+			conn.Close()
+			return abort(fmt.Errorf("%s: couldn't begin transaction: %w", p.Name, err))
+		}
+
+		branches = append(branches, b)
+		sessions[p.Name] = conn
+		names[p.Name] = b.gid
+	}
+
+	if err := fn(sessions); err != nil {
+		return abort(err)
+	}
+
+	for _, b := range branches {
+		_, prepare, _, _, _ := b.p.statements(b.gid)
+		if err := twoPhaseExec(ctx, b.conn, prepare); err != nil {
+			return abort(fmt.Errorf("%s: couldn't prepare transaction: %w", b.p.Name, err))
+		}
+
+		b.prepared = true
+	}
+
+	if err := log.LogCommit(ctx, base, names); err != nil {
+		return abort(fmt.Errorf("couldn't log commit decision: %w", err))
+	}
+
+	for _, b := range branches {
+		_, _, _, commitPrepared, _ := b.p.statements(b.gid)
+		if err := twoPhaseExec(ctx, b.conn, commitPrepared); err != nil {
+			return fmt.Errorf("RunTwoPhase: %s: couldn't commit prepared transaction %s; it will be finished by RecoverTwoPhase: %w", b.p.Name, b.gid, err)
+		}
+	}
+
+	if err := log.LogDone(ctx, base); err != nil {
+		return fmt.Errorf("RunTwoPhase: couldn't mark transaction %s as done: %w", base, err)
+	}
+
+	return nil
+}
+
+// preparedTwoPhase lists the sorm branches left prepared on p.
+func (p TwoPhaseParticipant) preparedTwoPhase(ctx context.Context) ([]string, error) {
+	q := "select gid from pg_prepared_xacts where database = current_database() and gid like 'sorm\\_%'"
+	if p.Mode == TwoPhaseXA {
+		q = "xa recover"
+	}
+
+	logQuery(ctx, q, nil)
+
+	start := time.Now()
+
+	rows, err := p.DB.QueryContext(ctx, q)
+	if err != nil {
+		logQueryAfter(ctx, q, nil, start, err)
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	var l []string
+	for rows.Next() {
+		var gid string
+
+		if p.Mode == TwoPhaseXA {
+			var formatID, gtridLength, bqualLength int
+			if err := rows.Scan(&formatID, &gtridLength, &bqualLength, &gid); err != nil {
+				return nil, err
+			}
+			if gtridLength <= len(gid) {
+				gid = gid[:gtridLength]
+			}
+		} else if err := rows.Scan(&gid); err != nil {
+			return nil, err
+		}
+
+		if strings.HasPrefix(gid, "sorm_") {
+			l = append(l, gid)
+		}
+	}
+
+	logQueryAfter(ctx, q, nil, start, rows.Err())
+
+	return l, rows.Err()
+}
+
+// RecoverTwoPhase commits every branch of the transactions that log reports
+// as decided but not done. Branches that no longer exist are assumed to have
+// been committed already. Any other sorm branch still prepared on a
+// participant has no logged decision, so it's rolled back. That includes the
+// branches of a RunTwoPhase that hasn't logged its decision yet, so recovery
+// shouldn't run alongside new transactions, e.g. only at startup.
+func RecoverTwoPhase(ctx context.Context, participants []TwoPhaseParticipant, log TwoPhaseLog) error {
+	byName := make(map[string]TwoPhaseParticipant)
+	for _, p := range participants {
+		byName[p.Name] = p
+	}
+
+	// list prepared branches before reading the log, so that a decision
+	// logged in between is seen and its branches aren't rolled back
+	prepared := make(map[string][]string)
+	for _, p := range participants {
+		l, err := p.preparedTwoPhase(ctx)
+		if err != nil {
+			return fmt.Errorf("RecoverTwoPhase: %s: couldn't list prepared transactions: %w", p.Name, err)
+		}
+
+		prepared[p.Name] = l
+	}
+
+	pending, err := log.Pending(ctx)
+	if err != nil {
+		return fmt.Errorf("RecoverTwoPhase: %w", err)
+	}
+
+	decided := make(map[[2]string]bool)
+
+	for base, branches := range pending {
+		for name, gid := range branches {
+			p, ok := byName[name]
+			if !ok {
+				return fmt.Errorf("RecoverTwoPhase: transaction %s references unknown participant %q", base, name)
+			}
+
+			decided[[2]string{name, gid}] = true
+
+			_, _, _, commitPrepared, _ := p.statements(gid)
+			if err := twoPhaseExec(ctx, p.DB, commitPrepared); err != nil && !hasErrorCode(err, []string{"42704"}, []uint64{1397}) {
+				return fmt.Errorf("RecoverTwoPhase: %s: couldn't commit prepared transaction %s: %w", name, gid, err)
+			}
+		}
+
+		if err := log.LogDone(ctx, base); err != nil {
+			return fmt.Errorf("RecoverTwoPhase: couldn't mark transaction %s as done: %w", base, err)
+		}
+	}
+
+	for _, p := range participants {
+		for _, gid := range prepared[p.Name] {
+			if decided[[2]string{p.Name, gid}] {
+				continue
+			}
+
+			_, _, _, _, rollbackPrepared := p.statements(gid)
+			if err := twoPhaseExec(ctx, p.DB, rollbackPrepared); err != nil && !hasErrorCode(err, []string{"42704"}, []uint64{1397}) {
+				return fmt.Errorf("RecoverTwoPhase: %s: couldn't roll back prepared transaction %s: %w", p.Name, gid, err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,152 @@
+package sorm
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryTwoPhaseLog struct {
+	pending map[string]map[string]string
+}
+
+func (l *memoryTwoPhaseLog) LogCommit(ctx context.Context, gid string, branches map[string]string) error {
+	if l.pending == nil {
+		l.pending = make(map[string]map[string]string)
+	}
+	l.pending[gid] = branches
+	return nil
+}
+
+func (l *memoryTwoPhaseLog) LogDone(ctx context.Context, gid string) error {
+	delete(l.pending, gid)
+	return nil
+}
+
+func (l *memoryTwoPhaseLog) Pending(ctx context.Context) (map[string]map[string]string, error) {
+	return l.pending, nil
+}
+
+func TestRunTwoPhase(t *testing.T) {
+	a := assert.New(t)
+
+	db1, mock1, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db1.Close()
+
+	db2, mock2, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db2.Close()
+
+	mock1.ExpectExec(`^begin$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock1.ExpectExec(`insert into orders`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock1.ExpectExec(`^prepare transaction 'sorm_[0-9a-f]+_0'$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock1.ExpectExec(`^commit prepared 'sorm_[0-9a-f]+_0'$`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock2.ExpectExec(`^xa start 'sorm_[0-9a-f]+_1'$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock2.ExpectExec(`insert into ledger`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock2.ExpectExec(`^xa end 'sorm_[0-9a-f]+_1'$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock2.ExpectExec(`^xa prepare 'sorm_[0-9a-f]+_1'$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock2.ExpectExec(`^xa commit 'sorm_[0-9a-f]+_1'$`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	var log memoryTwoPhaseLog
+
+	err = RunTwoPhase(context.Background(), []TwoPhaseParticipant{
+		{Name: "orders", DB: db1, Mode: TwoPhasePostgres},
+		{Name: "ledger", DB: db2, Mode: TwoPhaseXA},
+	}, &log, func(sessions map[string]Querier) error {
+		if _, err := sessions["orders"].ExecContext(context.Background(), "insert into orders"); err != nil {
+			return err
+		}
+		_, err := sessions["ledger"].ExecContext(context.Background(), "insert into ledger")
+		return err
+	})
+
+	a.NoError(err)
+	a.Empty(log.pending)
+	a.NoError(mock1.ExpectationsWereMet())
+	a.NoError(mock2.ExpectationsWereMet())
+}
+
+func TestRunTwoPhaseRollback(t *testing.T) {
+	a := assert.New(t)
+
+	db1, mock1, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db1.Close()
+
+	mock1.ExpectExec(`^begin$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock1.ExpectExec(`^rollback$`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	var log memoryTwoPhaseLog
+
+	err = RunTwoPhase(context.Background(), []TwoPhaseParticipant{{Name: "orders", DB: db1}}, &log, func(sessions map[string]Querier) error {
+		return errors.New("boom")
+	})
+
+	a.EqualError(err, "RunTwoPhase: boom")
+	a.Empty(log.pending)
+	a.NoError(mock1.ExpectationsWereMet())
+}
+
+func TestRecoverTwoPhase(t *testing.T) {
+	a := assert.New(t)
+
+	db1, mock1, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db1.Close()
+
+	mock1.ExpectQuery(`^select gid from pg_prepared_xacts`).WillReturnRows(sqlmock.NewRows([]string{"gid"}))
+	mock1.ExpectExec(regexp.QuoteMeta(`commit prepared 'sorm_abc_0'`)).WillReturnError(txStateError("42704"))
+
+	log := memoryTwoPhaseLog{pending: map[string]map[string]string{"sorm_abc": {"orders": "sorm_abc_0"}}}
+
+	a.NoError(RecoverTwoPhase(context.Background(), []TwoPhaseParticipant{{Name: "orders", DB: db1}}, &log))
+	a.Empty(log.pending)
+	a.NoError(mock1.ExpectationsWereMet())
+}
+
+func TestRecoverTwoPhasePresumedAbort(t *testing.T) {
+	a := assert.New(t)
+
+	db1, mock1, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db1.Close()
+
+	db2, mock2, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db2.Close()
+
+	mock1.ExpectQuery(`^select gid from pg_prepared_xacts`).WillReturnRows(sqlmock.NewRows([]string{"gid"}).AddRow("sorm_abc_0").AddRow("sorm_def_0"))
+	mock2.ExpectQuery(`^xa recover$`).WillReturnRows(sqlmock.NewRows([]string{"formatID", "gtrid_length", "bqual_length", "data"}).AddRow(1, 10, 0, "sorm_abc_1").AddRow(1, 10, 0, "sorm_def_1").AddRow(1, 5, 0, "other"))
+	mock1.ExpectExec(regexp.QuoteMeta(`commit prepared 'sorm_abc_0'`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock2.ExpectExec(regexp.QuoteMeta(`xa commit 'sorm_abc_1'`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock1.ExpectExec(regexp.QuoteMeta(`rollback prepared 'sorm_def_0'`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock2.ExpectExec(regexp.QuoteMeta(`xa rollback 'sorm_def_1'`)).WillReturnError(&txMySQLError{Number: 1397, Message: "XAER_NOTA: Unknown XID"})
+
+	log := memoryTwoPhaseLog{pending: map[string]map[string]string{"sorm_abc": {"orders": "sorm_abc_0", "ledger": "sorm_abc_1"}}}
+
+	a.NoError(RecoverTwoPhase(context.Background(), []TwoPhaseParticipant{
+		{Name: "orders", DB: db1},
+		{Name: "ledger", DB: db2, Mode: TwoPhaseXA},
+	}, &log))
+	a.Empty(log.pending)
+	a.NoError(mock1.ExpectationsWereMet())
+	a.NoError(mock2.ExpectationsWereMet())
+}
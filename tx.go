@@ -0,0 +1,144 @@
+package sorm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+type TransactionOptions struct {
+	TxOptions  *sql.TxOptions
+	MaxRetries int
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	Retryable  func(err error) bool
+}
+
+func WithTransaction(ctx context.Context, db *sql.DB, opts *TransactionOptions, fn func(tx *sql.Tx) error) error {
+	if opts == nil {
+		opts = &TransactionOptions{}
+	}
+
+	retryable := opts.Retryable
+	if retryable == nil {
+		retryable = IsSerializationFailure
+	}
+
+	backoff := opts.Backoff
+
+	for attempt := 0; ; attempt++ {
+		err := runTransaction(ctx, db, opts.TxOptions, fn)
+		if err == nil {
+			return nil
+		}
+
+		if attempt >= opts.MaxRetries || !retryable(err) {
+			return fmt.Errorf("WithTransaction: %w", err)
+		}
+
+		if backoff > 0 {
+			t := time.NewTimer(backoff)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return fmt.Errorf("WithTransaction: %w", ctx.Err())
+			case <-t.C:
+			}
+
+			backoff *= 2
+			if opts.MaxBackoff > 0 && backoff > opts.MaxBackoff {
+				backoff = opts.MaxBackoff
+			}
+		}
+	}
+}
+
+func runTransaction(ctx context.Context, db *sql.DB, txOptions *sql.TxOptions, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, txOptions)
+	if err != nil {
+		return fmt.Errorf("couldn't open a transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("couldn't commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// IsSerializationFailure reports whether err is a serialization failure
+// (SQLSTATE 40001) or a deadlock (SQLSTATE 40P01, MySQL error 1213).
+func IsSerializationFailure(err error) bool {
+	return hasErrorCode(err, []string{"40001", "40P01"}, []uint64{1213})
+}
+
+func hasErrorCode(err error, states []string, numbers []uint64) bool {
+	for ; err != nil; err = errors.Unwrap(err) {
+		state, number := errorCodes(err)
+
+		for _, s := range states {
+			if state == s {
+				return true
+			}
+		}
+
+		for _, n := range numbers {
+			if number == n {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// errorCodes pulls the SQLSTATE and vendor error number out of a single
+// driver error without importing any drivers. SQLite's extended result code
+// stands in for the error number.
+func errorCodes(err error) (string, uint64) {
+	var state string
+	var number uint64
+
+	if s, ok := err.(interface{ SQLState() string }); ok {
+		state = s.SQLState()
+	}
+
+	v := reflect.ValueOf(err)
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		return state, number
+	}
+
+	v = reflect.Indirect(v)
+	if v.Kind() != reflect.Struct {
+		return state, number
+	}
+
+	for _, name := range []string{"Number", "ExtendedCode"} {
+		if number != 0 {
+			break
+		}
+
+		f := v.FieldByName(name)
+		switch {
+		case !f.IsValid():
+		case f.Kind() >= reflect.Uint && f.Kind() <= reflect.Uint64:
+			number = f.Uint()
+		case f.Kind() >= reflect.Int && f.Kind() <= reflect.Int64 && f.Int() > 0:
+			number = uint64(f.Int())
+		}
+	}
+
+	if f := v.FieldByName("Code"); state == "" && f.IsValid() && f.Kind() == reflect.String {
+		state = f.String()
+	}
+
+	return state, number
+}
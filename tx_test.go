@@ -0,0 +1,112 @@
+package sorm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type txStateError string
+
+func (e txStateError) Error() string    { return "sql state " + string(e) }
+func (e txStateError) SQLState() string { return string(e) }
+
+type txMySQLError struct {
+	Number  uint16
+	Message string
+}
+
+func (e *txMySQLError) Error() string { return fmt.Sprintf("Error %d: %s", e.Number, e.Message) }
+
+func TestIsSerializationFailure(t *testing.T) {
+	a := assert.New(t)
+
+	a.True(IsSerializationFailure(txStateError("40001")))
+	a.True(IsSerializationFailure(fmt.Errorf("wrapped: %w", txStateError("40P01"))))
+	a.True(IsSerializationFailure(&txMySQLError{Number: 1213, Message: "Deadlock found"}))
+	a.False(IsSerializationFailure(txStateError("23505")))
+	a.False(IsSerializationFailure(&txMySQLError{Number: 1062}))
+	a.False(IsSerializationFailure(errors.New("nope")))
+	a.False(IsSerializationFailure(nil))
+}
+
+func TestWithTransactionRetry(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectBegin()
+	mockDB.ExpectExec(`update things`).WillReturnError(txStateError("40001"))
+	mockDB.ExpectRollback()
+	mockDB.ExpectBegin()
+	mockDB.ExpectExec(`update things`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectCommit()
+
+	var calls int
+	err = WithTransaction(context.Background(), db, &TransactionOptions{MaxRetries: 3}, func(tx *sql.Tx) error {
+		calls++
+		_, err := tx.ExecContext(context.Background(), "update things")
+		return err
+	})
+
+	a.NoError(err)
+	a.Equal(2, calls)
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestWithTransactionNoRetry(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectBegin()
+	mockDB.ExpectRollback()
+
+	var calls int
+	err = WithTransaction(context.Background(), db, &TransactionOptions{MaxRetries: 3}, func(tx *sql.Tx) error {
+		calls++
+		return errors.New("boom")
+	})
+
+	a.EqualError(err, "WithTransaction: boom")
+	a.Equal(1, calls)
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestWithTransactionRetriesExhausted(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	for i := 0; i < 2; i++ {
+		mockDB.ExpectBegin()
+		mockDB.ExpectRollback()
+	}
+
+	var calls int
+	err = WithTransaction(context.Background(), db, &TransactionOptions{MaxRetries: 1}, func(tx *sql.Tx) error {
+		calls++
+		return txStateError("40001")
+	})
+
+	a.EqualError(err, "WithTransaction: sql state 40001")
+	a.Equal(2, calls)
+	a.NoError(mockDB.ExpectationsWereMet())
+}
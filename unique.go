@@ -0,0 +1,118 @@
+package sorm
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+type ValidationError struct {
+	Field   string
+	Column  string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("validation failed for %s: %s", e.Field, e.Message)
+}
+
+// checkUnique looks for other records with the same value in each field tagged
+// `validate:"unique"`, or `validate:"unique,within:org_id"` to only look at
+// records with the same org_id. When saving, previous is the stored record:
+// the record itself is excluded, and fields that haven't changed since are
+// skipped, so they don't cost a query.
+func checkUnique(ctx context.Context, tx Querier, vdesc *structDescription, idFields []structField, v, previous reflect.Value) error {
+	plan, err := getPlanContext(ctx, v.Type())
+	if err != nil {
+		return err
+	}
+
+	for _, f := range getSQLWritableFields(vdesc) {
+		t := f.Tag("validate")
+		if t == nil || t.Value() != "unique" {
+			continue
+		}
+
+		unchanged := func(index []int) bool {
+			return previous.IsValid() && reflect.DeepEqual(previous.FieldByIndex(index).Interface(), v.FieldByIndex(index).Interface())
+		}
+
+		col := getSQLColumnName(f)
+
+		conds := []string{columnComparison(f, col) + " = " + makeParameter(1)}
+		args := []interface{}{fieldValue(f, v)}
+
+		var scope string
+		if p := t.Parameter("within"); p != nil && p.Value() != "" {
+			s := p.Value()
+			sf := plan.fieldForColumn(s)
+			if sf == nil {
+				return fmt.Errorf("couldn't find field on %s for unique scope %s", vdesc.Name(), s)
+			}
+
+			if unchanged(f.Index()) && unchanged(sf.Index) {
+				continue
+			}
+
+			args = append(args, v.FieldByIndex(sf.Index).Interface())
+			conds = append(conds, s+" = "+makeParameter(len(args)))
+			scope = s
+		} else if unchanged(f.Index()) {
+			continue
+		}
+
+		if previous.IsValid() {
+			var self []string
+			for _, idField := range idFields {
+				args = append(args, v.FieldByIndex(idField.Index()).Interface())
+				self = append(self, getSQLColumnName(idField)+" = "+makeParameter(len(args)))
+			}
+
+			conds = append(conds, "not ("+strings.Join(self, " and ")+")")
+		}
+
+		for _, s := range []string{col, scope} {
+			if s == "" {
+				continue
+			}
+
+			if err := checkIdentifier(s); err != nil {
+				return err
+			}
+		}
+
+		stmt, err := buildSelect(vdesc, getSQLTableNameContext(ctx, vdesc), "1", "where "+strings.Join(conds, " and ")+" limit 1", args, findOptions{includeExpired: true})
+		if err != nil {
+			return err
+		}
+
+		logQuery(ctx, stmt.Query, stmt.Args)
+
+		start := time.Now()
+
+		var n int
+		err = tx.QueryRowContext(ctx, stmt.Query, stmt.Args...).Scan(&n)
+		if err == sql.ErrNoRows {
+			logQueryAfter(ctx, stmt.Query, stmt.Args, start, nil)
+			continue
+		}
+
+		logQueryAfter(ctx, stmt.Query, stmt.Args, start, err)
+
+		if err != nil {
+			return err
+		}
+
+		msg := "must be unique"
+		if scope != "" {
+			msg += " within " + scope
+		}
+
+		return &ValidationError{Field: f.Name(), Column: col, Message: msg}
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package sorm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type UniqueProject struct {
+	ID    int
+	OrgID int
+	Slug  string `validate:"unique,within:org_id"`
+}
+
+func TestUniqueCreateRecord(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectBegin()
+	mockDB.ExpectQuery(`select 1 from unique_projects where slug = \$1 and org_id = \$2 limit 1`).WithArgs("a", 7).WillReturnRows(sqlmock.NewRows([]string{"1"}))
+	mockDB.ExpectQuery(`insert into unique_projects \(org_id, slug\) values \(\$1, \$2\) returning id`).WithArgs(7, "a").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mockDB.ExpectQuery(`select 1 from unique_projects where slug = \$1 and org_id = \$2 limit 1`).WithArgs("a", 7).WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mockDB.ExpectCommit()
+
+	tx, _ := db.Begin()
+
+	a.NoError(CreateRecord(context.Background(), tx, &UniqueProject{OrgID: 7, Slug: "a"}))
+
+	err = CreateRecord(context.Background(), tx, &UniqueProject{OrgID: 7, Slug: "a"})
+	a.EqualError(err, "CreateRecord: validation failed for Slug: must be unique within org_id")
+
+	var verr *ValidationError
+	if a.True(errors.As(err, &verr)) {
+		a.Equal(&ValidationError{Field: "Slug", Column: "slug", Message: "must be unique within org_id"}, verr)
+	}
+
+	a.NoError(tx.Commit())
+}
+
+func TestUniqueSaveRecord(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectBegin()
+	mockDB.ExpectQuery(`select \* from unique_projects where id = \$1 limit 1`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "org_id", "slug"}).AddRow(1, 7, "a"))
+	mockDB.ExpectQuery(`select 1 from unique_projects where slug = \$1 and org_id = \$2 and not \(id = \$3\) limit 1`).WithArgs("b", 7, 1).WillReturnRows(sqlmock.NewRows([]string{"1"}))
+	mockDB.ExpectExec(`update unique_projects set slug = \$2 where id = \$1`).WithArgs(1, "b").WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectCommit()
+
+	tx, _ := db.Begin()
+
+	a.NoError(SaveRecord(context.Background(), tx, &UniqueProject{ID: 1, OrgID: 7, Slug: "b"}))
+
+	a.NoError(tx.Commit())
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+type UniqueTask struct {
+	ID    int
+	OrgID int
+	Name  string
+	Slug  string `validate:"unique,within:org_id"`
+}
+
+func TestUniqueSaveRecordUnchanged(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectBegin()
+	mockDB.ExpectQuery(`select \* from unique_tasks where id = \$1 limit 1`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "org_id", "name", "slug"}).AddRow(1, 7, "a", "a"))
+	mockDB.ExpectExec(`update unique_tasks set name = \$2 where id = \$1`).WithArgs(1, "b").WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectQuery(`select \* from unique_tasks where id = \$1 limit 1`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "org_id", "name", "slug"}).AddRow(1, 7, "b", "a"))
+	mockDB.ExpectQuery(`select 1 from unique_tasks where slug = \$1 and org_id = \$2 and not \(id = \$3\) limit 1`).WithArgs("a", 8, 1).WillReturnRows(sqlmock.NewRows([]string{"1"}))
+	mockDB.ExpectExec(`update unique_tasks set org_id = \$2 where id = \$1`).WithArgs(1, 8).WillReturnResult(sqlmock.NewResult(0, 1))
+	mockDB.ExpectCommit()
+
+	tx, _ := db.Begin()
+
+	// only the name changed, so there's nothing to check
+	a.NoError(SaveRecord(context.Background(), tx, &UniqueTask{ID: 1, OrgID: 7, Name: "b", Slug: "a"}))
+
+	// moving to another org has to check the slug there
+	a.NoError(SaveRecord(context.Background(), tx, &UniqueTask{ID: 1, OrgID: 8, Name: "b", Slug: "a"}))
+
+	a.NoError(tx.Commit())
+	a.NoError(mockDB.ExpectationsWereMet())
+}
@@ -0,0 +1,214 @@
+package sorm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type liveColumn struct {
+	Name     string
+	Type     string
+	Nullable bool
+}
+
+// introspectTable reads the live columns of tbl from information_schema,
+// falling back to SQLite's table_info pragma where that doesn't exist.
+func introspectTable(ctx context.Context, db Querier, tbl string) ([]liveColumn, error) {
+	schema, name := splitTableName(tbl)
+
+	query := "select column_name, data_type, is_nullable from information_schema.columns where table_name = " + makeParameter(1)
+	args := []interface{}{name}
+	if schema != "" {
+		query += " and table_schema = " + makeParameter(2)
+		args = append(args, schema)
+	}
+	query += " order by ordinal_position"
+
+	cols, err := queryLiveColumns(ctx, db, query, args, func(scan func(...interface{}) error) (liveColumn, error) {
+		var c liveColumn
+		var nullable string
+		if err := scan(&c.Name, &c.Type, &nullable); err != nil {
+			return c, err
+		}
+
+		c.Nullable = strings.EqualFold(nullable, "yes")
+
+		return c, nil
+	})
+	if err == nil {
+		return cols, nil
+	}
+
+	if err := checkIdentifier(tbl); err != nil {
+		return nil, err
+	}
+
+	query = "pragma table_info(" + quoteIdentifier(name) + ")"
+	if schema != "" {
+		query = "pragma " + quoteIdentifier(schema) + ".table_info(" + quoteIdentifier(name) + ")"
+	}
+
+	return queryLiveColumns(ctx, db, query, nil, func(scan func(...interface{}) error) (liveColumn, error) {
+		var c liveColumn
+		var cid, notNull, pk int
+		var def interface{}
+		if err := scan(&cid, &c.Name, &c.Type, &notNull, &def, &pk); err != nil {
+			return c, err
+		}
+
+		c.Nullable = notNull == 0 && pk == 0
+
+		return c, nil
+	})
+}
+
+func queryLiveColumns(ctx context.Context, db Querier, query string, args []interface{}, fn func(scan func(...interface{}) error) (liveColumn, error)) ([]liveColumn, error) {
+	logQuery(ctx, query, args)
+
+	start := time.Now()
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		logQueryAfter(ctx, query, args, start, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var l []liveColumn
+	for rows.Next() {
+		c, err := fn(rows.Scan)
+		if err != nil {
+			logQueryAfter(ctx, query, args, start, err)
+			return nil, err
+		}
+
+		l = append(l, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		logQueryAfter(ctx, query, args, start, err)
+		return nil, err
+	}
+
+	logQueryAfter(ctx, query, args, start, nil)
+
+	return l, nil
+}
+
+// sqlTypeFamily reduces a column type to a rough family so that e.g. int4
+// and bigint, or varchar(16) and text, compare as the same thing.
+func sqlTypeFamily(typ string) string {
+	typ = strings.ToLower(typ)
+
+	switch {
+	case strings.HasPrefix(typ, "bool"), typ == "bit", strings.HasPrefix(typ, "tinyint(1)"):
+		return "boolean"
+	case strings.Contains(typ, "int"), strings.Contains(typ, "serial"):
+		return "integer"
+	case strings.Contains(typ, "real"), strings.Contains(typ, "float"), strings.Contains(typ, "double"), strings.Contains(typ, "numeric"), strings.Contains(typ, "decimal"):
+		return "real"
+	case strings.Contains(typ, "char"), strings.Contains(typ, "text"), strings.Contains(typ, "clob"), typ == "uuid", strings.HasPrefix(typ, "json"):
+		return "text"
+	case strings.Contains(typ, "time"), strings.HasPrefix(typ, "date"):
+		return "timestamp"
+	case strings.Contains(typ, "blob"), typ == "bytea", strings.Contains(typ, "binary"):
+		return "blob"
+	}
+
+	return ""
+}
+
+func compatibleSQLTypes(want, have string) bool {
+	a, b := sqlTypeFamily(want), sqlTypeFamily(have)
+	if a == "" || b == "" || a == b {
+		return true
+	}
+
+	// booleans are integers in SQLite and MySQL
+	return a == "boolean" && b == "integer"
+}
+
+// ModelValidationError lists every way a model disagrees with its live table.
+type ModelValidationError struct {
+	Model    string
+	Table    string
+	Problems []ValidationError
+}
+
+func (e *ModelValidationError) Error() string {
+	var l []string
+	for _, p := range e.Problems {
+		l = append(l, p.Error())
+	}
+
+	return fmt.Sprintf("model %s doesn't match table %s: %s", e.Model, e.Table, strings.Join(l, "; "))
+}
+
+// ValidateModel compares model against its live table and returns a
+// *ModelValidationError describing fields with no column, columns that can
+// hold null when the field can't, and columns whose type doesn't fit the
+// field.
+func ValidateModel(ctx context.Context, db Querier, model interface{}) error {
+	vtyp, err := structTypeOf(model)
+	if err != nil {
+		return fmt.Errorf("ValidateModel: %w", err)
+	}
+
+	vdesc, err := getDescriptionContext(ctx, vtyp)
+	if err != nil {
+		return fmt.Errorf("ValidateModel: could not get detailed reflection information for type %s: %w", vtyp.String(), err)
+	}
+
+	tbl := getSQLTableNameContext(ctx, vdesc)
+
+	live, err := introspectTable(ctx, db, tbl)
+	if err != nil {
+		return fmt.Errorf("ValidateModel: couldn't read columns of %s: %w", tbl, err)
+	}
+	if len(live) == 0 {
+		return fmt.Errorf("ValidateModel: table %s doesn't exist", tbl)
+	}
+
+	byName := make(map[string]liveColumn)
+	for _, c := range live {
+		byName[strings.ToLower(c.Name)] = c
+	}
+
+	isID := make(map[string]bool)
+	for _, f := range getSQLIDFields(vdesc) {
+		isID[f.Name()] = true
+	}
+
+	verr := ModelValidationError{Model: vtyp.Name(), Table: tbl}
+
+	for _, f := range getSQLWritableFields(vdesc) {
+		col := getSQLColumnName(f)
+
+		c, ok := byName[strings.ToLower(col)]
+		if !ok {
+			verr.Problems = append(verr.Problems, ValidationError{Field: f.Name(), Column: col, Message: "column doesn't exist"})
+			continue
+		}
+
+		typ, nullable, err := getSQLColumnType(f, vtyp.FieldByIndex(f.Index()).Type)
+		if err != nil {
+			continue
+		}
+
+		if c.Nullable && !nullable && !isID[f.Name()] {
+			verr.Problems = append(verr.Problems, ValidationError{Field: f.Name(), Column: col, Message: "column is nullable but the field can't hold null"})
+		}
+
+		if !compatibleSQLTypes(typ, c.Type) {
+			verr.Problems = append(verr.Problems, ValidationError{Field: f.Name(), Column: col, Message: fmt.Sprintf("column type %s doesn't match field type %s", c.Type, vtyp.FieldByIndex(f.Index()).Type)})
+		}
+	}
+
+	if len(verr.Problems) > 0 {
+		return &verr
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package sorm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type ValidatedObject struct {
+	ID       int
+	Name     string
+	Nickname *string
+	Score    float64
+	Missing  string
+}
+
+func TestValidateModelInformationSchema(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`select column_name, data_type, is_nullable from information_schema\.columns where table_name = \$1 order by ordinal_position`).WithArgs("validated_objects").WillReturnRows(
+		sqlmock.NewRows([]string{"column_name", "data_type", "is_nullable"}).
+			AddRow("id", "integer", "NO").
+			AddRow("name", "character varying", "YES").
+			AddRow("nickname", "text", "YES").
+			AddRow("score", "text", "NO"),
+	)
+
+	err = ValidateModel(context.Background(), db, &ValidatedObject{})
+
+	var verr *ModelValidationError
+	if !a.True(errors.As(err, &verr)) {
+		return
+	}
+
+	a.Equal([]ValidationError{
+		{Field: "Name", Column: "name", Message: "column is nullable but the field can't hold null"},
+		{Field: "Score", Column: "score", Message: "column type text doesn't match field type float64"},
+		{Field: "Missing", Column: "missing", Message: "column doesn't exist"},
+	}, verr.Problems)
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}
+
+func TestValidateModelPragma(t *testing.T) {
+	a := assert.New(t)
+
+	db, mockDB, err := sqlmock.New()
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	mockDB.ExpectQuery(`information_schema`).WillReturnError(errors.New("no such table: information_schema.columns"))
+	mockDB.ExpectQuery(`pragma table_info\(simple_objects\)`).WillReturnRows(
+		sqlmock.NewRows([]string{"cid", "name", "type", "notnull", "dflt_value", "pk"}).
+			AddRow(0, "id", "INTEGER", 0, nil, 1).
+			AddRow(1, "name", "TEXT", 1, nil, 0),
+	)
+	mockDB.ExpectQuery(`information_schema`).WillReturnRows(sqlmock.NewRows([]string{"column_name", "data_type", "is_nullable"}))
+
+	a.NoError(ValidateModel(context.Background(), db, &SimpleObject{}))
+	a.EqualError(ValidateModel(context.Background(), db, &SimpleObject{}), "ValidateModel: table simple_objects doesn't exist")
+
+	a.NoError(mockDB.ExpectationsWereMet())
+}